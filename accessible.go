@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+/*
+With -accessible, results are described in plain sentences on stdout instead
+of drawing the map, for screen readers: no braille, no cursor movement, one
+line per sentence in a fixed order (the address, the place, the coordinates,
+the network, then the other fields of the info pane). In watch mode the
+first result is described in full and later ones only by what changed.
+*/
+
+var accessible bool
+
+/*
+addAccessibleFlag - Register -accessible on the flags of a mode
+*/
+func addAccessibleFlag(flags *flag.FlagSet) {
+	flags.BoolVar(&accessible, "accessible", false,
+		"Describe the result in sentences instead of drawing the map, for screen readers")
+}
+
+// Fields the narrative describes in sentences of their own
+var narratedFields = map[string]bool{
+	"ip": true, "hostname": true, "city": true, "region": true, "country": true,
+	"loc": true, "org": true, "postal": true, "timezone": true,
+}
+
+/*
+narrate - The sentences describing res
+*/
+func narrate(res IPInfoResult) []string {
+	d := NewInfoData(res)
+	var lines []string
+
+	if d.Hostname != "" {
+		lines = append(lines, fmt.Sprintf(tr("Address %s, hostname %s."), d.IP, d.Hostname))
+	} else {
+		lines = append(lines, fmt.Sprintf(tr("Address %s."), d.IP))
+	}
+
+	if place := joinNonEmpty(d.City, d.Region, d.Country); place != "" {
+		lines = append(lines, fmt.Sprintf(tr("Located in %s."), place))
+	}
+	if d.HasLocation {
+		lines = append(lines, narrateCoords(d.Lat, d.Lon))
+	} else {
+		lines = append(lines, tr("No location is known."))
+	}
+	if d.HasDistance {
+		lines = append(lines, fmt.Sprintf(tr("%s from home."), formatDistance(d.DistanceKm)))
+	}
+	if d.Org != "" {
+		lines = append(lines, fmt.Sprintf(tr("Network: %s."), d.Org))
+	}
+	if d.Postal != "" {
+		lines = append(lines, fmt.Sprintf(tr("Postal code: %s."), d.Postal))
+	}
+	if d.Timezone != "" {
+		lines = append(lines, fmt.Sprintf(tr("Time zone: %s, local time %s."),
+			d.Timezone, d.LocalTime.Format("15:04")))
+	}
+
+	for _, field := range config.InfoFields {
+		if narratedFields[field.Path] {
+			continue
+		}
+		if value := displayValue(res, field.Path); value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s.", tr(field.Label), value))
+		}
+	}
+	return lines
+}
+
+/*
+narrateCoords - Coordinates read out with their hemispheres instead of signs
+*/
+func narrateCoords(lat, lon float64) string {
+	ns, ew := tr("north"), tr("east")
+	if lat < 0 {
+		ns = tr("south")
+	}
+	if lon < 0 {
+		ew = tr("west")
+	}
+	return fmt.Sprintf(tr("Coordinates: %s degrees %s, %s degrees %s."),
+		formatNumber(math.Abs(lat), 4), ns, formatNumber(math.Abs(lon), 4), ew)
+}
+
+/*
+narrateChanges - The sentences describing what changed from previous to
+res, none if nothing did
+*/
+func narrateChanges(previous, res IPInfoResult) []string {
+	var lines []string
+	for _, field := range []InfoField{
+		{"IP Address", "ip"}, {"Hostname", "hostname"}, {"City", "city"},
+		{"Region", "region"}, {"Country", "country"}, {"Org", "org"},
+	} {
+		before, after := fieldValue(previous, field.Path), fieldValue(res, field.Path)
+		if before == after {
+			continue
+		}
+		switch {
+		case before == "":
+			lines = append(lines, fmt.Sprintf(tr("%s is now %s."), tr(field.Label), after))
+		case after == "":
+			lines = append(lines, fmt.Sprintf(tr("%s is no longer known, it was %s."),
+				tr(field.Label), before))
+		default:
+			lines = append(lines, fmt.Sprintf(tr("%s changed from %s to %s."),
+				tr(field.Label), before, after))
+		}
+	}
+
+	lon0, lat0, err0 := previous.GetLonLat()
+	lon1, lat1, err1 := res.GetLonLat()
+	if err0 == nil && err1 == nil {
+		if d := distanceKm(Point{Lat: lat0, Lon: lon0}, Point{Lat: lat1, Lon: lon1}); d >= 1 {
+			lines = append(lines, fmt.Sprintf(tr("The location moved by %s."), formatDistance(d)))
+		}
+	}
+	return lines
+}
+
+/*
+joinNonEmpty - parts that are not empty, separated by commas
+*/
+func joinNonEmpty(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+/*
+Narrator - Describes the results of a watched target as they come, in full
+the first time and then by their changes
+*/
+type Narrator struct {
+	w        io.Writer
+	previous IPInfoResult
+}
+
+/*
+NewNarrator - Create a narrator writing to w
+*/
+func NewNarrator(w io.Writer) *Narrator {
+	return &Narrator{w: w}
+}
+
+/*
+Handle - Describe the message of a lookup, as a bus handler
+*/
+func (n *Narrator) Handle(m Message) {
+	at := time.Now().Format("15:04:05")
+	switch m.Topic {
+	case MsgResultReady:
+		if n.previous == nil {
+			n.write(narrate(m.Result))
+		} else if changes := narrateChanges(n.previous, m.Result); len(changes) > 0 {
+			n.write(append([]string{fmt.Sprintf(tr("At %s:"), at)}, changes...))
+		}
+		n.previous = m.Result
+	case MsgLookupFailed:
+		n.write([]string{fmt.Sprintf(tr("At %s, the lookup failed: %s."), at, m.Err)})
+	case MsgResultDropped:
+		n.write([]string{fmt.Sprintf(tr("At %s, the result was dropped by the script."), at)})
+	}
+}
+
+func (n *Narrator) write(lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(n.w, line)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+AggregateRow - An aggregated prefix and where the entries it covers are
+*/
+type AggregateRow struct {
+	Prefix    string         `json:"prefix"`
+	Entries   int            `json:"entries"`
+	Located   int            `json:"located"`
+	Countries map[string]int `json:"countries"`
+	TopOrg    string         `json:"top_org,omitempty"`
+}
+
+/*
+readPrefixes - The IP Addresses and prefixes of r, the first word of every
+line. Invalid entries are skipped with a warning.
+*/
+func readPrefixes(r io.Reader) ([]*net.IPNet, error) {
+	var prefixes []*net.IPNet
+	err := scanLines(r, func(line string) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			return
+		}
+		words := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+		if len(words) == 0 {
+			return
+		}
+		prefix, err := parsePrefix(words[0])
+		if err != nil {
+			warnf("%s", err)
+			return
+		}
+		prefixes = append(prefixes, normalizePrefix(prefix))
+	})
+	return prefixes, err
+}
+
+/*
+aggregateRows - The aggregated prefixes of entries, each with the entries it
+covers located by their first address when locate is set
+*/
+func aggregateRows(entries []*net.IPNet, locate bool) []AggregateRow {
+	prefixes := aggregatePrefixes(entries)
+	rows := make([]AggregateRow, len(prefixes))
+	orgs := make([]map[string]int, len(prefixes))
+	for i, prefix := range prefixes {
+		rows[i] = AggregateRow{Prefix: prefix.String(), Countries: make(map[string]int)}
+		orgs[i] = make(map[string]int)
+	}
+	located := make(map[string]IPInfoResult)
+	for _, entry := range entries {
+		// The last prefix sorting before the entry is the one covering it
+		i := sort.Search(len(prefixes), func(i int) bool {
+			return len(prefixes[i].IP) > len(entry.IP) ||
+				(len(prefixes[i].IP) == len(entry.IP) && bytes.Compare(prefixes[i].IP, entry.IP) > 0)
+		}) - 1
+		if i < 0 || !prefixes[i].Contains(entry.IP) {
+			continue
+		}
+		rows[i].Entries++
+		if !locate || !isPublicIP(entry.IP) {
+			continue
+		}
+		ip := entry.IP.String()
+		res, ok := located[ip]
+		if !ok {
+			var err error
+			if res, err = locateTarget([]string{ip}); err != nil {
+				warnf("%s: %s", ip, err)
+			}
+			located[ip] = res
+		}
+		if res == nil {
+			continue
+		}
+		rows[i].Located++
+		if country := fieldValue(res, "country"); country != "" {
+			rows[i].Countries[country]++
+		}
+		if org := orgName(fieldValue(res, "org")); org != "" {
+			orgs[i][org]++
+		}
+	}
+	for i := range rows {
+		rows[i].TopOrg = topKey(orgs[i])
+	}
+	return rows
+}
+
+/*
+topKey - The key of counts with the highest count, the first in order on a
+tie
+*/
+func topKey(counts map[string]int) string {
+	top := ""
+	for key, n := range counts {
+		if top == "" || n > counts[top] || (n == counts[top] && key < top) {
+			top = key
+		}
+	}
+	return top
+}
+
+/*
+formatCountries - Countries by count, "DE 3, FR 1"
+*/
+func formatCountries(counts map[string]int) string {
+	var countries []string
+	for country := range counts {
+		countries = append(countries, country)
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		if counts[countries[i]] != counts[countries[j]] {
+			return counts[countries[i]] > counts[countries[j]]
+		}
+		return countries[i] < countries[j]
+	})
+	for i, country := range countries {
+		countries[i] = fmt.Sprintf("%s %d", country, counts[country])
+	}
+	return strings.Join(countries, ", ")
+}
+
+func writeAggregateRows(w io.Writer, entries int, rows []AggregateRow, locate bool) error {
+	fmt.Fprintf(w, "%d entries aggregated into %d prefixes\n\n", entries, len(rows))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if !locate {
+		fmt.Fprintln(tw, "Prefix\tEntries\t")
+		for _, row := range rows {
+			fmt.Fprintf(tw, "%s\t%d\t\n", row.Prefix, row.Entries)
+		}
+		return tw.Flush()
+	}
+	fmt.Fprintln(tw, "Prefix\tEntries\tLocated\tCountries\tTop org\t")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t\n", row.Prefix, row.Entries, row.Located,
+			formatCountries(row.Countries), row.TopOrg)
+	}
+	return tw.Flush()
+}
+
+/*
+aggregateHelp - What the usage message of aggregate says after the synopsis
+*/
+const aggregateHelp = `Aggregate the IP Addresses and CIDR prefixes of file (or stdin), one
+per line, into the fewest prefixes covering exactly the same addresses,
+with the countries and main org of the entries of each prefix:
+`
+
+func runAggregate(args []string) error {
+	flags := flag.NewFlagSet("aggregate", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	locate := flags.Bool("locate", true,
+		"Locate the entries of every prefix (-locate=false only aggregates)")
+	plain := flags.Bool("plain", false, "Print only the prefixes, one per line")
+	asJSON := flags.Bool("json", false, "Print the prefixes as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s aggregate [-locate=false] [-plain] [-json] [file]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(aggregateHelp))
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintf(os.Stderr, "  %s aggregate < ips.txt\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	entries, err := readPrefixes(input)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return invalidInput("No IP Address or prefix to aggregate.")
+	}
+
+	if *plain {
+		for _, prefix := range aggregatePrefixes(entries) {
+			fmt.Println(prefix)
+		}
+		return nil
+	}
+	rows := aggregateRows(entries, *locate)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return writeAggregateRows(os.Stdout, len(entries), rows, *locate)
+}
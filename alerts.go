@@ -0,0 +1,536 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Alert rules watch the bus in the streaming modes (logs, monitor, capture)
+and publish a MsgAlert for each hit, on which the actions of the rule act:
+
+	flash    flash the marker of the address in the interface
+	bell     ring the terminal bell (every alert rings it with -bell)
+	events   publish an "alert" event to the event sinks, -webhook-events
+	         alert sending only these to the webhook
+	command  run the command of the rule with the event as JSON on stdin
+
+Rules without actions flash and publish events. Four rules are built in,
+enabled with -alert:
+
+	new_country  an address of a country not seen before in the session
+	blocklisted  an address listed on a DNS blocklist (see dnsbl.go)
+	policy       a result dropped by the -script
+	ownership    an address of an owned prefix attributed to another AS or
+	             org (see ownership.go)
+
+Others are defined under "alerts" in the config file and enabled unless
+"disabled" (-alert also enables them). They fire once per address whose
+result matches "when", an expression as those of the filter (see
+filter.go), or with a "threshold" when at least that many hits (log lines,
+connections...) of matching addresses are seen within "window", counted
+together or by the value of the field "count_by":
+
+	"alerts": [
+	  {"name": "from_ru", "when": "country == \"RU\"", "actions": ["bell", "flash"]},
+	  {"name": "asn_burst", "count_by": "asn", "threshold": 100, "window": "5m",
+	   "actions": ["events", "command"], "command": "/usr/local/bin/page-oncall"}
+	]
+
+A threshold rule fires at most once per window for a value of count_by.
+*/
+
+const (
+	alertNewCountry  = "new_country"
+	alertBlocklisted = "blocklisted"
+	alertPolicy      = "policy"
+)
+
+// Actions of the alert rules
+const (
+	actionFlash   = "flash"
+	actionBell    = "bell"
+	actionEvents  = "events"
+	actionCommand = "command"
+)
+
+var defaultAlertActions = []string{actionFlash, actionEvents}
+
+const (
+	defaultAlertWindow  = 5 * time.Minute
+	alertCommandTimeout = 10 * time.Second
+)
+
+// How long the marker of an alert flashes
+const (
+	flashDuration = 3 * time.Second
+	flashPeriod   = 250 * time.Millisecond
+)
+
+/*
+AlertRule - A condition on what the bus carries, and what to do when it is
+met
+*/
+type AlertRule struct {
+	Name      string   `json:"name"`
+	When      string   `json:"when,omitempty"`      // on the result, see filter.go
+	Threshold int      `json:"threshold,omitempty"` // hits within Window, 0 to fire per address
+	Window    string   `json:"window,omitempty"`    // default 5m
+	CountBy   string   `json:"count_by,omitempty"`  // field the hits are counted by
+	Actions   []string `json:"actions,omitempty"`
+	Command   string   `json:"command,omitempty"`
+	Disabled  bool     `json:"disabled,omitempty"`
+
+	when   *Expr
+	window time.Duration
+	match  func(m Message) (bool, string) // of the built-in rules, and why
+	hits   map[string][]hitCount          // by value of CountBy, oldest first
+	fired  map[string]time.Time           // by value of CountBy
+}
+
+/*
+hitCount - Hits seen within a second
+*/
+type hitCount struct {
+	at time.Time
+	n  int
+}
+
+/*
+prepare - Check the rule and compile its expression
+*/
+func (rule *AlertRule) prepare() error {
+	if rule.Name == "" {
+		return fmt.Errorf("Alert rule without a name")
+	}
+	if rule.When != "" {
+		when, err := ParseExpr(rule.When)
+		if err != nil {
+			return fmt.Errorf("Invalid when of alert rule '%s': %s", rule.Name, err)
+		}
+		rule.when = when
+	}
+	rule.window = defaultAlertWindow
+	if rule.Window != "" {
+		window, err := time.ParseDuration(rule.Window)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("Invalid window '%s' of alert rule '%s': Expected a duration.",
+				rule.Window, rule.Name)
+		}
+		rule.window = window
+	}
+	if rule.Threshold < 0 {
+		return fmt.Errorf("Invalid threshold %d of alert rule '%s': Expected a positive number.",
+			rule.Threshold, rule.Name)
+	}
+	if len(rule.Actions) == 0 {
+		rule.Actions = defaultAlertActions
+	}
+	for _, action := range rule.Actions {
+		switch action {
+		case actionFlash, actionBell, actionEvents:
+		case actionCommand:
+			if strings.TrimSpace(rule.Command) == "" {
+				return fmt.Errorf("Alert rule '%s' runs a command but has none", rule.Name)
+			}
+			if _, err := splitCommand(rule.Command); err != nil {
+				return fmt.Errorf("Alert rule '%s': %s", rule.Name, err)
+			}
+		default:
+			return fmt.Errorf("Unknown action '%s' of alert rule '%s': Expected %s, %s, %s or %s.",
+				action, rule.Name, actionFlash, actionBell, actionEvents, actionCommand)
+		}
+	}
+	rule.hits = make(map[string][]hitCount)
+	rule.fired = make(map[string]time.Time)
+	return nil
+}
+
+/*
+matches - Whether res passes the when of the rule
+*/
+func (rule *AlertRule) matches(res IPInfoResult) bool {
+	if rule.when == nil {
+		return true
+	}
+	ok, err := rule.when.Match(FilterEnv(res))
+	return err == nil && ok
+}
+
+/*
+count - Count n hits of res at at, and whether that makes the threshold,
+with why
+*/
+func (rule *AlertRule) count(res IPInfoResult, n int, at time.Time) (bool, string) {
+	key := ""
+	if rule.CountBy != "" {
+		key = toString(FilterEnv(res)(rule.CountBy))
+	}
+
+	hits := rule.hits[key]
+	second := at.Truncate(time.Second)
+	if last := len(hits) - 1; last >= 0 && hits[last].at.Equal(second) {
+		hits[last].n += n
+	} else {
+		hits = append(hits, hitCount{second, n})
+	}
+	i := 0
+	for i < len(hits) && at.Sub(hits[i].at) >= rule.window {
+		i++
+	}
+	hits = hits[i:]
+	rule.hits[key] = hits
+
+	total := 0
+	for _, h := range hits {
+		total += h.n
+	}
+	if total < rule.Threshold || at.Sub(rule.fired[key]) < rule.window {
+		return false, ""
+	}
+	rule.fired[key] = at
+	if rule.CountBy == "" {
+		return true, fmt.Sprintf("%d hits in %s", total, rule.window)
+	}
+	return true, fmt.Sprintf("%d hits from %s %s in %s", total, rule.CountBy, key, rule.window)
+}
+
+/*
+Alerts - Evaluates the enabled rules on the bus. A nil *Alerts checks
+nothing. Rules are only touched from the goroutine of the subscription.
+*/
+type Alerts struct {
+	rules []*AlertRule
+	bell  bool
+	stop  func() // of the subscription to the bus
+
+	commands sync.WaitGroup // of the command action still running
+
+	results map[string]IPInfoResult // by target, to count their hits
+	pending map[string][]hitCount   // hits of targets not located yet
+	listed  map[string]bool         // blocklist answers by address
+}
+
+/*
+alertOptions - Command line flags selecting the alert rules of a mode
+*/
+type alertOptions struct {
+	rules string
+	bell  bool
+}
+
+func addAlertFlags(flags *flag.FlagSet) *alertOptions {
+	opts := &alertOptions{}
+	flags.StringVar(&opts.rules, "alert", "",
+		"Comma separated alert rules to enable: new_country, blocklisted, policy,\n"+
+			"ownership or those of the config file")
+	flags.BoolVar(&opts.bell, "bell", false,
+		"Ring the terminal bell on alerts")
+	return opts
+}
+
+/*
+open - Start evaluating the rules of the config file and those enabled on
+the command line
+*/
+func (opts *alertOptions) open() (*Alerts, error) {
+	a := &Alerts{
+		bell:    opts.bell,
+		results: make(map[string]IPInfoResult),
+		pending: make(map[string][]hitCount),
+		listed:  make(map[string]bool),
+	}
+	a.rules = []*AlertRule{
+		{Name: alertNewCountry, Disabled: true, match: matchNewCountry},
+		{Name: alertBlocklisted, Disabled: true, match: a.matchBlocklisted},
+		{Name: alertPolicy, Disabled: true, match: matchPolicy},
+		{Name: alertOwnership, Disabled: true, match: matchOwnership},
+	}
+	for _, rule := range config.Alerts {
+		if a.rule(rule.Name) != nil {
+			return nil, invalidInput("Invalid alert rules in the config file: %s",
+				fmt.Sprintf(tr("'%s' is defined twice"), rule.Name))
+		}
+		rule := rule
+		a.rules = append(a.rules, &rule)
+	}
+	for _, rule := range a.rules {
+		if err := rule.prepare(); err != nil {
+			return nil, invalidInput("Invalid alert rules in the config file: %s", err)
+		}
+	}
+	for _, name := range parseFieldList(opts.rules) {
+		rule := a.rule(name)
+		if rule == nil {
+			return nil, invalidInput("Unknown alert rule '%s': Expected one of %s.",
+				name, strings.Join(a.names(), ", "))
+		}
+		rule.Disabled = false
+	}
+	a.stop = bus.Subscribe(a.check, MsgResultReady, MsgResultDropped, MsgHit)
+	return a, nil
+}
+
+func (a *Alerts) rule(name string) *AlertRule {
+	for _, rule := range a.rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (a *Alerts) names() []string {
+	var names []string
+	for _, rule := range a.rules {
+		names = append(names, rule.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+check - Evaluate the enabled rules on m
+*/
+func (a *Alerts) check(m Message) {
+	now := time.Now()
+	switch m.Topic {
+	case MsgHit:
+		res, ok := a.results[m.Target]
+		if !ok {
+			a.pending[m.Target] = append(a.pending[m.Target], hitCount{now, m.Hits})
+			return
+		}
+		a.hit(m, res, m.Hits, now)
+		return
+	case MsgResultReady:
+		a.results[m.Target] = m.Result
+		for _, h := range a.pending[m.Target] {
+			a.hit(m, m.Result, h.n, h.at)
+		}
+		delete(a.pending, m.Target)
+	case MsgResultDropped:
+		delete(a.pending, m.Target)
+	}
+
+	for _, rule := range a.rules {
+		if rule.Disabled || rule.Threshold > 0 || !rule.matches(m.Result) {
+			continue
+		}
+		if rule.match != nil {
+			if hit, why := rule.match(m); hit {
+				a.fire(rule, m, why)
+			}
+		} else if m.Topic == MsgResultReady {
+			a.fire(rule, m, fmt.Sprintf("matched %s", rule.When))
+		}
+	}
+}
+
+/*
+hit - Count n hits of the target of m, located as res, in the threshold
+rules
+*/
+func (a *Alerts) hit(m Message, res IPInfoResult, n int, at time.Time) {
+	for _, rule := range a.rules {
+		if rule.Disabled || rule.Threshold == 0 || !rule.matches(res) {
+			continue
+		}
+		if fire, why := rule.count(res, n, at); fire {
+			a.fire(rule, Message{Source: m.Source, Target: m.Target, Result: res}, why)
+		}
+	}
+}
+
+/*
+fire - Publish the alert of rule about the target of m and run its command
+*/
+func (a *Alerts) fire(rule *AlertRule, m Message, why string) {
+	alert := Message{Topic: MsgAlert, Source: m.Source, Target: m.Target, Result: m.Result,
+		Alert: rule.Name, Reason: why, Actions: rule.Actions}
+	bus.Publish(alert)
+	if hasAction(rule.Actions, actionCommand) {
+		a.commands.Add(1)
+		go func() {
+			defer a.commands.Done()
+			runAlertCommand(rule.Command, alert)
+		}()
+	}
+}
+
+/*
+Ring - Ring the bell for the alert m, if -bell or one of its actions.
+Without an interface the bell goes to stderr.
+*/
+func (a *Alerts) Ring(gui *Gui, m Message) {
+	if a == nil || !(a.bell || hasAction(m.Actions, actionBell)) {
+		return
+	}
+	if gui != nil {
+		gui.Execute(func(g *Gui) error {
+			g.Bell()
+			return nil
+		})
+		return
+	}
+	if isTerminal(os.Stderr) {
+		fmt.Fprint(os.Stderr, "\a")
+	}
+}
+
+/*
+Close - Stop evaluating the rules, once what was already published is
+checked and the commands it ran are done
+*/
+func (a *Alerts) Close() {
+	if a == nil || a.stop == nil {
+		return
+	}
+	a.stop()
+	a.commands.Wait()
+}
+
+func hasAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+alertEvent - The event of an alert of the bus
+*/
+func alertEvent(m Message) Event {
+	e := NewEvent(EventAlert, m.Result)
+	e.Rule, e.Reason = m.Alert, m.Reason
+	return e
+}
+
+/*
+runAlertCommand - Run command (a program and its arguments, split by
+splitCommand when the rule was loaded) with the event of the alert m as JSON
+on stdin
+*/
+func runAlertCommand(command string, m Message) {
+	argv, _ := splitCommand(command)
+	data, err := json.Marshal(alertEvent(m))
+	if err != nil {
+		warnf("Alert %s: %s", m.Alert, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%s: %s", err, msg)
+		}
+		warnf("Alert %s: %s failed: %s", m.Alert, argv[0], err)
+	}
+}
+
+func matchNewCountry(m Message) (bool, string) {
+	if m.Topic != MsgResultReady || !m.NewCountry {
+		return false, ""
+	}
+	return true, fmt.Sprintf("first address from %s", fieldValue(m.Result, "country"))
+}
+
+func matchPolicy(m Message) (bool, string) {
+	return m.Topic == MsgResultDropped, "dropped by the script"
+}
+
+/*
+matchBlocklisted - Whether the address of the result is on a blocklist, as
+found by -dnsbl or a plugin, else by checking the lists once per address
+*/
+func (a *Alerts) matchBlocklisted(m Message) (bool, string) {
+	if m.Topic != MsgResultReady {
+		return false, ""
+	}
+	if listed, ok := lookupPath(m.Result, "reputation.listed").([]interface{}); ok {
+		return len(listed) > 0, fieldValue(m.Result, "reputation.summary")
+	}
+	ip := net.ParseIP(fieldValue(m.Result, "ip"))
+	if ip == nil {
+		return false, ""
+	}
+	if listed, checked := a.listed[ip.String()]; checked {
+		return listed, "blocklisted"
+	}
+	rep := checkReputation(ip, dnsblLists())
+	a.listed[ip.String()] = len(rep.Listed()) > 0
+	return a.listed[ip.String()], rep.Summary()
+}
+
+// Interface
+
+/*
+alert - Show the alert m: flash the marker of its target and ring the bell,
+as its actions say
+*/
+func (b *Batch) alert(gui *Gui, alerts *Alerts, m Message) {
+	flash := hasAction(m.Actions, actionFlash)
+	b.mu.Lock()
+	if flash {
+		if b.flashing == nil {
+			b.flashing = make(map[string]time.Time)
+		}
+		b.flashing[m.Target] = time.Now().Add(flashDuration)
+	}
+	b.lastAlert = fmt.Sprintf(tr("Alert %s: %s, %s"), m.Alert, m.Target, m.Reason)
+	b.mu.Unlock()
+
+	alerts.Ring(gui, m)
+	if gui == nil {
+		return
+	}
+	if !flash {
+		b.refresh(gui)
+		return
+	}
+	go func() {
+		for i := time.Duration(0); i <= flashDuration/flashPeriod; i++ {
+			b.refresh(gui)
+			time.Sleep(flashPeriod)
+		}
+	}()
+}
+
+/*
+flash - Whether the marker of target is in the lit phase of its flashing.
+Must be called with b.mu held.
+*/
+func (b *Batch) flash(target string, now time.Time) bool {
+	until, ok := b.flashing[target]
+	if !ok || now.After(until) {
+		return false
+	}
+	return until.Sub(now)/flashPeriod%2 == 0
+}
+
+/*
+followAlerts - Show the alerts of the bus in the interface, or only ring the
+bell without one (-format jsonl)
+*/
+func (b *Batch) followAlerts(gui *Gui, alerts *Alerts) {
+	bus.Subscribe(func(m Message) {
+		b.alert(gui, alerts, m)
+	}, MsgAlert)
+}
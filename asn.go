@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+AS numbers are named from an offline table, so that providers answering a
+bare AS number and the origins of BGP routes read as "AS3320 (Deutsche
+Telekom, DE)" without another lookup. A few well-known networks are built
+in; `ip411 db update` downloads the full RIPE NCC list of AS names.
+*/
+
+/*
+ASNInfo - Holder of an AS number
+*/
+type ASNInfo struct {
+	Name    string
+	Country string
+}
+
+var builtinASNs = map[uint32]ASNInfo{
+	174:    {"Cogent Communications", "US"},
+	209:    {"Lumen (CenturyLink)", "US"},
+	701:    {"Verizon Business", "US"},
+	1299:   {"Arelion (Telia Carrier)", "SE"},
+	2914:   {"NTT America", "US"},
+	3257:   {"GTT Communications", "US"},
+	3320:   {"Deutsche Telekom", "DE"},
+	3356:   {"Lumen (Level 3)", "US"},
+	3491:   {"PCCW Global", "HK"},
+	4134:   {"China Telecom", "CN"},
+	4837:   {"China Unicom", "CN"},
+	5089:   {"Virgin Media", "GB"},
+	6453:   {"Tata Communications", "US"},
+	6461:   {"Zayo", "US"},
+	6762:   {"Telecom Italia Sparkle", "IT"},
+	6830:   {"Liberty Global", "NL"},
+	6939:   {"Hurricane Electric", "US"},
+	7018:   {"AT&T", "US"},
+	7922:   {"Comcast", "US"},
+	8075:   {"Microsoft", "US"},
+	8359:   {"MTS", "RU"},
+	8881:   {"Versatel", "DE"},
+	9009:   {"M247", "GB"},
+	9121:   {"Turk Telekom", "TR"},
+	9498:   {"Bharti Airtel", "IN"},
+	12389:  {"Rostelecom", "RU"},
+	12322:  {"Free SAS", "FR"},
+	13335:  {"Cloudflare", "US"},
+	14061:  {"DigitalOcean", "US"},
+	14618:  {"Amazon (AWS)", "US"},
+	15169:  {"Google", "US"},
+	16276:  {"OVH", "FR"},
+	16509:  {"Amazon (AWS)", "US"},
+	20940:  {"Akamai", "NL"},
+	22773:  {"Cox Communications", "US"},
+	24940:  {"Hetzner Online", "DE"},
+	31898:  {"Oracle Cloud", "US"},
+	32934:  {"Facebook (Meta)", "US"},
+	36351:  {"IBM Cloud (SoftLayer)", "US"},
+	37963:  {"Alibaba", "CN"},
+	45090:  {"Tencent Cloud", "CN"},
+	45102:  {"Alibaba Cloud", "CN"},
+	51167:  {"Contabo", "DE"},
+	54113:  {"Fastly", "US"},
+	63949:  {"Akamai (Linode)", "US"},
+	132203: {"Tencent Cloud", "CN"},
+	136907: {"Huawei Cloud", "HK"},
+	396982: {"Google Cloud", "US"},
+}
+
+var asnTable struct {
+	once  sync.Once
+	m     map[uint32]ASNInfo
+	built time.Time // when the table was downloaded, zero for the built-in one
+}
+
+/*
+asnTablePath - Location of the downloaded AS names
+*/
+func asnTablePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "asn.txt")
+}
+
+/*
+readASNTable - Read AS names in the format of the RIPE NCC list, one
+"<number> <name>, <country>" per line
+*/
+func readASNTable(r io.Reader) (map[uint32]ASNInfo, error) {
+	table := make(map[uint32]ASNInfo)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		space := strings.IndexByte(line, ' ')
+		if space < 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(line[:space], 10, 32)
+		if err != nil {
+			continue
+		}
+		info := ASNInfo{Name: strings.TrimSpace(line[space+1:])}
+		if comma := strings.LastIndex(info.Name, ", "); comma >= 0 &&
+			len(info.Name)-comma-2 == 2 {
+			info.Country = info.Name[comma+2:]
+			info.Name = info.Name[:comma]
+		}
+		table[uint32(n)] = info
+	}
+	return table, scanner.Err()
+}
+
+/*
+lookupASN - Holder of an AS number ("AS3320" or "3320"), from the downloaded
+table if there is one, else the built-in one
+*/
+func lookupASN(asn string) (ASNInfo, bool) {
+	asnTable.once.Do(func() {
+		asnTable.m = builtinASNs
+		f, err := os.Open(asnTablePath())
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		table, err := readASNTable(f)
+		if err != nil {
+			warnf("Could not read the AS names: %s", err)
+			return
+		}
+		for n, info := range builtinASNs {
+			if _, ok := table[n]; !ok {
+				table[n] = info
+			}
+		}
+		asnTable.m = table
+		if info, err := f.Stat(); err == nil {
+			asnTable.built = info.ModTime()
+		}
+	})
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asn), "AS"), 10, 32)
+	if err != nil {
+		return ASNInfo{}, false
+	}
+	info, ok := asnTable.m[uint32(n)]
+	return info, ok
+}
+
+/*
+asnLabel - An AS number with its holder, "AS3320 (Deutsche Telekom, DE)",
+or as it is if unknown
+*/
+func asnLabel(asn string) string {
+	info, ok := lookupASN(asn)
+	if !ok {
+		return asn
+	}
+	if info.Country == "" {
+		return asn + " (" + info.Name + ")"
+	}
+	return asn + " (" + info.Name + ", " + info.Country + ")"
+}
+
+/*
+nameOrg - Complete an org field that is a bare AS number with the name of
+its holder, in the "AS<number> <name>" form of ipinfo. Returns the AS names
+it was completed from, "" if it was not.
+*/
+func nameOrg(res IPInfoResult) string {
+	org := fieldValue(res, "org")
+	if org == "" || asnFromOrg(org) != org {
+		return ""
+	}
+	info, ok := lookupASN(org)
+	if !ok {
+		return ""
+	}
+	res["org"] = org + " " + info.Name
+	if asnTable.built.IsZero() {
+		return "built-in AS names"
+	}
+	return "AS names of " + asnTable.built.Format("2006-01-02")
+}
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Batch - State of a batch lookup: every target read from the input, the
+results located so far and the targets that could not be located
+*/
+type Batch struct {
+	mu        sync.Mutex
+	targets   []string
+	results   map[string]IPInfoResult
+	failed    map[string]error
+	countries map[string]bool
+	dropped   int
+	queue     *RetryQueue
+	pipeline  *Pipeline
+	source    string // of the messages of the bus: the mode
+
+	// Statistics panel, see stats.go
+	statsDim    int
+	statsByName bool
+	selection   *statSelection
+
+	filter *Expr // see filter.go
+
+	// Search, see search.go
+	search   string
+	matches  []string
+	matchIdx int
+
+	// Groups of the group mode, see groups.go
+	groupOf    map[string]string // target to group name
+	groupColor map[string]int    // group name to ANSI color
+
+	labels map[string]string // target to map label, see inventory.go
+
+	intake *Intake    // of the high-rate modes, see intake.go
+	flows  *FlowTable // conversations of the capture mode, see flows.go
+
+	// Top talkers table, see talkers.go
+	traffic     *TrafficStore
+	showTalkers bool
+	talkersSort int
+	talker      string // marked as '@'
+
+	weightMetric int // scaling the markers, see weights.go
+
+	// Countries filled on the map, see choropleth.go
+	choropleth int
+	countryOf  map[string]string // target to country
+	window     int               // index in timeWindows, see window.go
+
+	// Pause and steps, see playback.go
+	playback []PlaybackEntry
+	paused   bool
+	step     int
+
+	// Alerts, see alerts.go
+	flashing  map[string]time.Time // target to the end of its flashing
+	lastAlert string
+
+	// Distance report, see distances.go. Summarized when clusterKm > 0.
+	datacenters []Datacenter
+	clusterKm   float64
+
+	// Caps of the live modes, unlimited if 0, see limits.go
+	recent      *recentSet // targets by last sighting
+	maxTargets  int
+	maxPlayback int
+	forgotten   int
+}
+
+/*
+NewBatch - Create an empty batch retrying failed lookups through queue
+*/
+func NewBatch(queue *RetryQueue) *Batch {
+	return &Batch{
+		results:   make(map[string]IPInfoResult),
+		failed:    make(map[string]error),
+		countries: make(map[string]bool),
+		traffic:   NewTrafficStore(),
+		queue:     queue,
+		source:    "batch",
+
+		recent:      newRecentSet(),
+		maxPlayback: maxPlayback,
+	}
+}
+
+/*
+readTargets - Read one target per line from r (see input.go), skipping blank
+lines and comments starting with '#'
+*/
+func readTargets(r io.Reader) ([]string, error) {
+	var targets []string
+	err := scanLines(r, func(line string) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			return
+		}
+		if target := normalizeTarget(line); target != "" {
+			targets = append(targets, target)
+		}
+	})
+	return targets, err
+}
+
+/*
+batchHelp - What the usage message of batch says after the synopsis
+*/
+const batchHelp = `Locate and plot one IP Address, hostname or lat,lon per line (or CSV
+record) of file, or of stdin if no file is given. Failed lookups are
+retried in the background. Hostnames are resolved concurrently and may
+hold numeric ranges: web[01-20].example.com stands for web01 to web20.
+`
+
+func runBatch(args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	queuePath := flags.String("queue", "",
+		"File used to persist lookups waiting to be retried, by default one per list of targets\n"+
+			"in the user cache directory, off to keep them in memory")
+	reportPath := flags.String("report", "",
+		"Write the distances between the targets to this .json or .csv file")
+	dcPath := flags.String("datacenters", "",
+		"Report the nearest of the datacenters of this file (name,lat,lon or name,ip)")
+	clusterKm := flags.Float64("cluster-km", 500,
+		"Distance under which targets are clustered together")
+	resolveFlags := addResolveFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s batch [-queue file] [-report file] [-datacenters file] [-resolver addr] [file]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(batchHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	targets, err := readTargets(input)
+	if err != nil {
+		return err
+	}
+	if targets, err = expandTargets(targets); err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+	resolver, err := resolveFlags.open()
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	switch *queuePath {
+	case "":
+		*queuePath = defaultQueuePath(targets)
+	case "off":
+		*queuePath = ""
+	}
+	queue, err := NewRetryQueue(*queuePath)
+	if err != nil {
+		return err
+	}
+
+	b := NewBatch(queue)
+	b.pipeline = pipeline
+	b.clusterKm = *clusterKm
+	if *dcPath != "" {
+		if b.datacenters, err = readDatacenters(*dcPath); err != nil {
+			return err
+		}
+	}
+	for _, target := range queue.Targets() {
+		b.add(target)
+	}
+	var fresh, hosts []string
+	for _, target := range targets {
+		if isHostname(target) {
+			hosts = append(hosts, target)
+		} else if b.add(target) {
+			fresh = append(fresh, target)
+		}
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		go func() {
+			if len(hosts) > 0 {
+				guiShowStatus(gui, "Resolving %d hostnames", len(hosts))
+				fresh = append(fresh, b.addHosts(resolver, hosts)...)
+				b.refresh(gui)
+			}
+			for _, target := range fresh {
+				b.lookup(target)
+			}
+			if *reportPath != "" {
+				b.mu.Lock()
+				report := b.distanceReport()
+				b.mu.Unlock()
+				if err := report.Save(*reportPath); err != nil {
+					guiShowStatus(gui, "Could not write the report: %s", err)
+				}
+			}
+		}()
+		go b.retry()
+		return nil
+	})
+}
+
+/*
+add - Add target to the batch, returning false if it was already there.
+Beyond maxTargets, the least recently seen target is forgotten.
+*/
+func (b *Batch) add(target string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.recent.touch(target) {
+		return false
+	}
+	b.targets = append(b.targets, target)
+	b.recent.trim(b.maxTargets, b.forget)
+	return true
+}
+
+/*
+setKeybindings - Register the keys of the views specific to batches
+*/
+func (b *Batch) setKeybindings(g *Gui) error {
+	legendSource = b.legendLines
+	if b.maxTargets > 0 {
+		memorySource = b.memoryUsage
+	}
+	if err := b.setStatsKeybindings(g); err != nil {
+		return err
+	}
+	if err := b.setFilterKeybindings(g); err != nil {
+		return err
+	}
+	if err := b.setSearchKeybindings(g); err != nil {
+		return err
+	}
+	if err := b.setFirewallKeybindings(g); err != nil {
+		return err
+	}
+	if err := b.setExportKeybindings(g); err != nil {
+		return err
+	}
+	if err := g.SetKeybinding("", 'C', ModNone, b.nextChoropleth); err != nil {
+		return err
+	}
+	return g.SetKeybinding("", 'B', ModNone, b.bookmarkAll)
+}
+
+/*
+retry - Retry queued lookups as they come due, forever
+*/
+func (b *Batch) retry() {
+	for {
+		b.lookup(b.queue.Next())
+	}
+}
+
+/*
+follow - Refresh the interface with every lookup of the bus
+*/
+func (b *Batch) follow(gui *Gui) {
+	bus.Subscribe(func(Message) {
+		b.refresh(gui)
+	}, MsgResultReady, MsgLookupFailed, MsgResultDropped)
+}
+
+/*
+lookup - Locate target, queueing it for a retry if the provider could not be
+reached. Targets that are not IP Addresses or have no location fail for good.
+The outcome is published on the bus.
+*/
+func (b *Batch) lookup(target string) {
+	bus.Publish(Message{Topic: MsgLookupRequested, Source: b.source, Target: target})
+	var ipinfo IPInfoResult
+	var rtt time.Duration
+	if p, ok := targetPoint(target); ok {
+		ipinfo = pointResult(p)
+	} else {
+		ip, err := makeIP([]string{target})
+		if err != nil {
+			b.fail(target, err)
+			return
+		}
+
+		ipinfo, rtt, err = getIPInfoTimed(ip)
+		if err != nil {
+			if qerr := b.queue.Fail(target, err); qerr != nil {
+				log.Println(qerr)
+			}
+			bus.Publish(Message{Topic: MsgLookupFailed, Source: b.source, Target: target, Err: err})
+			return
+		}
+		if err := b.queue.Done(target); err != nil {
+			log.Println(err)
+		}
+	}
+
+	if err := locationError(ipinfo); err != nil {
+		b.fail(target, err)
+		return
+	}
+
+	keep, err := b.pipeline.Process(ipinfo)
+	if err != nil {
+		log.Println(err)
+	}
+	if !keep {
+		b.mu.Lock()
+		b.dropped++
+		b.mu.Unlock()
+		bus.Publish(Message{Topic: MsgResultDropped, Source: b.source, Target: target, Result: ipinfo})
+		return
+	}
+
+	country, _ := ipinfo.GetKey("country")
+
+	b.mu.Lock()
+	b.results[target] = ipinfo
+	b.record(target, time.Now().Format("15:04:05"))
+	newCountry := country != "" && !b.countries[country]
+	b.countries[country] = true
+	b.mu.Unlock()
+
+	bus.Publish(Message{Topic: MsgResultReady, Source: b.source, Target: target,
+		Result: ipinfo, RTT: rtt, NewCountry: newCountry})
+}
+
+func (b *Batch) fail(target string, err error) {
+	b.mu.Lock()
+	b.failed[target] = err
+	b.mu.Unlock()
+	bus.Publish(Message{Topic: MsgLookupFailed, Source: b.source, Target: target, Err: err})
+}
+
+/*
+shown - The located targets on the map: passing the filter, in the row
+selected in the statistics, in the time window and up to the current step.
+Must be called with b.mu held.
+*/
+func (b *Batch) shown() []string {
+	seen := b.windowTraffic()
+	hidden := b.playbackHidden()
+	var targets []string
+	for _, target := range b.targets {
+		ipinfo, ok := b.results[target]
+		if !ok || !b.selection.match(ipinfo) || !b.matchFilter(ipinfo) ||
+			!b.inWindow(target, seen) || hidden[target] {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+/*
+markers - Map markers for every shown target, then for the lookups waiting
+to be retried
+*/
+func (b *Batch) markers() []Marker {
+	ranks := b.talkerRanks()
+	pending := b.queue.Pending()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	values, max := b.weightValues()
+	now := time.Now()
+	var markers []Marker
+	for _, target := range b.shown() {
+		ipinfo := b.results[target]
+		lon, lat, err := ipinfo.GetLonLat()
+		if err != nil {
+			continue
+		}
+		text := markerLabel(ipinfo)
+		if _, scripted := ipinfo["label"]; !scripted && b.labels[target] != "" {
+			text = b.labels[target]
+		}
+		if rank, ok := ranks[target]; ok {
+			text = rank
+		}
+		if target == b.currentMatch() || target == b.talker || target == b.playbackCurrent() {
+			text = "@"
+		}
+		m := Marker{Lon: lon, Lat: lat, Text: text, Color: b.groupColor[b.groupOf[target]],
+			Weight: markerWeight(values[target], max), Targets: []string{target}}
+		if m.Color == 0 && m.Weight > 0 {
+			m.Color = heatColor(m.Weight)
+		}
+		if b.flash(target, now) {
+			m.Text, m.Color = "!", 31 // red
+		} else if b.choropleth != 0 && m.Text != "@" {
+			continue
+		}
+		markers = append(markers, m)
+	}
+	return append(markers, b.pendingMarkers(pending)...)
+}
+
+/*
+pendingMarkers - '?' markers for the lookups waiting to be retried, which
+have no location yet: each is put where pendingPlaces says, an approximate
+location the info pane and the legend call so. Must be called with b.mu
+held.
+*/
+func (b *Batch) pendingMarkers(pending []QueuedLookup) []Marker {
+	places := b.pendingPlaces(pending)
+	var markers []Marker
+	for _, job := range pending {
+		near, ok := places[job.Target]
+		if !ok {
+			continue
+		}
+		if lon, lat, err := b.results[near].GetLonLat(); err == nil {
+			markers = append(markers, Marker{Lon: lon, Lat: lat, Text: pendingLabel,
+				Targets: []string{job.Target}})
+		}
+	}
+	return markers
+}
+
+/*
+pendingPlaces - The shown target each lookup waiting to be retried borrows
+the location of: one of its network, the BGP prefix of the target (-bgp) or
+else its /24 (/48 for IPv6). The others are only listed in the info pane.
+Must be called with b.mu held.
+*/
+func (b *Batch) pendingPlaces(pending []QueuedLookup) map[string]string {
+	if len(pending) == 0 {
+		return nil
+	}
+	type network struct {
+		prefix *net.IPNet
+		target string
+	}
+	var networks []network
+	for _, target := range b.shown() {
+		_, _, err := b.results[target].GetLonLat()
+		prefix := targetPrefix(target, b.results[target])
+		if err != nil || prefix == nil {
+			continue
+		}
+		if ones, bits := prefix.Mask.Size(); ones == bits {
+			prefix = neighborhood(prefix.IP)
+		}
+		networks = append(networks, network{prefix, target})
+	}
+
+	places := make(map[string]string)
+	for _, job := range pending {
+		ip := net.ParseIP(job.Target)
+		if ip == nil {
+			continue
+		}
+		for _, n := range networks {
+			if n.prefix.Contains(ip) {
+				places[job.Target] = n.target
+				break
+			}
+		}
+	}
+	return places
+}
+
+/*
+neighborhood - The /24 of an IPv4 Address, the /48 of an IPv6 one
+*/
+func neighborhood(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}
+	}
+	return &net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}
+}
+
+/*
+summary - Lines for the info pane: overall progress, then lookups waiting to
+be retried (marked '?') and failed targets (marked '!')
+*/
+func (b *Batch) summary() []string {
+	pending := b.queue.Pending()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	line := fmt.Sprintf(tr("Targets: %s  Located: %s  Pending: %s  Failed: %s"),
+		formatCount(len(b.targets)), formatCount(len(b.results)),
+		formatCount(len(pending)), formatCount(len(b.failed)))
+	if b.dropped > 0 {
+		line += fmt.Sprintf(tr("  Dropped: %s"), formatCount(b.dropped))
+	}
+	if b.filter != nil {
+		shown := 0
+		for _, res := range b.results {
+			if b.matchFilter(res) {
+				shown++
+			}
+		}
+		line += fmt.Sprintf(tr("  Shown: %s (%s)"), formatCount(shown), b.filter)
+	}
+	lines := append(b.playbackSummary(), line)
+	if b.lastAlert != "" {
+		lines = append(lines, b.lastAlert)
+	}
+	if b.flows != nil {
+		lines = append(lines, b.flows.String())
+	}
+	if window := b.windowSummary(); window != "" {
+		lines = append(lines, window)
+	}
+	if legend := b.weightLegend(); legend != "" {
+		lines = append(lines, legend)
+	}
+	if legend := b.choroplethLegend(); legend != "" {
+		lines = append(lines, legend)
+	}
+	lines = append(lines, b.groupSummary()...)
+	if b.clusterKm > 0 {
+		lines = append(lines, b.distanceReport().Summary()...)
+	}
+	if b.search != "" {
+		lines = append(lines, b.searchSummary())
+	}
+
+	now := time.Now()
+	places := b.pendingPlaces(pending)
+	for _, job := range pending {
+		line := fmt.Sprintf("? %s  attempt %d, retry in %s: %s",
+			job.Target, job.Attempts, job.NextTry.Sub(now).Round(time.Second),
+			job.Error)
+		if near, ok := places[job.Target]; ok {
+			line += fmt.Sprintf(tr("  (shown at %s, approximate)"), near)
+		}
+		lines = append(lines, line)
+	}
+	for _, target := range b.targets {
+		if err, ok := b.failed[target]; ok {
+			lines = append(lines, fmt.Sprintf("! %s: %s", target, err))
+		}
+	}
+	return lines
+}
+
+/*
+refresh - Redraw the map and the info pane. Without a gui (-format jsonl)
+there is nothing to redraw.
+*/
+func (b *Batch) refresh(gui *Gui) {
+	if gui == nil {
+		return
+	}
+	gui.Execute(func(g *Gui) error {
+
+		mapView, err := g.View("map")
+		if err != nil {
+			return err
+		}
+		markers := b.markers()
+		b.mu.Lock()
+		mapFill = b.countryWeights()
+		b.mu.Unlock()
+		drawMap(mapView, markers)
+		if err := showStack(g); err != nil {
+			return err
+		}
+
+		infoView, err := g.View("info")
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		infoView.Clear()
+		for _, line := range b.summary() {
+			fmt.Fprintln(infoView, line)
+		}
+		if statsView, err := g.View("stats"); err == nil {
+			b.renderStats(statsView)
+		}
+		if talkersView, err := g.View("talkers"); err == nil {
+			b.renderTalkers(talkersView)
+		}
+		mu.Unlock()
+
+		return nil
+	})
+	if b.intake != nil {
+		guiShowStatus(gui, "%s", b.intake)
+	} else {
+		guiLoadStatus(gui)
+	}
+}
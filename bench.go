@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+benchSample - Well-known public addresses spread over the continents, for
+benchmarks without an address list
+*/
+var benchSample = []string{
+	"8.8.8.8",         // Google DNS, US
+	"1.1.1.1",         // Cloudflare DNS, anycast
+	"9.9.9.9",         // Quad9, anycast
+	"193.0.14.129",    // K-root, RIPE NCC, NL
+	"202.12.27.33",    // M-root, WIDE, JP
+	"200.160.0.8",     // NIC.br, BR
+	"196.216.2.1",     // AFRINIC, MU
+	"203.119.101.61",  // APNIC, AU
+	"77.88.8.8",       // Yandex DNS, RU
+	"114.114.114.114", // 114DNS, CN
+	"185.199.108.153", // GitHub Pages
+	"151.101.1.69",    // Fastly
+}
+
+/*
+BenchResult - How a provider did on the sample
+*/
+type BenchResult struct {
+	Provider     string  `json:"provider"`
+	Lookups      int     `json:"lookups"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	MedianMs     float64 `json:"median_ms"`
+	P90Ms        float64 `json:"p90_ms"`
+	Completeness float64 `json:"completeness"` // of the common fields, over answered lookups
+}
+
+/*
+completeness - Fraction of the common fields res fills in
+*/
+func completeness(res IPInfoResult) float64 {
+	n := 0
+	for _, field := range commonFields {
+		if fieldValue(res, field) != "" {
+			n++
+		}
+	}
+	return float64(n) / float64(len(commonFields))
+}
+
+/*
+benchProvider - Look every address up with p
+*/
+func benchProvider(p *Provider, ips []net.IP) BenchResult {
+	r := BenchResult{Provider: p.Name, Lookups: len(ips)}
+	var latencies []float64
+	var complete float64
+	for _, ip := range ips {
+		res, rtt, err := p.Lookup(ip)
+		if err == nil && fieldValue(res, "error") != "" {
+			err = fmt.Errorf("%s", fieldValue(res, "error"))
+		}
+		if err != nil {
+			warnf("%s %s: %s", p.Name, ip, err)
+			r.Errors++
+			continue
+		}
+		latencies = append(latencies, float64(rtt)/float64(time.Millisecond))
+		complete += completeness(res)
+	}
+	sort.Float64s(latencies)
+	r.MedianMs = percentile(latencies, 50)
+	r.P90Ms = percentile(latencies, 90)
+	if r.Lookups > 0 {
+		r.ErrorRate = float64(r.Errors) / float64(r.Lookups)
+	}
+	if answered := r.Lookups - r.Errors; answered > 0 {
+		r.Completeness = complete / float64(answered)
+	}
+	return r
+}
+
+func writeBenchResults(w io.Writer, results []BenchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Provider\tLookups\tErrors\tMedian ms\tP90 ms\tFields\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f%%\t%.0f\t%.0f\t%.0f%%\t\n", r.Provider, r.Lookups,
+			100*r.ErrorRate, r.MedianMs, r.P90Ms, 100*r.Completeness)
+	}
+	return tw.Flush()
+}
+
+/*
+benchHelp - What the usage message of bench says after the synopsis
+*/
+const benchHelp = `Look the IP Addresses of file (or a built-in sample of well-known
+addresses) up with every provider, and compare their latency, error
+rate and how many of the common fields they fill in.
+`
+
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	addQuietFlag(flags)
+	names := flags.String("providers", "",
+		"Comma separated providers to compare (default all but mock)")
+	asJSON := flags.Bool("json", false, "Print the comparison as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s bench [-providers list] [-json] [file]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(benchHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+
+	var selected []*Provider
+	if *names == "" {
+		for _, p := range providers {
+			if p.answer == nil {
+				selected = append(selected, p)
+			}
+		}
+	} else {
+		for _, name := range parseFieldList(*names) {
+			p, err := findProvider(name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, p)
+		}
+	}
+
+	targets := benchSample
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if targets, err = readTargets(f); err != nil {
+			return err
+		}
+	}
+	var ips []net.IP
+	for _, target := range targets {
+		ip, err := makeIP([]string{target})
+		if err != nil {
+			return withExitCode(exitInvalidInput, err)
+		}
+		ips = append(ips, ip)
+	}
+
+	var results []BenchResult
+	for _, p := range selected {
+		fmt.Fprintf(os.Stderr, "Benchmarking %s...\n", p.Name)
+		results = append(results, benchProvider(p, ips))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return writeBenchResults(os.Stdout, results)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*
+With -bgp the covering prefix, origin AS and RPKI validity of the located IP
+Address are queried from RIPEstat and added to the result under "bgp", where
+the info pane, scripts and sinks can use them:
+
+	bgp.prefix       "8.8.8.0/24", "" if the address is not announced
+	bgp.origin       "AS15169"
+	bgp.origin_name  "AS15169 (Google, US)", see asn.go
+	bgp.rpki         valid, invalid, invalid_asn, invalid_length or unknown
+*/
+
+const ripestatURL = "https://stat.ripe.net/data"
+
+var bgpFlag = flag.Bool("bgp", false,
+	"Add the BGP prefix, origin AS and RPKI validity of the IP Address (RIPEstat)")
+
+var bgpClient = &http.Client{Timeout: 10 * time.Second}
+
+var bgpInfoFields = []InfoField{
+	{"BGP prefix", "bgp.prefix"},
+	{"Origin AS", "bgp.origin_name"},
+	{"RPKI", "bgp.rpki"},
+}
+
+/*
+BGPInfo - Routing context of an IP Address
+*/
+type BGPInfo struct {
+	Prefix string `json:"prefix"`
+	Origin string `json:"origin"`
+	RPKI   string `json:"rpki"`
+}
+
+func ripestat(call string, params url.Values, data interface{}) error {
+	resp, err := bgpClient.Get(ripestatURL + "/" + call + "/data.json?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RIPEstat %s: %s", call, resp.Status)
+	}
+
+	reply := struct {
+		Data interface{} `json:"data"`
+	}{data}
+	return json.NewDecoder(resp.Body).Decode(&reply)
+}
+
+/*
+lookupBGP - Query the routing context of ip
+*/
+func lookupBGP(ip string) (*BGPInfo, error) {
+	var network struct {
+		ASNs   []string `json:"asns"`
+		Prefix string   `json:"prefix"`
+	}
+	if err := ripestat("network-info", url.Values{"resource": {ip}}, &network); err != nil {
+		return nil, err
+	}
+	info := &BGPInfo{Prefix: network.Prefix}
+	if network.Prefix == "" || len(network.ASNs) == 0 {
+		return info, nil
+	}
+	info.Origin = "AS" + network.ASNs[0]
+
+	var validation struct {
+		Status string `json:"status"`
+	}
+	params := url.Values{"resource": {info.Origin}, "prefix": {info.Prefix}}
+	if err := ripestat("rpki-validation", params, &validation); err != nil {
+		return nil, err
+	}
+	info.RPKI = validation.Status
+	return info, nil
+}
+
+/*
+Warning - What is suspicious about the route, "" if nothing
+*/
+func (info *BGPInfo) Warning() string {
+	switch {
+	case info.Prefix == "":
+		return "Not announced in BGP"
+	case info.RPKI != "" && info.RPKI != "valid" && info.RPKI != "unknown":
+		return fmt.Sprintf("RPKI %s: %s from %s", info.RPKI, info.Prefix, info.Origin)
+	}
+	return ""
+}
+
+/*
+addBGP - Add the routing context of the IP Address of res under "bgp"
+*/
+func addBGP(res IPInfoResult) (*BGPInfo, error) {
+	ip, _ := res.GetKey("ip")
+	if ip == "" {
+		return nil, fmt.Errorf("No IP Address in the result")
+	}
+	info, err := lookupBGP(ip)
+	if err != nil {
+		return nil, err
+	}
+	res["bgp"] = map[string]interface{}{
+		"prefix":      info.Prefix,
+		"origin":      info.Origin,
+		"origin_name": asnLabel(info.Origin),
+		"rpki":        info.RPKI,
+	}
+	return info, nil
+}
+
+/*
+bgpStatus - Add the routing context to res, returning what the status bar
+should warn about ("" if nothing)
+*/
+func bgpStatus(res IPInfoResult) string {
+	info, err := addBGP(res)
+	if err != nil {
+		return fmt.Sprintf("BGP lookup failed: %s", err)
+	}
+	return info.Warning()
+}
+
+/*
+showBGPFields - Add the BGP fields to the info pane unless the config file
+already lists them
+*/
+func showBGPFields() {
+	for _, field := range config.InfoFields {
+		if field.Path == "bgp.prefix" {
+			return
+		}
+	}
+	fields := append([]InfoField{}, config.InfoFields...)
+	config.InfoFields = append(fields, bgpInfoFields...)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Bookmarks name the targets looked up often. They are kept in the config
+file and used as "@name" wherever an IP Address is expected:
+
+	{"bookmarks": {"home-server": "203.0.113.7", "blog": "example.com"}}
+
+	b      list the bookmarks, enter locates the selected one
+	B      in batch, logs, monitor and group, bookmark every located target
+*/
+
+/*
+resolveBookmark - Target of the bookmark named by an "@name" argument. Other
+arguments are returned as is.
+*/
+func resolveBookmark(arg string) (string, error) {
+	if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	target, ok := config.Bookmarks[arg[1:]]
+	if !ok {
+		return "", fmt.Errorf("Unknown bookmark '%s'", arg[1:])
+	}
+	return target, nil
+}
+
+/*
+bookmarkNames - Names of the bookmarks, sorted
+*/
+func bookmarkNames() []string {
+	names := make([]string, 0, len(config.Bookmarks))
+	for name := range config.Bookmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+AddBookmark - Bookmark target as name, or as name-2, name-3... if name is
+taken. Nothing is added if target already has a bookmark. Returns whether a
+bookmark was added.
+*/
+func (c *Config) AddBookmark(name, target string) bool {
+	for _, t := range c.Bookmarks {
+		if t == target {
+			return false
+		}
+	}
+	if c.Bookmarks == nil {
+		c.Bookmarks = make(map[string]string)
+	}
+	unique := name
+	for i := 2; ; i++ {
+		if _, taken := c.Bookmarks[unique]; !taken {
+			break
+		}
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	c.Bookmarks[unique] = target
+	return true
+}
+
+/*
+setBookmarkKeybindings - Register the key of the bookmark menu. show is
+called in its own goroutine with the target of the chosen bookmark.
+*/
+func setBookmarkKeybindings(g *Gui, show func(target string)) error {
+	return g.SetKeybinding("", 'b', ModNone, func(g *Gui, v *View) error {
+		names := bookmarkNames()
+		items := make([]string, len(names))
+		for i, name := range names {
+			items[i] = fmt.Sprintf("@%-20s %s", name, config.Bookmarks[name])
+		}
+		return selectDialog(g, "Bookmarks: enter locate, esc close", items,
+			func(g *Gui, i int) error {
+				go show(config.Bookmarks[names[i]])
+				return nil
+			})
+	})
+}
+
+/*
+bookmarkAll - Bookmark every located target of the batch, named by hostname
+when it has one, once confirmed
+*/
+func (b *Batch) bookmarkAll(g *Gui, v *View) error {
+	b.mu.Lock()
+	n := len(b.results)
+	b.mu.Unlock()
+	if n == 0 {
+		guiShowStatus(g, "Bookmarked %d targets", 0)
+		return nil
+	}
+	return confirmDialog(g, b.addBookmarks, "Bookmark the %d located targets in the config file?", n)
+}
+
+func (b *Batch) addBookmarks(g *Gui) error {
+	b.mu.Lock()
+	added := 0
+	for _, target := range b.targets {
+		res, ok := b.results[target]
+		if !ok {
+			continue
+		}
+		name := fieldValue(res, "hostname")
+		if name == "" {
+			name = target
+		}
+		if config.AddBookmark(name, target) {
+			added++
+		}
+	}
+	b.mu.Unlock()
+
+	if err := config.Save(); err != nil {
+		guiShowStatus(g, "Could not save config: %s", err)
+		return nil
+	}
+	guiShowStatus(g, "Bookmarked %d targets", added)
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+The modes are wired through a bus: input sources (arguments, stdin, capture
+files, the proxy...) publish what happens to each lookup, and sinks (the
+interface, the event sinks, exports) subscribe to what they show, instead of
+sources calling every sink themselves. Each subscriber gets the messages in
+the order they were published, in a goroutine of its own, so a slow sink
+(a webhook) holds back neither the sources nor the other sinks. A subscriber
+more than busQueueSize messages behind loses the oldest, counted in the
+status bar with the caps of limits.go.
+*/
+
+// Most messages waiting for a subscriber
+const busQueueSize = 10000
+
+// Topics of the messages of the bus
+const (
+	// MsgLookupRequested - A target is about to be located
+	MsgLookupRequested = "lookup_requested"
+	// MsgResultReady - A target was located and its result kept
+	MsgResultReady = "result_ready"
+	// MsgLookupFailed - A target could not be located, or will be retried
+	MsgLookupFailed = "lookup_failed"
+	// MsgResultDropped - A result was dropped by the script of -script
+	MsgResultDropped = "result_dropped"
+	// MsgMapUpdated - The map was drawn again
+	MsgMapUpdated = "map_updated"
+	// MsgAlert - A result matched an alert rule, see alerts.go
+	MsgAlert = "alert"
+	// MsgHit - A target was seen again (a log line, a connection...)
+	MsgHit = "hit"
+)
+
+/*
+Message - Something that happened to a lookup, published on the bus
+*/
+type Message struct {
+	Topic      string
+	Source     string // "batch", "capture", "watch", "proxy"...
+	Target     string
+	Result     IPInfoResult
+	RTT        time.Duration
+	Err        error
+	NewCountry bool     // the result is the first of its country in this session
+	Alert      string   // the rule of a MsgAlert
+	Reason     string   // why the rule matched
+	Actions    []string // of the rule of a MsgAlert
+	Hits       int      // of a MsgHit
+}
+
+/*
+Bus - Delivers published messages to the subscribers of their topic. A nil
+*Bus drops everything.
+*/
+type Bus struct {
+	mu      sync.Mutex
+	subs    map[string][]*subscription
+	dropped uint64 // atomic, messages pushed out of full queues
+}
+
+/*
+subscription - The messages waiting for a subscriber. Publishers never wait
+for them to be handled, even from a handler.
+*/
+type subscription struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []Message
+	closed  bool
+}
+
+/*
+push - Queue m, making room by dropping the oldest message if the queue is
+full. Returns whether one was dropped.
+*/
+func (s *subscription) push(m Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	full := len(s.pending) >= busQueueSize
+	if full {
+		s.pending[0] = Message{}
+		s.pending = s.pending[1:]
+	}
+	s.pending = append(s.pending, m)
+	s.cond.Signal()
+	return full
+}
+
+/*
+next - The next message, false once closed and drained
+*/
+func (s *subscription) next() (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.pending) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.pending) == 0 {
+		return Message{}, false
+	}
+	m := s.pending[0]
+	s.pending = s.pending[1:]
+	return m, true
+}
+
+func (s *subscription) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+// The bus of the process, one mode running at a time
+var bus = NewBus()
+
+/*
+NewBus - Create a bus without subscribers
+*/
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]*subscription)}
+}
+
+/*
+Subscribe - Call handler with every message of topics until the returned
+function is called, which waits for the messages already published to be
+handled
+*/
+func (b *Bus) Subscribe(handler func(Message), topics ...string) func() {
+	if b == nil {
+		return func() {}
+	}
+	sub := &subscription{}
+	sub.cond = sync.NewCond(&sub.mu)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			m, ok := sub.next()
+			if !ok {
+				return
+			}
+			handler(m)
+		}
+	}()
+
+	b.mu.Lock()
+	for _, topic := range topics {
+		b.subs[topic] = append(b.subs[topic], sub)
+	}
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		for _, topic := range topics {
+			subs := b.subs[topic]
+			for i, s := range subs {
+				if s == sub {
+					b.subs[topic] = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+		}
+		b.mu.Unlock()
+		sub.close()
+		<-done
+	}
+}
+
+/*
+Publish - Queue m for the subscribers of its topic
+*/
+func (b *Bus) Publish(m Message) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs[m.Topic] {
+		if sub.push(m) {
+			atomic.AddUint64(&b.dropped, 1)
+		}
+	}
+}
+
+/*
+Dropped - How many messages subscribers lost for being too far behind
+*/
+func (b *Bus) Dropped() uint64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&b.dropped)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBusDropsTheOldestOfFullQueues(t *testing.T) {
+	b := NewBus()
+	received := make(chan string, busQueueSize+20)
+	release := make(chan struct{})
+	stop := b.Subscribe(func(m Message) {
+		received <- m.Target
+		<-release
+	}, MsgHit)
+
+	// The first message holds the handler, the others wait in the queue
+	b.Publish(Message{Topic: MsgHit, Target: "0"})
+	<-received
+	for i := 1; i <= busQueueSize+10; i++ {
+		b.Publish(Message{Topic: MsgHit, Target: strconv.Itoa(i)})
+	}
+	b.Publish(Message{Topic: MsgResultReady, Target: "not subscribed"})
+	if dropped := b.Dropped(); dropped != 10 {
+		t.Fatalf("%d messages dropped, expected 10", dropped)
+	}
+
+	close(release)
+	stop()
+	close(received)
+	want := 11
+	for target := range received {
+		if target != strconv.Itoa(want) {
+			t.Fatalf("received %s, expected %d", target, want)
+		}
+		want++
+	}
+	if want != busQueueSize+11 {
+		t.Fatalf("received up to %d, expected %d", want-1, busQueueSize+10)
+	}
+
+	var none *Bus
+	if none.Dropped() != 0 {
+		t.Error("a nil bus dropped messages")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"container/list"
+	"flag"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Cache - Provider responses kept for a while, by key ("ip411:ipinfo:8.8.8.8"), so
+that the same address is not looked up again before its entry expires
+*/
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Stats() CacheStats
+}
+
+/*
+CacheStats - How well a cache does
+*/
+type CacheStats struct {
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	Entries int `json:"entries"`
+}
+
+/*
+HitRate - Fraction of the gets that hit, 0 before any
+*/
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+/*
+MemoryCache - A cache in memory, forgetting the least recently used entries
+beyond its size
+*/
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	recent  *list.List // of *memoryEntry, most recently used first
+	stats   CacheStats
+}
+
+/*
+NewMemoryCache - Create a cache of at most size entries
+*/
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, entries: make(map[string]*list.Element), recent: list.New()}
+}
+
+/*
+Get - The value of key, false if absent or expired
+*/
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && time.Now().After(elem.Value.(*memoryEntry).expires) {
+		c.recent.Remove(elem)
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.recent.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).value, true
+}
+
+/*
+Set - Keep value for key during ttl
+*/
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key, value, time.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.recent.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(entry)
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+/*
+Stats - Hits and misses so far, entries now
+*/
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+/*
+-cache (or cache in the config file) keeps the provider responses of
+lookups, so that an address is looked up once per -cache-ttl. In memory the
+cache lasts as long as the process; in Redis or Valkey it is shared by every
+ip411 pointed at the server, CLI, server and proxy alike:
+
+	-cache memory
+	-cache redis://:password@cache.internal:6379/2
+	-cache rediss://cache.internal    (TLS)
+
+Keys are namespaced, "ip411:ipinfo:8.8.8.8" by default, so that teams or
+setups may share a server without sharing entries.
+*/
+
+// Entries kept by -cache memory
+const defaultCacheSize = 100000
+
+var lookupCache = struct {
+	raw       string
+	cache     Cache // nil without -cache
+	ttl       time.Duration
+	namespace string
+}{ttl: 24 * time.Hour, namespace: "ip411"}
+
+/*
+setCache - Parse and use raw as the lookup cache
+*/
+func setCache(raw string) error {
+	switch {
+	case raw == "" || raw == "none":
+		lookupCache.cache = nil
+	case raw == "memory":
+		lookupCache.cache = instrumentCache(NewMemoryCache(defaultCacheSize), "memory")
+	case strings.HasPrefix(raw, "redis://") || strings.HasPrefix(raw, "rediss://"):
+		cache, err := NewRedisCache(raw)
+		if err != nil {
+			return invalidInput("Invalid cache '%s': %s", raw, err)
+		}
+		lookupCache.cache = instrumentCache(cache, "redis")
+	default:
+		return invalidInput("Invalid cache '%s': Expected memory, redis:// or rediss://.", raw)
+	}
+	lookupCache.raw = raw
+	return nil
+}
+
+/*
+cacheFlag - -cache, checked as soon as it is parsed
+*/
+type cacheFlag struct{}
+
+func (cacheFlag) String() string {
+	return lookupCache.raw
+}
+
+/*
+Set .
+*/
+func (cacheFlag) Set(raw string) error {
+	return setCache(raw)
+}
+
+func addCacheFlags(flags *flag.FlagSet) {
+	flags.Var(cacheFlag{}, "cache",
+		"Cache provider responses: memory, or redis://[:password@]host[:port][/db]\n"+
+			"(rediss:// for TLS) to share them with other ip411 instances")
+	flags.DurationVar(&lookupCache.ttl, "cache-ttl", lookupCache.ttl,
+		"How long cached responses are used")
+	flags.StringVar(&lookupCache.namespace, "cache-namespace", lookupCache.namespace,
+		"Prefix of the cache keys, to keep apart setups sharing a server")
+}
+
+/*
+cacheKey - The key of the response of p for ip
+*/
+func cacheKey(p *Provider, ip net.IP) string {
+	return lookupCache.namespace + ":" + p.Name + ":" + ip.String()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+/*
+The calc mode does IP math without any lookup, printing one result per
+line:
+
+	contains prefix ip...   which addresses or prefixes are within prefix,
+	                        failing if one is not
+	range start end         the prefixes covering the addresses start to end
+	next prefix [n]         the prefix of the same length n after (default 1)
+	prev prefix [n]         the prefix of the same length n before
+	sample prefix [n]       n random addresses of prefix (default 10)
+*/
+
+const maxSample = 100000
+
+/*
+optionalCount - The count argument at i of args, def if absent
+*/
+func optionalCount(args []string, i, def int) (int, error) {
+	if len(args) <= i {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[i])
+	if err != nil || n < 0 {
+		return 0, invalidInput("Invalid count '%s': Expected a positive number.", args[i])
+	}
+	return n, nil
+}
+
+/*
+parsePrefixArg - parsePrefix for the command line, normalized
+*/
+func parsePrefixArg(s string) (*net.IPNet, error) {
+	prefix, err := parsePrefix(s)
+	if err != nil {
+		return nil, withExitCode(exitInvalidInput, err)
+	}
+	return normalizePrefix(prefix), nil
+}
+
+func calcContains(args []string) error {
+	if len(args) < 2 {
+		return invalidInput("Specify a prefix and the addresses to test.")
+	}
+	prefix, err := parsePrefixArg(args[0])
+	if err != nil {
+		return err
+	}
+	outside := 0
+	for _, arg := range args[1:] {
+		member, err := parsePrefixArg(arg)
+		if err != nil {
+			return err
+		}
+		memberOnes, _ := member.Mask.Size()
+		ones, _ := prefix.Mask.Size()
+		if len(member.IP) == len(prefix.IP) && prefix.Contains(member.IP) && memberOnes >= ones {
+			fmt.Printf("%s in %s\n", arg, prefix)
+		} else {
+			fmt.Printf("%s not in %s\n", arg, prefix)
+			outside++
+		}
+	}
+	if outside > 0 {
+		return fmt.Errorf("%d of %d not in %s", outside, len(args)-1, prefix)
+	}
+	return nil
+}
+
+func calcRange(args []string) error {
+	if len(args) != 2 {
+		return invalidInput("Specify the first and last address of the range.")
+	}
+	start, end := net.ParseIP(args[0]), net.ParseIP(args[1])
+	if start == nil || end == nil {
+		return invalidInput("Invalid range %s - %s: Expected two IP Addresses.", args[0], args[1])
+	}
+	prefixes, err := rangePrefixes(start, end)
+	if err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+	for _, prefix := range prefixes {
+		fmt.Println(prefix)
+	}
+	return nil
+}
+
+func calcShift(args []string, sign int) error {
+	if len(args) < 1 || len(args) > 2 {
+		return invalidInput("Specify a prefix and optionally a count.")
+	}
+	prefix, err := parsePrefixArg(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := optionalCount(args, 1, 1)
+	if err != nil {
+		return err
+	}
+	shifted, err := shiftPrefix(prefix, sign*n)
+	if err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+	fmt.Println(shifted)
+	return nil
+}
+
+func calcSample(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return invalidInput("Specify a prefix and optionally a count.")
+	}
+	prefix, err := parsePrefixArg(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := optionalCount(args, 1, 10)
+	if err != nil {
+		return err
+	}
+	if n > maxSample {
+		return invalidInput("Invalid count %d: Expected at most %d.", n, maxSample)
+	}
+	ips, err := sampleAddresses(prefix, n)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		fmt.Println(ip)
+	}
+	return nil
+}
+
+/*
+calcHelp - What the usage message of calc says after the synopsis
+*/
+const calcHelp = `IP math: test which addresses or prefixes are within a prefix (failing if
+one is not), convert a range to prefixes, give the n-th next or previous
+prefix of the same length, or n random addresses of a prefix.
+`
+
+func runCalc(args []string) error {
+	flags := flag.NewFlagSet("calc", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s calc contains prefix ip...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s calc range start end\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s calc next|prev prefix [n]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s calc sample prefix [n]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(calcHelp))
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify an operation.")
+	}
+	operands := flags.Args()[1:]
+	switch flags.Arg(0) {
+	case "contains":
+		return calcContains(operands)
+	case "range":
+		return calcRange(operands)
+	case "next":
+		return calcShift(operands, 1)
+	case "prev":
+		return calcShift(operands, -1)
+	case "sample":
+		return calcSample(operands)
+	}
+	flags.Usage()
+	return invalidInput("Unknown operation '%s'.", flags.Arg(0))
+}
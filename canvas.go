@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+/*
+Canvas - Dots drawn with braille characters, each character cell holding
+two columns and four rows of dots, with text laid over the dots and colors
+per cell. Text hides the dots of its cells without erasing them. Coordinates
+are in dots and may be negative.
+*/
+type Canvas struct {
+	dots  map[cellPos]rune // braille bits of each cell
+	text  map[cellPos]rune
+	attrs map[cellPos]CellAttr
+}
+
+type cellPos struct {
+	col, row int
+}
+
+// Bits of the dots of a braille cell, by row and column
+var brailleBits = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+const brailleBlank = 0x2800
+
+/*
+CellAttr - The look of a character cell: ANSI foreground (31 red...) and
+background (41 red...) colors, 0 for the default, and boldness
+*/
+type CellAttr struct {
+	Fg   int
+	Bg   int
+	Bold bool
+}
+
+/*
+escape - The ANSI sequence switching to a, "" for the default look
+*/
+func (a CellAttr) escape() string {
+	var codes []string
+	if a.Bold {
+		codes = append(codes, "1")
+	}
+	if a.Fg != 0 {
+		codes = append(codes, fmt.Sprint(a.Fg))
+	}
+	if a.Bg != 0 {
+		codes = append(codes, fmt.Sprint(a.Bg))
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+/*
+NewCanvas - Create an empty canvas
+*/
+func NewCanvas() *Canvas {
+	return &Canvas{
+		dots:  make(map[cellPos]rune),
+		text:  make(map[cellPos]rune),
+		attrs: make(map[cellPos]CellAttr),
+	}
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+/*
+cellOf - The cell of the dot x,y and the bit of the dot in it
+*/
+func cellOf(x, y int) (cellPos, rune) {
+	pos := cellPos{floorDiv(x, 2), floorDiv(y, 4)}
+	return pos, brailleBits[y-pos.row*4][x-pos.col*2]
+}
+
+/*
+Set - Draw the dot x,y
+*/
+func (c *Canvas) Set(x, y int) {
+	pos, bit := cellOf(x, y)
+	c.dots[pos] |= bit
+}
+
+/*
+UnSet - Erase the dot x,y
+*/
+func (c *Canvas) UnSet(x, y int) {
+	pos, bit := cellOf(x, y)
+	c.dots[pos] &^= bit
+	if c.dots[pos] == 0 {
+		delete(c.dots, pos)
+	}
+}
+
+/*
+Get - Whether the dot x,y is drawn
+*/
+func (c *Canvas) Get(x, y int) bool {
+	pos, bit := cellOf(x, y)
+	return c.dots[pos]&bit != 0
+}
+
+/*
+SetText - Lay text over the cells from the one of the dot x,y rightwards
+*/
+func (c *Canvas) SetText(x, y int, text string) {
+	pos, _ := cellOf(x, y)
+	for i, r := range []rune(text) {
+		c.text[cellPos{pos.col + i, pos.row}] = r
+	}
+}
+
+/*
+Paint - Give n cells, from the one of the dot x,y rightwards, the look attr
+*/
+func (c *Canvas) Paint(x, y, n int, attr CellAttr) {
+	pos, _ := cellOf(x, y)
+	for i := 0; i < n; i++ {
+		c.attrs[cellPos{pos.col + i, pos.row}] = attr
+	}
+}
+
+/*
+Styled - Whether any cell was painted
+*/
+func (c *Canvas) Styled() bool {
+	return len(c.attrs) > 0
+}
+
+/*
+DrawLine - Draw the dots of the line from x1,y1 to x2,y2
+*/
+func (c *Canvas) DrawLine(x1, y1, x2, y2 float64) {
+	dx, dy := x2-x1, y2-y1
+	steps := int(math.Max(math.Abs(dx), math.Abs(dy)))
+	for i := 0; i <= steps; i++ {
+		t := 1.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		c.Set(int(x1+t*dx), int(y1+t*dy))
+	}
+}
+
+/*
+cell - The character of a cell: its text, else its dots
+*/
+func (c *Canvas) cell(pos cellPos) rune {
+	if r, ok := c.text[pos]; ok {
+		return r
+	}
+	if bits := c.dots[pos]; bits != 0 {
+		return brailleBlank + bits
+	}
+	return ' '
+}
+
+/*
+rows - The cells of the dots from minX,minY to maxX,maxY as lines, with the
+ANSI sequences of the painted cells if styled
+*/
+func (c *Canvas) rows(minX, minY, maxX, maxY int, styled bool) []string {
+	minPos, _ := cellOf(minX, minY)
+	maxPos, _ := cellOf(maxX, maxY)
+	var lines []string
+	for row := minPos.row; row <= maxPos.row; row++ {
+		var line strings.Builder
+		var current CellAttr
+		for col := minPos.col; col <= maxPos.col; col++ {
+			pos := cellPos{col, row}
+			if attr := c.attrs[pos]; styled && attr != current {
+				if current != (CellAttr{}) {
+					line.WriteString("\x1b[0m")
+				}
+				line.WriteString(attr.escape())
+				current = attr
+			}
+			line.WriteRune(c.cell(pos))
+		}
+		if current != (CellAttr{}) {
+			line.WriteString("\x1b[0m")
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+/*
+Rows - The cells of the dots from minX,minY to maxX,maxY, one line per row
+*/
+func (c *Canvas) Rows(minX, minY, maxX, maxY int) []string {
+	return c.rows(minX, minY, maxX, maxY, false)
+}
+
+/*
+StyledRows - Rows, with the painted cells in their colors
+*/
+func (c *Canvas) StyledRows(minX, minY, maxX, maxY int) []string {
+	return c.rows(minX, minY, maxX, maxY, true)
+}
+
+/*
+String - The canvas as text, trimmed to the cells drawn or written
+*/
+func (c *Canvas) String() string {
+	first := true
+	var min, max cellPos
+	extend := func(pos cellPos) {
+		if first {
+			min, max, first = pos, pos, false
+			return
+		}
+		if pos.col < min.col {
+			min.col = pos.col
+		}
+		if pos.row < min.row {
+			min.row = pos.row
+		}
+		if pos.col > max.col {
+			max.col = pos.col
+		}
+		if pos.row > max.row {
+			max.row = pos.row
+		}
+	}
+	for pos := range c.dots {
+		extend(pos)
+	}
+	for pos := range c.text {
+		extend(pos)
+	}
+	if first {
+		return ""
+	}
+	return strings.Join(c.Rows(min.col*2, min.row*4, max.col*2, max.row*4), "\n")
+}
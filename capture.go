@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+/*
+The capture mode locates the public endpoints of the packets of a capture
+file. Each endpoint is counted in the top talkers table with its
+conversations (see flows.go) and the bytes and packets it sent or received. By default the whole file is read at once for
+an aggregate view; with -speed it is replayed as it was captured, that many
+times faster, so that the time window and the pause apply.
+*/
+
+// How often the counts are redrawn while reading
+const captureRefresh = time.Second
+
+/*
+captureHelp - What the usage message of capture says after the synopsis
+*/
+const captureHelp = `Locate and plot the public endpoints of the packets of a pcap file
+(tcpdump -w), ranked by bytes and packets in the top talkers table.
+Live capture is not supported: capture with tcpdump, then read the file.
+`
+
+func runCapture(args []string) error {
+	flags := flag.NewFlagSet("capture", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	file := flags.String("r", "", "Read packets from this pcap file")
+	speed := flags.Float64("speed", 0,
+		"Replay the capture this many times faster than it was captured (default all at once)")
+	format := addFormatFlag(flags)
+	intakeFlags := addIntakeFlags(flags)
+	limitFlags := addLimitFlags(flags)
+	sinks := addSinkFlags(flags)
+	alertFlags := addAlertFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s capture -r file [-speed n] [-format f] [intake flags] [limit flags] [alert flags] [sink flags]\n",
+			os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(captureHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if *file == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return invalidInput("Invalid arguments: Specify a capture file with -r.")
+	}
+	if *speed < 0 {
+		return invalidInput("Invalid -speed %g: Expected a positive factor.", *speed)
+	}
+	if err := checkFormat(*format); err != nil {
+		flags.Usage()
+		return err
+	}
+	intake, err := intakeFlags.open()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+	defer f.Close()
+	packets, err := NewPcapReader(bufio.NewReader(f))
+	if err != nil {
+		return withExitCode(exitInvalidInput, fmt.Errorf("%s: %s", *file, err))
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	alerts, err := alertFlags.open()
+	if err != nil {
+		return err
+	}
+	defer alerts.Close()
+
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		return err
+	}
+	b := NewBatch(queue)
+	b.source = "capture"
+	b.pipeline = pipeline
+	b.intake = intake
+	flows := NewFlowTable()
+	b.flows = flows
+	if err := limitFlags.apply(b); err != nil {
+		return err
+	}
+
+	read := func(gui *Gui) {
+		defer intake.Close()
+		offered := newRecentSet()
+		var first time.Time
+		start, lastRefresh := time.Now(), time.Now()
+		n := 0
+		for {
+			p, err := packets.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				log.Printf("%s: %s", *file, err)
+				break
+			}
+			n++
+			if *speed > 0 {
+				if first.IsZero() {
+					first = p.Time
+				}
+				due := time.Duration(float64(p.Time.Sub(first)) / *speed)
+				time.Sleep(due - time.Since(start))
+			}
+
+			packet, ok := decodePacket(packets.LinkType, p.Data)
+			if !ok {
+				continue
+			}
+			// Hits are the conversations an endpoint takes part in
+			delta := Traffic{Bytes: p.Length, Packets: 1}
+			if _, started := flows.Add(packet, p.Length, p.Time); started {
+				delta.Hits = 1
+			}
+			for _, addr := range []net.IP{packet.Src, packet.Dst} {
+				if !isPublicIP(addr) {
+					continue
+				}
+				ip := addr.String()
+				if intake.Keeps(ip) {
+					b.count(ip, delta)
+				}
+				if !offered.has(ip) && intake.Offer(ip) {
+					offered.touch(ip)
+					offered.trim(limitFlags.targets, nil)
+				}
+			}
+			if time.Since(lastRefresh) >= captureRefresh {
+				b.refresh(gui)
+				lastRefresh = time.Now()
+			}
+		}
+		b.refresh(gui)
+		if gui != nil {
+			guiShowStatus(gui, "%d packets read from %s", n, *file)
+		}
+	}
+	lookup := func(ip string) {
+		if b.add(ip) {
+			b.lookup(ip)
+		}
+	}
+	if *format == formatJSONL {
+		events.sinks = append(events.sinks, NewStdoutSink(parseFieldList(sinks.fields)))
+		b.followAlerts(nil, alerts)
+		go read(nil)
+		intake.Run(lookup)
+		if n := intake.Dropped(); n > 0 {
+			warnf("%s", intake)
+		}
+		if n := queue.Len(); n > 0 {
+			warnf("%d lookups left for the next run to retry", n)
+		}
+		return nil
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setTalkersKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setPlaybackKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		b.followAlerts(gui, alerts)
+		go b.retry()
+		go b.expireWindow(gui)
+		go read(gui)
+		go intake.Run(lookup)
+		return nil
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+The target of the command line may read several ways: 12.5,45.2 as
+coordinates or as the address 12.5.45.2, "48.85 2.35" likewise, and a
+hostname as any of its addresses. Instead of guessing, ip411 asks which one
+is meant when run from a terminal. Otherwise the first reading is taken, in
+this order: coordinates, then addresses (IPv4 before IPv6, lowest first),
+then corrected addresses.
+*/
+
+/*
+targetReading - One way to read a target, Target being what it is located as
+*/
+type targetReading struct {
+	Label  string
+	Target string
+}
+
+// Targets of the command line to what they were read as, see chooseTarget
+var chosenTargets = make(map[string]string)
+
+/*
+chosenTarget - What target was read as by chooseTarget, target itself if it
+was not ambiguous
+*/
+func chosenTarget(target string) string {
+	if chosen, ok := chosenTargets[target]; ok {
+		return chosen
+	}
+	return target
+}
+
+/*
+commandTarget - What arg is located as: its bookmark, cleaned up and read as
+chosen by chooseTarget
+*/
+func commandTarget(arg string) (string, error) {
+	target, err := resolveBookmark(arg)
+	if err != nil {
+		return "", err
+	}
+	return chosenTarget(cleanTarget(target)), nil
+}
+
+/*
+readings - The ways target can be read, none if it is an IP Address or
+cannot be located
+*/
+func readings(target string) []targetReading {
+	if parseTargetIP(target) != nil || strings.HasPrefix(target, "@") {
+		return nil
+	}
+
+	var found []targetReading
+	latlon := target
+	if fields := strings.Fields(target); len(fields) == 2 {
+		latlon = fields[0] + "," + fields[1]
+	}
+	if p, ok := targetPoint(latlon); ok {
+		found = append(found, targetReading{"coordinates " + formatLatLon(p), formatLatLon(p)})
+	}
+	if n, err := strconv.ParseUint(target, 10, 32); err == nil {
+		// The integer form of an IPv4 Address
+		ip := net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).String()
+		return append(found, targetReading{"IP Address " + ip, ip})
+	}
+	if fixed := correctedIP(target); fixed != "" {
+		if len(found) == 0 {
+			// Left to makeIP, which suggests it
+			return nil
+		}
+		return append(found, targetReading{"IP Address " + fixed, fixed})
+	}
+	if len(found) > 0 || suggestIP(target) != "" {
+		return found
+	}
+
+	addrs, err := net.LookupIP(target)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range preferredAddrs(addrs) {
+		found = append(found, targetReading{fmt.Sprintf("%s (%s)", addr, target), addr.String()})
+	}
+	return found
+}
+
+/*
+preferredAddrs - addrs without duplicates, IPv4 first, then in ascending
+order, so that the first does not depend on the resolver
+*/
+func preferredAddrs(addrs []net.IP) []net.IP {
+	seen := make(map[string]bool)
+	var unique []net.IP
+	for _, addr := range addrs {
+		if !seen[addr.String()] {
+			seen[addr.String()] = true
+			unique = append(unique, addr)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		v4i, v4j := unique[i].To4() != nil, unique[j].To4() != nil
+		if v4i != v4j {
+			return v4i
+		}
+		return bytes.Compare(unique[i].To16(), unique[j].To16()) < 0
+	})
+	return unique
+}
+
+/*
+chooseTarget - Settle what the target of the command line is read as,
+asking on a terminal if it is ambiguous
+*/
+func chooseTarget(arg string) error {
+	target, err := resolveBookmark(arg)
+	if err != nil {
+		// Reported when it is located
+		return nil
+	}
+	target = cleanTarget(target)
+	found := readings(target)
+	if len(found) == 0 {
+		return nil
+	}
+
+	choice := found[0]
+	if len(found) > 1 {
+		if isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+			if choice, err = promptReading(arg, found); err != nil {
+				return err
+			}
+		} else {
+			warnf("'%s' could be %d targets, using %s", arg, len(found), choice.Label)
+		}
+	}
+	chosenTargets[target] = choice.Target
+	return nil
+}
+
+/*
+promptReading - Ask which of found arg stands for, the first by default
+*/
+func promptReading(arg string, found []targetReading) (targetReading, error) {
+	fmt.Fprintf(os.Stderr, tr("'%s' could be:\n"), arg)
+	for i, r := range found {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, r.Label)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, tr("Which one [1]? "))
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return found[0], nil
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && n >= 1 && n <= len(found) {
+			return found[n-1], nil
+		}
+		if err != nil {
+			return targetReading{}, fmt.Errorf(tr("No target chosen for '%s'"), arg)
+		}
+		fmt.Fprintf(os.Stderr, tr("Expected a number from 1 to %d\n"), len(found))
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import "fmt"
+
+/*
+In the batch modes the map can color whole countries by a metric of the
+addresses shown instead of plotting them, from cyan through yellow to red
+on the logarithmic scale of the weighted markers (see weights.go), with its
+legend in the info pane. Addresses are assigned to the country whose borders
+hold their location, else to the country of their result. The borders are
+downloaded by "ip411 db update" (see countries.go).
+
+	C    next metric (none, addresses, hits)
+*/
+
+// Metrics of the choropleth, after none
+var choroplethMetrics = []string{"addresses", "hits"}
+
+// Weights of the countries filled by drawMap, only touched from the gui
+// goroutine
+var mapFill map[string]float64
+
+/*
+countryGrid - The country of every cell of a canvas, kept as long as the
+canvas is drawn the same size over the same viewport
+*/
+type countryGrid struct {
+	view          Viewport
+	width, height float64
+	codes         map[cellPos]string
+}
+
+var lastCountryGrid countryGrid // only touched from the gui goroutine
+
+/*
+LonLat - The location at the canvas point x,y
+*/
+func (mc *MapCanvas) LonLat(x, y float64) (lon, lat float64) {
+	minLon, maxLon, minLat, maxLat := mc.view.Bounds()
+	return minLon + x*(maxLon-minLon)/mc.width, maxLat - y*(maxLat-minLat)/mc.height
+}
+
+/*
+FillCountries - Color the cells of the countries of weights in the heat
+color of their weight, or without colors fill them with more dots the
+heavier they are
+*/
+func (mc *MapCanvas) FillCountries(weights map[string]float64) {
+	grid := lastCountryGrid
+	if grid.codes == nil || grid.view != mc.view || grid.width != mc.width || grid.height != mc.height {
+		grid = countryGrid{view: mc.view, width: mc.width, height: mc.height,
+			codes: make(map[cellPos]string)}
+		for y := 0; y <= int(mc.height); y += 4 {
+			for x := 0; x <= int(mc.width); x += 2 {
+				lon, lat := mc.LonLat(float64(x)+1, float64(y)+2)
+				if code := countryAt(lon, lat); code != "" {
+					grid.codes[cellPos{x / 2, y / 4}] = code
+				}
+			}
+		}
+		lastCountryGrid = grid
+	}
+
+	for pos, code := range grid.codes {
+		w := weights[code]
+		if w <= 0 {
+			continue
+		}
+		x, y := pos.col*2, pos.row*4
+		if consoleColors {
+			mc.canvas.Paint(x, y, 1, CellAttr{Bg: heatColor(w) + 10})
+			continue
+		}
+		// 2, 4 or 8 dots of the cell, by the thirds of heatColor
+		rows := 1
+		if w >= 2.0/3 {
+			rows = 4
+		} else if w >= 1.0/3 {
+			rows = 2
+		}
+		for dy := 0; dy < rows; dy++ {
+			mc.canvas.Set(x, y+dy)
+			mc.canvas.Set(x+1, y+dy)
+		}
+	}
+}
+
+/*
+countryOf - The country of a located result: the one whose borders hold it,
+else the one of the result
+*/
+func countryOf(res IPInfoResult) string {
+	if lon, lat, err := res.GetLonLat(); err == nil {
+		if code := countryAt(lon, lat); code != "" {
+			return code
+		}
+	}
+	return fieldValue(res, "country")
+}
+
+/*
+countryValues - The value of the choropleth metric of every country of the
+shown targets, and the largest. Must be called with b.mu held.
+*/
+func (b *Batch) countryValues() (map[string]int, int) {
+	if b.choropleth == 0 {
+		return nil, 0
+	}
+	var hits map[string]Traffic
+	if choroplethMetrics[b.choropleth-1] == "hits" {
+		hits = b.windowTraffic()
+	}
+	if b.countryOf == nil {
+		b.countryOf = make(map[string]string)
+	}
+
+	values := make(map[string]int)
+	max := 0
+	for _, target := range b.shown() {
+		code, ok := b.countryOf[target]
+		if !ok {
+			code = countryOf(b.results[target])
+			b.countryOf[target] = code
+		}
+		if code == "" {
+			continue
+		}
+		if hits != nil {
+			values[code] += hits[target].Hits
+		} else {
+			values[code]++
+		}
+		if values[code] > max {
+			max = values[code]
+		}
+	}
+	return values, max
+}
+
+/*
+countryWeights - Weights of the countries to fill, nil without a metric.
+Must be called with b.mu held.
+*/
+func (b *Batch) countryWeights() map[string]float64 {
+	values, max := b.countryValues()
+	if values == nil {
+		return nil
+	}
+	weights := make(map[string]float64, len(values))
+	for code, v := range values {
+		weights[code] = markerWeight(v, max)
+	}
+	return weights
+}
+
+/*
+choroplethLegend - Line of the info pane explaining the colors of the
+countries, "" without a metric. Must be called with b.mu held.
+*/
+func (b *Batch) choroplethLegend() string {
+	values, max := b.countryValues()
+	if values == nil {
+		return ""
+	}
+	if max == 0 {
+		return fmt.Sprintf(tr("Countries by %s: none yet"), tr(choroplethMetrics[b.choropleth-1]))
+	}
+	low, high := heatThresholds(max)
+	return fmt.Sprintf(tr("Countries by %s: cyan < %d <= yellow < %d <= red, largest %d (%d countries)"),
+		tr(choroplethMetrics[b.choropleth-1]), low, high, max, len(values))
+}
+
+func (b *Batch) nextChoropleth(g *Gui, v *View) error {
+	if _, err := loadCountryShapes(); err != nil {
+		guiShowStatus(g, "%s", err)
+		return nil
+	}
+	b.mu.Lock()
+	b.choropleth = (b.choropleth + 1) % (len(choroplethMetrics) + 1)
+	b.mu.Unlock()
+
+	b.refresh(g)
+	return nil
+}
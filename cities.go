@@ -0,0 +1,185 @@
+package main
+
+import "sort"
+
+/*
+City - A major city, its ISO country code and population in millions
+(metropolitan area)
+*/
+type City struct {
+	Name       string
+	Country    string
+	Population float64
+	Point
+}
+
+/*
+cities - Major cities of the world, drawn as context when the map is zoomed
+and used as the gazetteer of reverse geocoding
+*/
+var cities = []City{
+	{"Tokyo", "JP", 37.4, Point{35.68, 139.69}},
+	{"Delhi", "IN", 31.0, Point{28.61, 77.21}},
+	{"Shanghai", "CN", 27.1, Point{31.23, 121.47}},
+	{"São Paulo", "BR", 22.0, Point{-23.55, -46.63}},
+	{"Mexico City", "MX", 21.8, Point{19.43, -99.13}},
+	{"Cairo", "EG", 21.3, Point{30.04, 31.24}},
+	{"Dhaka", "BD", 21.0, Point{23.81, 90.41}},
+	{"Mumbai", "IN", 20.7, Point{19.08, 72.88}},
+	{"Beijing", "CN", 20.5, Point{39.90, 116.41}},
+	{"Osaka", "JP", 19.1, Point{34.69, 135.50}},
+	{"Karachi", "PK", 16.1, Point{24.86, 67.01}},
+	{"Chongqing", "CN", 15.9, Point{29.56, 106.55}},
+	{"Istanbul", "TR", 15.2, Point{41.01, 28.98}},
+	{"Buenos Aires", "AR", 15.2, Point{-34.60, -58.38}},
+	{"Kolkata", "IN", 14.9, Point{22.57, 88.36}},
+	{"Kinshasa", "CD", 14.3, Point{-4.44, 15.27}},
+	{"Lagos", "NG", 14.4, Point{6.52, 3.38}},
+	{"Manila", "PH", 13.9, Point{14.60, 120.98}},
+	{"Guangzhou", "CN", 13.6, Point{23.13, 113.26}},
+	{"Rio de Janeiro", "BR", 13.5, Point{-22.91, -43.17}},
+	{"Moscow", "RU", 12.6, Point{55.76, 37.62}},
+	{"Los Angeles", "US", 12.4, Point{34.05, -118.24}},
+	{"Lahore", "PK", 12.6, Point{31.55, 74.34}},
+	{"Bangalore", "IN", 12.3, Point{12.97, 77.59}},
+	{"Paris", "FR", 11.0, Point{48.86, 2.35}},
+	{"Jakarta", "ID", 10.8, Point{-6.21, 106.85}},
+	{"Chennai", "IN", 10.9, Point{13.08, 80.27}},
+	{"Lima", "PE", 10.7, Point{-12.05, -77.04}},
+	{"Bogotá", "CO", 10.9, Point{4.71, -74.07}},
+	{"Bangkok", "TH", 10.5, Point{13.76, 100.50}},
+	{"Seoul", "KR", 9.9, Point{37.57, 126.98}},
+	{"Nagoya", "JP", 9.5, Point{35.18, 136.91}},
+	{"London", "GB", 9.3, Point{51.51, -0.13}},
+	{"Tehran", "IR", 9.1, Point{35.69, 51.39}},
+	{"Chicago", "US", 8.9, Point{41.88, -87.63}},
+	{"Ho Chi Minh City", "VN", 8.6, Point{10.82, 106.63}},
+	{"Luanda", "AO", 8.3, Point{-8.84, 13.23}},
+	{"Hong Kong", "HK", 7.5, Point{22.32, 114.17}},
+	{"Baghdad", "IQ", 7.1, Point{33.31, 44.36}},
+	{"Riyadh", "SA", 7.2, Point{24.71, 46.68}},
+	{"Santiago", "CL", 6.7, Point{-33.45, -70.67}},
+	{"Madrid", "ES", 6.6, Point{40.42, -3.70}},
+	{"Toronto", "CA", 6.2, Point{43.65, -79.38}},
+	{"Dallas", "US", 6.3, Point{32.78, -96.80}},
+	{"Houston", "US", 6.1, Point{29.76, -95.37}},
+	{"Miami", "US", 6.1, Point{25.76, -80.19}},
+	{"Khartoum", "SD", 5.8, Point{15.50, 32.56}},
+	{"Singapore", "SG", 5.9, Point{1.35, 103.82}},
+	{"Atlanta", "US", 5.9, Point{33.75, -84.39}},
+	{"Saint Petersburg", "RU", 5.4, Point{59.93, 30.34}},
+	{"Philadelphia", "US", 5.7, Point{39.95, -75.17}},
+	{"Washington", "US", 5.3, Point{38.91, -77.04}},
+	{"Dar es Salaam", "TZ", 6.7, Point{-6.79, 39.21}},
+	{"Johannesburg", "ZA", 6.0, Point{-26.20, 28.05}},
+	{"Barcelona", "ES", 5.6, Point{41.39, 2.17}},
+	{"Sydney", "AU", 5.3, Point{-33.87, 151.21}},
+	{"Melbourne", "AU", 5.1, Point{-37.81, 144.96}},
+	{"Nairobi", "KE", 4.9, Point{-1.29, 36.82}},
+	{"Berlin", "DE", 3.6, Point{52.52, 13.40}},
+	{"Rome", "IT", 4.3, Point{41.90, 12.50}},
+	{"Milan", "IT", 3.1, Point{45.46, 9.19}},
+	{"Boston", "US", 4.9, Point{42.36, -71.06}},
+	{"San Francisco", "US", 4.7, Point{37.77, -122.42}},
+	{"Phoenix", "US", 4.9, Point{33.45, -112.07}},
+	{"Seattle", "US", 4.0, Point{47.61, -122.33}},
+	{"Montreal", "CA", 4.3, Point{45.50, -73.57}},
+	{"Cape Town", "ZA", 4.7, Point{-33.92, 18.42}},
+	{"Casablanca", "MA", 3.8, Point{33.57, -7.59}},
+	{"Accra", "GH", 2.6, Point{5.60, -0.19}},
+	{"Addis Ababa", "ET", 5.2, Point{9.03, 38.74}},
+	{"Dubai", "AE", 3.6, Point{25.20, 55.27}},
+	{"Taipei", "TW", 7.0, Point{25.03, 121.57}},
+	{"Kuala Lumpur", "MY", 8.4, Point{3.14, 101.69}},
+	{"Hanoi", "VN", 5.1, Point{21.03, 105.85}},
+	{"Kyiv", "UA", 3.0, Point{50.45, 30.52}},
+	{"Warsaw", "PL", 1.8, Point{52.23, 21.01}},
+	{"Vienna", "AT", 1.9, Point{48.21, 16.37}},
+	{"Budapest", "HU", 1.8, Point{47.50, 19.04}},
+	{"Amsterdam", "NL", 1.2, Point{52.37, 4.90}},
+	{"Brussels", "BE", 2.1, Point{50.85, 4.35}},
+	{"Frankfurt", "DE", 2.3, Point{50.11, 8.68}},
+	{"Munich", "DE", 1.5, Point{48.14, 11.58}},
+	{"Hamburg", "DE", 1.8, Point{53.55, 9.99}},
+	{"Stockholm", "SE", 1.7, Point{59.33, 18.07}},
+	{"Copenhagen", "DK", 1.4, Point{55.68, 12.57}},
+	{"Oslo", "NO", 1.1, Point{59.91, 10.75}},
+	{"Helsinki", "FI", 1.3, Point{60.17, 24.94}},
+	{"Dublin", "IE", 1.2, Point{53.35, -6.26}},
+	{"Lisbon", "PT", 2.9, Point{38.72, -9.14}},
+	{"Athens", "GR", 3.2, Point{37.98, 23.73}},
+	{"Prague", "CZ", 1.3, Point{50.08, 14.44}},
+	{"Zurich", "CH", 1.4, Point{47.37, 8.54}},
+	{"Manchester", "GB", 2.8, Point{53.48, -2.24}},
+	{"Vancouver", "CA", 2.6, Point{49.28, -123.12}},
+	{"Denver", "US", 2.9, Point{39.74, -104.99}},
+	{"Auckland", "NZ", 1.7, Point{-36.85, 174.76}},
+	{"Perth", "AU", 2.1, Point{-31.95, 115.86}},
+	{"Anchorage", "US", 0.3, Point{61.22, -149.90}},
+	{"Honolulu", "US", 1.0, Point{21.31, -157.86}},
+	{"Reykjavik", "IS", 0.2, Point{64.15, -21.94}},
+}
+
+/*
+minCityPopulation - Smallest city (in millions) worth drawing at a zoom
+*/
+func minCityPopulation(zoom float64) float64 {
+	switch {
+	case zoom >= 16:
+		return 0
+	case zoom >= 8:
+		return 1
+	case zoom >= 4:
+		return 3
+	case zoom >= 2:
+		return 8
+	}
+	return -1
+}
+
+/*
+drawCities - Label the cities of the viewport big enough for its zoom,
+biggest first, skipping labels that would overlap the markers or each other
+*/
+func drawCities(mc *MapCanvas, markers []Marker) {
+	min := minCityPopulation(mc.view.Zoom)
+	if min < 0 {
+		return
+	}
+
+	// Cells of the canvas taken, by row
+	taken := make(map[int][][2]int)
+	claim := func(lon, lat float64, width int) bool {
+		x, y := mc.GetX(lon), mc.GetY(lat)
+		if !mc.inside(x, y) {
+			return false
+		}
+		row, col := int(y)/4, int(x)/2
+		for _, span := range taken[row] {
+			if col < span[1]+1 && span[0] < col+width+1 {
+				return false
+			}
+		}
+		taken[row] = append(taken[row], [2]int{col, col + width})
+		return true
+	}
+
+	for _, m := range markers {
+		claim(m.Lon, m.Lat, len([]rune(m.Text)))
+	}
+
+	sorted := make([]City, len(cities))
+	copy(sorted, cities)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Population > sorted[j].Population
+	})
+	for _, city := range sorted {
+		if city.Population < min {
+			continue
+		}
+		label := "·" + city.Name
+		if claim(city.Lon, city.Lat, len([]rune(label))) {
+			mc.PlotText(city.Lon, city.Lat, label)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+)
+
+/*
+Provider requests all go through one HTTP client, built on the first lookup
+from the provider flags, so that the transport can be swapped (see
+http3.go) and requests dialed to a Unix socket (see providerurl.go).
+*/
+
+var (
+	providerClientOnce sync.Once
+	providerClient     *http.Client
+)
+
+/*
+providerHTTP - The client of provider requests
+*/
+func providerHTTP() *http.Client {
+	providerClientOnce.Do(func() {
+		transport := http.DefaultTransport
+		if customProvider.socket != "" {
+			t := http.DefaultTransport.(*http.Transport).Clone()
+			t.DialContext = dialProvider
+			transport = t
+		}
+		if useHTTP3 {
+			transport = newHTTP3Transport(transport)
+		}
+		providerClient = &http.Client{Transport: transport}
+	})
+	return providerClient
+}
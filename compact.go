@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Terminals narrower than compactWidth columns (phones over SSH, Termux) get a
+compact layout: the map keeps the 2:1 proportions of the world instead of
+filling the height, and the info pane below it takes the rest, with short
+labels and values cut to the width. The layout follows resizes.
+*/
+
+const compactWidth = 60
+
+// Set by layout, protected by mu
+var compact bool
+
+// Short labels of the default info fields
+var compactLabels = map[string]string{
+	"Hostname":           "Host",
+	"Longitude,Latitude": "Loc",
+	"Region":             "Reg",
+	"Country":            "Ctry",
+	"Postal":             "Zip",
+}
+
+/*
+compactLabel - label translated and shortened to at most 6 characters
+*/
+func compactLabel(label string) string {
+	if short, ok := compactLabels[label]; ok {
+		return tr(short)
+	}
+	label = tr(label)
+	if runes := []rune(label); len(runes) > 6 {
+		return string(runes[:6])
+	}
+	return label
+}
+
+/*
+compactLine - "label: value" cut to width columns
+*/
+func compactLine(label, value string, width int) string {
+	line := []rune(fmt.Sprintf("%s: %s", compactLabel(label), value))
+	if width > 1 && len(line) > width {
+		line = append(line[:width-1], '~')
+	}
+	return string(line)
+}
+
+/*
+compactMapHeight - Rows of a map of width columns drawn in its proportions,
+leaving at least minRest rows of height to the panes below
+*/
+func compactMapHeight(width, height, minRest int) int {
+	// A cell is 2 dots wide and 4 high, the world twice as wide as high
+	rows := width/4 + 1
+	if rows > height-minRest {
+		rows = height - minRest
+	}
+	if rows < 3 {
+		rows = 3
+	}
+	return rows
+}
+
+/*
+setCompact - Switch the layout to compact or back, redrawing the panes
+whose content depends on it. Called by layout.
+*/
+func setCompact(g *Gui, on bool) error {
+	mu.Lock()
+	changed := compact != on
+	compact = on
+	res := infoResult
+	mu.Unlock()
+	if !changed || res == nil {
+		return nil
+	}
+	if view, err := g.View("info"); err == nil {
+		mu.Lock()
+		renderInfo(view, res)
+		mu.Unlock()
+	}
+	return redrawMap(g)
+}
+
+/*
+renderCompactInfo - The info fields, one short line each
+*/
+func renderCompactInfo(view *View, res IPInfoResult) {
+	width, _ := view.Size()
+	for _, field := range config.InfoFields {
+		fmt.Fprintln(view, compactLine(field.Label, strings.TrimSpace(displayValue(res, field.Path)), width))
+	}
+}
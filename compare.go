@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+/*
+The compare mode locates two targets and shows them side by side: both on
+the map, as A and B, and their info fields in two columns, the rows that
+differ marked with *. Without a second target, the first is compared with
+the client's IP Address. Each target can be resolved with its own DNS server
+(-resolver-a, -resolver-b), e.g. to compare the address a customer's resolver
+gives for a hostname with the one the local resolver gives:
+
+	ip411 compare -resolver-a 9.9.9.9 www.example.com www.example.com
+
+Piped, or with -json, the comparison is printed as JSON, with every field
+that differs.
+*/
+
+// The comparison shown, its header taking a line of the info pane.
+// Protected by mu.
+var shownComparison *Comparison
+
+/*
+Comparison - Two results and how they differ
+*/
+type Comparison struct {
+	A          ComparedTarget `json:"a"`
+	B          ComparedTarget `json:"b"`
+	DistanceKm *float64       `json:"distance_km,omitempty"`
+	Changes    []FieldChange  `json:"changes"` // Was of A, Now of B
+}
+
+/*
+ComparedTarget - A target of the comparison and its result
+*/
+type ComparedTarget struct {
+	Target string       `json:"target"`
+	Result IPInfoResult `json:"result"`
+}
+
+/*
+Name - The target as shown: "www.example.com (93.184.216.34)", or the
+address alone
+*/
+func (t ComparedTarget) Name() string {
+	ip := fieldValue(t.Result, "ip")
+	switch {
+	case ip == "":
+		return t.Target
+	case t.Target == "" || t.Target == ip:
+		return ip
+	}
+	return fmt.Sprintf("%s (%s)", t.Target, ip)
+}
+
+/*
+locateCompared - Locate arg, the client's IP Address if "", resolving a
+hostname with server unless it is ""
+*/
+func locateCompared(arg, server string) (ComparedTarget, error) {
+	if arg == "" {
+		res, err := locateTarget(nil)
+		return ComparedTarget{Result: res}, err
+	}
+	target, err := commandTarget(arg)
+	if err != nil {
+		return ComparedTarget{}, withExitCode(exitInvalidInput, err)
+	}
+	located := target
+	if server != "" && isHostname(target) {
+		hr, err := (&resolveOptions{server: server, workers: 1}).open()
+		if err != nil {
+			return ComparedTarget{}, err
+		}
+		addr := hr.ResolveAll([]string{target})[target]
+		if addr.Err != nil {
+			return ComparedTarget{}, withExitCode(exitLookupFailed,
+				fmt.Errorf("Resolving %s with %s: %s", target, server, addr.Err))
+		}
+		located = addr.IP
+	}
+	res, err := locateTarget([]string{located})
+	if err == nil {
+		err = withExitCode(exitLookupFailed, locationError(res))
+		// Compared are the lookups, not what the user wrote about them
+		delete(res, "note")
+	}
+	return ComparedTarget{Target: target, Result: res}, err
+}
+
+/*
+compareResults - The comparison of a and b
+*/
+func compareResults(a, b ComparedTarget) Comparison {
+	c := Comparison{A: a, B: b, Changes: diffResults(a.Result, b.Result)}
+	lonA, latA, errA := a.Result.GetLonLat()
+	lonB, latB, errB := b.Result.GetLonLat()
+	if errA == nil && errB == nil {
+		km := distanceKm(Point{Lat: latA, Lon: lonA}, Point{Lat: latB, Lon: lonB})
+		c.DistanceKm = &km
+	}
+	return c
+}
+
+/*
+cutColumn - text cut or padded to width columns, wide runes (CJK, emoji)
+taking two
+*/
+func cutColumn(text string, width int) string {
+	if width > 1 && runewidth.StringWidth(text) > width {
+		text = runewidth.Truncate(text, width, "~")
+	}
+	return runewidth.FillRight(text, width)
+}
+
+/*
+renderComparison - Write the info fields of both results into the info view
+in two columns, the rows that differ marked. Must be called with mu held.
+*/
+func renderComparison(view *View, c Comparison) {
+	view.Clear()
+	width, _ := view.Size()
+	labelWidth := 0
+	for _, field := range config.InfoFields {
+		if n := runewidth.StringWidth(tr(field.Label)); n > labelWidth {
+			labelWidth = n
+		}
+	}
+	// "* label  A  B"
+	column := (width - labelWidth - 6) / 2
+	if column < 1 {
+		column = 1
+	}
+	header := fmt.Sprintf("  %s  %s  %s", cutColumn("", labelWidth),
+		cutColumn("A "+c.A.Name(), column), cutColumn("B "+c.B.Name(), column))
+	if consoleColors {
+		header = "\x1b[1m" + header + "\x1b[0m"
+	}
+	fmt.Fprintln(view, header)
+	for _, field := range config.InfoFields {
+		a := strings.TrimSpace(displayValue(c.A.Result, field.Path))
+		b := strings.TrimSpace(displayValue(c.B.Result, field.Path))
+		mark := " "
+		if a != b {
+			mark = "*"
+		}
+		line := fmt.Sprintf("%s %s  %s  %s", mark, cutColumn(tr(field.Label), labelWidth),
+			cutColumn(a, column), cutColumn(b, column))
+		if mark == "*" && consoleColors {
+			line = "\x1b[1;33m" + line + "\x1b[0m"
+		}
+		fmt.Fprintln(view, line)
+	}
+}
+
+/*
+showComparison - Load both targets into the map, the info pane and the
+status bar
+*/
+func showComparison(gui *Gui, c Comparison) {
+	gui.Execute(func(g *Gui) error {
+		info, err := g.View("info")
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		shownComparison = &c
+		renderComparison(info, c)
+		mu.Unlock()
+
+		view, err := g.View("map")
+		if err != nil {
+			return err
+		}
+		var markers []Marker
+		for _, t := range []struct {
+			text   string
+			color  int
+			target ComparedTarget
+		}{{"A", 36, c.A}, {"B", 33, c.B}} {
+			if lon, lat, err := t.target.Result.GetLonLat(); err == nil {
+				markers = append(markers, Marker{Lon: lon, Lat: lat, Text: t.text, Color: t.color,
+					Targets: []string{fieldValue(t.target.Result, "ip")}})
+			}
+		}
+		drawMap(view, markers)
+		return nil
+	})
+	differ := fmt.Sprintf(tr("%d fields differ"), len(c.Changes))
+	if c.DistanceKm != nil {
+		guiShowStatus(gui, "A and B are %s apart, %s", formatDistance(*c.DistanceKm), differ)
+	} else {
+		guiShowStatus(gui, "%s", differ)
+	}
+}
+
+/*
+compareHelp - What the usage message of compare says after the synopsis
+*/
+const compareHelp = `Locate two targets, or one and the client's IP Address, and show them on
+the map as A and B with their info fields side by side, marking (*) the
+ones that differ. A target can be resolved with its own DNS server.
+`
+
+func runCompare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	jsonOut := flags.Bool("json", false, "Print the comparison as JSON")
+	resolverA := flags.String("resolver-a", "",
+		"Resolve the first target with this DNS server, host:port (default the system resolver)")
+	resolverB := flags.String("resolver-b", "",
+		"Resolve the second target with this DNS server, host:port (default the system resolver)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s compare [-json] [-tui] [-resolver-a addr] [-resolver-b addr] [-provider list] a [b]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(compareHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one or two targets.")
+	}
+	a, err := locateCompared(flags.Arg(0), *resolverA)
+	if err != nil {
+		return err
+	}
+	b, err := locateCompared(flags.Arg(1), *resolverB)
+	if err != nil {
+		return err
+	}
+	c := compareResults(a, b)
+
+	if *jsonOut || checkTerminal() != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	}
+	legendSource = func() []string {
+		return []string{
+			fmt.Sprintf("\x1b[36mA\x1b[0m   %s", a.Name()),
+			fmt.Sprintf("\x1b[33mB\x1b[0m   %s", b.Name()),
+		}
+	}
+	return runGui(func(gui *Gui) error {
+		go showComparison(gui, c)
+		return nil
+	})
+}
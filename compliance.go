@@ -0,0 +1,93 @@
+package main
+
+import "strings"
+
+/*
+Results are tagged under "compliance" with the groupings data-protection
+reviews care about, from their country and region:
+
+	eu              member state of the European Union
+	eea             European Economic Area (the EU, Iceland, Liechtenstein
+	                and Norway), where the GDPR applies
+	uk              United Kingdom (UK GDPR)
+	adequacy        country with an EU adequacy decision: transfers need no
+	                further safeguards (the United States only for the
+	                companies certified under the Data Privacy Framework, so
+	                it is not tagged)
+	us_privacy_law  US state with a comprehensive consumer privacy law
+
+Filters test them with in, as do scripts:
+
+	"eea" in compliance || "adequacy" in compliance
+
+	def process(result):
+	    tags = result.get("compliance", [])
+	    return "eea" in tags or "adequacy" in tags
+
+Results without any tag have no "compliance" field.
+*/
+
+var euCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "HR": true, "CY": true, "CZ": true,
+	"DK": true, "EE": true, "FI": true, "FR": true, "DE": true, "GR": true,
+	"HU": true, "IE": true, "IT": true, "LV": true, "LT": true, "LU": true,
+	"MT": true, "NL": true, "PL": true, "PT": true, "RO": true, "SK": true,
+	"SI": true, "ES": true, "SE": true,
+}
+
+// Members of the EEA outside of the EU
+var eeaCountries = map[string]bool{"IS": true, "LI": true, "NO": true}
+
+var adequacyCountries = map[string]bool{
+	"AD": true, "AR": true, "CA": true, "FO": true, "GG": true, "IL": true,
+	"IM": true, "JP": true, "JE": true, "NZ": true, "KR": true, "CH": true,
+	"GB": true, "UY": true,
+}
+
+// Lower case, as providers spell the region in full
+var usPrivacyLawStates = map[string]bool{
+	"california": true, "virginia": true, "colorado": true, "connecticut": true,
+	"utah": true, "texas": true, "oregon": true, "montana": true, "iowa": true,
+	"delaware": true, "new hampshire": true, "new jersey": true,
+	"tennessee": true, "minnesota": true, "maryland": true, "indiana": true,
+	"kentucky": true, "rhode island": true, "nebraska": true,
+}
+
+/*
+complianceTags - The compliance tags of a country code and region name
+*/
+func complianceTags(country, region string) []string {
+	country = strings.ToUpper(country)
+	var tags []string
+	if euCountries[country] {
+		tags = append(tags, "eu")
+	}
+	if euCountries[country] || eeaCountries[country] {
+		tags = append(tags, "eea")
+	}
+	if country == "GB" {
+		tags = append(tags, "uk")
+	}
+	if adequacyCountries[country] {
+		tags = append(tags, "adequacy")
+	}
+	if country == "US" && usPrivacyLawStates[strings.ToLower(strings.TrimSpace(region))] {
+		tags = append(tags, "us_privacy_law")
+	}
+	return tags
+}
+
+/*
+tagCompliance - Tag res under "compliance"
+*/
+func tagCompliance(res IPInfoResult) {
+	tags := complianceTags(fieldValue(res, "country"), fieldValue(res, "region"))
+	if len(tags) == 0 {
+		return
+	}
+	list := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		list[i] = tag
+	}
+	res["compliance"] = list
+}
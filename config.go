@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+/*
+InfoField - A field shown in the info pane. Path is a dotted JSON path into
+the lookup result ("asn.name").
+*/
+type InfoField struct {
+	Label string `json:"label"`
+	Path  string `json:"path"`
+}
+
+/*
+Config - Settings read from the config file
+*/
+type Config struct {
+	InfoFields      []InfoField       `json:"info_fields,omitempty"`
+	InfoTemplate    string            `json:"info_template,omitempty"`
+	Home            *Point            `json:"home,omitempty"`
+	Bookmarks       map[string]string `json:"bookmarks,omitempty"`
+	Groups          map[string]*Group `json:"groups,omitempty"`
+	Provider        string            `json:"provider,omitempty"`     // comma separated, see failover.go
+	ProviderURL     string            `json:"provider_url,omitempty"` // see providerurl.go
+	RoundRobin      bool              `json:"round_robin,omitempty"`
+	Cache           string            `json:"cache,omitempty"` // see cache.go
+	CacheTTL        string            `json:"cache_ttl,omitempty"`
+	CacheNamespace  string            `json:"cache_namespace,omitempty"`
+	DNSBLs          []string          `json:"dnsbls,omitempty"`
+	Locale          string            `json:"locale,omitempty"` // of numbers, see locale.go
+	Units           string            `json:"units,omitempty"`
+	Precision       *int              `json:"precision,omitempty"` // decimals of coordinates
+	Lang            string            `json:"lang,omitempty"`      // of messages, see i18n.go
+	Alerts          []AlertRule       `json:"alerts,omitempty"`
+	RecheckInterval string            `json:"recheck_interval,omitempty"` // see recheck.go
+	OwnedPrefixes   []OwnedPrefix     `json:"owned_prefixes,omitempty"`   // see ownership.go
+
+	path string
+}
+
+var defaultInfoFields = []InfoField{
+	{"Hostname", "hostname"},
+	{"Org", "org"},
+	{"Longitude,Latitude", "loc"},
+	{"City", "city"},
+	{"Region", "region"},
+	{"Country", "country"},
+	{"Postal", "postal"},
+}
+
+var config = &Config{InfoFields: defaultInfoFields}
+
+/*
+defaultConfigPath - Location of the config file, $IP411_CONFIG or
+ip411/config.json in the user's config directory
+*/
+func defaultConfigPath() string {
+	if path := os.Getenv("IP411_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "config.json")
+}
+
+/*
+LoadConfig - Read the config file at path. A missing file gives the
+defaults.
+*/
+func LoadConfig(path string) (*Config, error) {
+	c := &Config{path: path}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(c.InfoFields) == 0 {
+		c.InfoFields = defaultInfoFields
+	}
+	return c, nil
+}
+
+/*
+Save - Write the config back to the file it was loaded from
+*/
+func (c *Config) Save() error {
+	if c.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0600)
+}
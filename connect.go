@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+/*
+With -connect-test port ip411 opens a TCP connection to the target the way
+browsers do (Happy Eyeballs: IPv6 first, IPv4 raced after a short delay) and
+reports which address family won and how long the handshake took. For a
+hostname with addresses of both families this shows which one real clients
+end up using; for an address there is no race. The outcome is added under
+"connect" and next to the plotted point:
+
+	connect.summary       "IPv6 in 23 ms to [2001:db8::1]:443 (IPv4 and IPv6 resolved)"
+	connect.family        "IPv4" or "IPv6", empty if the connection failed
+	connect.address       the address connected to
+	connect.handshake_ms  time to establish the connection
+	connect.error         why it failed, if it did
+*/
+
+var connectPort = flag.Int("connect-test", 0,
+	"Connect to this TCP port of the target with Happy Eyeballs and show which address family won")
+
+const connectTimeout = 5 * time.Second
+
+var connectInfoFields = []InfoField{
+	{"Connect", "connect.summary"},
+}
+
+/*
+addressFamily - "IPv4" or "IPv6"
+*/
+func addressFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+/*
+resolvedFamilies - The address families host resolves to, host itself if
+it is an address
+*/
+func resolvedFamilies(ctx context.Context, host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		return addressFamily(ip) + " address"
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "not resolved"
+	}
+	v4, v6 := false, false
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	switch {
+	case v4 && v6:
+		return "IPv4 and IPv6 resolved"
+	case v6:
+		return "only IPv6 resolved"
+	}
+	return "only IPv4 resolved"
+}
+
+/*
+addConnectTest - Connect to port of host (the target as given, so that
+hostnames race their addresses) and add the outcome under "connect" of res.
+The marker label shows the winning family and the handshake time, unless a
+script set another label.
+*/
+func addConnectTest(res IPInfoResult, host string, port int) {
+	if _, ok := targetPoint(host); ok || host == "" {
+		host = fieldValue(res, "ip")
+	}
+	if host == "" {
+		// A location, nothing to connect to
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	families := resolvedFamilies(ctx, host)
+
+	// A zero FallbackDelay is the default of 300 ms of RFC 6555
+	dialer := net.Dialer{}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	handshake := time.Since(start)
+	if err != nil {
+		res["connect"] = map[string]interface{}{
+			"summary": fmt.Sprintf("port %d failed: %s (%s)", port, err, families),
+			"error":   err.Error(),
+		}
+		if _, ok := res["label"]; !ok {
+			res["label"] = "X no connect"
+		}
+		return
+	}
+	defer conn.Close()
+
+	remote := conn.RemoteAddr().(*net.TCPAddr)
+	family := addressFamily(remote.IP)
+	ms := float64(handshake.Microseconds()) / 1000
+	res["connect"] = map[string]interface{}{
+		"summary":      fmt.Sprintf("%s in %.0f ms to %s (%s)", family, ms, remote, families),
+		"family":       family,
+		"address":      remote.String(),
+		"handshake_ms": ms,
+	}
+	if _, ok := res["label"]; !ok {
+		res["label"] = fmt.Sprintf("X %s %.0fms", family, ms)
+	}
+}
+
+/*
+connectHost - The host to connect to for the target arguments, "" for the
+client's own address
+*/
+func connectHost(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	host, err := resolveBookmark(args[0])
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+/*
+showConnectFields - Add the connection test to the info pane unless the
+config file already lists it
+*/
+func showConnectFields() {
+	for _, field := range config.InfoFields {
+		if field.Path == "connect.summary" {
+			return
+		}
+	}
+	fields := append([]InfoField{}, config.InfoFields...)
+	config.InfoFields = append(fields, connectInfoFields...)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+/*
+The map is drawn with braille characters, two columns and four rows of dots
+per cell. Consoles without the glyphs (the fonts of the Windows console host
+and of the Linux virtual consoles, or a non-UTF-8 locale) get an ASCII
+rendering instead, each cell becoming one of ' . ' : which shows which of
+its halves have dots. -glyphs forces either.
+
+NO_COLOR (https://no-color.org) turns the colored markers off.
+*/
+
+const (
+	glyphsAuto    = "auto"
+	glyphsBraille = "braille"
+	glyphsASCII   = "ascii"
+)
+
+var glyphs = glyphsAuto
+
+// Set by setupConsole
+var (
+	asciiMap      bool
+	consoleColors = true
+)
+
+func addGlyphsFlag(flags *flag.FlagSet) {
+	flags.StringVar(&glyphs, "glyphs", glyphsAuto,
+		"Draw the map with braille or ascii characters (default detected from the console)")
+}
+
+/*
+setupConsole - Prepare the console for the interface and pick the glyphs
+and colors it can show
+*/
+func setupConsole() error {
+	braille := prepareConsole()
+	switch glyphs {
+	case glyphsAuto:
+		asciiMap = !braille
+	case glyphsBraille:
+		asciiMap = false
+	case glyphsASCII:
+		asciiMap = true
+	default:
+		return invalidInput("Invalid -glyphs '%s': Expected auto, braille or ascii.", glyphs)
+	}
+	_, noColor := os.LookupEnv("NO_COLOR")
+	consoleColors = !noColor && os.Getenv("TERM") != "dumb"
+	return nil
+}
+
+/*
+utf8Locale - Whether the locale of the environment is UTF-8, assuming so
+when none is set
+*/
+func utf8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return true
+}
+
+// Dots of a braille cell (bits of the code point) in its upper and lower half
+const (
+	brailleUpper = 0x01 | 0x02 | 0x08 | 0x10
+	brailleLower = 0x04 | 0x20 | 0x40 | 0x80
+)
+
+/*
+asciiGlyphs - text with its braille characters replaced by ASCII ones
+*/
+func asciiGlyphs(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x2800 || r > 0x28ff {
+			return r
+		}
+		dots := r - 0x2800
+		switch {
+		case dots == 0:
+			return ' '
+		case dots&brailleUpper != 0 && dots&brailleLower != 0:
+			return ':'
+		case dots&brailleUpper != 0:
+			return '\''
+		}
+		return '.'
+	}, text)
+}
+
+/*
+copyInfo - Copy the address shown in the info pane (its location if it has
+none) to the clipboard
+*/
+func copyInfo(g *Gui, v *View) error {
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+
+	text := fieldValue(res, "ip")
+	if text == "" {
+		text = fieldValue(res, "loc")
+	}
+	if text == "" {
+		guiShowStatus(g, "Nothing to copy")
+		return nil
+	}
+	if err := writeClipboard(text); err != nil {
+		guiShowStatus(g, "Could not copy %s: %s", text, err)
+		return nil
+	}
+	guiShowStatus(g, "Copied %s", text)
+	return nil
+}
+
+func setClipboardKeybindings(g *Gui) error {
+	return g.SetKeybinding("", 'y', ModNone, copyInfo)
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+/*
+prepareConsole - Tell whether the console has braille glyphs: terminal
+emulators do with a UTF-8 locale, the Linux virtual consoles do not
+*/
+func prepareConsole() bool {
+	return utf8Locale() && os.Getenv("TERM") != "linux"
+}
+
+// Commands writing stdin to the clipboard, tried in order
+var clipboardCommands = [][]string{
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+/*
+writeClipboard - Put text on the clipboard with pbcopy, or the first of
+clipboardCommands installed
+*/
+func writeClipboard(text string) error {
+	commands := clipboardCommands
+	if runtime.GOOS == "darwin" {
+		commands = [][]string{{"pbcopy"}}
+	}
+	for _, command := range commands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %s %s", command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	return fmt.Errorf("No clipboard command found (wl-copy, xclip or xsel)")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode     = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode     = kernel32.NewProc("SetConsoleMode")
+	procSetConsoleOutputCP = kernel32.NewProc("SetConsoleOutputCP")
+	procGlobalAlloc        = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree         = kernel32.NewProc("GlobalFree")
+	procGlobalLock         = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock       = kernel32.NewProc("GlobalUnlock")
+	procLstrcpyW           = kernel32.NewProc("lstrcpyW")
+
+	user32               = syscall.NewLazyDLL("user32.dll")
+	procOpenClipboard    = user32.NewProc("OpenClipboard")
+	procCloseClipboard   = user32.NewProc("CloseClipboard")
+	procEmptyClipboard   = user32.NewProc("EmptyClipboard")
+	procSetClipboardData = user32.NewProc("SetClipboardData")
+)
+
+const (
+	enableVirtualTerminalProcessing = 0x0004
+	codePageUTF8                    = 65001
+	cfUnicodeText                   = 13
+	gmemMoveable                    = 0x0002
+)
+
+/*
+prepareConsole - Switch the console to UTF-8 output and ANSI escape
+sequences, and tell whether it has braille glyphs: Windows Terminal, ConEmu
+and the terminals of editors do, the console host's fonts do not
+*/
+func prepareConsole() bool {
+	procSetConsoleOutputCP.Call(codePageUTF8)
+	handle := os.Stdout.Fd()
+	var mode uint32
+	if ok, _, _ := procGetConsoleMode.Call(handle, uintptr(unsafe.Pointer(&mode))); ok != 0 {
+		procSetConsoleMode.Call(handle, uintptr(mode|enableVirtualTerminalProcessing))
+	}
+	return os.Getenv("WT_SESSION") != "" || os.Getenv("ConEmuANSI") == "ON" ||
+		os.Getenv("TERM_PROGRAM") != ""
+}
+
+/*
+writeClipboard - Put text on the Windows clipboard
+*/
+func writeClipboard(text string) error {
+	utf16, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+	if ok, _, err := procOpenClipboard.Call(0); ok == 0 {
+		return err
+	}
+	defer procCloseClipboard.Call()
+	if ok, _, err := procEmptyClipboard.Call(); ok == 0 {
+		return err
+	}
+
+	size := uintptr(len(utf16) * 2)
+	mem, _, err := procGlobalAlloc.Call(gmemMoveable, size)
+	if mem == 0 {
+		return err
+	}
+	ptr, _, err := procGlobalLock.Call(mem)
+	if ptr == 0 {
+		procGlobalFree.Call(mem)
+		return err
+	}
+	procLstrcpyW.Call(ptr, uintptr(unsafe.Pointer(&utf16[0])))
+	procGlobalUnlock.Call(mem)
+
+	// The clipboard owns the memory once it accepted it
+	if ok, _, err := procSetClipboardData.Call(cfUnicodeText, mem); ok == 0 {
+		procGlobalFree.Call(mem)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/*
+Borders of the countries, from the Natural Earth 1:110m admin 0 dataset
+(public domain) downloaded by "ip411 db update", for the choropleth of the
+batch modes (see choropleth.go)
+*/
+
+const countryShapesURL = "https://raw.githubusercontent.com/nvkelso/natural-earth-vector/master/geojson/ne_110m_admin_0_countries.geojson"
+
+/*
+Borders - Outer rings and the holes in them, with their bounding box
+*/
+type Borders struct {
+	Rings [][]Point
+	Box   BBox
+}
+
+/*
+CountryShape - The borders of a country
+*/
+type CountryShape struct {
+	Code string // ISO 3166-1 alpha-2
+	Borders
+}
+
+/*
+contains - Whether lon,lat is within the borders, holes excepted (even-odd
+rule over all of the rings)
+*/
+func (s Borders) contains(lon, lat float64) bool {
+	if lon < s.Box.MinLon || lon > s.Box.MaxLon || lat < s.Box.MinLat || lat > s.Box.MaxLat {
+		return false
+	}
+	inside := false
+	for _, ring := range s.Rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.Lat > lat) != (b.Lat > lat) &&
+				lon < (b.Lon-a.Lon)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+/*
+addRing - Add a ring of lon,lat pairs to the borders
+*/
+func (s *Borders) addRing(coords [][2]float64) {
+	if len(s.Rings) == 0 {
+		s.Box = BBox{180, 90, -180, -90}
+	}
+	ring := make([]Point, len(coords))
+	for i, c := range coords {
+		ring[i] = Point{Lat: c[1], Lon: c[0]}
+		s.Box.MinLon = math.Min(s.Box.MinLon, c[0])
+		s.Box.MaxLon = math.Max(s.Box.MaxLon, c[0])
+		s.Box.MinLat = math.Min(s.Box.MinLat, c[1])
+		s.Box.MaxLat = math.Max(s.Box.MaxLat, c[1])
+	}
+	s.Rings = append(s.Rings, ring)
+}
+
+/*
+geoJSONFeatures - A GeoJSON feature collection
+*/
+type geoJSONFeatures struct {
+	Features []struct {
+		Properties map[string]interface{} `json:"properties"`
+		Geometry   struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+/*
+geoJSONBorders - The borders of a Polygon or MultiPolygon geometry, false for
+other geometries
+*/
+func geoJSONBorders(typ string, coordinates json.RawMessage) (Borders, bool, error) {
+	var polygons [][][][2]float64
+	switch typ {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(coordinates, &polygon); err != nil {
+			return Borders{}, false, err
+		}
+		polygons = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(coordinates, &polygons); err != nil {
+			return Borders{}, false, err
+		}
+	default:
+		return Borders{}, false, nil
+	}
+
+	var borders Borders
+	for _, polygon := range polygons {
+		for _, ring := range polygon {
+			if err := checkRing(ring); err != nil {
+				return Borders{}, false, err
+			}
+			borders.addRing(ring)
+		}
+	}
+	return borders, true, nil
+}
+
+func countryShapesPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "countries.geojson")
+}
+
+/*
+readCountryShapes - Read the countries of a GeoJSON feature collection,
+coded by their ISO_A2_EH (else ISO_A2) property
+*/
+func readCountryShapes(r io.Reader) ([]CountryShape, error) {
+	var collection geoJSONFeatures
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+
+	var shapes []CountryShape
+	for _, feature := range collection.Features {
+		code := toString(feature.Properties["ISO_A2_EH"])
+		if code == "" || code == "-99" {
+			code = toString(feature.Properties["ISO_A2"])
+		}
+		if code == "" || code == "-99" {
+			continue
+		}
+		borders, ok, err := geoJSONBorders(feature.Geometry.Type, feature.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid borders of %s: %s", code, err)
+		}
+		if ok {
+			shapes = append(shapes, CountryShape{code, borders})
+		}
+	}
+	return shapes, nil
+}
+
+var (
+	countryShapesOnce sync.Once
+	countryShapes     []CountryShape
+	countryShapesErr  error
+)
+
+/*
+loadCountryShapes - The countries downloaded by "ip411 db update", read once
+*/
+func loadCountryShapes() ([]CountryShape, error) {
+	countryShapesOnce.Do(func() {
+		path := countryShapesPath()
+		f, err := os.Open(path)
+		if err != nil {
+			countryShapesErr = errors.New(tr("No country borders: run ip411 db update"))
+			return
+		}
+		defer f.Close()
+		countryShapes, countryShapesErr = readCountryShapes(f)
+		if countryShapesErr != nil {
+			countryShapesErr = fmt.Errorf("%s: %s", path, countryShapesErr)
+		}
+	})
+	return countryShapes, countryShapesErr
+}
+
+/*
+countryAt - The code of the country at lon,lat, "" at sea or without the
+borders
+*/
+func countryAt(lon, lat float64) string {
+	shapes, _ := loadCountryShapes()
+	for _, shape := range shapes {
+		if shape.contains(lon, lat) {
+			return shape.Code
+		}
+	}
+	return ""
+}
+
+/*
+updateCountryShapes - Download the borders of the countries
+*/
+func updateCountryShapes(url string) error {
+	path := countryShapesPath()
+	if path == "" {
+		return fmt.Errorf("No cache directory to store the country borders in")
+	}
+	var count int
+	err := download(url, path, func(data []byte) error {
+		shapes, err := readCountryShapes(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if count = len(shapes); count == 0 {
+			return fmt.Errorf("No countries found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d country borders saved to %s\n", count, path)
+	return nil
+}
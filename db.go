@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+Offline datasets ip411 keeps in the user's cache directory
+*/
+
+const asnNamesURL = "https://ftp.ripe.net/ripe/asnames/asn.txt"
+
+var dbClient = &http.Client{Timeout: 5 * time.Minute}
+
+/*
+download - GET url into path, replacing it only once the download is
+complete and check accepts it
+*/
+func download(url, path string, check func(data []byte) error) error {
+	return downloadAs(url, path, func(data []byte) ([]byte, error) {
+		return data, check(data)
+	})
+}
+
+/*
+downloadAs - GET url into path as convert turns it, replacing it only once
+the download is complete and converted
+*/
+func downloadAs(url, path string, convert func(data []byte) ([]byte, error)) error {
+	resp, err := dbClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if data, err = convert(data); err != nil {
+		return fmt.Errorf("%s: %s", url, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+/*
+updateASNTable - Download the AS names
+*/
+func updateASNTable(url string) error {
+	path := asnTablePath()
+	if path == "" {
+		return fmt.Errorf("No cache directory to store the AS names in")
+	}
+	var count int
+	err := download(url, path, func(data []byte) error {
+		table, err := readASNTable(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if count = len(table); count == 0 {
+			return fmt.Errorf("No AS names found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d AS names saved to %s\n", count, path)
+	return nil
+}
+
+/*
+dbHelp - What the usage message of db says after the synopsis
+*/
+const dbHelp = `Download the offline datasets: the names and countries of the AS
+numbers, used for providers answering bare AS numbers and for the
+origins of -bgp, the borders of the countries, filled by <C> in the
+batch modes, and of their states and provinces, drawn when zoomed into
+a country and counted by the statistics panel.
+`
+
+func runDB(args []string) error {
+	flags := flag.NewFlagSet("db", flag.ExitOnError)
+	addQuietFlag(flags)
+	asnURL := flags.String("asn-url", asnNamesURL, "Where to download the AS names from")
+	countriesURL := flags.String("countries-url", countryShapesURL,
+		"Where to download the country borders (GeoJSON) from")
+	subdivisionsURL := flags.String("subdivisions-url", subdivisionsURL,
+		"Where to download the subdivisions of the countries (GeoJSON) from")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s db update\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(dbHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || flags.Arg(0) != "update" {
+		flags.Usage()
+		return invalidInput("Invalid arguments: Expected update.")
+	}
+	if err := updateASNTable(*asnURL); err != nil {
+		return err
+	}
+	if err := updateCountryShapes(*countriesURL); err != nil {
+		return err
+	}
+	return updateSubdivisions(*subdivisionsURL)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Dialogs are the modal views asking the user something, one at a time:
+
+	confirmDialog  a yes or no question     y yes, n or esc no
+	inputDialog    a line of text           enter accept, esc cancel
+	selectDialog   an item of a list        up/down (k/j) move, enter choose,
+	                                        esc cancel
+
+While a dialog has the focus the keys of the other views do nothing. Its
+callback runs in the gui goroutine once it is closed, and may open the next
+dialog.
+*/
+
+const dialogView = "dialog"
+
+type dialogKind int
+
+const (
+	dialogConfirm dialogKind = iota
+	dialogInput
+	dialogSelect
+)
+
+/*
+dialog - A dialog open
+*/
+type dialog struct {
+	kind     dialogKind
+	title    string
+	items    []string                               // of a select dialog
+	validate func(text string) error                // of an input dialog, nil for any text
+	accept   func(g *Gui, text string, i int) error // the text typed, or the item chosen
+}
+
+// The dialog open, nil if none, only touched from the gui goroutine
+var openDialog *dialog
+
+/*
+showDialog - Open d in a view at x0,y0 and x1,y1 holding text, unless
+another dialog is open
+*/
+func showDialog(g *Gui, d *dialog, x0, y0, x1, y1 int, text string) (*View, error) {
+	if openDialog != nil {
+		return nil, nil
+	}
+	view, err := g.SetView(dialogView, x0, y0, x1, y1)
+	if err != nil && err != ErrUnknownView {
+		return nil, err
+	}
+	openDialog = d
+	view.Title = d.title
+	view.Modal = true
+	view.Clear()
+	fmt.Fprint(view, text)
+	return view, g.SetCurrentView(dialogView)
+}
+
+/*
+confirmDialog - Ask the question of format, calling yes if the answer is y
+*/
+func confirmDialog(g *Gui, yes func(g *Gui) error, format string, args ...interface{}) error {
+	maxX, maxY := g.Size()
+	question := fmt.Sprintf(tr(format), args...)
+	answers := tr("<y> yes, <n> no")
+	width := len([]rune(question)) + 4
+	if n := len([]rune(answers)) + 4; n > width {
+		width = n
+	}
+	if width > maxX-2 {
+		width = maxX - 2
+	}
+	x0, y0 := (maxX-width)/2, maxY/2-2
+	d := &dialog{kind: dialogConfirm, title: tr("Confirm"),
+		accept: func(g *Gui, text string, i int) error { return yes(g) }}
+	_, err := showDialog(g, d, x0, y0, x0+width, y0+3, question+"\n"+answers)
+	return err
+}
+
+/*
+inputDialog - Ask for a line of text above the status bar, starting with
+text. accept gets the text once validate, unless nil, accepts it; the error
+of validate is shown in the title until the text is fixed.
+*/
+func inputDialog(g *Gui, title, text string, validate func(text string) error,
+	accept func(g *Gui, text string) error) error {
+	maxX, maxY := g.Size()
+	d := &dialog{kind: dialogInput, title: tr(title), validate: validate,
+		accept: func(g *Gui, text string, i int) error { return accept(g, text) }}
+	view, err := showDialog(g, d, -1, maxY-statusHeight-2, maxX, maxY-statusHeight, text)
+	if view == nil || err != nil {
+		return err
+	}
+	view.Editable = true
+	return view.SetCursor(len([]rune(text)), 0)
+}
+
+/*
+selectDialog - Ask for one of items, calling choose with its index
+*/
+func selectDialog(g *Gui, title string, items []string, choose func(g *Gui, i int) error) error {
+	maxX, maxY := g.Size()
+	d := &dialog{kind: dialogSelect, title: tr(title), items: items,
+		accept: func(g *Gui, text string, i int) error { return choose(g, i) }}
+	view, err := showDialog(g, d, maxX/4, maxY/6, maxX*3/4, maxY*5/6, strings.Join(items, "\n"))
+	if view == nil || err != nil {
+		return err
+	}
+	view.Highlight = true
+	view.SelBgColor = ColorGreen
+	view.SelFgColor = ColorBlack
+	selectLine(view, 0, len(items))
+	return nil
+}
+
+func closeDialog(g *Gui, v *View) error {
+	openDialog = nil
+	if err := g.DeleteView(dialogView); err != nil {
+		return err
+	}
+	return g.SetCurrentView("map")
+}
+
+func dialogEnter(g *Gui, v *View) error {
+	d := openDialog
+	switch {
+	case d == nil:
+		return nil
+	case d.kind == dialogInput:
+		text := strings.TrimSpace(v.Buffer())
+		if d.validate != nil {
+			if err := d.validate(text); err != nil {
+				v.Title = fmt.Sprintf("%s: %s", d.title, err)
+				return nil
+			}
+		}
+		if err := closeDialog(g, v); err != nil {
+			return err
+		}
+		return d.accept(g, text, 0)
+	case d.kind == dialogSelect:
+		i := selectedLine(v)
+		if i >= len(d.items) {
+			return nil
+		}
+		if err := closeDialog(g, v); err != nil {
+			return err
+		}
+		return d.accept(g, "", i)
+	}
+	return nil
+}
+
+func dialogYes(g *Gui, v *View) error {
+	d := openDialog
+	if d == nil || d.kind != dialogConfirm {
+		return nil
+	}
+	if err := closeDialog(g, v); err != nil {
+		return err
+	}
+	return d.accept(g, "", 0)
+}
+
+func dialogNo(g *Gui, v *View) error {
+	if openDialog == nil || openDialog.kind != dialogConfirm {
+		return nil
+	}
+	return closeDialog(g, v)
+}
+
+func dialogMove(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		if d := openDialog; d != nil && d.kind == dialogSelect {
+			selectLine(v, selectedLine(v)+delta, len(d.items))
+		}
+		return nil
+	}
+}
+
+/*
+setDialogKeybindings - Register the keys of the dialogs
+*/
+func setDialogKeybindings(g *Gui) error {
+	bindings := []struct {
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{KeyEnter, dialogEnter},
+		{KeyEsc, closeDialog},
+		{'y', dialogYes},
+		{'n', dialogNo},
+		{KeyArrowUp, dialogMove(-1)},
+		{KeyArrowDown, dialogMove(1)},
+		{'k', dialogMove(-1)},
+		{'j', dialogMove(1)},
+	}
+	for _, binding := range bindings {
+		if err := g.SetKeybinding(dialogView, binding.key, ModNone, binding.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
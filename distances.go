@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Distance reports of batches: the great-circle distance between every pair of
+located targets, the nearest neighbour and cluster of each target, the
+nearest datacenter of each and the centroid of them all.
+*/
+
+/*
+Datacenter - A named location targets can be served from
+*/
+type Datacenter struct {
+	Name string `json:"name"`
+	Point
+}
+
+/*
+readDatacenters - Read "name,lat,lon" records, or "name,target" records
+whose target is looked up
+*/
+func readDatacenters(path string) ([]Datacenter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := newCSVReader(f)
+	reader.Comment = '#'
+	var dcs []Datacenter
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%s: Expected name,lat,lon or name,target", path)
+		}
+
+		name, target := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if len(record) >= 3 {
+			target += "," + strings.TrimSpace(record[2])
+		}
+		res, err := locateTarget([]string{target})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		lon, lat, err := res.GetLonLat()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		dcs = append(dcs, Datacenter{name, Point{Lat: lat, Lon: lon}})
+	}
+	return dcs, nil
+}
+
+/*
+LocatedTarget - A target and where it was located
+*/
+type LocatedTarget struct {
+	Target string `json:"target"`
+	Point
+}
+
+/*
+located - Located targets passing the filter. Must be called with b.mu
+held.
+*/
+func (b *Batch) located() []LocatedTarget {
+	var targets []LocatedTarget
+	for _, target := range b.targets {
+		res, ok := b.results[target]
+		if !ok || !b.matchFilter(res) {
+			continue
+		}
+		if lon, lat, err := res.GetLonLat(); err == nil {
+			targets = append(targets, LocatedTarget{target, Point{Lat: lat, Lon: lon}})
+		}
+	}
+	return targets
+}
+
+/*
+centroid - Geographic center of points, averaged on the sphere
+*/
+func centroid(points []Point) Point {
+	var x, y, z float64
+	for _, p := range points {
+		lat, lon := radians(p.Lat), radians(p.Lon)
+		x += math.Cos(lat) * math.Cos(lon)
+		y += math.Cos(lat) * math.Sin(lon)
+		z += math.Sin(lat)
+	}
+	lon := math.Atan2(y, x)
+	lat := math.Atan2(z, math.Hypot(x, y))
+	return Point{Lat: lat * 180 / math.Pi, Lon: lon * 180 / math.Pi}
+}
+
+/*
+nearestDatacenter - The datacenter nearest to p and its distance
+*/
+func nearestDatacenter(p Point, dcs []Datacenter) (Datacenter, float64) {
+	best, bestKm := Datacenter{}, math.Inf(1)
+	for _, dc := range dcs {
+		if d := distanceKm(p, dc.Point); d < bestKm {
+			best, bestKm = dc, d
+		}
+	}
+	return best, bestKm
+}
+
+/*
+TargetDistances - The row of a target in a distance report
+*/
+type TargetDistances struct {
+	LocatedTarget
+	Cluster      int     `json:"cluster"`
+	Nearest      string  `json:"nearest,omitempty"`
+	NearestKm    float64 `json:"nearest_km,omitempty"`
+	Datacenter   string  `json:"datacenter,omitempty"`
+	DatacenterKm float64 `json:"datacenter_km,omitempty"`
+}
+
+/*
+DistanceReport - Distances between the targets of a batch. Targets closer
+than ClusterKm, directly or through other targets, share a cluster.
+*/
+type DistanceReport struct {
+	Centroid  Point             `json:"centroid"`
+	ClusterKm float64           `json:"cluster_km"`
+	Clusters  int               `json:"clusters"`
+	Targets   []TargetDistances `json:"targets"`
+	Matrix    [][]float64       `json:"distances_km"`
+}
+
+/*
+NewDistanceReport - Compute the report of targets
+*/
+func NewDistanceReport(targets []LocatedTarget, dcs []Datacenter, clusterKm float64) *DistanceReport {
+	n := len(targets)
+	r := &DistanceReport{ClusterKm: clusterKm, Matrix: make([][]float64, n)}
+
+	points := make([]Point, n)
+	for i, t := range targets {
+		points[i] = t.Point
+		r.Matrix[i] = make([]float64, n)
+	}
+	if n > 0 {
+		c := centroid(points)
+		r.Centroid = Point{Lat: roundCoord(c.Lat), Lon: roundCoord(c.Lon)}
+	}
+
+	// Single-linkage clustering, with a union-find of the targets
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := distanceKm(points[i], points[j])
+			r.Matrix[i][j], r.Matrix[j][i] = d, d
+			if d <= clusterKm {
+				parent[root(i)] = root(j)
+			}
+		}
+	}
+
+	clusterIDs := make(map[int]int)
+	for i, t := range targets {
+		row := TargetDistances{LocatedTarget: t}
+		id, ok := clusterIDs[root(i)]
+		if !ok {
+			id = len(clusterIDs) + 1
+			clusterIDs[root(i)] = id
+		}
+		row.Cluster = id
+
+		row.NearestKm = math.Inf(1)
+		for j := range targets {
+			if j != i && r.Matrix[i][j] < row.NearestKm {
+				row.Nearest, row.NearestKm = targets[j].Target, r.Matrix[i][j]
+			}
+		}
+		if row.Nearest == "" {
+			row.NearestKm = 0
+		}
+
+		if len(dcs) > 0 {
+			dc, d := nearestDatacenter(t.Point, dcs)
+			row.Datacenter, row.DatacenterKm = dc.Name, d
+		}
+		r.Targets = append(r.Targets, row)
+	}
+	r.Clusters = len(clusterIDs)
+	return r
+}
+
+/*
+WriteJSON - Write the report as a JSON object
+*/
+func (r *DistanceReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+/*
+WriteCSV - Write the report as one record per target, ending with its
+distance to every target
+*/
+func (r *DistanceReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"target", "lat", "lon", "cluster", "nearest", "nearest_km",
+		"datacenter", "datacenter_km"}
+	for _, t := range r.Targets {
+		header = append(header, t.Target)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	km := func(d float64) string { return strconv.FormatFloat(d, 'f', 1, 64) }
+	for i, t := range r.Targets {
+		record := []string{t.Target, strconv.FormatFloat(t.Lat, 'f', coordPrecision, 64),
+			strconv.FormatFloat(t.Lon, 'f', coordPrecision, 64), strconv.Itoa(t.Cluster),
+			t.Nearest, km(t.NearestKm), t.Datacenter, km(t.DatacenterKm)}
+		for _, d := range r.Matrix[i] {
+			record = append(record, km(d))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+/*
+Save - Write the report to path, as CSV if it ends in .csv and JSON
+otherwise
+*/
+func (r *DistanceReport) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".csv" {
+		err = r.WriteCSV(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+/*
+Summary - Lines of the report for the info pane
+*/
+func (r *DistanceReport) Summary() []string {
+	if len(r.Targets) < 2 {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("Centroid: %s (near %s)  Clusters within %s: %s",
+		formatCoords(r.Centroid.Lat, r.Centroid.Lon), reverseGeocode(r.Centroid).City,
+		formatDistance(r.ClusterKm), formatCount(r.Clusters))}
+
+	counts := make(map[string]int)
+	for _, t := range r.Targets {
+		if t.Datacenter != "" {
+			counts[t.Datacenter]++
+		}
+	}
+	if len(counts) > 0 {
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+		}
+		lines = append(lines, "Nearest datacenter: "+strings.Join(parts, ", "))
+	}
+	return lines
+}
+
+/*
+distanceReport - Report of the located targets of the batch. Must be called
+with b.mu held.
+*/
+func (b *Batch) distanceReport() *DistanceReport {
+	return NewDistanceReport(b.located(), b.datacenters, b.clusterKm)
+}
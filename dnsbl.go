@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+With -dnsbl the IP Address is checked against DNS blocklists, concurrently
+with the lookup, and the outcome is added under "reputation":
+
+	reputation.summary  "listed on 1 of 4: zen.spamhaus.org (127.0.0.4)"
+	reputation.listed   lists the address is on
+	reputation.checked  number of lists that answered
+
+The lists are those of "dnsbls" in the config file, else defaultDNSBLs.
+Some lists refuse queries from public resolvers; their refusals count as
+unanswered rather than listed.
+*/
+
+var defaultDNSBLs = []string{
+	"zen.spamhaus.org",
+	"b.barracudacentral.org",
+	"bl.spamcop.net",
+	"dnsbl-1.uceprotect.net",
+}
+
+const dnsblTimeout = 5 * time.Second
+
+var dnsblFlag = flag.Bool("dnsbl", false,
+	"Check the IP Address against DNS blocklists (Spamhaus, Barracuda...)")
+
+var reputationInfoFields = []InfoField{
+	{"Reputation", "reputation.summary"},
+}
+
+/*
+DNSBLResult - Answer of a blocklist
+*/
+type DNSBLResult struct {
+	List   string
+	Listed bool
+	Code   string // return code of a listing, as 127.0.0.2
+	Err    error
+}
+
+/*
+dnsblQuery - Name to resolve to check ip on list: the reversed octets of an
+IPv4 Address, or the reversed nibbles of an IPv6 one
+*/
+func dnsblQuery(ip net.IP, list string) string {
+	var parts []string
+	if ip4 := ip.To4(); ip4 != nil {
+		for i := 3; i >= 0; i-- {
+			parts = append(parts, fmt.Sprint(ip4[i]))
+		}
+	} else {
+		ip16 := ip.To16()
+		for i := 15; i >= 0; i-- {
+			parts = append(parts, fmt.Sprintf("%x", ip16[i]&0xf), fmt.Sprintf("%x", ip16[i]>>4))
+		}
+	}
+	return strings.Join(parts, ".") + "." + list
+}
+
+/*
+checkDNSBL - Look ip up on list
+*/
+func checkDNSBL(ip net.IP, list string) DNSBLResult {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsblTimeout)
+	defer cancel()
+
+	r := DNSBLResult{List: list}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, dnsblQuery(ip, list))
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		return r
+	} else if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Code = addrs[0]
+	// 127.255.255.0/24 answers are errors (refused, rate limited), not
+	// listings
+	if strings.HasPrefix(r.Code, "127.255.255.") {
+		r.Err = fmt.Errorf("Query refused (%s)", r.Code)
+		return r
+	}
+	r.Listed = true
+	return r
+}
+
+/*
+Reputation - Answers of the blocklists about an IP Address
+*/
+type Reputation struct {
+	Results []DNSBLResult
+}
+
+/*
+checkReputation - Check ip against every list, concurrently
+*/
+func checkReputation(ip net.IP, lists []string) *Reputation {
+	rep := &Reputation{Results: make([]DNSBLResult, len(lists))}
+	var wg sync.WaitGroup
+	for i, list := range lists {
+		wg.Add(1)
+		go func(i int, list string) {
+			defer wg.Done()
+			rep.Results[i] = checkDNSBL(ip, list)
+		}(i, list)
+	}
+	wg.Wait()
+	return rep
+}
+
+/*
+Listed - The results of the lists ip is on
+*/
+func (rep *Reputation) Listed() []DNSBLResult {
+	var listed []DNSBLResult
+	for _, r := range rep.Results {
+		if r.Listed {
+			listed = append(listed, r)
+		}
+	}
+	return listed
+}
+
+/*
+Checked - Number of lists that answered
+*/
+func (rep *Reputation) Checked() int {
+	n := 0
+	for _, r := range rep.Results {
+		if r.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+/*
+Summary - One line account of the reputation
+*/
+func (rep *Reputation) Summary() string {
+	listed := rep.Listed()
+	if len(listed) == 0 {
+		return fmt.Sprintf("not listed on %d of %d blocklists", rep.Checked(), len(rep.Results))
+	}
+	names := make([]string, len(listed))
+	for i, r := range listed {
+		names[i] = fmt.Sprintf("%s (%s)", r.List, r.Code)
+	}
+	return fmt.Sprintf("listed on %d of %d: %s", len(listed), rep.Checked(),
+		strings.Join(names, ", "))
+}
+
+/*
+add - Add the reputation to res under "reputation"
+*/
+func (rep *Reputation) add(res IPInfoResult) {
+	listed := []interface{}{}
+	for _, r := range rep.Listed() {
+		listed = append(listed, r.List)
+	}
+	res["reputation"] = map[string]interface{}{
+		"summary": rep.Summary(),
+		"listed":  listed,
+		"checked": float64(rep.Checked()),
+	}
+}
+
+/*
+Warning - What the status bar should warn about, "" if nothing
+*/
+func (rep *Reputation) Warning() string {
+	if len(rep.Listed()) == 0 {
+		return ""
+	}
+	return "Blocklisted: " + rep.Summary()
+}
+
+func dnsblLists() []string {
+	if len(config.DNSBLs) > 0 {
+		return config.DNSBLs
+	}
+	return defaultDNSBLs
+}
+
+/*
+startReputation - Start checking the IP Address of the target of the command
+line. The channel yields nil for targets that are coordinates or that do not
+resolve.
+*/
+func startReputation(args []string) <-chan *Reputation {
+	ch := make(chan *Reputation, 1)
+	go func() {
+		if len(args) > 0 {
+			target, err := commandTarget(args[0])
+			if _, ok := targetPoint(target); err != nil || ok {
+				ch <- nil
+				return
+			}
+		}
+		ip, err := makeIP(args)
+		if err != nil {
+			ch <- nil
+			return
+		}
+		if ip == nil {
+			// The client's own address is only known once located
+			ch <- nil
+			return
+		}
+		ch <- checkReputation(ip, dnsblLists())
+	}()
+	return ch
+}
+
+/*
+reputationOf - Check the IP Address of res, once located
+*/
+func reputationOf(res IPInfoResult) *Reputation {
+	ip := net.ParseIP(fieldValue(res, "ip"))
+	if ip == nil {
+		return nil
+	}
+	return checkReputation(ip, dnsblLists())
+}
+
+/*
+showReputationFields - Add the reputation to the info pane unless the config
+file already lists it
+*/
+func showReputationFields() {
+	for _, field := range config.InfoFields {
+		if field.Path == "reputation.summary" {
+			return
+		}
+	}
+	fields := append([]InfoField{}, config.InfoFields...)
+	config.InfoFields = append(fields, reputationInfoFields...)
+}
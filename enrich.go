@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+orgName - The org of an ipinfo org field without its AS number ("AS24940
+Hetzner Online GmbH" gives "Hetzner Online GmbH")
+*/
+func orgName(org string) string {
+	if asn := asnFromOrg(org); asn != "" {
+		return strings.TrimSpace(strings.TrimPrefix(org, asn))
+	}
+	return org
+}
+
+/*
+Enricher - Annotates the IP Addresses of text with their lookup results,
+looking each address up once
+*/
+type Enricher struct {
+	fields []string
+	cache  map[string]string
+}
+
+/*
+NewEnricher - Annotate with the values of fields, "org" being the org name
+without its AS number
+*/
+func NewEnricher(fields []string) *Enricher {
+	return &Enricher{fields: fields, cache: make(map[string]string)}
+}
+
+/*
+annotation - The annotation of an IP Address, "" for addresses that are not
+public or could not be looked up
+*/
+func (e *Enricher) annotation(ip string) string {
+	if note, ok := e.cache[ip]; ok {
+		return note
+	}
+	note := ""
+	if res, err := locateTarget([]string{ip}); err != nil {
+		warnf("%s: %s", ip, err)
+	} else {
+		var values []string
+		for _, field := range e.fields {
+			value := fieldValue(res, field)
+			if field == "org" {
+				value = orgName(value)
+			}
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			note = "[" + strings.Join(values, ", ") + "]"
+		}
+	}
+	e.cache[ip] = note
+	return note
+}
+
+/*
+Line - line with an annotation after each public IP Address
+*/
+func (e *Enricher) Line(line string) string {
+	matches := findIPs(line)
+	if len(matches) == 0 {
+		return line
+	}
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		out.WriteString(line[last:m.End])
+		if isPublicIP(m.IP) {
+			out.WriteString(e.annotation(m.IP.String()))
+		}
+		last = m.End
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+/*
+Copy - Copy r to w line by line, annotated. Every line is flushed as soon as
+it is written so that enrich can follow a growing log.
+*/
+func (e *Enricher) Copy(w io.Writer, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, werr := writer.WriteString(e.Line(line)); werr != nil {
+				return werr
+			}
+			if werr := writer.Flush(); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+/*
+enrichHelp - What the usage message of enrich says after the synopsis
+*/
+const enrichHelp = `Copy file (or stdin) to stdout, annotating every public IP Address
+with its lookup, as in 1.2.3.4[DE, Hetzner Online GmbH]. Everything
+else is left as it is, so enrich can sit in any pipe:
+`
+
+func runEnrich(args []string) error {
+	flags := flag.NewFlagSet("enrich", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	fields := flags.String("fields", "country,org",
+		"Comma separated fields of the annotations")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s enrich [-fields list] [file]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(enrichHelp))
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintf(os.Stderr, "  tail -f access.log | %s enrich\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+	fieldList := parseFieldList(*fields)
+	if len(fieldList) == 0 {
+		return invalidInput("Specify at least one field.")
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	return NewEnricher(fieldList).Copy(os.Stdout, input)
+}
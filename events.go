@@ -0,0 +1,279 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// EventLookup - A target was located
+	EventLookup = "lookup"
+	// EventIPChange - The watched public IP Address changed
+	EventIPChange = "ip_change"
+	// EventNewCountry - A country was seen for the first time in this session
+	EventNewCountry = "new_country"
+	// EventAlert - A result matched an alert rule, see alerts.go
+	EventAlert = "alert"
+	// EventChanged - A stored address changed when checked again, see recheck.go
+	EventChanged = "changed"
+)
+
+/*
+Event - Something that happened in a long-running mode, published to the
+configured sinks as JSON
+*/
+type Event struct {
+	Type     string        `json:"type"`
+	Time     time.Time     `json:"time"`
+	IP       string        `json:"ip,omitempty"`
+	Previous string        `json:"previous,omitempty"`
+	Rule     string        `json:"rule,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Country  string        `json:"country,omitempty"`
+	Lat      *float64      `json:"lat,omitempty"`
+	Lon      *float64      `json:"lon,omitempty"`
+	RTT      time.Duration `json:"rtt_ns,omitempty"`
+	Result   IPInfoResult  `json:"result,omitempty"`
+}
+
+/*
+NewEvent - Create an event of type typ for a lookup result
+*/
+func NewEvent(typ string, ipinfo IPInfoResult) Event {
+	e := Event{Type: typ, Time: time.Now(), Result: ipinfo}
+	e.IP, _ = ipinfo.GetKey("ip")
+	e.Country, _ = ipinfo.GetKey("country")
+	if lon, lat, err := ipinfo.GetLonLat(); err == nil {
+		e.Lat, e.Lon = &lat, &lon
+	}
+	return e
+}
+
+/*
+Sink - Destination for events
+*/
+type Sink interface {
+	Publish(e Event) error
+	Close() error
+}
+
+/*
+Events - Fans events out to every configured sink. A nil *Events drops
+everything, so modes without sinks don't need to check.
+*/
+type Events struct {
+	sinks []Sink
+	stop  func() // of the subscription to the bus
+}
+
+/*
+Emit - Publish e to every sink, logging sinks that fail
+*/
+func (ev *Events) Emit(e Event) {
+	if ev == nil {
+		return
+	}
+	for _, sink := range ev.sinks {
+		if err := sink.Publish(e); err != nil {
+			warnf("Could not publish %s event: %s", e.Type, err)
+		}
+	}
+}
+
+/*
+deliver - Publish the events of a result or an alert of the bus
+*/
+func (ev *Events) deliver(m Message) {
+	if m.Topic == MsgAlert {
+		if hasAction(m.Actions, actionEvents) {
+			ev.Emit(alertEvent(m))
+		}
+		return
+	}
+	e := NewEvent(EventLookup, m.Result)
+	e.RTT = m.RTT
+	ev.Emit(e)
+	if m.NewCountry {
+		ev.Emit(NewEvent(EventNewCountry, m.Result))
+	}
+}
+
+/*
+Close - Publish the results still on the bus and close every sink
+*/
+func (ev *Events) Close() {
+	if ev == nil {
+		return
+	}
+	if ev.stop != nil {
+		ev.stop()
+	}
+	for _, sink := range ev.sinks {
+		if err := sink.Close(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+/*
+sinkOptions - Command line flags selecting the event sinks of a mode
+*/
+type sinkOptions struct {
+	mqtt   string
+	topic  string
+	jsonl  string
+	syslog string
+	fields string
+
+	es       string
+	esIndex  string
+	esAPIKey string
+
+	influx         string
+	influxToken    string
+	influxInterval time.Duration
+
+	webhook         string
+	webhookTemplate string
+	webhookSecret   string
+	webhookEvents   string
+
+	exec        string
+	execEvents  string
+	execJobs    int
+	execTimeout time.Duration
+
+	notify       bool
+	notifyEvents string
+}
+
+func addSinkFlags(flags *flag.FlagSet) *sinkOptions {
+	opts := &sinkOptions{}
+	flags.StringVar(&opts.mqtt, "mqtt", "",
+		"Publish events to the MQTT broker at this URL (tcp://host:1883)")
+	flags.StringVar(&opts.topic, "topic", "ip411/events",
+		"MQTT topic events are published to")
+	flags.StringVar(&opts.jsonl, "jsonl", "",
+		"Append one JSON line per event to this file or unix:/path socket")
+	flags.StringVar(&opts.syslog, "syslog", "",
+		"Send events to syslog: local, udp://host:port or tcp://host:port")
+	flags.StringVar(&opts.fields, "fields", "",
+		"Comma separated fields kept in -jsonl and -syslog events (default all)")
+	flags.StringVar(&opts.es, "es", "",
+		"Index events into the Elasticsearch/OpenSearch cluster at this URL")
+	flags.StringVar(&opts.esIndex, "es-index", "ip411", "Elasticsearch index name")
+	flags.StringVar(&opts.esAPIKey, "es-api-key", os.Getenv("IP411_ES_API_KEY"),
+		"Elasticsearch API key (default $IP411_ES_API_KEY, else the keyring)")
+	flags.StringVar(&opts.influx, "influx", "",
+		"Write metrics in line protocol to this InfluxDB/VictoriaMetrics write URL")
+	flags.StringVar(&opts.influxToken, "influx-token", os.Getenv("IP411_INFLUX_TOKEN"),
+		"InfluxDB API token (default $IP411_INFLUX_TOKEN, else the keyring)")
+	flags.DurationVar(&opts.influxInterval, "influx-interval", 10*time.Second,
+		"How often metrics are written")
+	flags.StringVar(&opts.webhook, "webhook", "", "POST events to this URL")
+	flags.StringVar(&opts.webhookTemplate, "webhook-template", "",
+		"text/template file rendering the webhook body (default the event as JSON)")
+	flags.StringVar(&opts.webhookSecret, "webhook-secret", os.Getenv("IP411_WEBHOOK_SECRET"),
+		"Sign webhook bodies with HMAC-SHA256 in X-Ip411-Signature\n"+
+			"(default $IP411_WEBHOOK_SECRET)")
+	flags.StringVar(&opts.webhookEvents, "webhook-events", "",
+		"Comma separated event types sent to the webhook (default all)")
+	flags.StringVar(&opts.exec, "exec", "",
+		"Run this command per event, quoted as in a shell but run without one,\n"+
+			"placeholders such as {ip} and {country} replaced by the fields of the event")
+	flags.StringVar(&opts.execEvents, "exec-events", "",
+		"Comma separated event types the command is run for (default all)")
+	flags.IntVar(&opts.execJobs, "exec-jobs", defaultExecJobs,
+		"How many commands run at once")
+	flags.DurationVar(&opts.execTimeout, "exec-timeout", defaultExecTimeout,
+		"How long a command may run before it is killed")
+	flags.BoolVar(&opts.notify, "notify", false,
+		"Show desktop notifications: notify-send on Linux, Notification Center on macOS")
+	flags.StringVar(&opts.notifyEvents, "notify-events", "",
+		"Comma separated event types notified (default alert,ip_change)")
+	return opts
+}
+
+/*
+open - Connect the sinks selected on the command line, publishing the
+results of the bus
+*/
+func (opts *sinkOptions) open() (*Events, error) {
+	ev := &Events{}
+	if opts.mqtt != "" {
+		sink, err := NewMQTTSink(opts.mqtt, opts.topic)
+		if err != nil {
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	fields := parseFieldList(opts.fields)
+	if opts.jsonl != "" {
+		sink, err := NewJSONLSink(opts.jsonl, fields)
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.syslog != "" {
+		sink, err := NewSyslogSink(opts.syslog, fields)
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.es != "" {
+		if opts.esAPIKey == "" {
+			opts.esAPIKey = providerToken("es")
+		}
+		sink, err := NewElasticsearchSink(opts.es, opts.esIndex, opts.esAPIKey)
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.influx != "" {
+		if opts.influxToken == "" {
+			opts.influxToken = providerToken("influx")
+		}
+		sink, err := NewInfluxSink(opts.influx, opts.influxToken, opts.influxInterval)
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.webhook != "" {
+		sink, err := NewWebhookSink(opts.webhook, opts.webhookTemplate,
+			opts.webhookSecret, parseFieldList(opts.webhookEvents))
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.exec != "" {
+		sink, err := NewExecSink(opts.exec, parseFieldList(opts.execEvents),
+			opts.execJobs, opts.execTimeout)
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	if opts.notify {
+		sink, err := NewNotifySink(parseFieldList(opts.notifyEvents))
+		if err != nil {
+			ev.Close()
+			return nil, err
+		}
+		ev.sinks = append(ev.sinks, sink)
+	}
+	ev.stop = bus.Subscribe(ev.deliver, MsgResultReady, MsgAlert)
+	return ev, nil
+}
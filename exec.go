@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	execBacklog = 100
+	// Default of -exec-jobs and -exec-timeout
+	defaultExecJobs    = 4
+	defaultExecTimeout = 10 * time.Second
+)
+
+// Placeholders of the arguments of -exec: {ip}, {country}, {asn.name}...
+var execPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+/*
+ExecSink - Runs a command for each event. The command is split into a
+program and its arguments by splitCommand, quoted as in a shell but run
+without one, and the placeholders of
+each argument are replaced by the fields of the event (type, time, ip,
+previous, rule, reason, country, lat, lon) or else of the lookup result
+({asn.name}), empty when missing. The event is written as JSON to the
+standard input of the command, whose output is discarded. At most jobs
+commands run at once, each killed after timeout.
+*/
+type ExecSink struct {
+	argv    []string
+	types   map[string]bool
+	timeout time.Duration
+	backlog chan Event
+	wg      sync.WaitGroup
+}
+
+/*
+NewExecSink - Create a sink running command for the event types listed in
+types, all of them if types is empty
+*/
+func NewExecSink(command string, types []string, jobs int, timeout time.Duration) (*ExecSink, error) {
+	argv, err := splitCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid exec command: %s", err)
+	}
+	if jobs < 1 {
+		return nil, fmt.Errorf("Invalid exec jobs %d: Expected at least 1.", jobs)
+	}
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
+
+	s := &ExecSink{
+		argv:    argv,
+		timeout: timeout,
+		backlog: make(chan Event, execBacklog),
+	}
+	if len(types) > 0 {
+		s.types = make(map[string]bool)
+		for _, typ := range types {
+			s.types[typ] = true
+		}
+	}
+	for i := 0; i < jobs; i++ {
+		s.wg.Add(1)
+		go s.runLoop()
+	}
+	return s, nil
+}
+
+/*
+Publish - Queue the command of e
+*/
+func (s *ExecSink) Publish(e Event) error {
+	if s.types != nil && !s.types[e.Type] {
+		return nil
+	}
+	select {
+	case s.backlog <- e:
+		return nil
+	default:
+		return fmt.Errorf("Exec backlog full, dropping %s event", e.Type)
+	}
+}
+
+/*
+expand - The arguments of the command for e, placeholders replaced
+*/
+func (s *ExecSink) expand(e Event) ([]string, error) {
+	fields, err := eventFields(e, nil)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(s.argv))
+	for i, arg := range s.argv {
+		args[i] = execPlaceholder.ReplaceAllStringFunc(arg, func(p string) string {
+			name := p[1 : len(p)-1]
+			if name != "result" {
+				if val, ok := fields[name]; ok {
+					return toString(val)
+				}
+			}
+			return toString(lookupPath(e.Result, name))
+		})
+	}
+	return args, nil
+}
+
+/*
+run - Run the command of e once
+*/
+func (s *ExecSink) run(e Event) error {
+	args, err := s.expand(e)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s timed out after %s", args[0], s.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %s", args[0], err)
+	}
+	return nil
+}
+
+func (s *ExecSink) runLoop() {
+	defer s.wg.Done()
+	for e := range s.backlog {
+		if err := s.run(e); err != nil {
+			warnf("Exec for %s event failed: %s", e.Type, err)
+		}
+	}
+}
+
+/*
+Close - Wait for the queued commands to be run
+*/
+func (s *ExecSink) Close() error {
+	close(s.backlog)
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+/*
+Exit codes, for scripts to tell failures apart. These are part of the
+command line interface: never renumber them, only add new ones.
+*/
+const (
+	exitOK           = 0
+	exitFailure      = 1 // any other error
+	exitInvalidInput = 2 // bad arguments, flags or targets (as flag.ExitOnError)
+	exitLookupFailed = 3 // the provider could not be reached or answered an error
+	exitRateLimited  = 4 // the provider quota is exhausted, see -max-wait
+	exitPolicy       = 5 // the result was dropped by the -script
+)
+
+/*
+exitCodeHelp - Description of the exit codes for usage messages
+*/
+const exitCodeHelp = `Exit codes:
+  0  success
+  1  any other error
+  2  invalid input: arguments, flags or targets
+  3  lookup failure
+  4  rate-limited: the provider quota is exhausted (see -max-wait)
+  5  policy violation: the result was dropped by the -script
+`
+
+/*
+ExitError - An error carrying the exit code it should end the program with
+*/
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+/*
+withExitCode - err, ending the program with code. nil stays nil.
+*/
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*ExitError); ok && e.Code != exitFailure {
+		// Keep the most specific code
+		return e
+	}
+	return &ExitError{code, err}
+}
+
+/*
+invalidInput - An error about the command line, ending the program with
+exitInvalidInput
+*/
+func invalidInput(format string, args ...interface{}) error {
+	return &ExitError{exitInvalidInput, fmt.Errorf(tr(format), args...)}
+}
+
+/*
+exitCode - The exit code of err
+*/
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if e, ok := err.(*ExitError); ok {
+		return e.Code
+	}
+	return exitFailure
+}
+
+/*
+exit - Log err and end the program with its exit code
+*/
+func exit(err error) {
+	log.Print(err)
+	telemetry.Shutdown()
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+/*
+The multi-IP modes export the targets shown on the map to a file:
+
+	E      open the export prompt: format and file
+	enter  write the file, once confirmed if it exists
+	esc    close the prompt
+
+The prompt takes "format file", the formats being
+	ips       the addresses, one per line
+	cidr      the fewest prefixes covering them (see prefixes.go)
+	fail2ban  fail2ban-client commands banning them, in the jail given as a
+	          third word (sshd by default)
+	stix      a STIX 2.1 bundle of indicators (see indicators.go)
+	misp      ip-dst attributes in the CSV format of MISP
+*/
+
+var exportFormats = []string{"ips", "cidr", "fail2ban", "stix", "misp"}
+
+const defaultExport = "cidr ip411-export.txt"
+
+/*
+exportList - The shown targets in the given format. Must be called with
+b.mu held.
+*/
+func (b *Batch) exportList(format, jail string) (string, int, error) {
+	var buf bytes.Buffer
+	n := 0
+	switch format {
+	case "ips":
+		for _, target := range b.shown() {
+			fmt.Fprintln(&buf, target)
+			n++
+		}
+	case "cidr":
+		prefixes, desc := b.shownPrefixes()
+		fmt.Fprintf(&buf, "# %s\n", desc)
+		for _, prefix := range prefixes {
+			fmt.Fprintln(&buf, prefix)
+		}
+		n = len(prefixes)
+	case "fail2ban":
+		targets := b.shown()
+		fmt.Fprintf(&buf, "#!/bin/sh\n# %s\n\n", b.shownDescription(len(targets)))
+		for _, target := range targets {
+			fmt.Fprintf(&buf, "fail2ban-client set %s banip %s\n", jail, target)
+		}
+		n = len(targets)
+	case "stix":
+		return b.exportSTIX()
+	case "misp":
+		return b.exportMISP()
+	default:
+		return "", 0, fmt.Errorf("Unknown export format %q: Expected one of %s", format,
+			strings.Join(exportFormats, ", "))
+	}
+	return buf.String(), n, nil
+}
+
+func (b *Batch) openExport(g *Gui, v *View) error {
+	return inputDialog(g, "Export: "+strings.Join(exportFormats, "|")+
+		" file [jail] (enter, esc)", defaultExport, checkExport, b.confirmExport)
+}
+
+func checkExport(text string) error {
+	if args := strings.Fields(text); len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf(tr("Expected a format and a file, e.g. %s"), defaultExport)
+	}
+	return nil
+}
+
+/*
+confirmExport - Export as the text of the prompt says, once the user agrees
+to overwrite the file if it exists
+*/
+func (b *Batch) confirmExport(g *Gui, text string) error {
+	args := strings.Fields(text)
+	write := func(g *Gui) error {
+		return b.writeExport(g, args)
+	}
+	if _, err := os.Stat(args[1]); err == nil {
+		return confirmDialog(g, write, "Overwrite %s?", args[1])
+	}
+	return write(g)
+}
+
+func (b *Batch) writeExport(g *Gui, args []string) error {
+	jail := "sshd"
+	if len(args) == 3 {
+		jail = args[2]
+	}
+
+	b.mu.Lock()
+	data, n, err := b.exportList(args[0], jail)
+	b.mu.Unlock()
+
+	if err != nil {
+		guiShowStatus(g, "%s", err)
+		return nil
+	}
+	if n == 0 {
+		guiShowStatus(g, "No target shown, nothing written")
+		return nil
+	}
+	if err := ioutil.WriteFile(args[1], []byte(data), 0644); err != nil {
+		guiShowStatus(g, "Could not export: %s", err)
+		return nil
+	}
+	guiShowStatus(g, "%d lines written to %s", n, args[1])
+	return nil
+}
+
+/*
+setExportKeybindings - Register the key of the export prompt
+*/
+func (b *Batch) setExportKeybindings(g *Gui) error {
+	return g.SetKeybinding("", 'E', ModNone, b.openExport)
+}
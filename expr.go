@@ -0,0 +1,774 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Expressions are a small language shared by filters and alert rules (scripts
+are Starlark, see script.go). They are evaluated against the fields of a
+result:
+
+	country == "RU" && org =~ "^AS13335 "
+	city in ["Vienna", "Graz"] || !has(postal)
+	lower(hostname) + "." + region
+
+Operators, loosest binding first: || (or), && (and), ! (not), comparisons
+(== != < <= > >= =~ !~ in), + -, * /, unary -. Field names may be dotted
+paths into nested objects. Missing fields evaluate to nil.
+*/
+
+/*
+Env - Resolves field names while evaluating an expression
+*/
+type Env func(name string) interface{}
+
+/*
+Expr - A parsed expression
+*/
+type Expr struct {
+	source string
+	root   exprNode
+}
+
+type exprNode interface {
+	eval(env Env) (interface{}, error)
+}
+
+/*
+ParseExpr - Parse an expression
+*/
+func ParseExpr(source string) (*Expr, error) {
+	p, err := newExprParser(source)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected '%s'", p.peek().text)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+/*
+Eval - Evaluate the expression
+*/
+func (e *Expr) Eval(env Env) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+/*
+Match - Evaluate the expression as a condition
+*/
+func (e *Expr) Match(env Env) (bool, error) {
+	val, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(val), nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+/*
+ResultEnv - Env resolving names to the fields of an IPInfoResult, following
+dotted paths into nested objects
+*/
+func ResultEnv(res IPInfoResult) Env {
+	return func(name string) interface{} {
+		return lookupPath(map[string]interface{}(res), name)
+	}
+}
+
+/*
+lookupPath - Value at a dotted path ("asn.name") in a JSON object, nil if any
+part of the path is missing
+*/
+func lookupPath(obj map[string]interface{}, path string) interface{} {
+	var cur interface{} = obj
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// Tokens
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	pos  int
+}
+
+var exprOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||",
+	"<", ">", "!", "(", ")", "[", "]", ",", "+", "-", "*", "/", "="}
+
+func tokenizeExpr(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+	for i < len(source) {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(source) && source[i] != c {
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+					switch source[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(source[i])
+					}
+				} else {
+					sb.WriteByte(source[i])
+				}
+				i++
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("Unterminated string at %d in '%s'", start, source)
+			}
+			i++
+			tokens = append(tokens, exprToken{tokString, sb.String(), start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{tokNumber, source[start:i], start})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(source) && (isWordChar(source[i]) || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{tokIdent, source[start:i], start})
+		default:
+			matched := false
+			for _, op := range exprOps {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, exprToken{tokOp, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("Unexpected '%c' at %d in '%s'", c, i, source)
+			}
+		}
+	}
+	return append(tokens, exprToken{tokEOF, "end of expression", len(source)}), nil
+}
+
+// Parser
+
+type exprParser struct {
+	source string
+	tokens []exprToken
+	pos    int
+}
+
+func newExprParser(source string) (*exprParser, error) {
+	tokens, err := tokenizeExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	return &exprParser{source: source, tokens: tokens}, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) done() bool {
+	return p.peek().kind == tokEOF
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords
+func (p *exprParser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		return p.errorf("expected '%s', found '%s'", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Invalid expression '%s' at %d: %s", p.source, p.peek().pos,
+		fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	return p.parseOr()
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{or: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "=~", "!~", "in")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if op == "=~" || op == "!~" {
+		node := matchNode{negate: op == "!~", left: left, right: right}
+		if lit, ok := right.(literalNode); ok {
+			pattern, ok := lit.val.(string)
+			if !ok {
+				return nil, p.errorf("regular expression must be a string")
+			}
+			node.re, err = regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+	return compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseAdd() (exprNode, error) {
+	left, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		left = arithNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMul() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return arithNode{op: "-", left: literalNode{float64(0)}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return literalNode{t.text}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", t.text)
+		}
+		return literalNode{f}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "nil", "null":
+			return literalNode{nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t.text)
+		}
+		return fieldNode{t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			node, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			var items []exprNode
+			if _, ok := p.accept("]"); ok {
+				return listNode{items}, nil
+			}
+			for {
+				item, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if _, ok := p.accept("]"); ok {
+					return listNode{items}, nil
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if t.kind != tokEOF {
+		p.pos--
+	}
+	return nil, p.errorf("unexpected '%s'", t.text)
+}
+
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return nil, p.errorf("unknown function '%s'", name)
+	}
+	var args []exprNode
+	if _, ok := p.accept(")"); !ok {
+		for {
+			// has() takes a field name, not its value
+			if name == "has" && p.peek().kind == tokIdent {
+				args = append(args, literalNode{p.next().text})
+			} else {
+				arg, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			if _, ok := p.accept(")"); ok {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return callNode{name: name, fn: fn, args: args}, nil
+}
+
+// Evaluation
+
+type literalNode struct{ val interface{} }
+
+func (n literalNode) eval(env Env) (interface{}, error) { return n.val, nil }
+
+type fieldNode struct{ name string }
+
+func (n fieldNode) eval(env Env) (interface{}, error) { return env(n.name), nil }
+
+type listNode struct{ items []exprNode }
+
+func (n listNode) eval(env Env) (interface{}, error) {
+	vals := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		val, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+type logicNode struct {
+	or          bool
+	left, right exprNode
+}
+
+func (n logicNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(left) == n.or {
+		return n.or, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+type notNode struct{ operand exprNode }
+
+func (n notNode) eval(env Env) (interface{}, error) {
+	val, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(val), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n compareNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "in":
+		switch r := right.(type) {
+		case []interface{}:
+			for _, item := range r {
+				if valuesEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			return strings.Contains(r, toString(left)), nil
+		}
+		return false, nil
+	}
+
+	if lf, lok := toNumber(left); lok {
+		if rf, rok := toNumber(right); rok {
+			return compareOrdered(n.op, lf < rf, lf == rf), nil
+		}
+	}
+	if left == nil || right == nil {
+		return false, nil
+	}
+	ls, rs := toString(left), toString(right)
+	return compareOrdered(n.op, ls < rs, ls == rs), nil
+}
+
+func compareOrdered(op string, less, equal bool) bool {
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	case ">=":
+		return !less
+	}
+	return false
+}
+
+type matchNode struct {
+	negate      bool
+	left, right exprNode
+	re          *regexp.Regexp
+}
+
+func (n matchNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	re := n.re
+	if re == nil {
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		re, err = regexp.Compile(toString(right))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if left == nil {
+		return n.negate, nil
+	}
+	return re.MatchString(toString(left)) != n.negate, nil
+}
+
+type arithNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n arithNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	lf, lok := left.(float64)
+	rf, rok := right.(float64)
+	if n.op == "+" && !(lok && rok) {
+		return toString(left) + toString(right), nil
+	}
+	if !lok {
+		lf, lok = toNumber(left)
+	}
+	if !rok {
+		rf, rok = toNumber(right)
+	}
+	if !lok || !rok {
+		return nil, fmt.Errorf("Cannot apply '%s' to %s and %s", n.op,
+			toString(left), toString(right))
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, fmt.Errorf("Division by zero")
+	}
+	return lf / rf, nil
+}
+
+type callNode struct {
+	name string
+	fn   exprFunc
+	args []exprNode
+}
+
+func (n callNode) eval(env Env) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		val, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = val
+	}
+	if n.name == "has" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Expected 1 argument, got %d", len(args))
+		}
+		return env(toString(args[0])) != nil, nil
+	}
+	return n.fn(args)
+}
+
+type exprFunc func(args []interface{}) (interface{}, error)
+
+func stringFunc(f func(string) interface{}) exprFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Expected 1 argument, got %d", len(args))
+		}
+		return f(toString(args[0])), nil
+	}
+}
+
+func stringPairFunc(f func(a, b string) bool) exprFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("Expected 2 arguments, got %d", len(args))
+		}
+		return f(toString(args[0]), toString(args[1])), nil
+	}
+}
+
+// has() is evaluated by callNode itself since it needs the Env
+var exprFuncs = map[string]exprFunc{
+	"has":        nil,
+	"lower":      stringFunc(func(s string) interface{} { return strings.ToLower(s) }),
+	"upper":      stringFunc(func(s string) interface{} { return strings.ToUpper(s) }),
+	"trim":       stringFunc(func(s string) interface{} { return strings.TrimSpace(s) }),
+	"len":        stringFunc(func(s string) interface{} { return float64(len(s)) }),
+	"string":     stringFunc(func(s string) interface{} { return s }),
+	"contains":   stringPairFunc(strings.Contains),
+	"startswith": stringPairFunc(strings.HasPrefix),
+	"endswith":   stringPairFunc(strings.HasSuffix),
+	"number": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Expected 1 argument, got %d", len(args))
+		}
+		if f, ok := toNumber(args[0]); ok {
+			return f, nil
+		}
+		return nil, nil
+	},
+}
+
+// Values
+
+func truthy(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+func toNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		af, aok := toNumber(a)
+		bf, bok := toNumber(b)
+		return aok && bok && af == bf
+	}
+	return toString(a) == toString(b)
+}
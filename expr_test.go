@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func exprResult(t *testing.T) IPInfoResult {
+	var res IPInfoResult
+	err := json.Unmarshal([]byte(`{"ip": "1.1.1.1", "country": "AT", "city": "Vienna",
+		"org": "AS13335 Cloudflare, Inc.", "asn": {"asn": "AS13335", "name": "Cloudflare"},
+		"score": 42, "compliance": ["eu", "eea"], "hostname": "ONE.one.one.one"}`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestExprEval(t *testing.T) {
+	res := exprResult(t)
+	for _, c := range []struct {
+		source string
+		want   interface{}
+	}{
+		{`country`, "AT"},
+		{`asn.name`, "Cloudflare"},
+		{`asn.missing.deeper`, nil},
+		{`postal`, nil},
+		{`score + 8`, 50.0},
+		{`score - 2 * 10 / 4`, 37.0},
+		{`-score`, -42.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`city + ", " + country`, "Vienna, AT"},
+		{`"n" + 1`, "n1"},
+		{`lower(hostname) + "." + country`, "one.one.one.one.AT"},
+		{`upper(trim(" at "))`, "AT"},
+		{`len(city)`, 6.0},
+		{`number("3.5") * 2`, 7.0},
+		{`number("x")`, nil},
+		{`'it\'s' + "\t"`, "it's\t"},
+		{`[1, "a"]`, []interface{}{1.0, "a"}},
+	} {
+		e, err := ParseExpr(c.source)
+		if err != nil {
+			t.Errorf("ParseExpr(%q): %s", c.source, err)
+			continue
+		}
+		got, err := e.Eval(ResultEnv(res))
+		if err != nil {
+			t.Errorf("%q: %s", c.source, err)
+			continue
+		}
+		if toString(got) != toString(c.want) || (got == nil) != (c.want == nil) {
+			t.Errorf("%q = %#v, expected %#v", c.source, got, c.want)
+		}
+	}
+}
+
+func TestExprMatch(t *testing.T) {
+	res := exprResult(t)
+	for _, c := range []struct {
+		source string
+		want   bool
+	}{
+		{`country == "AT"`, true},
+		{`country != "AT"`, false},
+		{`country == "RU" || city == "Vienna"`, true},
+		{`country == "AT" && org =~ "^AS13335 "`, true},
+		{`org !~ "^AS13335 "`, false},
+		{`postal =~ "."`, false},
+		{`postal !~ "."`, true},
+		{`!has(postal) && has(asn.name)`, true},
+		{`city in ["Vienna", "Graz"]`, true},
+		{`"eea" in compliance || "adequacy" in compliance`, true},
+		{`"Cloud" in org`, true},
+		{`"uk" in compliance`, false},
+		{`score > 40 && score <= 42 && score >= 42 && score < 43`, true},
+		{`score == "42"`, true},
+		{`city < "Zurich"`, true},
+		{`postal < "a"`, false},
+		{`postal == nil && null == nil`, true},
+		{`!(country == "AT")`, false},
+		{`true && !false`, true},
+		{`startswith(hostname, "ONE") && endswith(hostname, ".one")`, true},
+		{`contains(lower(org), "cloudflare")`, true},
+		{`score`, true},
+		{`score - 42`, false},
+		{`""`, false},
+		{`[]`, false},
+		{`compliance`, true},
+		// The right side is not evaluated once the left one decides
+		{`country == "AT" || 1 / 0`, true},
+		{`country == "RU" && 1 / 0`, false},
+	} {
+		e, err := ParseExpr(c.source)
+		if err != nil {
+			t.Errorf("ParseExpr(%q): %s", c.source, err)
+			continue
+		}
+		if e.String() != c.source {
+			t.Errorf("String() = %q, expected %q", e.String(), c.source)
+		}
+		got, err := e.Match(ResultEnv(res))
+		if err != nil {
+			t.Errorf("%q: %s", c.source, err)
+		} else if got != c.want {
+			t.Errorf("%q = %v, expected %v", c.source, got, c.want)
+		}
+	}
+}
+
+func TestExprParseErrors(t *testing.T) {
+	for _, source := range []string{
+		``,
+		`country ==`,
+		`country == "AT`,
+		`(country == "AT"`,
+		`[1, 2`,
+		`country "AT"`,
+		`unknown(country)`,
+		`lower(country`,
+		`country # "AT"`,
+		`1.2.3 == 1`,
+		`country = "AT"`,
+		`)`,
+	} {
+		if e, err := ParseExpr(source); err == nil {
+			t.Errorf("ParseExpr(%q) = %v, expected an error", source, e)
+		}
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	res := exprResult(t)
+	for _, source := range []string{
+		`score / 0`,
+		`city * 2`,
+		`city =~ "("`,
+		`city =~ country + "("`,
+		`lower(city, country)`,
+		`contains(city)`,
+		`has()`,
+		`number()`,
+	} {
+		e, err := ParseExpr(source)
+		if err != nil {
+			// Constant patterns are compiled when parsed
+			continue
+		}
+		if val, err := e.Eval(ResultEnv(res)); err == nil {
+			t.Errorf("%q = %#v, expected an error", source, val)
+		}
+	}
+}
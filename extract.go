@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Field extraction prints chosen fields of a lookup instead of showing the map,
+for shell scripts:
+
+	ip411 -field country -field org 8.8.8.8
+
+Fields are addressed by dotted paths, as in the info pane (bgp.prefix).
+Missing fields print as empty values so that every value keeps its place.
+*/
+
+/*
+extractOptions - Command line flags selecting the fields to print
+*/
+type extractOptions struct {
+	fields stringList
+	tabs   bool
+}
+
+func addExtractFlags(flags *flag.FlagSet) *extractOptions {
+	opts := &extractOptions{}
+	flags.Var(&opts.fields, "field",
+		"Print this field of the result instead of showing the map (repeatable)")
+	flags.BoolVar(&opts.tabs, "tabs", false,
+		"Print the -field values on one tab separated line")
+	return opts
+}
+
+/*
+enabled - Whether fields were selected
+*/
+func (opts *extractOptions) enabled() bool {
+	return len(opts.fields) > 0
+}
+
+/*
+write - Print the selected fields of res, one per line or tab separated
+*/
+func (opts *extractOptions) write(w io.Writer, res IPInfoResult) error {
+	// Values are kept on one line so that the output can be split reliably
+	oneLine := strings.NewReplacer("\t", " ", "\n", " ")
+	values := make([]string, len(opts.fields))
+	for i, field := range opts.fields {
+		values[i] = oneLine.Replace(fieldValue(res, field))
+	}
+	sep := "\n"
+	if opts.tabs {
+		sep = "\t"
+	}
+	_, err := fmt.Fprintln(w, strings.Join(values, sep))
+	return err
+}
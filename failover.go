@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Several providers can be chained, as in -provider ipinfo,ip-api or
+"provider": "ipinfo,ip-api" in the config file. Lookups go to the first
+provider and fail over to the next when it cannot be reached or is out of
+quota. With -round-robin, lookups start with each provider in turn instead,
+spreading a batch over their free tiers.
+
+A provider failing repeatedly is skipped for a while by its circuit breaker,
+so that a dead API does not slow every lookup down.
+*/
+
+const (
+	// Consecutive failures opening the breaker of a provider
+	breakerThreshold = 3
+	// How long an open breaker skips its provider
+	breakerCooldown = time.Minute
+)
+
+/*
+Breaker - Circuit breaker of a provider
+*/
+type Breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+/*
+Allow - Whether the provider can be tried: the breaker is closed, or its
+cooldown is over and one trial lookup goes through
+*/
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.openUntil.After(now) {
+		return false
+	}
+	if b.failures >= breakerThreshold {
+		// Half-open: let this lookup through, and hold the others back
+		// until it is known to have succeeded
+		b.openUntil = now.Add(breakerCooldown)
+	}
+	return true
+}
+
+/*
+Success - Record a lookup that succeeded, closing the breaker
+*/
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.mu.Unlock()
+}
+
+/*
+Failure - Record a lookup that failed, opening the breaker after too many
+*/
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+	}
+	b.mu.Unlock()
+}
+
+/*
+Open - Whether the breaker currently skips its provider
+*/
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.openUntil.After(time.Now())
+}
+
+/*
+ProviderChain - Providers lookups fail over between, in order
+*/
+type ProviderChain struct {
+	Providers  []*Provider
+	RoundRobin bool
+
+	next uint32
+}
+
+/*
+chain - The providers lookups are made with, set by -provider or the
+provider of the config file
+*/
+var chain = &ProviderChain{Providers: providers[:1]}
+
+/*
+parseProviderChain - The chain of a comma separated list of providers
+*/
+func parseProviderChain(list string) ([]*Provider, error) {
+	var chained []*Provider
+	for _, name := range parseFieldList(list) {
+		p, err := findProvider(name)
+		if err != nil {
+			return nil, err
+		}
+		chained = append(chained, p)
+	}
+	if len(chained) == 0 {
+		return nil, invalidInput("Specify at least one provider.")
+	}
+	return chained, nil
+}
+
+/*
+includes - Whether p is part of the chain
+*/
+func (c *ProviderChain) includes(p *Provider) bool {
+	for _, q := range c.Providers {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Names - The providers of the chain, comma separated
+*/
+func (c *ProviderChain) Names() string {
+	names := make([]string, len(c.Providers))
+	for i, p := range c.Providers {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ",")
+}
+
+/*
+Lookup - Look ip up with the first provider of the chain that answers.
+Providers out of quota are passed over; if no other provider answers,
+the lookup waits for the first of them.
+*/
+func (c *ProviderChain) Lookup(ip net.IP) (IPInfoResult, time.Duration, error) {
+	if len(c.Providers) == 1 {
+		return c.Providers[0].Lookup(ip)
+	}
+	start := 0
+	if c.RoundRobin {
+		start = int((atomic.AddUint32(&c.next, 1) - 1) % uint32(len(c.Providers)))
+	}
+
+	var errs []string
+	var limited *Provider
+	for i := range c.Providers {
+		p := c.Providers[(start+i)%len(c.Providers)]
+		if !p.breaker.Allow() {
+			errs = append(errs, p.Name+": skipped after repeated failures")
+			continue
+		}
+		res, rtt, err := p.lookup(ip, false)
+		if err == nil {
+			p.breaker.Success()
+			return res, rtt, nil
+		}
+		if exitCode(err) == exitRateLimited {
+			// Not a failure of the provider, it is back once the quota resets
+			if limited == nil {
+				limited = p
+			}
+		} else {
+			p.breaker.Failure()
+		}
+		errs = append(errs, err.Error())
+	}
+
+	if limited != nil {
+		res, rtt, err := limited.Lookup(ip)
+		if err == nil {
+			return res, rtt, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, 0, withExitCode(exitLookupFailed,
+		fmt.Errorf("Every provider failed: %s", strings.Join(errs, "; ")))
+}
+
+/*
+QuotaString - Quota of the providers for the status bar
+*/
+func (c *ProviderChain) QuotaString() string {
+	if len(c.Providers) == 1 {
+		return c.Providers[0].quota.String()
+	}
+	parts := make([]string, len(c.Providers))
+	for i, p := range c.Providers {
+		parts[i] = p.Name + " " + p.quota.String()
+		if p.breaker.Open() {
+			parts[i] += " (down)"
+		}
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+The filter of the multi-IP modes (batch, logs, monitor) hides the results
+that do not match an expression (see expr.go) from the map, the statistics
+panel and the counts of the info pane:
+
+	f      edit the filter
+	enter  apply it, an empty filter shows every result
+	esc    close the prompt, keeping the current filter
+
+Besides the fields of the result, asn is the AS number taken from org when
+the result has no asn field of its own:
+
+	country == "RU" && asn != 13335
+*/
+
+/*
+FilterEnv - Env of filter expressions over res
+*/
+func FilterEnv(res IPInfoResult) Env {
+	env := ResultEnv(res)
+	return func(name string) interface{} {
+		val := env(name)
+		if val == nil && name == "asn" {
+			asn := asnFromOrg(fieldValue(res, "org"))
+			if n, err := strconv.Atoi(strings.TrimPrefix(asn, "AS")); err == nil {
+				return float64(n)
+			}
+		}
+		return val
+	}
+}
+
+/*
+matchFilter - Whether res passes the filter of the batch. Must be called with
+b.mu held.
+*/
+func (b *Batch) matchFilter(res IPInfoResult) bool {
+	if b.filter == nil {
+		return true
+	}
+	ok, err := b.filter.Match(FilterEnv(res))
+	return err == nil && ok
+}
+
+func (b *Batch) openFilter(g *Gui, v *View) error {
+	b.mu.Lock()
+	text := ""
+	if b.filter != nil {
+		text = b.filter.String()
+	}
+	b.mu.Unlock()
+	return inputDialog(g, "Filter (enter apply, esc cancel)", text, checkFilter,
+		func(g *Gui, text string) error {
+			before := currentViewState()
+			err := b.applyFilter(g, text)
+			recordState(before)
+			return err
+		})
+}
+
+func checkFilter(text string) error {
+	if text == "" {
+		return nil
+	}
+	_, err := ParseExpr(text)
+	return err
+}
+
+func (b *Batch) applyFilter(g *Gui, text string) error {
+	var filter *Expr
+	if text != "" {
+		filter, _ = ParseExpr(text)
+	}
+
+	b.mu.Lock()
+	b.filter = filter
+	b.mu.Unlock()
+
+	b.refresh(g)
+	return nil
+}
+
+/*
+setFilterKeybindings - Register the key of the filter prompt, and the
+filter for undo
+*/
+func (b *Batch) setFilterKeybindings(g *Gui) error {
+	currentFilter = func() string {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.filter == nil {
+			return ""
+		}
+		return b.filter.String()
+	}
+	restoreFilter = b.applyFilter
+	return g.SetKeybinding("", 'f', ModNone, b.openFilter)
+}
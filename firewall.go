@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+)
+
+/*
+The multi-IP modes turn the targets shown on the map (filtered, selected in
+the statistics, in the time window) into firewall rules, written to a file
+for review rather than applied:
+
+	F      open the rules prompt: format, action and file
+	enter  write the rules, once confirmed if the file exists
+	esc    close the prompt
+
+The prompt takes "format action file", e.g. "nftables drop rules.nft". The
+rules cover the BGP prefix of each target when it was looked up with -bgp,
+else the address itself, aggregated (see prefixes.go).
+*/
+
+var firewallFormats = []string{"nftables", "iptables", "ufw", "aws"}
+
+const defaultFirewallRule = "nftables drop ip411-rules.nft"
+
+/*
+splitFamilies - The IPv4 and IPv6 prefixes of prefixes
+*/
+func splitFamilies(prefixes []*net.IPNet) (v4, v6 []string) {
+	for _, prefix := range prefixes {
+		if prefix.IP.To4() != nil {
+			v4 = append(v4, prefix.String())
+		} else {
+			v6 = append(v6, prefix.String())
+		}
+	}
+	return v4, v6
+}
+
+/*
+firewallRules - Rules of the given format applying action (drop or accept)
+to the incoming traffic of prefixes. header describes the selection in a
+comment.
+*/
+func firewallRules(format, action string, prefixes []*net.IPNet, header string) (string, error) {
+	if action != "drop" && action != "accept" {
+		return "", fmt.Errorf("Unknown action %q: Expected drop or accept", action)
+	}
+	v4, v6 := splitFamilies(prefixes)
+	var buf bytes.Buffer
+	switch format {
+	case "nftables":
+		fmt.Fprintf(&buf, "#!/usr/sbin/nft -f\n# %s\n\n", header)
+		fmt.Fprintf(&buf, "table inet ip411 {\n")
+		for _, set := range []struct {
+			name, family string
+			elements     []string
+		}{{"selected4", "ipv4_addr", v4}, {"selected6", "ipv6_addr", v6}} {
+			fmt.Fprintf(&buf, "\tset %s {\n\t\ttype %s\n\t\tflags interval\n", set.name, set.family)
+			if len(set.elements) > 0 {
+				fmt.Fprintf(&buf, "\t\telements = {\n\t\t\t%s\n\t\t}\n",
+					strings.Join(set.elements, ",\n\t\t\t"))
+			}
+			fmt.Fprintf(&buf, "\t}\n\n")
+		}
+		fmt.Fprintf(&buf, "\tchain input {\n\t\ttype filter hook input priority 0; policy accept;\n")
+		fmt.Fprintf(&buf, "\t\tip saddr @selected4 %s\n\t\tip6 saddr @selected6 %s\n\t}\n}\n", action, action)
+	case "iptables":
+		target := map[string]string{"drop": "DROP", "accept": "ACCEPT"}[action]
+		fmt.Fprintf(&buf, "#!/bin/sh\n# %s\nset -e\n\n", header)
+		for _, prefix := range v4 {
+			fmt.Fprintf(&buf, "iptables -I INPUT -s %s -j %s -m comment --comment ip411\n", prefix, target)
+		}
+		for _, prefix := range v6 {
+			fmt.Fprintf(&buf, "ip6tables -I INPUT -s %s -j %s -m comment --comment ip411\n", prefix, target)
+		}
+	case "ufw":
+		verb := map[string]string{"drop": "deny", "accept": "allow"}[action]
+		fmt.Fprintf(&buf, "#!/bin/sh\n# %s\nset -e\n\n", header)
+		for _, prefix := range append(v4, v6...) {
+			fmt.Fprintf(&buf, "ufw insert 1 %s from %s comment ip411\n", verb, prefix)
+		}
+	case "aws":
+		// Security groups only allow, whatever is not allowed is dropped
+		if action != "accept" {
+			return "", fmt.Errorf("AWS security groups only allow traffic: Use the accept action")
+		}
+		type ipRange struct {
+			CidrIP      string `json:"CidrIp"`
+			Description string `json:"Description"`
+		}
+		type ipv6Range struct {
+			CidrIPv6    string `json:"CidrIpv6"`
+			Description string `json:"Description"`
+		}
+		permission := struct {
+			IPProtocol string      `json:"IpProtocol"`
+			IPRanges   []ipRange   `json:"IpRanges"`
+			IPv6Ranges []ipv6Range `json:"Ipv6Ranges"`
+		}{IPProtocol: "-1", IPRanges: []ipRange{}, IPv6Ranges: []ipv6Range{}}
+		for _, prefix := range v4 {
+			permission.IPRanges = append(permission.IPRanges, ipRange{prefix, "ip411"})
+		}
+		for _, prefix := range v6 {
+			permission.IPv6Ranges = append(permission.IPv6Ranges, ipv6Range{prefix, "ip411"})
+		}
+		// For aws ec2 authorize-security-group-ingress --ip-permissions file://...
+		data, err := json.MarshalIndent([]interface{}{permission}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		buf.Write(data)
+		buf.WriteString("\n")
+	default:
+		return "", fmt.Errorf("Unknown firewall format %q: Expected one of %s", format,
+			strings.Join(firewallFormats, ", "))
+	}
+	return buf.String(), nil
+}
+
+func (b *Batch) openFirewall(g *Gui, v *View) error {
+	return inputDialog(g, "Rules: "+strings.Join(firewallFormats, "|")+
+		" drop|accept file (enter, esc)", defaultFirewallRule, checkFirewall, b.confirmFirewall)
+}
+
+func checkFirewall(text string) error {
+	if len(strings.Fields(text)) != 3 {
+		return fmt.Errorf(tr("Expected a format, an action and a file, e.g. %s"), defaultFirewallRule)
+	}
+	return nil
+}
+
+/*
+confirmFirewall - Write the rules the text of the prompt asks for, once the
+user agrees to overwrite the file if it exists
+*/
+func (b *Batch) confirmFirewall(g *Gui, text string) error {
+	args := strings.Fields(text)
+	write := func(g *Gui) error {
+		return b.writeFirewall(g, args)
+	}
+	if _, err := os.Stat(args[2]); err == nil {
+		return confirmDialog(g, write, "Overwrite %s?", args[2])
+	}
+	return write(g)
+}
+
+func (b *Batch) writeFirewall(g *Gui, args []string) error {
+	b.mu.Lock()
+	prefixes, desc := b.shownPrefixes()
+	b.mu.Unlock()
+
+	if len(prefixes) == 0 {
+		guiShowStatus(g, "No target shown, nothing written")
+		return nil
+	}
+	rules, err := firewallRules(args[0], args[1], prefixes, desc)
+	if err != nil {
+		guiShowStatus(g, "%s", err)
+		return nil
+	}
+	if err := ioutil.WriteFile(args[2], []byte(rules), 0644); err != nil {
+		guiShowStatus(g, "Could not write rules: %s", err)
+		return nil
+	}
+	guiShowStatus(g, "%s rules for %d prefixes written to %s, review before applying",
+		args[0], len(prefixes), args[2])
+	return nil
+}
+
+/*
+setFirewallKeybindings - Register the key of the rules prompt
+*/
+func (b *Batch) setFirewallKeybindings(g *Gui) error {
+	return g.SetKeybinding("", 'F', ModNone, b.openFirewall)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Offline lookups, for demos and deterministic runs of the interface and the
+batch modes:
+
+	-provider mock   makes up a stable result for every address, from the
+	                 cities of the gazetteer, without any request
+	-record dir      saves every provider response as dir/<provider>/<ip>.json
+	-replay dir      answers from those files instead of the provider
+
+A replayed lookup without a recorded response fails like an unreachable
+provider would.
+*/
+
+var (
+	recordDir string
+	replayDir string
+)
+
+/*
+fixturePath - File of the recorded response of p for ip
+*/
+func fixturePath(dir string, p *Provider, ip net.IP) string {
+	name := "self"
+	if ip != nil {
+		// IPv6 colons are not allowed in Windows file names
+		name = strings.Replace(ip.String(), ":", "_", -1)
+	}
+	return filepath.Join(dir, p.Name, name+".json")
+}
+
+func recordResponse(p *Provider, ip net.IP, body []byte) error {
+	path := fixturePath(recordDir, p, ip)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+func replayResponse(p *Provider, ip net.IP) ([]byte, error) {
+	body, err := ioutil.ReadFile(fixturePath(replayDir, p, ip))
+	if os.IsNotExist(err) {
+		return nil, withExitCode(exitLookupFailed,
+			fmt.Errorf("No recorded %s response for %s", p.Name, ipOrSelf(ip)))
+	}
+	return body, err
+}
+
+func ipOrSelf(ip net.IP) string {
+	if ip == nil {
+		return "the client"
+	}
+	return ip.String()
+}
+
+/*
+mockAnswer - A made-up result for ip, always the same for the same address:
+a city of the gazetteer and a private AS number
+*/
+func mockAnswer(ip net.IP) map[string]interface{} {
+	if ip == nil {
+		ip = net.ParseIP("192.0.2.1")
+	}
+	h := fnv.New32a()
+	h.Write(ip)
+	sum := h.Sum32()
+
+	city := cities[sum%uint32(len(cities))]
+	// Spread addresses of the same city over a few km
+	lat := city.Lat + float64(sum>>8%100)/1000 - 0.05
+	lon := city.Lon + float64(sum>>16%100)/1000 - 0.05
+	asn := 64512 + sum%1000
+	return map[string]interface{}{
+		"ip":       ip.String(),
+		"hostname": fmt.Sprintf("host-%s.mock.invalid", strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())),
+		"city":     city.Name,
+		"country":  city.Country,
+		"loc":      fmt.Sprintf("%.4f,%.4f", lat, lon),
+		"org":      fmt.Sprintf("AS%d Mock Network %d", asn, asn-64511),
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+The capture mode counts conversations rather than packets: the packets of
+both directions between two endpoints (protocol, addresses and ports) make
+one conversation until it has been idle for flowTimeout, in the time of the
+capture. A conversation seen again after that is a new one.
+*/
+
+const flowTimeout = 2 * time.Minute
+
+/*
+FlowKey - The endpoints of a conversation, ordered so that both directions
+share the key
+*/
+type FlowKey struct {
+	Protocol uint8
+	A, B     string // IP Addresses
+	APort    uint16
+	BPort    uint16
+}
+
+func newFlowKey(p IPPacket) FlowKey {
+	srcPort, dstPort := p.Ports()
+	k := FlowKey{p.Protocol, p.Src.String(), p.Dst.String(), srcPort, dstPort}
+	if k.B < k.A || (k.A == k.B && k.BPort < k.APort) {
+		k.A, k.B, k.APort, k.BPort = k.B, k.A, k.BPort, k.APort
+	}
+	return k
+}
+
+/*
+Flow - A conversation
+*/
+type Flow struct {
+	Key     FlowKey
+	First   time.Time
+	Last    time.Time
+	Packets int
+	Bytes   int
+
+	elem *list.Element // in the order of the table
+}
+
+/*
+FlowTable - Conversations of a capture, active ones by key
+*/
+type FlowTable struct {
+	mu       sync.Mutex
+	active   map[FlowKey]*Flow
+	order    *list.List // of the active *Flow, the most recently active first
+	max      int        // active conversations, unlimited if 0, see limits.go
+	total    int
+	lastScan time.Time
+}
+
+/*
+NewFlowTable - Create an empty table
+*/
+func NewFlowTable() *FlowTable {
+	return &FlowTable{active: make(map[FlowKey]*Flow), order: list.New()}
+}
+
+/*
+Add - Count a packet of length bytes captured at t in its conversation.
+Returns the conversation and whether the packet started it.
+*/
+func (ft *FlowTable) Add(p IPPacket, length int, t time.Time) (*Flow, bool) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	key := newFlowKey(p)
+	flow, ok := ft.active[key]
+	if ok && t.Sub(flow.Last) > flowTimeout {
+		ft.order.Remove(flow.elem)
+		ok = false
+	}
+	if !ok {
+		flow = &Flow{Key: key, First: t}
+		flow.elem = ft.order.PushFront(flow)
+		ft.active[key] = flow
+		ft.total++
+		// Beyond the cap, the least recently active conversation is
+		// forgotten: seen again, it counts as a new one
+		for ft.max > 0 && len(ft.active) > ft.max {
+			ft.remove(ft.order.Back().Value.(*Flow))
+		}
+	} else {
+		ft.order.MoveToFront(flow.elem)
+	}
+	if t.After(flow.Last) {
+		flow.Last = t
+	}
+	flow.Packets++
+	flow.Bytes += length
+	ft.expire(t)
+	return flow, !ok
+}
+
+/*
+expire - Forget the conversations idle for flowTimeout at t, at most once
+per timeout so that busy captures don't scan the table on every packet
+*/
+func (ft *FlowTable) expire(t time.Time) {
+	if t.Sub(ft.lastScan) < flowTimeout {
+		return
+	}
+	ft.lastScan = t
+	for _, flow := range ft.active {
+		if t.Sub(flow.Last) > flowTimeout {
+			ft.remove(flow)
+		}
+	}
+}
+
+func (ft *FlowTable) remove(flow *Flow) {
+	ft.order.Remove(flow.elem)
+	delete(ft.active, flow.Key)
+}
+
+/*
+Usage - The active conversations and their cap, 0 if unlimited
+*/
+func (ft *FlowTable) Usage() (int, int) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return len(ft.active), ft.max
+}
+
+/*
+String - Line of the info pane counting the conversations
+*/
+func (ft *FlowTable) String() string {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return fmt.Sprintf("Conversations: %d", ft.total)
+}
@@ -0,0 +1,28 @@
+package main
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+/*
+Point - A location in degrees
+*/
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+/*
+distanceKm - Great-circle distance between two points (haversine formula)
+*/
+func distanceKm(a, b Point) float64 {
+	dLat := radians(b.Lat - a.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(a.Lat))*math.Cos(radians(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,23 @@
+module github.com/cruatta/ip411
+
+go 1.24.0
+
+require (
+	github.com/gdamore/tcell/v2 v2.8.1
+	github.com/google/pprof v0.0.0-20260115054156-294ebfa9ad83
+	github.com/mattn/go-runewidth v0.0.16
+	github.com/quic-go/quic-go v0.59.1
+	go.starlark.net v0.0.0-20231121155337-90ade8b19d09
+)
+
+require (
+	github.com/gdamore/encoding v1.0.1 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
+	github.com/rivo/uniseg v0.4.3 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/term v0.34.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+)
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+/*
+The hidden render-fixture mode draws the map of a fixture, a JSON array of
+results, on a canvas of fixed dimensions and prints it, so that changes to
+the rendering can be checked against golden files:
+
+	ip411 render-fixture -width 80 -height 24 fixture.json
+	ip411 render-fixture -golden map.golden fixture.json          exit 1 if it differs
+	ip411 render-fixture -golden map.golden -update fixture.json  rewrite it
+
+A result is drawn as the target of the single mode, with its label. The
+"color" (an ANSI color, 31 red...) and "weight" (0 to 1) of a result, which
+no provider answers, color its marker and draw a disc under it. The output
+depends on nothing but the flags and the fixture: colors and glyphs are
+fixed by -color and -ascii, not detected from the console. Only -region maps
+zoomed into a country draw its subdivisions, from the datasets of db update.
+The fixtures of testdata are checked the same way by TestRenderMap.
+*/
+
+/*
+fixtureMarkers - The markers of the results of a fixture, in their order
+*/
+func fixtureMarkers(results []IPInfoResult) ([]Marker, error) {
+	markers := make([]Marker, 0, len(results))
+	for i, res := range results {
+		lon, lat, err := res.GetLonLat()
+		if err != nil {
+			return nil, fmt.Errorf("Result %d of the fixture: %s", i, err)
+		}
+		m := Marker{Lon: lon, Lat: lat, Text: markerLabel(res),
+			Targets: []string{fieldValue(res, "ip")}}
+		if color, ok := res["color"].(float64); ok {
+			m.Color = int(color)
+		}
+		if weight, ok := res["weight"].(float64); ok {
+			if weight < 0 || weight > 1 {
+				return nil, fmt.Errorf("Result %d of the fixture: Invalid weight %g, expected 0 to 1", i, weight)
+			}
+			m.Weight = weight
+		}
+		markers = append(markers, m)
+	}
+	return markers, nil
+}
+
+/*
+goldenDiff - Where rendered differs from the golden file text, "" if it
+does not
+*/
+func goldenDiff(golden, rendered string) string {
+	want := strings.Split(golden, "\n")
+	got := strings.Split(rendered, "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n-%q\n+%q", i+1, w, g)
+		}
+	}
+	return ""
+}
+
+/*
+renderFixtureHelp - What the usage message of render-fixture says after the synopsis
+*/
+const renderFixtureHelp = `Draw the map of the results of fixture (a JSON array) on a canvas of
+fixed dimensions, or check it against a golden file.
+`
+
+func runRenderFixture(args []string) error {
+	flags := flag.NewFlagSet("render-fixture", flag.ExitOnError)
+	addRegionFlag(flags)
+	width := flags.Int("width", 80, "Columns of the canvas")
+	height := flags.Int("height", 24, "Rows of the canvas")
+	color := flags.Bool("color", false, "Draw the colored markers in ANSI colors")
+	ascii := flags.Bool("ascii", false, "Draw with ascii characters instead of braille")
+	golden := flags.String("golden", "", "Compare the rendering with this file instead of printing it")
+	update := flags.Bool("update", false, "Write the rendering to the -golden file")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s render-fixture [-width n] [-height n] [-color] [-ascii] [-region r] [-golden file [-update]] fixture\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(renderFixtureHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify a fixture.")
+	}
+	if *width < 1 || *height < 1 {
+		return invalidInput("Invalid canvas %dx%d: Expected positive dimensions.", *width, *height)
+	}
+	if *update && *golden == "" {
+		return invalidInput("Invalid -update: Specify the -golden file to write.")
+	}
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var results []IPInfoResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return invalidInput("Invalid fixture %s: %s", flags.Arg(0), err)
+	}
+	markers, err := fixtureMarkers(results)
+	if err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+
+	consoleColors, asciiMap = *color, *ascii
+	rendered, _ := renderMap(*width, *height, markers)
+	rendered += "\n"
+	switch {
+	case *update:
+		return ioutil.WriteFile(*golden, []byte(rendered), 0644)
+	case *golden != "":
+		want, err := ioutil.ReadFile(*golden)
+		if err != nil {
+			return err
+		}
+		if diff := goldenDiff(string(want), rendered); diff != "" {
+			return fmt.Errorf("Rendering differs from %s at %s", *golden, diff)
+		}
+		return nil
+	}
+	_, err = fmt.Print(rendered)
+	return err
+}
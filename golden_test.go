@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files of testdata")
+
+/*
+The map of every case is compared with testdata/<name>.golden, rewritten by
+
+	go test -run TestRenderMap -update
+
+The cases stay under subdivisionZoom and draw no choropleth, which would
+depend on the datasets of db update: the coastlines, cities, cables and backbone are
+compiled in.
+*/
+var renderCases = []struct {
+	name          string
+	fixture       string
+	width, height int
+	color, ascii  bool
+	view          Viewport
+	layers        string // keys of the layers shown
+}{
+	{name: "world", fixture: "world.json", width: 80, height: 24},
+	{name: "world-ascii", fixture: "world.json", width: 60, height: 20, ascii: true},
+	{name: "weights", fixture: "weights.json", width: 80, height: 24, color: true},
+	{name: "europe", fixture: "europe.json", width: 80, height: 24,
+		view: Viewport{CenterLon: 8, CenterLat: 50, Zoom: 3}, layers: "c"},
+	{name: "world-layers", fixture: "world.json", width: 80, height: 24, layers: "cr"},
+}
+
+/*
+resetRenderState - Put back the globals renderMap reads to those of a plain
+run, so that no case depends on the ones before it
+*/
+func resetRenderState() {
+	viewport = Viewport{Zoom: 1}
+	mapRegion = nil
+	worldMapOnce, worldMap = sync.Once{}, nil
+	mapFill = nil
+	crosshair = nil
+	hoveredStack = nil
+	consoleColors, asciiMap = false, false
+	for _, layer := range layers {
+		layer.Enabled = false
+	}
+}
+
+func TestRenderMap(t *testing.T) {
+	defer resetRenderState()
+	for _, c := range renderCases {
+		t.Run(c.name, func(t *testing.T) {
+			resetRenderState()
+			data, err := ioutil.ReadFile(filepath.Join("testdata", c.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var results []IPInfoResult
+			if err := json.Unmarshal(data, &results); err != nil {
+				t.Fatal(err)
+			}
+			markers, err := fixtureMarkers(results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.view.Zoom != 0 {
+				viewport = c.view
+			}
+			for _, layer := range layers {
+				layer.Enabled = strings.ContainsRune(c.layers, layer.Key)
+			}
+			consoleColors, asciiMap = c.color, c.ascii
+
+			rendered, _ := renderMap(c.width, c.height, markers)
+			rendered += "\n"
+			golden := filepath.Join("testdata", c.name+".golden")
+			if *updateGolden {
+				if err := ioutil.WriteFile(golden, []byte(rendered), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := goldenDiff(string(want), rendered); diff != "" {
+				t.Errorf("Rendering differs from %s at %s", golden, diff)
+			}
+		})
+	}
+}
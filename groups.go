@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+/*
+Group - Named set of targets of the config file, plotted together by the
+group mode. Targets are IP Addresses, hostnames or @bookmarks. When Interval
+is set ("10m") the targets of the group are located again that often.
+
+	{"groups": {"prod-eu": {"targets": ["@fra-1", "198.51.100.4"],
+		"color": "red", "interval": "5m"}}}
+*/
+type Group struct {
+	Targets  []string `json:"targets"`
+	Color    string   `json:"color,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+}
+
+var ansiColors = map[string]int{
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+}
+
+var groupPalette = []string{"red", "green", "yellow", "blue", "magenta", "cyan"}
+
+/*
+groupNames - Names of the groups of the config file, sorted
+*/
+func groupNames() []string {
+	names := make([]string, 0, len(config.Groups))
+	for name := range config.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+groupHelp - What the usage message of group says after the synopsis
+*/
+const groupHelp = `Locate and plot the targets of the named groups of the config file,
+or of every group if none is named, each group in its own color.
+`
+
+func runGroup(args []string) error {
+	flags := flag.NewFlagSet("group", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	sinks := addSinkFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s group [sink flags] [name...]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(groupHelp))
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintf(os.Stderr, tr("Groups: %v\n"), groupNames())
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = groupNames()
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("No groups in the config file")
+	}
+
+	intervals := make(map[string]time.Duration)
+	for _, name := range names {
+		group, ok := config.Groups[name]
+		if !ok {
+			return invalidInput("Unknown group '%s'", name)
+		}
+		if group.Color != "" {
+			if _, ok := ansiColors[group.Color]; !ok {
+				return fmt.Errorf("Unknown color '%s' of group '%s'", group.Color, name)
+			}
+		}
+		if group.Interval != "" {
+			interval, err := time.ParseDuration(group.Interval)
+			if err != nil {
+				return fmt.Errorf("Invalid interval of group '%s': %s", name, err)
+			}
+			intervals[name] = interval
+		}
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		return err
+	}
+	b := NewBatch(queue)
+	b.source = "groups"
+	b.pipeline = pipeline
+	b.groupOf = make(map[string]string)
+	b.groupColor = make(map[string]int)
+	for i, name := range names {
+		group := config.Groups[name]
+		color := group.Color
+		if color == "" {
+			color = groupPalette[i%len(groupPalette)]
+		}
+		b.groupColor[name] = ansiColors[color]
+		for _, target := range group.Targets {
+			if b.add(target) {
+				b.groupOf[target] = name
+			}
+		}
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		go b.retry()
+		for _, name := range names {
+			go b.watchGroup(name, intervals[name])
+		}
+		return nil
+	})
+}
+
+/*
+watchGroup - Locate the targets of a group, then again every interval if it
+is not zero
+*/
+func (b *Batch) watchGroup(name string, interval time.Duration) {
+	for {
+		for _, target := range config.Groups[name].Targets {
+			b.mu.Lock()
+			owner := b.groupOf[target]
+			b.mu.Unlock()
+			if owner == name {
+				b.lookup(target)
+			}
+		}
+		if interval == 0 {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+/*
+groupSummary - Legend of the groups for the info pane. Must be called with
+b.mu held.
+*/
+func (b *Batch) groupSummary() []string {
+	names := make([]string, 0, len(b.groupColor))
+	for name := range b.groupColor {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		total, located := 0, 0
+		for target, group := range b.groupOf {
+			if group != name {
+				continue
+			}
+			total++
+			if _, ok := b.results[target]; ok {
+				located++
+			}
+		}
+		lines = append(lines, fmt.Sprintf("\x1b[%dm%s\x1b[0m: %d/%d located",
+			b.groupColor[name], name, located, total))
+	}
+	return lines
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+The server modes answer probes of Kubernetes, load balancers or systemd
+watchdogs:
+
+	GET /healthz  200 while the process serves, liveness
+	GET /readyz   200 when the provider can be reached and the cache answers,
+	              503 otherwise and while shutting down, readiness
+
+Both answer JSON with the outcome of each check. The age of the offline AS
+names (ip411 db update) is reported too, without failing readiness as they
+are optional.
+
+On SIGTERM or SIGINT the server stops accepting connections and lets the
+lookups in flight finish, for up to shutdownTimeout. /readyz answers 503 to
+probes still coming over open connections meanwhile.
+*/
+
+const (
+	shutdownTimeout = 30 * time.Second
+	// How long the outcome of a provider check is reused
+	healthCheckTTL = 30 * time.Second
+	healthTimeout  = 2 * time.Second
+	// Age beyond which the offline datasets are reported stale
+	staleDBAge = 30 * 24 * time.Hour
+)
+
+/*
+HealthCheck - The outcome of one check of /readyz
+*/
+type HealthCheck struct {
+	Status string `json:"status"` // ok, failed, stale or missing
+	Detail string `json:"detail,omitempty"`
+}
+
+/*
+HealthReport - The body of /healthz and /readyz
+*/
+type HealthReport struct {
+	Status string                 `json:"status"` // ok, unavailable or shutting down
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+/*
+Health - The state of a server, as reported to probes
+*/
+type Health struct {
+	provider *Provider
+	cache    Cache
+
+	mu       sync.Mutex
+	draining bool
+	checked  time.Time   // of the last provider check
+	reached  HealthCheck // its outcome
+}
+
+/*
+providerCheck - Whether a TCP connection to the provider can be opened,
+checking at most once per healthCheckTTL
+*/
+func (h *Health) providerCheck() HealthCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.checked) < healthCheckTTL {
+		return h.reached
+	}
+	h.checked = time.Now()
+
+	u, err := url.Parse(h.provider.url(net.IPv4(8, 8, 8, 8), ""))
+	if err != nil {
+		h.reached = HealthCheck{"failed", err.Error()}
+		return h.reached
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", host, healthTimeout)
+	if err != nil {
+		h.reached = HealthCheck{"failed", err.Error()}
+		return h.reached
+	}
+	conn.Close()
+	h.reached = HealthCheck{"ok", fmt.Sprintf("%s reachable, quota %s", host, h.provider.quota.String())}
+	return h.reached
+}
+
+/*
+cacheCheck - Whether the cache answers: always for the cache in memory,
+after a PING for Redis
+*/
+func (h *Health) cacheCheck() HealthCheck {
+	cache := h.cache
+	if instrumented, ok := cache.(instrumentedCache); ok {
+		cache = instrumented.Cache
+	}
+	redis, ok := cache.(*RedisCache)
+	if !ok {
+		return HealthCheck{"ok", "in memory"}
+	}
+	if err := redis.Ping(); err != nil {
+		return HealthCheck{"failed", err.Error()}
+	}
+	return HealthCheck{"ok", "Redis reachable"}
+}
+
+/*
+dbCheck - The age of the offline AS names
+*/
+func dbCheck() HealthCheck {
+	path := asnTablePath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return HealthCheck{"missing", "no AS names, see ip411 db update"}
+	}
+	age := time.Since(info.ModTime())
+	status := "ok"
+	if age > staleDBAge {
+		status = "stale"
+	}
+	return HealthCheck{status, fmt.Sprintf("AS names updated %s ago", age.Round(time.Hour))}
+}
+
+/*
+ServeHTTP - Answer /healthz and /readyz
+*/
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	draining := h.draining
+	h.mu.Unlock()
+
+	report := HealthReport{Status: "ok"}
+	status := http.StatusOK
+	if strings.Trim(r.URL.Path, "/") == "readyz" {
+		report.Checks = map[string]HealthCheck{
+			"provider": h.providerCheck(),
+			"cache":    h.cacheCheck(),
+			"db":       dbCheck(),
+		}
+		if report.Checks["provider"].Status != "ok" || report.Checks["cache"].Status != "ok" {
+			report.Status, status = "unavailable", http.StatusServiceUnavailable
+		}
+		if draining {
+			report.Status, status = "shutting down", http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+/*
+serveGracefully - Serve handler on the socket of opts until SIGTERM or
+SIGINT, then drain the requests in flight
+*/
+func serveGracefully(opts *serverOptions, handler http.Handler, health *Health) error {
+	ln, err := opts.open()
+	if err != nil {
+		return err
+	}
+	if opts.pprof != "" {
+		if err := startPprof(opts.pprof); err != nil {
+			return err
+		}
+	}
+	srv := &http.Server{Handler: handler}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(stop)
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- srv.Serve(ln)
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	startWatchdog(done)
+	sdNotify("READY=1")
+	select {
+	case err := <-failed:
+		return err
+	case sig := <-stop:
+		log.Printf("%s received, draining lookups in flight", sig)
+	}
+	sdNotify("STOPPING=1")
+	health.mu.Lock()
+	health.draining = true
+	health.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("Shutdown: %s", err)
+	}
+	return nil
+}
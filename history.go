@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+The watch mode keeps every result of the address it watches as a snapshot,
+one JSON line per lookup in history/<ip>.jsonl of the user's cache directory
+(-history=false keeps none). The history mode compares the snapshots field
+by field:
+
+	ip411 history                   the addresses with a history
+	ip411 history list ip           the snapshots of ip, * marking changes
+	ip411 history diff ip [i [j]]   the fields that changed between snapshots
+	                                i and j, or between each snapshot and the
+	                                previous one (-json for the structured
+	                                changes)
+*/
+
+/*
+Snapshot - A result of an address, as of a lookup
+*/
+type Snapshot struct {
+	Time   time.Time    `json:"time"`
+	Result IPInfoResult `json:"result"`
+}
+
+/*
+FieldChange - A field that changed between two snapshots, Was or Now empty
+when it was added or removed
+*/
+type FieldChange struct {
+	Path string `json:"path"`
+	Was  string `json:"was,omitempty"`
+	Now  string `json:"now,omitempty"`
+}
+
+/*
+SnapshotDiff - The changes between two snapshots
+*/
+type SnapshotDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Time    time.Time     `json:"time"` // of the later snapshot
+	Changes []FieldChange `json:"changes"`
+}
+
+// Fields of the results not compared: annotations of ip411 rather than
+// answers of the providers
+var historyIgnored = []string{"note", "compliance", "provenance"}
+
+func historyDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "history")
+}
+
+func historyPath(ip string) string {
+	// ':' of IPv6 addresses is not allowed in file names on Windows
+	return filepath.Join(historyDir(), strings.Replace(ip, ":", "_", -1)+".jsonl")
+}
+
+/*
+appendSnapshot - Add res to the history of ip
+*/
+func appendSnapshot(ip string, res IPInfoResult) error {
+	if historyDir() == "" {
+		return fmt.Errorf("No cache directory to keep the history in")
+	}
+	data, err := json.Marshal(Snapshot{Time: time.Now(), Result: res})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(historyDir(), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyPath(ip), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+/*
+readHistory - The snapshots of ip, oldest first
+*/
+func readHistory(ip string) ([]Snapshot, error) {
+	f, err := os.Open(historyPath(ip))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No history of %s: watch it first", ip)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var snapshot Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			// A line cut short by a crash
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, scanner.Err()
+}
+
+func historyIgnores(path string) bool {
+	for _, field := range historyIgnored {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+diffResults - The fields that differ between two results, by path
+*/
+func diffResults(previous, res IPInfoResult) []FieldChange {
+	paths := make(map[string]bool)
+	for _, path := range flattenPaths(previous, "") {
+		paths[path] = true
+	}
+	for _, path := range flattenPaths(res, "") {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		if !historyIgnores(path) {
+			sorted = append(sorted, path)
+		}
+	}
+	sort.Strings(sorted)
+
+	changes := []FieldChange{}
+	for _, path := range sorted {
+		was, now := fieldValue(previous, path), fieldValue(res, path)
+		if was != now {
+			changes = append(changes, FieldChange{Path: path, Was: was, Now: now})
+		}
+	}
+	return changes
+}
+
+/*
+String - The change in words: "org: AS1 A -> AS2 B", "loc moved 12 km: ..."
+*/
+func (c FieldChange) String() string {
+	switch {
+	case c.Was == "":
+		return fmt.Sprintf("%s: added %s", c.Path, c.Now)
+	case c.Now == "":
+		return fmt.Sprintf("%s: removed %s", c.Path, c.Was)
+	case c.Path == "loc":
+		was, err1 := parseLatLon(c.Was)
+		now, err2 := parseLatLon(c.Now)
+		if err1 == nil && err2 == nil {
+			return fmt.Sprintf("loc moved %s: %s -> %s", formatDistance(distanceKm(was, now)),
+				c.Was, c.Now)
+		}
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Was, c.Now)
+}
+
+/*
+historyDiffs - The diffs between snapshots i and j, or if i is negative
+between each snapshot and the previous one that differ
+*/
+func historyDiffs(snapshots []Snapshot, i, j int) []SnapshotDiff {
+	if i >= 0 {
+		return []SnapshotDiff{{From: i, To: j, Time: snapshots[j].Time,
+			Changes: diffResults(snapshots[i].Result, snapshots[j].Result)}}
+	}
+	var diffs []SnapshotDiff
+	for k := 1; k < len(snapshots); k++ {
+		changes := diffResults(snapshots[k-1].Result, snapshots[k].Result)
+		if len(changes) > 0 {
+			diffs = append(diffs, SnapshotDiff{From: k - 1, To: k, Time: snapshots[k].Time,
+				Changes: changes})
+		}
+	}
+	return diffs
+}
+
+/*
+snapshotIndex - The snapshot numbered by arg, negative numbers counting from
+the last
+*/
+func snapshotIndex(arg string, n int) (int, error) {
+	i, err := strconv.Atoi(arg)
+	if err == nil && i < 0 {
+		i += n
+	}
+	if err != nil || i < 0 || i >= n {
+		return 0, invalidInput("Invalid snapshot '%s': Expected 0 to %d, or -1 for the last.", arg, n-1)
+	}
+	return i, nil
+}
+
+/*
+historyHelp - What the usage message of history says after the synopsis
+*/
+const historyHelp = `Compare the results kept by the watch mode. Without arguments, list the
+addresses with a history. list shows the snapshots of ip, diff the fields
+that changed between snapshots i and j (j defaulting to the last one), or
+between every snapshot and the previous one.
+`
+
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	addQuietFlag(flags)
+	jsonOut := flags.Bool("json", false, "Print the diffs as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s history [-json] [list|diff ip [i [j]]]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(historyHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		files, err := ioutil.ReadDir(historyDir())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, file := range files {
+			if name := file.Name(); strings.HasSuffix(name, ".jsonl") {
+				ip := strings.Replace(strings.TrimSuffix(name, ".jsonl"), "_", ":", -1)
+				fmt.Printf("%s\t%s\n", ip, file.ModTime().Format("2006-01-02 15:04"))
+			}
+		}
+		return nil
+	}
+	if flags.NArg() < 2 || flags.NArg() > 4 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify list or diff and an IP Address.")
+	}
+	ip, err := noteIP(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	snapshots, err := readHistory(ip)
+	if err != nil {
+		return err
+	}
+
+	switch flags.Arg(0) {
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for k, snapshot := range snapshots {
+			mark := " "
+			if k > 0 && len(diffResults(snapshots[k-1].Result, snapshot.Result)) > 0 {
+				mark = "*"
+			}
+			res := snapshot.Result
+			fmt.Fprintf(tw, "%s %d\t%s\t%s\t%s\t%s\t%s\n", mark, k,
+				snapshot.Time.Local().Format("2006-01-02 15:04:05"), fieldValue(res, "source"),
+				fieldValue(res, "country"), fieldValue(res, "city"), fieldValue(res, "org"))
+		}
+		return tw.Flush()
+	case "diff":
+		from := -1
+		to := len(snapshots) - 1
+		if flags.NArg() > 2 {
+			if from, err = snapshotIndex(flags.Arg(2), len(snapshots)); err != nil {
+				return err
+			}
+		}
+		if flags.NArg() > 3 {
+			if to, err = snapshotIndex(flags.Arg(3), len(snapshots)); err != nil {
+				return err
+			}
+		}
+		diffs := historyDiffs(snapshots, from, to)
+		if *jsonOut {
+			if diffs == nil {
+				diffs = []SnapshotDiff{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(diffs)
+		}
+		for _, diff := range diffs {
+			if len(diff.Changes) == 0 {
+				continue
+			}
+			fmt.Printf("%d -> %d (%s)\n", diff.From, diff.To,
+				diff.Time.Local().Format("2006-01-02 15:04:05"))
+			for _, change := range diff.Changes {
+				fmt.Printf("  %s\n", change)
+			}
+		}
+		if len(diffs) == 0 || from >= 0 && len(diffs[0].Changes) == 0 {
+			fmt.Fprintf(os.Stderr, "No changes in %d snapshots of %s\n", len(snapshots), ip)
+		}
+		return nil
+	}
+	flags.Usage()
+	return invalidInput("Unknown history command '%s'", flags.Arg(0))
+}
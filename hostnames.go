@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Batch inputs are often fleets of hostnames rather than addresses. Numeric
+ranges in brackets expand to one hostname per number, keeping the width of
+the first one: web[01-20].example.com gives web01 to web20. Hostnames are
+then resolved concurrently before the lookups, each plotted at its address
+and labelled with its name.
+*/
+
+// Most hostnames a target may expand to
+const maxExpansion = 10000
+
+// Time allowed to resolve one hostname
+const resolveTimeout = 5 * time.Second
+
+var rangePattern = regexp.MustCompile(`\[(\d+)-(\d+)\]`)
+
+/*
+expandTarget - The hostnames of the ranges of target, target alone if it has
+none
+*/
+func expandTarget(target string) ([]string, error) {
+	loc := rangePattern.FindStringSubmatchIndex(target)
+	if loc == nil {
+		return []string{target}, nil
+	}
+	first, last := target[loc[2]:loc[3]], target[loc[4]:loc[5]]
+	from, err1 := strconv.Atoi(first)
+	to, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || from > to {
+		return nil, fmt.Errorf("Invalid range [%s-%s] in '%s'", first, last, target)
+	}
+	if to-from >= maxExpansion {
+		return nil, fmt.Errorf("'%s' expands to more than %d hostnames", target, maxExpansion)
+	}
+	width := 0
+	if strings.HasPrefix(first, "0") {
+		width = len(first)
+	}
+	// Later ranges expand recursively
+	rest, err := expandTarget(target[loc[1]:])
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for n := from; n <= to; n++ {
+		for _, suffix := range rest {
+			targets = append(targets, fmt.Sprintf("%s%0*d%s", target[:loc[0]], width, n, suffix))
+		}
+		if len(targets) > maxExpansion {
+			return nil, fmt.Errorf("'%s' expands to more than %d hostnames", target, maxExpansion)
+		}
+	}
+	return targets, nil
+}
+
+/*
+expandTargets - targets with their ranges expanded
+*/
+func expandTargets(targets []string) ([]string, error) {
+	var expanded []string
+	for _, target := range targets {
+		hosts, err := expandTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, hosts...)
+	}
+	return expanded, nil
+}
+
+/*
+isHostname - Whether target must be resolved: not an IP Address, a lat,lon
+or a @bookmark
+*/
+func isHostname(target string) bool {
+	if parseTargetIP(target) != nil || strings.HasPrefix(target, "@") {
+		return false
+	}
+	_, ok := targetPoint(target)
+	return !ok
+}
+
+type resolveOptions struct {
+	server  string
+	workers int
+}
+
+func addResolveFlags(flags *flag.FlagSet) *resolveOptions {
+	opts := &resolveOptions{}
+	flags.StringVar(&opts.server, "resolver", "",
+		"Resolve hostnames with this DNS server, host:port (default the system resolver)")
+	flags.IntVar(&opts.workers, "resolve-workers", 16,
+		"Hostnames resolved at the same time")
+	return opts
+}
+
+/*
+HostResolver - Resolves many hostnames at once
+*/
+type HostResolver struct {
+	resolver *net.Resolver
+	workers  int
+}
+
+/*
+open - Create the resolver the flags describe
+*/
+func (opts *resolveOptions) open() (*HostResolver, error) {
+	if opts.workers <= 0 {
+		return nil, invalidInput("Invalid -resolve-workers %d: Expected a positive number.", opts.workers)
+	}
+	hr := &HostResolver{resolver: net.DefaultResolver, workers: opts.workers}
+	if opts.server != "" {
+		server := opts.server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		hr.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return hr, nil
+}
+
+/*
+HostAddress - The address a hostname resolved to, or why it did not
+*/
+type HostAddress struct {
+	IP  string
+	Err error
+}
+
+/*
+ResolveAll - The first address of every hostname of hosts
+*/
+func (hr *HostResolver) ResolveAll(hosts []string) map[string]HostAddress {
+	resolved := make(map[string]HostAddress)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+	for i := 0; i < hr.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range work {
+				ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+				addrs, err := hr.resolver.LookupIP(ctx, "ip", host)
+				cancel()
+				res := HostAddress{Err: err}
+				if err == nil && len(addrs) > 0 {
+					res.IP = addrs[0].String()
+				} else if err == nil {
+					res.Err = fmt.Errorf("No address for '%s'", host)
+				}
+				mu.Lock()
+				resolved[host] = res
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, host := range hosts {
+		work <- host
+	}
+	close(work)
+	wg.Wait()
+	return resolved
+}
+
+/*
+addHosts - Resolve hosts and add their addresses to the batch, labelled with
+the hostnames. Returns the addresses added, hostnames that did not resolve
+fail.
+*/
+func (b *Batch) addHosts(hr *HostResolver, hosts []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if !seen[host] {
+			seen[host] = true
+			unique = append(unique, host)
+		}
+	}
+	resolved := hr.ResolveAll(unique)
+
+	var fresh []string
+	for _, host := range unique {
+		res := resolved[host]
+		if res.Err != nil {
+			b.add(host)
+			b.fail(host, res.Err)
+			continue
+		}
+		if !b.add(res.IP) {
+			// Another hostname or the address itself was listed first
+			continue
+		}
+		b.mu.Lock()
+		if b.labels == nil {
+			b.labels = make(map[string]string)
+		}
+		b.labels[res.IP] = host
+		b.mu.Unlock()
+		fresh = append(fresh, res.IP)
+	}
+	return fresh
+}
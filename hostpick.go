@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+The host picker lists the public hosts of /etc/hosts and the Host aliases of
+~/.ssh/config to locate one without typing it:
+
+	h      open the picker
+	enter  locate the selected host
+	esc    close
+*/
+
+const hostsPath = "/etc/hosts"
+
+/*
+readHostsFile - Entries of a hosts(5) file, named by their first hostname
+*/
+func readHostsFile(r io.Reader) ([]InventoryHost, error) {
+	var hosts []InventoryHost
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		hosts = append(hosts, InventoryHost{Name: fields[1], Target: fields[0]})
+	}
+	return hosts, scanner.Err()
+}
+
+/*
+readSSHConfig - Host aliases of an ssh_config(5) file, with their HostName
+if they have one. Patterns and Match blocks are skipped.
+*/
+func readSSHConfig(r io.Reader) ([]InventoryHost, error) {
+	var hosts []InventoryHost
+	var block []int // positions in hosts of the aliases of the current Host
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "host":
+			block = nil
+			for _, alias := range fields[1:] {
+				if strings.ContainsAny(alias, "*?!") {
+					continue
+				}
+				block = append(block, len(hosts))
+				hosts = append(hosts, InventoryHost{Name: alias, Target: alias})
+			}
+		case "match":
+			block = nil
+		case "hostname":
+			for _, i := range block {
+				hosts[i].Target = fields[1]
+			}
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+/*
+quickPickHosts - Hosts of /etc/hosts and ~/.ssh/config. Missing files are
+skipped.
+*/
+func quickPickHosts() ([]InventoryHost, error) {
+	type source struct {
+		path string
+		read func(io.Reader) ([]InventoryHost, error)
+	}
+	sources := []source{{hostsPath, readHostsFile}}
+	if home, err := os.UserHomeDir(); err == nil {
+		sources = append(sources, source{filepath.Join(home, ".ssh", "config"), readSSHConfig})
+	}
+
+	var hosts []InventoryHost
+	for _, source := range sources {
+		f, err := os.Open(source.path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found, err := source.read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", source.path, err)
+		}
+		hosts = append(hosts, publicHosts(found)...)
+	}
+	return hosts, nil
+}
+
+func openHostPicker(g *Gui, show func(target string)) error {
+	hosts, err := quickPickHosts()
+	if err != nil {
+		guiShowStatus(g, "%s", err)
+		return nil
+	}
+	items := make([]string, len(hosts))
+	for i, host := range hosts {
+		items[i] = fmt.Sprintf("%-24s %s", host.Name, host.Target)
+	}
+	return selectDialog(g, "Hosts: enter locate, esc close", items, func(g *Gui, i int) error {
+		go show(hosts[i].Target)
+		return nil
+	})
+}
+
+/*
+setHostPickerKeybindings - Register the key of the host picker. show is
+called in its own goroutine with the chosen host.
+*/
+func setHostPickerKeybindings(g *Gui, show func(target string)) error {
+	return g.SetKeybinding("", 'h', ModNone, func(g *Gui, v *View) error {
+		return openHostPicker(g, show)
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+/*
+With -http3 provider requests over HTTPS are made with HTTP/3 (QUIC), which
+recovers from packet loss better than TCP on mobile links. Where it fails,
+UDP being blocked or the provider not speaking it, the request is made again
+over HTTP/1.1 or HTTP/2 and the host is left alone for http3Backoff. Plain
+HTTP requests (providers without TLS) are unaffected.
+*/
+
+var useHTTP3 bool
+
+// Short, as a host without HTTP/3 usually just never answers over UDP
+const http3HandshakeTimeout = 2 * time.Second
+
+// How long hosts that failed over HTTP/3 are asked over TCP instead
+const http3Backoff = 10 * time.Minute
+
+/*
+http3Transport - Tries HTTP/3, falls back to another transport
+*/
+type http3Transport struct {
+	h3       http.RoundTripper
+	fallback http.RoundTripper
+
+	mu     sync.Mutex
+	broken map[string]time.Time // host to when to try HTTP/3 again
+}
+
+func newHTTP3Transport(fallback http.RoundTripper) *http3Transport {
+	return &http3Transport{
+		h3: &http3.Transport{
+			QUICConfig: &quic.Config{HandshakeIdleTimeout: http3HandshakeTimeout},
+		},
+		fallback: fallback,
+		broken:   make(map[string]time.Time),
+	}
+}
+
+func (t *http3Transport) usable(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Now().After(t.broken[host])
+}
+
+/*
+RoundTrip - Make req over HTTP/3 if possible. Provider requests are GETs
+without body, so they can be sent again.
+*/
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || req.Body != nil || !t.usable(req.URL.Host) {
+		return t.fallback.RoundTrip(req)
+	}
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	warnf("%s: HTTP/3 failed, falling back: %s", req.URL.Host, err)
+	t.mu.Lock()
+	t.broken[req.URL.Host] = time.Now().Add(http3Backoff)
+	t.mu.Unlock()
+	return t.fallback.RoundTrip(req)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+The text of the interface (titles, status messages, labels of the info pane),
+the usage messages and the common errors are written in English in the code
+and translated when shown: tr looks the English text up in the catalog of the
+language, from LC_ALL, LC_MESSAGES or LANG, the config file's "lang" or
+-lang, and keeps it as is when the catalog does not have it. Format strings
+are translated before formatting, so a translation keeps their verbs in the
+same order.
+
+A language is added with a catalog in catalogs, keyed by its ISO 639-1 code.
+*/
+
+const defaultLanguage = "en"
+
+var language = envLanguage()
+
+/*
+catalogs - Translations of the English texts, by language
+*/
+var catalogs = map[string]map[string]string{
+	"fr": catalogFR,
+}
+
+/*
+envLanguage - The language of the messages, from the environment
+*/
+func envLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return localeLanguage(value)
+		}
+	}
+	return defaultLanguage
+}
+
+/*
+localeLanguage - The language of a POSIX locale name (fr_BE.UTF-8), or of a
+bare language code
+*/
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ToLower(locale)
+	if locale == "" || locale == "c" || locale == "posix" {
+		return defaultLanguage
+	}
+	return locale
+}
+
+/*
+languages - The languages messages can be shown in, sorted
+*/
+func languages() []string {
+	names := []string{defaultLanguage}
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+setLanguage - Show messages in lang, a language code or a locale name
+*/
+func setLanguage(lang string) error {
+	code := localeLanguage(lang)
+	if _, ok := catalogs[code]; !ok && code != defaultLanguage {
+		return invalidInput("Unknown language '%s': Expected one of %s.",
+			lang, strings.Join(languages(), ", "))
+	}
+	language = code
+	return nil
+}
+
+/*
+tr - The translation of msg in the language of the messages, msg if there is
+none
+*/
+func tr(msg string) string {
+	if translated, ok := catalogs[language][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+/*
+langFlag - -lang, applied as soon as it is parsed
+*/
+type langFlag struct{}
+
+func (langFlag) String() string {
+	return language
+}
+
+func (langFlag) Set(lang string) error {
+	return setLanguage(lang)
+}
+
+func addLangFlag(flags *flag.FlagSet) {
+	flags.Var(langFlag{}, "lang",
+		"Language of the messages, en or fr (default from the locale)")
+}
+
+/*
+printDefaults - PrintDefaults, with the usages of the flags translated
+*/
+func printDefaults(flags *flag.FlagSet) {
+	flags.VisitAll(func(f *flag.Flag) {
+		f.Usage = tr(f.Usage)
+	})
+	flags.PrintDefaults()
+}
@@ -0,0 +1,616 @@
+package main
+
+/*
+catalogFR - French
+*/
+var catalogFR = map[string]string{
+	// Info pane
+	"Hostname":            "Nom d'hôte",
+	"Org":                 "Organisation",
+	"Longitude,Latitude":  "Longitude,Latitude",
+	"City":                "Ville",
+	"Region":              "Région",
+	"Country":             "Pays",
+	"Postal":              "Code postal",
+	"Host":                "Hôte",
+	"Loc":                 "Pos",
+	"Reg":                 "Rég",
+	"Ctry":                "Pays",
+	"Zip":                 "CP",
+	"Info template: %s\n": "Modèle de l'info : %s\n",
+
+	// Status bar
+	"Quota: unknown":                      "Quota : inconnu",
+	"Quota: %d":                           "Quota : %d",
+	" (resets %s)":                        " (remis à zéro le %s)",
+	" - rate limited, requests queued":    " - limité, requêtes en attente",
+	"Provider: %s":                        "Fournisseur : %s",
+	"Cache: off":                          "Cache : désactivé",
+	"Cache: %s %d/%d hits":                "Cache : %s %d/%d trouvées",
+	"<L> legend":                          "<L> légende",
+	"Kept: %d/%d targets, %d/%d steps":    "Gardées : %d/%d cibles, %d/%d étapes",
+	" (%d forgotten)":                     " (%d oubliées)",
+	", %d/%d flows":                       ", %d/%d flux",
+	", %d messages dropped":               ", %d messages perdus",
+	"Legend <L>":                          "Légende <L>",
+	"X   a located address, or its label": "X   une adresse localisée, ou son étiquette",
+	"+   an exchange near the target":     "+   un point d'échange proche de la cible",
+	"+   the crosshair <x>":               "+   le réticule <x>",
+	"@   the current address: search match, top talker, playback":             "@   l'adresse courante : résultat de recherche, gros interlocuteur, lecture",
+	"!   an address flashing on an alert":                                     "!   une adresse clignotant sur une alerte",
+	"*   addresses sharing a cell, <x> on it lists them":                      "*   des adresses sur une même case, <x> dessus les liste",
+	"?   a lookup to retry, shown at an address of its network (approximate)": "?   une recherche à réessayer, montrée sur une adresse de son réseau (approximatif)",
+	"  (shown at %s, approximate)":                                            "  (montrée sur %s, approximatif)",
+	"1-9 the top talkers <T>, + the ones after":                               "1-9 les plus gros interlocuteurs <T>, + les suivants",
+	"Discs grow with the metric <m>":                                          "Les disques grandissent avec la mesure <m>",
+	"Layers: %s":                                                              "Couches : %s",
+	"Targets: %s  Located: %s  Pending: %s  Failed: %s":                       "Cibles : %s  Localisées : %s  En attente : %s  Échecs : %s",
+	"  Dropped: %s":    "  Écartées : %s",
+	"  Shown: %s (%s)": "  Affichées : %s (%s)",
+	"Queue: %d/%d":     "File : %d/%d",
+	"  Dropped: %d sampled out, %d over rate, %d queue full": "  Écartées : %d par échantillonnage, %d au-delà du débit, %d file pleine",
+	"Lookup failed: %s\n":  "Échec de la recherche : %s\n",
+	"Trying again in %s\n": "Nouvel essai dans %s\n",
+
+	// Status messages
+	"Lookup of %s failed: %s":                                        "Échec de la recherche de %s : %s",
+	"Result of %s dropped by script":                                 "Résultat de %s écarté par le script",
+	"Resolving %d hostnames":                                         "Résolution de %d noms d'hôte",
+	"No target shown, nothing written":                               "Aucune cible affichée, rien n'a été écrit",
+	"Could not write the report: %s":                                 "Impossible d'écrire le rapport : %s",
+	"Could not update the Tor exit relays: %s":                       "Impossible de mettre à jour les relais de sortie Tor : %s",
+	"%d packets read from %s":                                        "%d paquets lus dans %s",
+	"Nothing to copy":                                                "Rien à copier",
+	"Loading the %s...":                                              "Chargement : %s...",
+	"Expected a format, an action and a file, e.g. %s":               "Format, action et fichier attendus, par ex. %s",
+	"Expected a format and a file, e.g. %s":                          "Format et fichier attendus, par ex. %s",
+	"Could not write rules: %s":                                      "Impossible d'écrire les règles : %s",
+	"Could not save config: %s":                                      "Impossible d'enregistrer la configuration : %s",
+	"Could not load the %s: %s":                                      "Impossible de charger %s : %s",
+	"Could not export: %s":                                           "Impossible d'exporter : %s",
+	"Could not copy %s: %s":                                          "Impossible de copier %s : %s",
+	"Copied %s":                                                      "%s copié",
+	"Bookmarked %d targets":                                          "%d cibles ajoutées aux favoris",
+	"%s rules for %d prefixes written to %s, review before applying": "Règles %s pour %d préfixes écrites dans %s, à relire avant de les appliquer",
+	"%d lines written to %s":                                         "%d lignes écrites dans %s",
+
+	// Titles
+	"Bookmarks: enter locate, esc close":                          "Favoris : entrée localise, échap ferme",
+	"Hosts: enter locate, esc close":                              "Hôtes : entrée localise, échap ferme",
+	"Info fields: space toggle, J/K move, enter save, esc cancel": "Champs de l'info : espace coche, J/K déplace, entrée enregistre, échap annule",
+	"Search IP, hostname, org, city (enter, esc)":                 "Chercher IP, nom d'hôte, organisation, ville (entrée, échap)",
+	"Filter (enter apply, esc cancel)":                            "Filtre (entrée applique, échap annule)",
+	"Note (enter pin, esc cancel)":                                "Note (entrée épingle, échap annule)",
+	"Note: %s":                                                    "Note : %s",
+	" (pinned %s)":                                                " (épinglé le %s)",
+	", changed since: %s":                                         ", changé depuis : %s",
+	"No IP Address to pin":                                        "Aucune adresse IP à épingler",
+	"Could not save the note: %s":                                 "Impossible d'enregistrer la note : %s",
+	"Note of %s removed":                                          "Note de %s supprimée",
+	"Result of %s pinned":                                         "Résultat de %s épinglé",
+	"Confirm":                                                     "Confirmer",
+	"Nothing to undo":                                             "Rien à annuler",
+	"Nothing to redo":                                             "Rien à rétablir",
+	"From: %s":                                                    "Source : %s",
+	"cache hit":                                                   "trouvé en cache",
+	" (%s old)":                                                   " (âgé de %s)",
+	"replayed":                                                    "rejoué",
+	"queried %s":                                                  "demandé le %s",
+	"%d fields differ":                                            "%d champs diffèrent",
+	"A and B are %s apart, %s":                                    "A et B sont à %s l'un de l'autre, %s",
+	"<y> yes, <n> no":                                             "<y> oui, <n> non",
+	"Overwrite %s?":                                               "Écraser %s ?",
+	"Remove the note of %s?":                                      "Supprimer la note de %s ?",
+	"Bookmark the %d located targets in the config file?":    "Ajouter les %d cibles localisées aux favoris du fichier de configuration ?",
+	"Locate IP, hostname, @bookmark or lat,lon (enter, esc)": "Localiser IP, nom d'hôte, @favori ou lat,lon (entrée, échap)",
+	"Type an IP Address, hostname, @bookmark or lat,lon":     "Tapez une adresse IP, un nom d'hôte, un @favori ou lat,lon",
+	"%s by %s (tab, o, enter)":                               "%s par %s (tab, o, entrée)",
+	"Top talkers by %s, %s (o, enter)":                       "Plus gros interlocuteurs par %s, %s (o, entrée)",
+	"Address":                                                "Adresse",
+	"Packets":                                                "Paquets",
+	"Bytes":                                                  "Octets",
+	"Hits":                                                   "Requêtes",
+
+	// Results and errors
+	"%s: near %s, %s (%s), %s away":                                       "%s : près de %s, %s (%s), à %s",
+	"%s: No location in the answer of %s":                                 "%s : aucune position dans la réponse de %s",
+	"Bogon address (private, reserved or not routed), it has no location": "Adresse bogon (privée, réservée ou non routée), elle n'a pas de position",
+	"Invalid IP Address '%s': %s":                                         "Adresse IP invalide '%s' : %s",
+	"'%s' is neither an IP Address nor a hostname that resolves":          "'%s' n'est ni une adresse IP ni un nom d'hôte qui se résout",
+	"Did you mean %s?":                                                    "Vouliez-vous dire %s ?",
+	"An IPv4 Address has 4 numbers, not %d.":                              "Une adresse IPv4 a 4 nombres, pas %d.",
+	"A number is missing.":                                                "Il manque un nombre.",
+	"%d is out of range, the numbers go from 0 to 255.":                   "%d est hors limites, les nombres vont de 0 à 255.",
+	"'%s' could be:\n":                                                    "'%s' peut être :\n",
+	"Which one [1]? ":                                                     "Laquelle [1] ? ",
+	"Expected a number from 1 to %d\n":                                    "Un nombre de 1 à %d est attendu\n",
+	"No target chosen for '%s'":                                           "Aucune cible choisie pour '%s'",
+	"'%s' could be %d targets, using %s":                                  "'%s' peut être %d cibles, %s est utilisée",
+	"Result dropped by script":                                            "Résultat écarté par le script",
+	"Provider quota exhausted or low, waiting %s":                         "Quota du fournisseur épuisé ou bas, attente de %s",
+	"Provider quota exhausted, next lookup allowed in %s":                 "Quota du fournisseur épuisé, prochaine recherche permise dans %s",
+	"Invalid number of arguments: Specify one IP Address.":                "Nombre d'arguments invalide : indiquez une adresse IP.",
+	"Invalid number of arguments: Specify a location.":                    "Nombre d'arguments invalide : indiquez une position.",
+	"Invalid number of arguments: Specify one file.":                      "Nombre d'arguments invalide : indiquez un fichier.",
+	"Invalid number of arguments: Expected none.":                         "Nombre d'arguments invalide : aucun n'est attendu.",
+	"Invalid -connect-test %d: Expected a TCP port.":                      "-connect-test %d invalide : un port TCP est attendu.",
+	"Invalid -glyphs '%s': Expected auto, braille or ascii.":              "-glyphs '%s' invalide : auto, braille ou ascii attendu.",
+	"Invalid format '%s': Expected %s or %s.":                             "Format '%s' invalide : %s ou %s attendu.",
+	"%d addresses here":                                                   "%d adresses ici",
+	"Invalid units '%s': Expected km or mi.":                              "Unités '%s' invalides : km ou mi attendu.",
+	"Invalid precision %d: Expected 0 to %d decimals.":                    "Précision %d invalide : de 0 à %d décimales attendues.",
+	"Invalid precision '%s': Expected a number of decimals.":              "Précision '%s' invalide : un nombre de décimales est attendu.",
+	"Unknown language '%s': Expected one of %s.":                          "Langue '%s' inconnue : une de %s est attendue.",
+	"Unknown provider '%s', expected one of %s":                           "Fournisseur '%s' inconnu, un de %s est attendu",
+	"Unknown group '%s'":                                                  "Groupe '%s' inconnu",
+
+	// Narrative of -accessible
+	"Address %s, hostname %s.":      "Adresse %s, nom d'hôte %s.",
+	"Address %s.":                   "Adresse %s.",
+	"Located in %s.":                "Située à %s.",
+	"No location is known.":         "Aucune position n'est connue.",
+	"%s from home.":                 "À %s de chez vous.",
+	"Network: %s.":                  "Réseau : %s.",
+	"Postal code: %s.":              "Code postal : %s.",
+	"Time zone: %s, local time %s.": "Fuseau horaire : %s, heure locale %s.",
+	"north":                         "nord", "south": "sud", "east": "est", "west": "ouest",
+	"Coordinates: %s degrees %s, %s degrees %s.": "Coordonnées : %s degrés %s, %s degrés %s.",
+	"IP Address":                                   "Adresse IP",
+	"%s is now %s.":                                "%s est maintenant %s.",
+	"%s is no longer known, it was %s.":            "%s n'est plus connu, c'était %s.",
+	"%s changed from %s to %s.":                    "%s est passé de %s à %s.",
+	"The location moved by %s.":                    "La position s'est déplacée de %s.",
+	"At %s:":                                       "À %s :",
+	"At %s, the lookup failed: %s.":                "À %s, la recherche a échoué : %s.",
+	"At %s, the result was dropped by the script.": "À %s, le résultat a été écarté par le script.",
+	"Describe the result in sentences instead of drawing the map, for screen readers": "Décrire le résultat en phrases au lieu de dessiner la carte, pour les lecteurs d'écran",
+
+	// Time zones
+	" (result: %s)": " (résultat : %s)",
+	"band %s":       "bande %s",
+
+	// Choropleth
+	"addresses":                 "adresses",
+	"hits":                      "occurrences",
+	"Countries by %s: none yet": "Pays par %s : aucun pour l'instant",
+	"Countries by %s: cyan < %d <= yellow < %d <= red, largest %d (%d countries)": "Pays par %s : cyan < %d <= jaune < %d <= rouge, maximum %d (%d pays)",
+	"No subdivisions: run ip411 db update":                                        "Pas de subdivisions : lancez ip411 db update",
+	"No country borders: run ip411 db update":                                     "Pas de frontières des pays : lancez ip411 db update",
+
+	// Alerts
+	"ip411 alert: %s":                              "Alerte ip411 : %s",
+	"%s, was %s":                                   "%s, auparavant %s",
+	"ip411: the public IP Address changed":         "ip411 : l'adresse IP publique a changé",
+	"ip411: first address from %s":                 "ip411 : première adresse de %s",
+	"Alert %s: %s, %s":                             "Alerte %s : %s, %s",
+	"Unknown alert rule '%s': Expected one of %s.": "Règle d'alerte '%s' inconnue : une de %s est attendue.",
+	"Comma separated alert rules to enable: new_country, blocklisted, policy,\nownership or those of the config file": "Règles d'alerte à activer, séparées par des virgules : new_country, blocklisted,\npolicy, ownership ou celles du fichier de configuration",
+	"Invalid alert rules in the config file: %s": "Règles d'alerte invalides dans le fichier de configuration : %s",
+	"'%s' is defined twice":                      "'%s' est définie deux fois",
+	"Ring the terminal bell on alerts":           "Faire sonner le terminal lors des alertes",
+
+	// Flags
+	"Do not print warnings, only errors":                                                                                                "N'afficher que les erreurs, pas les avertissements",
+	"Start the interface even if stdout is not a terminal":                                                                              "Démarrer l'interface même si la sortie standard n'est pas un terminal",
+	"Draw the map with braille or ascii characters (default detected from the console)":                                                 "Dessiner la carte en braille ou en caractères ascii (détecté par défaut depuis la console)",
+	"Keep the map on europe, na, sa, africa, mena, apac, oceania or a bounding box\nminLon,minLat,maxLon,maxLat":                        "Garder la carte sur europe, na, sa, africa, mena, apac, oceania ou un cadre\nminLon,minLat,maxLon,maxLat",
+	"Invalid region '%s': Expected one of %s or a bounding box (%s).":                                                                   "Région '%s' invalide : une de %s ou un cadre est attendu (%s).",
+	"Units of the distances shown, km or mi (default from the locale)":                                                                  "Unités des distances affichées, km ou mi (par défaut selon la locale)",
+	"Language of the messages, en or fr (default from the locale)":                                                                      "Langue des messages, en ou fr (par défaut selon la locale)",
+	"Decimals of the coordinates shown and exported, 0 to 6 (default 2)":                                                                "Décimales des coordonnées affichées et exportées, de 0 à 6 (2 par défaut)",
+	"Render the info pane with this text/template file":                                                                                 "Rendre le panneau d'info avec ce fichier text/template",
+	"Fail with exit code 4 instead of waiting longer than this for the provider quota (0 waits)":                                        "Échouer avec le code 4 au lieu d'attendre le quota du fournisseur plus longtemps que cela (0 attend)",
+	"Geolocation API to look addresses up with, or mock (see ip411 providers).\nA comma separated list fails over from one to the next": "API de géolocalisation à interroger, ou mock (voir ip411 providers).\nUne liste séparée par des virgules passe de l'une à la suivante en cas d'échec",
+	"Spread lookups over the -provider list instead of failing over in order":                                                           "Répartir les recherches sur la liste de -provider au lieu de la suivre dans l'ordre",
+	"Add the BGP prefix, origin AS and RPKI validity of the IP Address (RIPEstat)":                                                      "Ajouter le préfixe BGP, l'AS d'origine et la validité RPKI de l'adresse IP (RIPEstat)",
+	"Check the IP Address against DNS blocklists (Spamhaus, Barracuda...)":                                                              "Vérifier l'adresse IP dans les listes de blocage DNS (Spamhaus, Barracuda...)",
+	"Flag the IP Address if it is a Tor exit relay":                                                                                     "Signaler l'adresse IP si c'est un relais de sortie Tor",
+	"Print this field of the result instead of showing the map (repeatable)":                                                            "Afficher ce champ du résultat au lieu de la carte (répétable)",
+	"Print the -field values on one tab separated line":                                                                                 "Afficher les valeurs de -field sur une ligne séparée par des tabulations",
+	"Show the n internet exchanges and PoPs nearest to the location":                                                                    "Montrer les n points d'échange internet et PoP les plus proches de la position",
+
+	// Errors and warnings of the modes
+	"No IP Address or prefix to aggregate.": "Aucune adresse IP ni préfixe à agréger.",
+	"Alert %s: %s":                          "Alerte %s : %s",
+	"Alert %s: %s failed: %s":               "Alerte %s : %s a échoué : %s",
+	"Could not read the AS names: %s":       "Impossible de lire les noms des AS : %s",
+	"%s %s: %s":                             "%s %s : %s",
+	"Invalid cache '%s': %s":                "Cache '%s' invalide : %s",
+	"Invalid cache '%s': Expected memory, redis:// or rediss://.":          "Cache '%s' invalide : memory, redis:// ou rediss:// est attendu.",
+	"Invalid count '%s': Expected a positive number.":                      "Nombre '%s' invalide : un nombre positif est attendu.",
+	"Specify a prefix and the addresses to test.":                          "Indiquez un préfixe et les adresses à tester.",
+	"Specify the first and last address of the range.":                     "Indiquez la première et la dernière adresse de la plage.",
+	"Invalid range %s - %s: Expected two IP Addresses.":                    "Plage %s - %s invalide : deux adresses IP sont attendues.",
+	"Specify a prefix and optionally a count.":                             "Indiquez un préfixe et éventuellement un nombre.",
+	"Invalid count %d: Expected at most %d.":                               "Nombre %d invalide : au plus %d est attendu.",
+	"Invalid number of arguments: Specify an operation.":                   "Nombre d'arguments invalide : indiquez une opération.",
+	"Unknown operation '%s'.":                                              "Opération '%s' inconnue.",
+	"Invalid arguments: Specify a capture file with -r.":                   "Arguments invalides : indiquez un fichier de capture avec -r.",
+	"Invalid -speed %g: Expected a positive factor.":                       "-speed %g invalide : un facteur positif est attendu.",
+	"%d lookups left for the next run to retry":                            "%d recherches restent à réessayer au prochain lancement",
+	"Invalid number of arguments: Specify one or two targets.":             "Nombre d'arguments invalide : indiquez une ou deux cibles.",
+	"Invalid arguments: Expected update.":                                  "Arguments invalides : update est attendu.",
+	"Specify at least one field.":                                          "Indiquez au moins un champ.",
+	"Could not publish %s event: %s":                                       "Impossible de publier l'événement %s : %s",
+	"Exec for %s event failed: %s":                                         "L'exécution pour l'événement %s a échoué : %s",
+	"Specify at least one provider.":                                       "Indiquez au moins un fournisseur.",
+	"Invalid number of arguments: Specify a fixture.":                      "Nombre d'arguments invalide : indiquez un jeu de données.",
+	"Invalid canvas %dx%d: Expected positive dimensions.":                  "Toile %dx%d invalide : des dimensions positives sont attendues.",
+	"Invalid -update: Specify the -golden file to write.":                  "-update invalide : indiquez le fichier -golden à écrire.",
+	"Invalid fixture %s: %s":                                               "Jeu de données %s invalide : %s",
+	"Groups: %v\n":                                                         "Groupes : %v\n",
+	"Invalid snapshot '%s': Expected 0 to %d, or -1 for the last.":         "Instantané '%s' invalide : de 0 à %d, ou -1 pour le dernier, est attendu.",
+	"Invalid number of arguments: Specify list or diff and an IP Address.": "Nombre d'arguments invalide : indiquez list ou diff et une adresse IP.",
+	"Unknown history command '%s'":                                         "Commande d'historique '%s' inconnue",
+	"Invalid -resolve-workers %d: Expected a positive number.":             "-resolve-workers %d invalide : un nombre positif est attendu.",
+	"%s: HTTP/3 failed, falling back: %s":                                  "%s : échec de HTTP/3, repli : %s",
+	"Invalid -queue %d: Expected a positive size.":                         "-queue %d invalide : une taille positive est attendue.",
+	"Invalid -sample '%s': %s.":                                            "-sample '%s' invalide : %s.",
+	"Invalid -ip-rate '%s': %s.":                                           "-ip-rate '%s' invalide : %s.",
+	"Invalid number of arguments: Specify an inventory file.":              "Nombre d'arguments invalide : indiquez un fichier d'inventaire.",
+	"Invalid cache_ttl '%s': %s":                                           "cache_ttl '%s' invalide : %s",
+	"Invalid owned_prefixes in the config file: %s":                        "owned_prefixes invalides dans le fichier de configuration : %s",
+	"Could not read the %s token from the keyring: %s":                     "Impossible de lire le jeton %s depuis le trousseau : %s",
+	"Invalid number of arguments: Specify login or logout and a provider.": "Nombre d'arguments invalide : indiquez login ou logout et un fournisseur.",
+	"Empty token": "Jeton vide",
+	"Unknown command '%s', expected login or logout":                            "Commande '%s' inconnue, login ou logout est attendu",
+	"Invalid %s %d: Expected a positive number.":                                "%s %d invalide : un nombre positif est attendu.",
+	"Invalid number of arguments: Specify an IP Address and a domain.":          "Nombre d'arguments invalide : indiquez une adresse IP et un domaine.",
+	"Invalid IP Address '%s'":                                                   "Adresse IP '%s' invalide",
+	"Specify list, show, add, pin or rm.":                                       "Indiquez list, show, add, pin ou rm.",
+	"Specify an IP Address.":                                                    "Indiquez une adresse IP.",
+	"Specify the text of the note.":                                             "Indiquez le texte de la note.",
+	"Unknown note command '%s'":                                                 "Commande de note '%s' inconnue",
+	"Could not show the notification of a %s event: %s":                         "Impossible d'afficher la notification d'un événement %s : %s",
+	"Standard output is not a terminal, use -tui to start the interface anyway": "La sortie standard n'est pas un terminal, utilisez -tui pour démarrer l'interface quand même",
+	"Profiling: %s": "Profilage : %s",
+	"Invalid arguments: perf takes flags only.":                  "Arguments invalides : perf ne prend que des options.",
+	"Invalid -targets %d: Expected a positive number.":           "-targets %d invalide : un nombre positif est attendu.",
+	"Invalid -duration %s: Expected a positive duration.":        "-duration %s invalide : une durée positive est attendue.",
+	"Could not record the response: %s":                          "Impossible d'enregistrer la réponse : %s",
+	"Invalid provider URL '%s': %s":                              "URL de fournisseur '%s' invalide : %s",
+	"Invalid provider URL '%s': Missing host.":                   "URL de fournisseur '%s' invalide : l'hôte manque.",
+	"Invalid provider URL '%s': Missing socket path.":            "URL de fournisseur '%s' invalide : le chemin du socket manque.",
+	"Invalid provider URL '%s': Expected http, https or unix.":   "URL de fournisseur '%s' invalide : http, https ou unix est attendu.",
+	"Invalid arguments: The proxy takes flags only.":             "Arguments invalides : le proxy ne prend que des options.",
+	"Invalid -cache-size %d: Expected a positive size.":          "-cache-size %d invalide : une taille positive est attendue.",
+	"Invalid -client-rate '%s': %s.":                             "-client-rate '%s' invalide : %s.",
+	"No %s token, the proxy shares the free quota (see %s auth)": "Pas de jeton %s, le proxy partage le quota gratuit (voir %s auth)",
+	"Invalid recheck_interval '%s': %s":                          "recheck_interval '%s' invalide : %s",
+	"Invalid number of arguments: recheck takes none.":           "Nombre d'arguments invalide : recheck n'en prend aucun.",
+	"Invalid interval %s: Expected a positive duration.":         "Intervalle %s invalide : une durée positive est attendue.",
+	"Redis cache: %s": "Cache Redis : %s",
+	"Specify a candidates file and at most one clients file.":                   "Indiquez un fichier de candidats et au plus un fichier de clients.",
+	"Invalid format '%s': Expected md or pdf.":                                  "Format '%s' invalide : md ou pdf est attendu.",
+	"Invalid number of rows %d: Expected 0 or more.":                            "Nombre de lignes %d invalide : 0 ou plus est attendu.",
+	"Invalid number of arguments: Expected at most a name.":                     "Nombre d'arguments invalide : au plus un nom est attendu.",
+	"Unknown schema '%s': Expected one of %s.":                                  "Schéma '%s' inconnu : un de %s est attendu.",
+	"systemd passed %d sockets, serving on the first only":                      "systemd a passé %d sockets, service sur le premier seulement",
+	"systemd notification: %s":                                                  "Notification systemd : %s",
+	"OTEL_EXPORTER_OTLP_PROTOCOL %s is not supported, exporting with http/json": "OTEL_EXPORTER_OTLP_PROTOCOL %s n'est pas pris en charge, export en http/json",
+	"Telemetry: %d spans dropped":                                               "Télémétrie : %d spans perdus",
+	"Telemetry: %s":                                                             "Télémétrie : %s",
+	"Telemetry: %s answered %s: %s":                                             "Télémétrie : %s a répondu %s : %s",
+	"Could not update the Tor exits, using the list of %s: %s":                  "Impossible de mettre à jour les sorties Tor, utilisation de la liste du %s : %s",
+	"Could not keep the result: %s":                                             "Impossible de garder le résultat : %s",
+	"Giving up on webhook after %d attempts: %s":                                "Abandon du webhook après %d tentatives : %s",
+
+	// Flags of the modes
+	"Locate the entries of every prefix (-locate=false only aggregates)": "Localiser les entrées de chaque préfixe (-locate=false ne fait qu'agréger)",
+	"Print only the prefixes, one per line":                              "N'afficher que les préfixes, un par ligne",
+	"Print the prefixes as JSON":                                         "Afficher les préfixes en JSON",
+	"File used to persist lookups waiting to be retried, by default one per list of targets\nin the user cache directory, off to keep them in memory": "Fichier conservant les recherches en attente d'un nouvel essai, par défaut un par liste\nde cibles dans le répertoire de cache de l'utilisateur, off pour les garder en mémoire",
+	"Write the distances between the targets to this .json or .csv file":                                                                              "Écrire les distances entre les cibles dans ce fichier .json ou .csv",
+	"Report the nearest of the datacenters of this file (name,lat,lon or name,ip)":                                                                    "Indiquer le plus proche des centres de données de ce fichier (nom,lat,lon ou nom,ip)",
+	"Distance under which targets are clustered together":                                                                                             "Distance en dessous de laquelle les cibles sont regroupées",
+	"Comma separated providers to compare (default all but mock)":                                                                                     "Fournisseurs à comparer, séparés par des virgules (par défaut tous sauf mock)",
+	"Print the comparison as JSON": "Afficher la comparaison en JSON",
+	"Cache provider responses: memory, or redis://[:password@]host[:port][/db]\n(rediss:// for TLS) to share them with other ip411 instances": "Mettre en cache les réponses des fournisseurs : memory, ou redis://[:password@]host[:port][/db]\n(rediss:// pour TLS) pour les partager avec d'autres instances d'ip411",
+	"How long cached responses are used":                                                           "Durée d'utilisation des réponses en cache",
+	"Prefix of the cache keys, to keep apart setups sharing a server":                              "Préfixe des clés du cache, pour séparer les installations partageant un serveur",
+	"Read packets from this pcap file":                                                             "Lire les paquets de ce fichier pcap",
+	"Replay the capture this many times faster than it was captured (default all at once)":         "Rejouer la capture autant de fois plus vite qu'elle a été faite (par défaut d'un coup)",
+	"Resolve the first target with this DNS server, host:port (default the system resolver)":       "Résoudre la première cible avec ce serveur DNS, hôte:port (par défaut le résolveur du système)",
+	"Resolve the second target with this DNS server, host:port (default the system resolver)":      "Résoudre la seconde cible avec ce serveur DNS, hôte:port (par défaut le résolveur du système)",
+	"Connect to this TCP port of the target with Happy Eyeballs and show which address family won": "Se connecter à ce port TCP de la cible avec Happy Eyeballs et montrer quelle famille d'adresses l'emporte",
+	"Where to download the AS names from":                                                          "Où télécharger les noms des AS",
+	"Where to download the country borders (GeoJSON) from":                                         "Où télécharger les frontières des pays (GeoJSON)",
+	"Where to download the subdivisions of the countries (GeoJSON) from":                           "Où télécharger les subdivisions des pays (GeoJSON)",
+	"Comma separated fields of the annotations":                                                    "Champs des annotations, séparés par des virgules",
+	"Publish events to the MQTT broker at this URL (tcp://host:1883)":                              "Publier les événements sur le broker MQTT à cette URL (tcp://hôte:1883)",
+	"MQTT topic events are published to":                                                           "Sujet MQTT sur lequel les événements sont publiés",
+	"Append one JSON line per event to this file or unix:/path socket":                             "Ajouter une ligne JSON par événement à ce fichier ou socket unix:/chemin",
+	"Send events to syslog: local, udp://host:port or tcp://host:port":                             "Envoyer les événements à syslog : local, udp://hôte:port ou tcp://hôte:port",
+	"Comma separated fields kept in -jsonl and -syslog events (default all)":                       "Champs gardés dans les événements -jsonl et -syslog, séparés par des virgules (par défaut tous)",
+	"Index events into the Elasticsearch/OpenSearch cluster at this URL":                           "Indexer les événements dans le cluster Elasticsearch/OpenSearch à cette URL",
+	"Elasticsearch index name":                                                                     "Nom de l'index Elasticsearch",
+	"Elasticsearch API key (default $IP411_ES_API_KEY, else the keyring)":                          "Clé d'API Elasticsearch (par défaut $IP411_ES_API_KEY, sinon le trousseau)",
+	"Write metrics in line protocol to this InfluxDB/VictoriaMetrics write URL":                    "Écrire les métriques en line protocol à cette URL d'écriture InfluxDB/VictoriaMetrics",
+	"InfluxDB API token (default $IP411_INFLUX_TOKEN, else the keyring)":                           "Jeton d'API InfluxDB (par défaut $IP411_INFLUX_TOKEN, sinon le trousseau)",
+	"How often metrics are written":                                                                "Fréquence d'écriture des métriques",
+	"POST events to this URL":                                                                      "Envoyer les événements par POST à cette URL",
+	"text/template file rendering the webhook body (default the event as JSON)":                    "Fichier text/template rendant le corps du webhook (par défaut l'événement en JSON)",
+	"Sign webhook bodies with HMAC-SHA256 in X-Ip411-Signature\n(default $IP411_WEBHOOK_SECRET)":   "Signer les corps du webhook en HMAC-SHA256 dans X-Ip411-Signature\n(par défaut $IP411_WEBHOOK_SECRET)",
+	"Comma separated event types sent to the webhook (default all)":                                "Types d'événements envoyés au webhook, séparés par des virgules (par défaut tous)",
+	"Run this command per event, quoted as in a shell but run without one,\nplaceholders such as {ip} and {country} replaced by the fields of the event": "Lancer cette commande pour chaque événement, avec les guillemets d'un shell mais sans shell,\nles marques comme {ip} et {country} remplacées par les champs de l'événement",
+	"Comma separated event types the command is run for (default all)":                                                                                   "Types d'événements pour lesquels la commande est lancée, séparés par des virgules (par défaut tous)",
+	"How many commands run at once":                                                           "Nombre de commandes lancées en même temps",
+	"How long a command may run before it is killed":                                          "Durée pendant laquelle une commande peut tourner avant d'être tuée",
+	"Show desktop notifications: notify-send on Linux, Notification Center on macOS":          "Afficher des notifications de bureau : notify-send sous Linux, Centre de notifications sous macOS",
+	"Comma separated event types notified (default alert,ip_change)":                          "Types d'événements notifiés, séparés par des virgules (par défaut alert,ip_change)",
+	"Columns of the canvas":                                                                   "Colonnes de la toile",
+	"Rows of the canvas":                                                                      "Lignes de la toile",
+	"Draw the colored markers in ANSI colors":                                                 "Dessiner les marqueurs colorés en couleurs ANSI",
+	"Draw with ascii characters instead of braille":                                           "Dessiner en caractères ascii au lieu du braille",
+	"Compare the rendering with this file instead of printing it":                             "Comparer le rendu avec ce fichier au lieu de l'afficher",
+	"Write the rendering to the -golden file":                                                 "Écrire le rendu dans le fichier -golden",
+	"Print the diffs as JSON":                                                                 "Afficher les différences en JSON",
+	"Resolve hostnames with this DNS server, host:port (default the system resolver)":         "Résoudre les noms d'hôte avec ce serveur DNS, hôte:port (par défaut le résolveur du système)",
+	"Hostnames resolved at the same time":                                                     "Noms d'hôte résolus en même temps",
+	"Only keep k of every n addresses seen, as 1/100 (default all)":                           "Ne garder que k adresses vues sur n, comme 1/100 (par défaut toutes)",
+	"Keep at most n sightings of an address per period, as 10/s or 100/5m (default no limit)": "Garder au plus n apparitions d'une adresse par période, comme 10/s ou 100/5m (par défaut sans limite)",
+	"Most addresses waiting to be looked up, more are dropped":                                "Nombre maximal d'adresses en attente de recherche, les suivantes sont abandonnées",
+	"Inventory format: ini, yaml (Ansible), terraform, stix (2.1 bundle) or misp (CSV export)\n(default: from the file name)": "Format de l'inventaire : ini, yaml (Ansible), terraform, stix (bundle 2.1) ou misp (export CSV)\n(par défaut : d'après le nom du fichier)",
+	"Plot the exchanges shown by -ixp on the map":                                               "Placer sur la carte les points d'échange montrés par -ixp",
+	"Most addresses kept on the map, the least recently seen are forgotten":                     "Nombre maximal d'adresses gardées sur la carte, les moins récemment vues sont oubliées",
+	"Most active conversations tracked by capture, the least recently active are forgotten":     "Nombre maximal de conversations suivies par capture, les moins récemment actives sont oubliées",
+	"Most located addresses kept for stepping through":                                          "Nombre maximal d'adresses localisées gardées pour les parcourir",
+	"Keep reading as the file grows, like tail -f":                                              "Continuer la lecture à mesure que le fichier grandit, comme tail -f",
+	"How often to look for new connections":                                                     "Fréquence de recherche de nouvelles connexions",
+	"map, or jsonl to stream one JSON event per line to stdout instead":                         "map, ou jsonl pour écrire à la place un événement JSON par ligne sur la sortie standard",
+	"Markers of the synthetic dataset":                                                          "Marqueurs du jeu de données synthétique",
+	"How long to render for":                                                                    "Durée du rendu",
+	"Render in ANSI colors, as on color terminals":                                              "Rendre en couleurs ANSI, comme sur les terminaux en couleur",
+	"Render with ascii characters instead of braille":                                           "Rendre en caractères ascii au lieu du braille",
+	"How many hot paths to list":                                                                "Nombre de chemins chauds à lister",
+	"Write the CPU profile to this file, for go tool pprof":                                     "Écrire le profil CPU dans ce fichier, pour go tool pprof",
+	"Print the report as JSON":                                                                  "Afficher le rapport en JSON",
+	"Post-process every result with the process function of this Starlark file":                 "Traiter chaque résultat avec la fonction process de ce fichier Starlark",
+	"Enrich results with this program (repeatable), see -plugin-mode":                           "Enrichir les résultats avec ce programme (répétable), voir -plugin-mode",
+	"once: run plugins per lookup, line: keep them running, one JSON line per lookup":           "once : lancer les plugins à chaque recherche, line : les garder lancés, une ligne JSON par recherche",
+	"Connect to ports 22, 80, 443 and 25 of the IP Address and show what the services announce": "Se connecter aux ports 22, 80, 443 et 25 de l'adresse IP et montrer ce qu'annoncent les services",
+	"Base URL of a self-hosted ipinfo-compatible API, http(s)://host[:port] or\nunix:///path/to/socket, used by the ipinfo provider instead of ipinfo.io": "URL de base d'une API compatible ipinfo auto-hébergée, http(s)://hôte[:port] ou\nunix:///chemin/du/socket, utilisée par le fournisseur ipinfo au lieu d'ipinfo.io",
+	"Save every provider response in this directory, for -replay":                                                                                         "Enregistrer chaque réponse des fournisseurs dans ce répertoire, pour -replay",
+	"Answer lookups with the responses saved by -record instead of the provider":                                                                          "Répondre aux recherches avec les réponses enregistrées par -record au lieu du fournisseur",
+	"Make HTTPS provider requests over HTTP/3 (QUIC), falling back to TCP where it fails":                                                                 "Faire les requêtes HTTPS aux fournisseurs en HTTP/3 (QUIC), avec repli sur TCP en cas d'échec",
+	"Most answers kept in memory without a -cache server":                                                                                                 "Nombre maximal de réponses gardées en mémoire sans serveur -cache",
+	"Serve at most n lookups per period to each client, as 100/m (default no limit)":                                                                      "Servir au plus n recherches par période à chaque client, comme 100/m (par défaut sans limite)",
+	"Check an address again once its last check is older than this":                                                                                       "Vérifier de nouveau une adresse une fois sa dernière vérification plus ancienne que cela",
+	"File keeping the state of the checks":                                                                                                                "Fichier gardant l'état des vérifications",
+	"Check what is due and exit":                                                                                                                          "Vérifier ce qui est dû et quitter",
+	"Show the state of the last checks without checking":                                                                                                  "Montrer l'état des dernières vérifications sans vérifier",
+	"Also check the addresses against the DNS blocklists":                                                                                                 "Vérifier aussi les adresses dans les listes de blocage DNS",
+	"Check the addresses of the notes":                                                                                                                    "Vérifier les adresses des notes",
+	"Check the bookmarks":                                                                                                                                 "Vérifier les favoris",
+	"Check an address of each owned prefix of the config file":                                                                                            "Vérifier une adresse de chaque préfixe possédé du fichier de configuration",
+	"Also query the BGP origin of the addresses (RIPEstat)":                                                                                               "Demander aussi l'origine BGP des adresses (RIPEstat)",
+	"File of candidate server locations (name,lat,lon or name,ip)":                                                                                        "Fichier des emplacements de serveur candidats (nom,lat,lon ou nom,ip)",
+	"Format of the report: md or pdf":                                                                                                                     "Format du rapport : md ou pdf",
+	"File to write the report to, - for stdout (default ip411-report-<time>.<format>)":                                                                    "Fichier où écrire le rapport, - pour la sortie standard (par défaut ip411-report-<heure>.<format>)",
+	"Title of the report": "Titre du rapport",
+	"Rows of the country and ASN breakdowns, the rest summed up (0 for all)": "Lignes des répartitions par pays et par ASN, le reste additionné (0 pour toutes)",
+	"Address to serve on, unless systemd passes a socket":                    "Adresse d'écoute, sauf si systemd passe un socket",
+	"Switch to this user once the socket is bound (when started as root)":    "Passer à cet utilisateur une fois le socket lié (si lancé en root)",
+	"Serve net/http/pprof on this address, as localhost:6060 (default off)":  "Servir net/http/pprof sur cette adresse, comme localhost:6060 (désactivé par défaut)",
+	"How often to locate the IP Address again":                               "Fréquence de nouvelle localisation de l'adresse IP",
+	"Keep every result, for ip411 history to compare":                        "Garder chaque résultat, pour que ip411 history les compare",
+
+	// Usage of the modes
+	aggregateHelp: `Agréger les adresses IP et les préfixes CIDR de file (ou de l'entrée
+standard), un par ligne, en le moins de préfixes couvrant exactement les
+mêmes adresses, avec les pays et l'organisation principale des entrées de
+chaque préfixe :
+`,
+	batchHelp: `Localiser et placer une adresse IP, un nom d'hôte ou lat,lon par ligne
+(ou enregistrement CSV) de file, ou de l'entrée standard si aucun fichier
+n'est donné. Les recherches échouées sont réessayées en arrière-plan. Les
+noms d'hôte sont résolus en parallèle et peuvent contenir des plages
+numériques : web[01-20].example.com désigne web01 à web20.
+`,
+	benchHelp: `Rechercher les adresses IP de file (ou d'un échantillon intégré d'adresses
+connues) avec chaque fournisseur, et comparer leur latence, leur taux
+d'erreur et le nombre de champs communs qu'ils remplissent.
+`,
+	calcHelp: `Calcul sur les IP : tester quelles adresses ou quels préfixes sont dans un
+préfixe (en échouant si l'un n'y est pas), convertir une plage en préfixes,
+donner le n-ième préfixe suivant ou précédent de même longueur, ou n
+adresses aléatoires d'un préfixe.
+`,
+	captureHelp: `Localiser et placer les extrémités publiques des paquets d'un fichier pcap
+(tcpdump -w), classées par octets et par paquets dans le tableau des gros
+interlocuteurs. La capture en direct n'est pas prise en charge : capturez
+avec tcpdump, puis lisez le fichier.
+`,
+	compareHelp: `Localiser deux cibles, ou une et l'adresse IP du client, et les montrer sur
+la carte comme A et B avec leurs champs d'info côte à côte, en marquant (*)
+ceux qui diffèrent. Une cible peut être résolue avec son propre serveur DNS.
+`,
+	dbHelp: `Télécharger les jeux de données hors ligne : les noms et les pays des
+numéros d'AS, utilisés pour les fournisseurs ne donnant que le numéro d'AS
+et pour les origines de -bgp, les frontières des pays, remplis par <C> dans
+les modes par lot, et celles de leurs États et provinces, dessinées en
+zoomant sur un pays et comptées par le panneau de statistiques.
+`,
+	enrichHelp: `Copier file (ou l'entrée standard) sur la sortie standard, en annotant
+chaque adresse IP publique avec sa recherche, comme dans 1.2.3.4[DE,
+Hetzner Online GmbH]. Le reste est laissé tel quel, pour que enrich puisse
+se placer dans n'importe quel tube :
+`,
+	renderFixtureHelp: `Dessiner la carte des résultats de fixture (un tableau JSON) sur une toile
+de dimensions fixes, ou la comparer à un fichier de référence.
+`,
+	groupHelp: `Localiser et placer les cibles des groupes nommés du fichier de
+configuration, ou de tous les groupes si aucun n'est nommé, chaque groupe
+dans sa couleur.
+`,
+	historyHelp: `Comparer les résultats gardés par le mode watch. Sans arguments, lister les
+adresses ayant un historique. list montre les instantanés de ip, diff les
+champs qui ont changé entre les instantanés i et j (j étant par défaut le
+dernier), ou entre chaque instantané et le précédent.
+`,
+	importHelp: `Localiser et placer les hôtes publics d'inventaires Ansible ou de fichiers
+d'état Terraform, étiquetés par leurs noms, ou les adresses IP d'indicateurs
+STIX 2.1 ou d'attributs MISP, colorés par étiquette. <E> les exporte en
+retour comme bundle STIX ou CSV MISP.
+`,
+	authHelp: `Enregistrer le jeton d'API d'un fournisseur dans le trousseau du système,
+lu sur l'entrée standard, ou le supprimer. Les fournisseurs et les
+variables d'environnement remplaçant leur jeton :
+`,
+	logsHelp: `Localiser et placer chaque adresse IP publique trouvée dans un fichier de
+journal, ou dans l'entrée standard si aucun fichier n'est donné.
+`,
+	mailHelp: `Vérifier si ip peut envoyer du courrier (pour domain) : DNS inverse
+confirmé, l'enregistrement SPF de domain et les listes de blocage DNS.
+Quand la sortie standard n'est pas un terminal le résumé est affiché, et
+une vérification échouée sort avec 5.
+`,
+	monitorHelp: `Localiser et placer l'extrémité distante de chaque connexion TCP de cet
+hôte, à mesure que les connexions vont et viennent.
+`,
+	noteHelp: `Garder des notes de dossier sur des adresses IP, montrées chaque fois
+qu'elles sont de nouveau recherchées :
+
+  list               toutes les notes
+  show ip            la note de ip et son résultat épinglé
+  add ip text...     attacher une note à ip, remplaçant celle qu'elle avait
+  pin ip [text...]   rechercher ip et épingler son résultat, avec une note
+  rm ip              supprimer la note de ip
+`,
+	perfHelp: `Rendre la carte d'un grand jeu de données synthétique de façon répétée, et
+indiquer les images par seconde, les allocations par image et les chemins
+chauds.
+`,
+	providersHelp: `Lister les fournisseurs de géolocalisation et les champs que chacun prend
+en charge.
+`,
+	proxyHelp: `Servir l'API ipinfo à une équipe avec le jeton de cet hôte, en mettant les
+réponses en cache et en limitant le débit des clients. Dirigez les clients
+vers lui avec -provider-url http://hôte:8080, voir /stats pour les
+statistiques du cache et /healthz et /readyz pour les sondes. SIGTERM
+termine les recherches en cours. Avec -cache redis://hôte le cache est
+partagé avec d'autres proxys et ip411.
+`,
+	recheckHelp: `Localiser de nouveau les adresses des notes, des favoris et des préfixes
+possédés une fois leur dernière vérification plus ancienne que l'intervalle,
+en signalant (!) celles dont la position, l'organisation, le nom d'hôte,
+l'origine BGP ou les inscriptions sur les listes de blocage ont changé.
+-alert ownership alerte sur les préfixes possédés attribués à un autre AS
+ou à une autre organisation.
+`,
+	regionHelp: `Localiser les clients listés dans file (ou l'entrée standard), puis comparer
+la distance de chaque emplacement candidat à eux, meilleure distance
+médiane d'abord. Les latences sont estimées d'après la distance.
+`,
+	reportHelp: `Localiser les adresses listées dans file (ou l'entrée standard) et écrire
+un rapport horodaté pour les audits : répartition par pays et par ASN,
+régions de conformité (UE/EEE, Royaume-Uni, adéquation, lois des États
+américains sur la vie privée), une note de méthodologie et une carte des
+positions.
+`,
+	geoHelp: `Afficher la ville, le pays et la région les plus proches de positions,
+d'après une liste intégrée des grandes villes.
+`,
+	schemaHelp: `Afficher le schéma JSON (version %s) des sorties JSON, ou seulement de l'une
+d'elles : %s
+`,
+	torHelp: `Placer chaque relais de sortie Tor actif, en rafraîchissant la liste toutes
+les heures.
+`,
+	watchHelp: `Localiser ip à chaque intervalle. Sans ip, l'adresse IP publique du client
+est surveillée et un événement ip_change est publié quand elle change.
+Chaque résultat est gardé pour que ip411 history montre ce qui a changé
+entre eux.
+`,
+
+	usageHelp: `Appuyez sur <C+c> pour quitter, <i> pour choisir les champs du panneau
+d'info, <b> pour lister les favoris du fichier de configuration, <h> pour
+choisir un hôte de /etc/hosts ou ~/.ssh/config, <c> pour montrer les câbles
+sous-marins, <r> les dorsales terrestres, <t> les relais de sortie Tor, <z>
+les fuseaux horaires et celui de la cible, <+>/<-> pour zoomer (les flèches
+déplacent, <0> réinitialise), <x> pour un réticule nommant le lieu visé, <y>
+pour copier l'adresse affichée dans le presse-papiers, <p> pour épingler le
+résultat avec une note, <l> pour localiser une autre cible, <L> pour déplier
+la légende des marqueurs, <u>/<C+r> pour annuler/rétablir zoom, déplacements,
+couches et filtres
+
+Arguments :
+  -h: Afficher ce message
+  ip: Adresse IP, nom d'hôte, @favori ou lat,lon facultatif à localiser et
+      placer (après -- si la latitude est négative).
+      Sans argument, l'adresse IP du client est utilisée
+  -field: Afficher des champs du résultat (chemins comme bgp.prefix) au lieu
+      de la carte, un par ligne ou avec -tabs sur une seule ligne
+  -accessible: Décrire le résultat en phrases pour les lecteurs d'écran au
+      lieu de dessiner la carte, et dans watch ce qui change
+  -region: Garder la carte sur une région (europe, na, apac...) ou un cadre
+      minLon,minLat,maxLon,maxLat, en ne chargeant que ses côtes
+  -precision: Décimales des coordonnées partout, 2 (environ un km) par défaut,
+      moins pour flouter les positions (0 : environ 111 km)
+  Quand la sortie standard n'est pas un terminal, le résultat est affiché en JSON, sauf avec -tui.
+  Seules les données vont sur stdout, avertissements et erreurs sur stderr (-quiet pour les erreurs seules)
+  batch: Localiser et placer chaque adresse IP ou nom d'hôte (web[01-20].example.com) lu dans un fichier (ou stdin)
+  watch: Localiser ip (ou l'adresse IP du client) à chaque intervalle
+  history: Montrer les champs qui ont changé entre les résultats gardés par watch
+  compare: Montrer deux cibles, ou une et l'IP du client, côte à côte
+  monitor: Localiser et placer les pairs des connexions TCP de cet hôte
+  logs: Localiser et placer les adresses IP d'un fichier de journal (ou stdin)
+  capture: Localiser et placer les extrémités des paquets d'un fichier pcap
+  group: Placer les groupes de cibles du fichier de configuration, une couleur par groupe
+  import: Placer les hôtes d'inventaires Ansible ou d'états Terraform, ou des indicateurs STIX et MISP
+  geo: Afficher la ville, le pays et la région les plus proches de positions
+  region: Classer des emplacements de serveurs candidats par distance à une liste de clients
+  report: Écrire un rapport Markdown ou PDF d'une liste d'adresses pour les audits
+  enrich: Copier du texte sur stdout en annotant les adresses IP, 1.2.3.4[DE, Hetzner]
+  aggregate: Regrouper des adresses IP et préfixes dans le moins de CIDR possible
+  calc: Calcul sur les IP : appartenance, plage en préfixes, sous-réseau suivant, échantillonnage
+  auth: Enregistrer un jeton d'API (ipinfo, ipapi, es, influx) dans le trousseau du système, ou le retirer
+  providers: Lister les fournisseurs de géolocalisation et les champs qu'ils fournissent
+  proxy: Servir l'API ipinfo à une équipe avec un seul jeton, avec un cache
+  bench: Comparer latence, erreurs et champs des fournisseurs sur un échantillon
+  perf: Mesurer la fréquence d'images, les allocations et les chemins chauds du rendu d'une grande carte
+  db: Télécharger les données hors ligne (noms d'AS)
+  tor: Placer les relais de sortie Tor en service
+  mail: Vérifier DNS inverse, SPF et listes de blocage d'un expéditeur de courriel
+  note: Tenir des notes d'enquête sur des adresses IP, montrées à chaque nouvelle recherche
+  recheck: Relocaliser régulièrement les adresses notées, les favoris et les préfixes possédés, en
+      signalant les changements et les préfixes attribués à un autre AS (-alert ownership)
+  schema: Afficher le schéma JSON des sorties JSON
+  Dans batch, logs, monitor, group et import, <s> montre des statistiques par pays, ASN et organisation
+  et <C> colore les pays selon leurs adresses ou occurrences (après ip411 db update)
+  Zoomée sur un pays, la carte montre ses États ou provinces (après ip411 db update)
+  Les adresses partageant une case de la carte sont empilées sous une *, le
+  réticule <x> posé dessus les liste
+  Dans logs, monitor et capture, <T> montre les plus gros interlocuteurs, triables par requêtes, octets et paquets
+  et <m> dimensionne les marqueurs selon l'une de ces mesures, <w> limite tout aux
+  5 dernières minutes ou à la dernière heure, <espace> met en pause et <[>/<]> parcourent
+  les adresses localisées
+  <f> filtre les résultats par une expression (country == "RU") et </> y
+  cherche, <n>/<N> passant d'une correspondance à l'autre. <B> les ajoute tous
+  aux favoris et <F> écrit des règles nftables, iptables, ufw ou de groupe de
+  sécurité AWS pour les préfixes affichés dans un fichier à relire, <E> les
+  exporte en adresses, en préfixes CIDR regroupés, en commandes fail2ban, en
+  bundle STIX ou en CSV MISP
+  Les résultats sont étiquetés sous compliance par eu, eea, uk, adequacy
+  (décision d'adéquation de l'UE) et us_privacy_law (État américain doté d'une
+  loi sur la vie privée), pour les filtres et les scripts ("eea" in compliance)
+  Les options de destination (-mqtt, -jsonl, -syslog, -es, -influx, -webhook,
+  -exec, -notify) publient des événements, voir <mode> -h
+  Les options d'alerte (-alert, -bell) font clignoter les marqueurs des adresses
+  qui répondent à des règles d'alerte dans logs, monitor et capture, et
+  publient des événements d'alerte. Les règles à seuil ("alerts" du fichier
+  de configuration) comptent les occurrences des adresses concernées sur une
+  fenêtre et peuvent faire sonner le terminal ou lancer une commande
+  Les options de limite (-max-targets, -max-flows, -max-playback) plafonnent
+  ce que gardent logs, monitor et capture, en oubliant d'abord le moins récent
+`,
+
+	exitCodeHelp: `Codes de sortie :
+  0  succès
+  1  toute autre erreur
+  2  entrée invalide : arguments, options ou cibles
+  3  échec de la recherche
+  4  limité : le quota du fournisseur est épuisé (voir -max-wait)
+  5  violation de politique : le résultat a été écarté par le -script
+`,
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+/*
+translated - The functions translating one of their arguments, by the index
+of the argument
+*/
+var translated = map[string]int{
+	"tr":            0,
+	"invalidInput":  0,
+	"warnf":         0,
+	"compactLabel":  0,
+	"guiShowStatus": 1,
+	"inputDialog":   1,
+	"selectDialog":  1,
+	"confirmDialog": 2,
+}
+
+/*
+flagUsage - The index of the usage among the arguments of the FlagSet methods
+defining a flag
+*/
+var flagUsage = map[string]int{
+	"String": 2, "Bool": 2, "Int": 2, "Int64": 2, "Uint": 2, "Float64": 2, "Duration": 2,
+	"StringVar": 3, "BoolVar": 3, "IntVar": 3, "Int64Var": 3, "UintVar": 3,
+	"Float64Var": 3, "DurationVar": 3, "Var": 2, "Func": 1,
+}
+
+var verbs = regexp.MustCompile(`%[-+# 0-9.*]*[a-zA-Z%]`)
+
+/*
+stringValue - The value of a constant string expression: literals, their
+concatenations and the string constants of consts
+*/
+func stringValue(e ast.Expr, consts map[string]string) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			s, err := strconv.Unquote(e.Value)
+			return s, err == nil
+		}
+	case *ast.Ident:
+		s, ok := consts[e.Name]
+		return s, ok
+	case *ast.ParenExpr:
+		return stringValue(e.X, consts)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			x, ok := stringValue(e.X, consts)
+			y, ok2 := stringValue(e.Y, consts)
+			return x + y, ok && ok2
+		}
+	}
+	return "", false
+}
+
+func TestCatalogFR(t *testing.T) {
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	consts := map[string]string{}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+				for _, spec := range gen.Specs {
+					spec := spec.(*ast.ValueSpec)
+					for i, name := range spec.Names {
+						if i < len(spec.Values) {
+							if s, ok := stringValue(spec.Values[i], nil); ok {
+								consts[name.Name] = s
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			arg := -1
+			switch fn := call.Fun.(type) {
+			case *ast.Ident:
+				if i, ok := translated[fn.Name]; ok {
+					arg = i
+				}
+			case *ast.SelectorExpr:
+				if i, ok := flagUsage[fn.Sel.Name]; ok {
+					if x, ok := fn.X.(*ast.Ident); ok && (x.Name == "flag" || x.Name == "flags") {
+						arg = i
+					}
+				}
+			}
+			if arg < 0 || arg >= len(call.Args) {
+				return true
+			}
+			msg, ok := stringValue(call.Args[arg], consts)
+			if !ok || !strings.ContainsFunc(verbs.ReplaceAllString(msg, ""), unicode.IsLetter) {
+				// Nothing to translate in "%s: %s"
+				return true
+			}
+			fr, ok := catalogFR[msg]
+			if !ok {
+				t.Errorf("%s: no French for %q", fset.Position(call.Pos()), msg)
+			} else if want, got := verbs.FindAllString(msg, -1), verbs.FindAllString(fr, -1); !reflect.DeepEqual(want, got) {
+				t.Errorf("%s: French for %q has the verbs %v, expected %v", fset.Position(call.Pos()), msg, got, want)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+Indicator sets of threat intelligence come in and go out through the import
+mode and the export prompt:
+
+	ip411 import -format stix bundle.json   indicators of a STIX 2.1 bundle
+	ip411 import -format misp event.csv     attributes of a MISP CSV export
+
+Their IP Addresses are plotted as a layer per tag (the labels of STIX
+indicators, the attribute tags of MISP), in the colors of the groups. E then
+writes the targets shown back out with "stix file" or "misp file", described
+with their country, org, tag and note (see notes.go).
+*/
+
+var stixAddress = regexp.MustCompile(`ipv[46]-addr:value\s*=\s*'([^']+)'`)
+
+/*
+indicatorTarget - The IP Address of an indicator value, "" unless it is a
+single address (1.2.3.4 or 1.2.3.4/32)
+*/
+func indicatorTarget(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, "/"); i >= 0 {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return ""
+		}
+		if ones, bits := network.Mask.Size(); ones != bits {
+			return ""
+		}
+		value = value[:i]
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func firstTag(tags []string) string {
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+/*
+readSTIX - The addresses of the indicators (of their patterns) and of the
+ipv4-addr and ipv6-addr objects of a STIX 2.1 bundle
+*/
+func readSTIX(r io.Reader) ([]InventoryHost, error) {
+	var bundle struct {
+		Type    string `json:"type"`
+		Objects []struct {
+			Type           string   `json:"type"`
+			Pattern        string   `json:"pattern"`
+			PatternType    string   `json:"pattern_type"`
+			Value          string   `json:"value"`
+			Labels         []string `json:"labels"`
+			IndicatorTypes []string `json:"indicator_types"`
+		} `json:"objects"`
+	}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("Could not read STIX bundle: %s", err)
+	}
+	if bundle.Type != "bundle" {
+		return nil, fmt.Errorf("Could not read STIX bundle: Expected type bundle, got '%s'", bundle.Type)
+	}
+
+	var hosts []InventoryHost
+	for _, obj := range bundle.Objects {
+		tag := firstTag(append(obj.Labels, obj.IndicatorTypes...))
+		switch obj.Type {
+		case "indicator":
+			if obj.PatternType != "" && obj.PatternType != "stix" {
+				continue
+			}
+			for _, m := range stixAddress.FindAllStringSubmatch(obj.Pattern, -1) {
+				if target := indicatorTarget(m[1]); target != "" {
+					hosts = append(hosts, InventoryHost{Target: target, Group: tag})
+				}
+			}
+		case "ipv4-addr", "ipv6-addr":
+			if target := indicatorTarget(obj.Value); target != "" {
+				hosts = append(hosts, InventoryHost{Target: target, Group: tag})
+			}
+		}
+	}
+	return hosts, nil
+}
+
+// MISP attribute types holding an address, and which part of "a|b" it is
+var mispAddressTypes = map[string]int{
+	"ip-src": 0, "ip-dst": 0, "ip-src|port": 0, "ip-dst|port": 0,
+	"domain|ip": 1,
+}
+
+/*
+readMISPCSV - The addresses of the attributes of a MISP CSV export, which
+has a header naming at least its type and value columns
+*/
+func readMISPCSV(r io.Reader) ([]InventoryHost, error) {
+	reader := newCSVReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read MISP CSV: %s", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	typeCol, ok1 := columns["type"]
+	valueCol, ok2 := columns["value"]
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("Could not read MISP CSV: Expected type and value columns")
+	}
+	tagCol, hasTags := columns["attribute_tag"]
+	if !hasTags {
+		tagCol, hasTags = columns["tags"]
+	}
+
+	var hosts []InventoryHost
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not read MISP CSV: %s", err)
+		}
+		if typeCol >= len(record) || valueCol >= len(record) {
+			continue
+		}
+		part, ok := mispAddressTypes[record[typeCol]]
+		if !ok {
+			continue
+		}
+		parts := strings.Split(record[valueCol], "|")
+		if part >= len(parts) {
+			continue
+		}
+		target := indicatorTarget(parts[part])
+		if target == "" {
+			continue
+		}
+		host := InventoryHost{Target: target}
+		if hasTags && tagCol < len(record) {
+			host.Group = firstTag(strings.Split(record[tagCol], ","))
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+/*
+sniffJSON - The inventory format of a JSON file from its start: stix for a
+bundle, terraform otherwise
+*/
+func sniffJSON(r io.Reader) string {
+	var top struct {
+		Type string `json:"type"`
+	}
+	if json.NewDecoder(r).Decode(&top) == nil && top.Type == "bundle" {
+		return "stix"
+	}
+	return "terraform"
+}
+
+// Export
+
+/*
+newUUID - A random (version 4) UUID
+*/
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+/*
+indicatorComment - What is known about a target: its country, org and note,
+and where that came from. Must be called with b.mu held.
+*/
+func (b *Batch) indicatorComment(target string) string {
+	ipinfo := b.results[target]
+	var parts []string
+	for _, field := range []string{"country", "org", "note.text"} {
+		if value := fieldValue(ipinfo, field); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if provider := fieldValue(ipinfo, "provenance.provider"); provider != "" {
+		via := "via " + provider
+		if fieldValue(ipinfo, "provenance.cache") == "hit" {
+			via += " (cached)"
+		}
+		parts = append(parts, via+" "+fieldValue(ipinfo, "provenance.queried_at"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+/*
+indicatorAddress - The IP Address of a shown target, "" if it has none.
+Must be called with b.mu held.
+*/
+func (b *Batch) indicatorAddress(target string) string {
+	if ip := net.ParseIP(fieldValue(b.results[target], "ip")); ip != nil {
+		return ip.String()
+	}
+	return indicatorTarget(target)
+}
+
+/*
+exportSTIX - The shown targets as the indicators of a STIX 2.1 bundle. Must
+be called with b.mu held.
+*/
+func (b *Batch) exportSTIX() (string, int, error) {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	objects := []map[string]interface{}{}
+	for _, target := range b.shown() {
+		ip := b.indicatorAddress(target)
+		if ip == "" {
+			continue
+		}
+		kind := "ipv4-addr"
+		if strings.Contains(ip, ":") {
+			kind = "ipv6-addr"
+		}
+		indicator := map[string]interface{}{
+			"type":         "indicator",
+			"spec_version": "2.1",
+			"id":           "indicator--" + newUUID(),
+			"created":      now,
+			"modified":     now,
+			"name":         ip,
+			"pattern":      fmt.Sprintf("[%s:value = '%s']", kind, ip),
+			"pattern_type": "stix",
+			"valid_from":   now,
+		}
+		if comment := b.indicatorComment(target); comment != "" {
+			indicator["description"] = comment
+		}
+		if tag := b.groupOf[target]; tag != "" {
+			indicator["labels"] = []string{tag}
+		}
+		objects = append(objects, indicator)
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"type":    "bundle",
+		"id":      "bundle--" + newUUID(),
+		"objects": objects,
+	}, "", "  ")
+	if err != nil {
+		return "", 0, err
+	}
+	return string(data) + "\n", len(objects), nil
+}
+
+// Columns of the CSV exports of MISP
+var mispHeader = []string{"uuid", "event_id", "category", "type", "value", "comment",
+	"to_ids", "date", "object_relation", "attribute_tag", "object_uuid", "object_name",
+	"object_meta_category"}
+
+/*
+exportMISP - The shown targets as ip-dst attributes of a MISP CSV export.
+Must be called with b.mu held.
+*/
+func (b *Batch) exportMISP() (string, int, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(mispHeader)
+	date := fmt.Sprint(time.Now().Unix())
+	n := 0
+	for _, target := range b.shown() {
+		ip := b.indicatorAddress(target)
+		if ip == "" {
+			continue
+		}
+		w.Write([]string{newUUID(), "", "Network activity", "ip-dst", ip,
+			b.indicatorComment(target), "1", date, "", b.groupOf[target], "", "", ""})
+		n++
+	}
+	w.Flush()
+	return buf.String(), n, w.Error()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+asnFromOrg - Extract the AS number from an ipinfo org field
+("AS15169 Google LLC" gives "AS15169")
+*/
+func asnFromOrg(org string) string {
+	if !strings.HasPrefix(org, "AS") {
+		return ""
+	}
+	return strings.SplitN(org, " ", 2)[0]
+}
+
+type influxSeries struct {
+	country string
+	asn     string
+}
+
+type influxCounter struct {
+	count int
+	rtt   time.Duration
+}
+
+/*
+InfluxSink - Aggregates lookup events and writes per-interval metrics in
+InfluxDB line protocol, which both InfluxDB (/write, /api/v2/write) and
+VictoriaMetrics accept:
+
+	ip411_lookups,country=DE,asn=AS3320 count=4i,rtt_ms=81.2 <ts>
+	ip411_summary lookups=10i,unique_countries=3i,unique_asns=5i,rtt_ms=77.0 <ts>
+*/
+type InfluxSink struct {
+	mu        sync.Mutex
+	url       string
+	token     string
+	client    *http.Client
+	series    map[influxSeries]*influxCounter
+	countries map[string]bool
+	asns      map[string]bool
+	done      chan struct{}
+}
+
+/*
+NewInfluxSink - Create a sink writing to the line protocol endpoint at url
+every interval. token, if set, is sent as an InfluxDB v2 API token.
+*/
+func NewInfluxSink(url, token string, interval time.Duration) (*InfluxSink, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("Invalid InfluxDB write URL '%s'", url)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("Invalid InfluxDB write interval %s", interval)
+	}
+	s := &InfluxSink{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+		done:   make(chan struct{}),
+	}
+	s.reset()
+	go s.writeLoop(interval)
+	return s, nil
+}
+
+func (s *InfluxSink) reset() {
+	s.series = make(map[influxSeries]*influxCounter)
+	s.countries = make(map[string]bool)
+	s.asns = make(map[string]bool)
+}
+
+/*
+Publish - Count a lookup event towards the current interval
+*/
+func (s *InfluxSink) Publish(e Event) error {
+	if e.Type != EventLookup {
+		return nil
+	}
+	org, _ := e.Result.GetKey("org")
+	key := influxSeries{country: e.Country, asn: asnFromOrg(org)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.series[key]
+	if !ok {
+		c = &influxCounter{}
+		s.series[key] = c
+	}
+	c.count++
+	c.rtt += e.RTT
+	if key.country != "" {
+		s.countries[key.country] = true
+	}
+	if key.asn != "" {
+		s.asns[key.asn] = true
+	}
+	return nil
+}
+
+func influxEscape(s string) string {
+	return strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`).Replace(s)
+}
+
+func avgMillis(total time.Duration, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return float64(total) / float64(n) / float64(time.Millisecond)
+}
+
+/*
+lines - Line protocol for the current interval, resetting the counters
+*/
+func (s *InfluxSink) lines(now time.Time) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf bytes.Buffer
+	if len(s.series) == 0 {
+		return nil
+	}
+
+	keys := make([]influxSeries, 0, len(s.series))
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.series[keys[i]].count > s.series[keys[j]].count
+	})
+
+	total := 0
+	var rtt time.Duration
+	for _, key := range keys {
+		c := s.series[key]
+		total += c.count
+		rtt += c.rtt
+
+		buf.WriteString("ip411_lookups")
+		if key.country != "" {
+			buf.WriteString(",country=" + influxEscape(key.country))
+		}
+		if key.asn != "" {
+			buf.WriteString(",asn=" + influxEscape(key.asn))
+		}
+		fmt.Fprintf(&buf, " count=%di,rtt_ms=%.1f %d\n", c.count,
+			avgMillis(c.rtt, c.count), now.UnixNano())
+	}
+	fmt.Fprintf(&buf, "ip411_summary lookups=%di,unique_countries=%di,"+
+		"unique_asns=%di,rtt_ms=%.1f %d\n", total, len(s.countries), len(s.asns),
+		avgMillis(rtt, total), now.UnixNano())
+
+	s.reset()
+	return buf.Bytes()
+}
+
+func (s *InfluxSink) write() error {
+	body := s.lines(time.Now())
+	if body == nil {
+		return nil
+	}
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("InfluxDB write failed with status %d: %s",
+			resp.StatusCode, data)
+	}
+	return nil
+}
+
+func (s *InfluxSink) writeLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.write(); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+/*
+Close - Write the metrics of the last, partial interval
+*/
+func (s *InfluxSink) Close() error {
+	close(s.done)
+	return s.write()
+}
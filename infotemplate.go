@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+/*
+InfoData - What an info pane template is rendered with. Besides the common
+fields, .Result holds the whole result (.Field gives nested fields by
+path) and a few values are computed. DistanceKm is measured from the home
+location of the config file.
+
+	{{.City}}, {{.Country}} ({{.Org}})
+	{{if .HasDistance}}{{distance .DistanceKm}} from home{{end}}
+	Local time: {{.LocalTime.Format "15:04 MST"}}
+	ASN name: {{.Field "asn.name"}}
+*/
+type InfoData struct {
+	IP       string
+	Hostname string
+	City     string
+	Region   string
+	Country  string
+	Postal   string
+	Org      string
+	Timezone string
+	Lat      float64
+	Lon      float64
+
+	HasLocation bool
+	HasDistance bool
+	DistanceKm  float64
+	LocalTime   time.Time
+
+	Result IPInfoResult
+}
+
+var (
+	infoTemplate      *template.Template
+	infoTemplateLines int
+)
+
+/*
+NewInfoData - Compute the template data of res
+*/
+func NewInfoData(res IPInfoResult) InfoData {
+	d := InfoData{Result: res, LocalTime: time.Now()}
+	d.IP = fieldValue(res, "ip")
+	d.Hostname = fieldValue(res, "hostname")
+	d.City = fieldValue(res, "city")
+	d.Region = fieldValue(res, "region")
+	d.Country = fieldValue(res, "country")
+	d.Postal = fieldValue(res, "postal")
+	d.Org = fieldValue(res, "org")
+	d.Timezone = fieldValue(res, "timezone")
+
+	if lon, lat, err := res.GetLonLat(); err == nil {
+		d.Lat, d.Lon, d.HasLocation = lat, lon, true
+		if config.Home != nil {
+			d.HasDistance = true
+			d.DistanceKm = distanceKm(*config.Home, Point{Lat: lat, Lon: lon})
+		}
+	}
+	if d.Timezone != "" {
+		if loc, err := time.LoadLocation(d.Timezone); err == nil {
+			d.LocalTime = d.LocalTime.In(loc)
+		}
+	}
+	return d
+}
+
+/*
+Field - Display string of the field at a dotted path of the result
+*/
+func (d InfoData) Field(path string) string {
+	return fieldValue(d.Result, path)
+}
+
+var infoFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"round": func(v float64, places int) float64 {
+		p := math.Pow(10, float64(places))
+		return math.Round(v*p) / p
+	},
+	// In the format of the locale, see locale.go
+	"number":   formatNumber,
+	"distance": formatDistance,
+	"coords":   formatCoords,
+}
+
+/*
+loadInfoTemplate - Render the info pane with the text/template at path
+instead of the field list
+*/
+func loadInfoTemplate(path string) error {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("info").Funcs(infoFuncs).Parse(string(text))
+	if err != nil {
+		return err
+	}
+	infoTemplate = tmpl
+	infoTemplateLines = strings.Count(strings.TrimRight(string(text), "\n"), "\n") + 1
+	return nil
+}
+
+/*
+executeInfoTemplate - Render res with the info template
+*/
+func executeInfoTemplate(res IPInfoResult) (string, error) {
+	var buf bytes.Buffer
+	err := infoTemplate.Execute(&buf, NewInfoData(res))
+	return buf.String(), err
+}
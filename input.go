@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+Targets can be given as IP Addresses, hostnames, @bookmarks or "lat,lon"
+coordinates, on the command line as in input files. Coordinates are plotted
+as they are, placed by reverse geocoding (see reverse.go) instead of a
+lookup. Input lines may also be CSV records, of which the first column is
+the target (or the first two, for coordinates).
+
+Targets pasted from elsewhere are cleaned up: 1.2.3.4:443 and
+https://example.com/path stand for their host, [2001:db8::1] for the
+address. IPv6 Addresses may carry a zone (fe80::1%eth0), which a lookup
+ignores.
+*/
+
+/*
+normalizeTarget - The target of an input line: "lat,lon" coordinates with
+their spaces removed, else the first CSV column
+*/
+func normalizeTarget(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.Contains(line, ",") {
+		return cleanTarget(line)
+	}
+
+	record, err := newCSVReader(strings.NewReader(line)).Read()
+	if err != nil || len(record) == 0 {
+		return line
+	}
+	if len(record) >= 2 {
+		pair := record[0] + "," + record[1]
+		if p, err := parseLatLon(pair); err == nil {
+			return formatLatLon(p)
+		}
+	}
+	return cleanTarget(record[0])
+}
+
+/*
+cleanTarget - target without what tends to be pasted along with it:
+whitespace (zero-width included), the URL around a host, the port after it
+and the brackets around an IPv6 Address
+*/
+func cleanTarget(target string) string {
+	target = strings.TrimFunc(target, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '\u200b' || r == '\ufeff'
+	})
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil && u.Host != "" {
+			target = u.Host
+		}
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil && host != "" {
+		target = host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+}
+
+/*
+parseTargetIP - The IP Address of target, which may carry an IPv6 zone
+(fe80::1%eth0), nil if it is not one
+*/
+func parseTargetIP(target string) net.IP {
+	if i := strings.LastIndexByte(target, '%'); i > 0 && strings.Contains(target[:i], ":") {
+		target = target[:i]
+	}
+	return net.ParseIP(target)
+}
+
+/*
+correctedIP - The IPv4 Address target stands for with commas or spaces
+between the numbers, O for 0 or l for 1, "" if none
+*/
+func correctedIP(target string) string {
+	fixed := strings.NewReplacer(",", ".", " ", ".", ";", ".", "O", "0", "o", "0",
+		"l", "1", "I", "1").Replace(target)
+	if fixed != target && net.ParseIP(fixed) != nil && strings.Count(fixed, ".") == 3 {
+		return fixed
+	}
+	return ""
+}
+
+/*
+suggestIP - What is wrong with target if it was meant as an IPv4 Address,
+with the address it likely stands for, "" if it does not look like one
+*/
+func suggestIP(target string) string {
+	if fixed := correctedIP(target); fixed != "" {
+		return fmt.Sprintf(tr("Did you mean %s?"), fixed)
+	}
+	if strings.Trim(target, "0123456789.") != "" || !strings.Contains(target, ".") {
+		return ""
+	}
+
+	parts := strings.Split(target, ".")
+	if len(parts) != 4 {
+		return fmt.Sprintf(tr("An IPv4 Address has 4 numbers, not %d."), len(parts))
+	}
+	trimmed := make([]string, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return tr("A number is missing.")
+		}
+		if n > 255 {
+			return fmt.Sprintf(tr("%d is out of range, the numbers go from 0 to 255."), n)
+		}
+		trimmed[i] = strconv.Itoa(n)
+	}
+	// Leading zeros, which some tools read as octal
+	return fmt.Sprintf(tr("Did you mean %s?"), strings.Join(trimmed, "."))
+}
+
+func formatLatLon(p Point) string {
+	return fmt.Sprintf("%g,%g", p.Lat, p.Lon)
+}
+
+/*
+targetPoint - The location of a "lat,lon" target
+*/
+func targetPoint(target string) (Point, bool) {
+	if !strings.Contains(target, ",") {
+		return Point{}, false
+	}
+	p, err := parseLatLon(target)
+	return p, err == nil
+}
+
+/*
+pointResult - Result for coordinates, in the shape of an ipinfo result
+*/
+func pointResult(p Point) IPInfoResult {
+	place := reverseGeocode(p)
+	return IPInfoResult{
+		"loc":     formatLoc(p.Lat, p.Lon),
+		"city":    place.City,
+		"country": place.Country,
+		"source":  "coordinates",
+	}
+}
+
+/*
+locateTarget - Result for the target of the command line: the client's IP
+Address if args is empty, coordinates as they are, else a lookup. Errors
+carry their exit code (see exitcodes.go).
+*/
+func locateTarget(args []string) (IPInfoResult, error) {
+	if len(args) > 0 {
+		target, err := commandTarget(args[0])
+		if err != nil {
+			return nil, withExitCode(exitInvalidInput, err)
+		}
+		if p, ok := targetPoint(target); ok {
+			return pointResult(p), nil
+		}
+	}
+	ip, err := makeIP(args)
+	if err != nil {
+		return nil, withExitCode(exitInvalidInput, err)
+	}
+	res, err := getIPInfo(ip)
+	return res, withExitCode(exitLookupFailed, err)
+}
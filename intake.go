@@ -0,0 +1,215 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Intake - Admission of the addresses a high-rate input sees (a busy log, the
+connections of a server under attack) ahead of their lookups:
+
+	-sample k/n    keep k of every n addresses, always the same ones
+	-ip-rate n/d   keep at most n sightings of an address per period d, so
+	               that a single noisy address cannot fill the queue
+	-queue n       sightings waiting for a lookup; more are dropped
+
+The reader never blocks: what is turned away is only counted, and the
+counts are shown in the status bar.
+*/
+type Intake struct {
+	keep, every int // sampling, keep every address if every is 0
+
+	ipLimit  int // per ipPeriod, unlimited if 0
+	ipPeriod time.Duration
+
+	queue chan string
+
+	mu          sync.Mutex
+	window      time.Time      // start of the current rate limit period
+	counts      map[string]int // sightings of each address in the period
+	sampledOut  int
+	rateLimited int
+	queueFull   int
+}
+
+/*
+intakeOptions - Command line flags of an Intake
+*/
+type intakeOptions struct {
+	sample string
+	ipRate string
+	queue  int
+}
+
+func addIntakeFlags(flags *flag.FlagSet) *intakeOptions {
+	opts := &intakeOptions{}
+	flags.StringVar(&opts.sample, "sample", "",
+		"Only keep k of every n addresses seen, as 1/100 (default all)")
+	flags.StringVar(&opts.ipRate, "ip-rate", "",
+		"Keep at most n sightings of an address per period, as 10/s or 100/5m (default no limit)")
+	flags.IntVar(&opts.queue, "queue", 1000,
+		"Most addresses waiting to be looked up, more are dropped")
+	return opts
+}
+
+/*
+parseRatio - Split "k/n" into its numbers
+*/
+func parseRatio(s string) (int, string, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("Missing '/'")
+	}
+	k, err := strconv.Atoi(parts[0])
+	if err != nil || k <= 0 {
+		return 0, "", fmt.Errorf("Invalid count '%s'", parts[0])
+	}
+	return k, parts[1], nil
+}
+
+/*
+parseRate - Split "n/d" into a count and a period, "10/s" meaning 10 per
+second
+*/
+func parseRate(s string) (int, time.Duration, error) {
+	limit, period, err := parseRatio(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("Expected a positive period")
+	}
+	return limit, d, err
+}
+
+/*
+open - Create the intake the flags describe
+*/
+func (opts *intakeOptions) open() (*Intake, error) {
+	if opts.queue <= 0 {
+		return nil, invalidInput("Invalid -queue %d: Expected a positive size.", opts.queue)
+	}
+	in := &Intake{queue: make(chan string, opts.queue), counts: make(map[string]int)}
+
+	if opts.sample != "" {
+		keep, rest, err := parseRatio(opts.sample)
+		if err == nil {
+			in.every, err = strconv.Atoi(rest)
+			if err == nil && (in.every <= 0 || keep > in.every) {
+				err = fmt.Errorf("Expected k/n with k <= n")
+			}
+		}
+		if err != nil {
+			return nil, invalidInput("Invalid -sample '%s': %s.", opts.sample, err)
+		}
+		in.keep = keep
+	}
+
+	if opts.ipRate != "" {
+		limit, period, err := parseRate(opts.ipRate)
+		if err != nil {
+			return nil, invalidInput("Invalid -ip-rate '%s': %s.", opts.ipRate, err)
+		}
+		in.ipLimit, in.ipPeriod = limit, period
+	}
+	return in, nil
+}
+
+/*
+Keeps - Whether ip is among the addresses sampling keeps. The choice only
+depends on the address, so that its sightings are all kept or all dropped.
+*/
+func (in *Intake) Keeps(ip string) bool {
+	if in.every == 0 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ip))
+	return int(h.Sum32()%uint32(in.every)) < in.keep
+}
+
+/*
+Offer - Queue ip for a lookup unless it is sampled out, over its rate or the
+queue is full. Never blocks.
+*/
+func (in *Intake) Offer(ip string) bool {
+	in.mu.Lock()
+	if !in.Keeps(ip) {
+		in.sampledOut++
+		in.mu.Unlock()
+		return false
+	}
+	if in.ipLimit > 0 {
+		// Fixed periods: the map only ever holds the addresses of one
+		if now := time.Now(); now.Sub(in.window) >= in.ipPeriod {
+			in.window = now
+			in.counts = make(map[string]int)
+		}
+		if in.counts[ip] >= in.ipLimit {
+			in.rateLimited++
+			in.mu.Unlock()
+			return false
+		}
+		in.counts[ip]++
+	}
+	in.mu.Unlock()
+
+	select {
+	case in.queue <- ip:
+		return true
+	default:
+		in.mu.Lock()
+		in.queueFull++
+		in.mu.Unlock()
+		return false
+	}
+}
+
+/*
+Run - Call lookup with every queued address, until Close
+*/
+func (in *Intake) Run(lookup func(ip string)) {
+	for ip := range in.queue {
+		lookup(ip)
+	}
+}
+
+/*
+Close - Stop taking addresses. Run returns once the queue is drained.
+*/
+func (in *Intake) Close() {
+	close(in.queue)
+}
+
+/*
+Dropped - Number of sightings turned away
+*/
+func (in *Intake) Dropped() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.sampledOut + in.rateLimited + in.queueFull
+}
+
+/*
+String - The state of the intake for the status bar
+*/
+func (in *Intake) String() string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	s := fmt.Sprintf(tr("Queue: %d/%d"), len(in.queue), cap(in.queue))
+	if in.sampledOut+in.rateLimited+in.queueFull == 0 {
+		return s
+	}
+	return s + fmt.Sprintf(tr("  Dropped: %d sampled out, %d over rate, %d queue full"),
+		in.sampledOut, in.rateLimited, in.queueFull)
+}
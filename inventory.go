@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+InventoryHost - A host of an infrastructure inventory: its name, used as the
+label on the map, and the IP Address or hostname to locate. Indicators (see
+indicators.go) have no name but the tag they are plotted under as a group.
+*/
+type InventoryHost struct {
+	Name   string
+	Target string
+	Group  string
+}
+
+var inventoryRange = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+
+/*
+expandHostRange - Hosts of an Ansible host pattern with a numeric range,
+"web[01:03]" giving web01, web02 and web03
+*/
+func expandHostRange(pattern string) []string {
+	m := inventoryRange.FindStringSubmatchIndex(pattern)
+	if m == nil {
+		return []string{pattern}
+	}
+	from, _ := strconv.Atoi(pattern[m[2]:m[3]])
+	to, _ := strconv.Atoi(pattern[m[4]:m[5]])
+	width := m[3] - m[2]
+
+	var hosts []string
+	for i := from; i <= to; i++ {
+		name := pattern[:m[0]] + fmt.Sprintf("%0*d", width, i) + pattern[m[1]:]
+		hosts = append(hosts, expandHostRange(name)...)
+	}
+	return hosts
+}
+
+/*
+readAnsibleINI - Hosts of an Ansible inventory in INI format. Sections of
+group variables and children are skipped.
+*/
+func readAnsibleINI(r io.Reader) ([]InventoryHost, error) {
+	var hosts []InventoryHost
+	skip := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			skip = strings.Contains(line, ":")
+			continue
+		}
+		if skip {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		target := ""
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "ansible_host=") {
+				target = strings.Trim(strings.TrimPrefix(field, "ansible_host="), `"'`)
+			}
+		}
+		for _, name := range expandHostRange(fields[0]) {
+			host := InventoryHost{Name: name, Target: target}
+			if host.Target == "" {
+				host.Target = name
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+/*
+readAnsibleYAML - Hosts of an Ansible inventory in YAML format. Only the
+layout of inventories is understood (nested mappings of groups, hosts and
+children), not YAML in general.
+*/
+func readAnsibleYAML(r io.Reader) ([]InventoryHost, error) {
+	type entry struct {
+		indent int
+		key    string
+	}
+
+	var hosts []InventoryHost
+	index := make(map[string]int) // host name to position in hosts
+	var stack []entry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		key, value := trimmed, ""
+		if i := strings.Index(trimmed, ":"); i >= 0 {
+			key, value = trimmed[:i], strings.TrimSpace(trimmed[i+1:])
+		}
+		key = strings.Trim(key, `"'`)
+		value = strings.Trim(value, `"'`)
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].key == "hosts" {
+			for _, name := range expandHostRange(key) {
+				if _, ok := index[name]; !ok {
+					index[name] = len(hosts)
+					hosts = append(hosts, InventoryHost{Name: name, Target: name})
+				}
+			}
+		}
+		if key == "ansible_host" && len(stack) > 1 && stack[len(stack)-2].key == "hosts" {
+			if i, ok := index[stack[len(stack)-1].key]; ok && value != "" {
+				hosts[i].Target = value
+			}
+		}
+		stack = append(stack, entry{indent, key})
+	}
+	return hosts, scanner.Err()
+}
+
+// Attributes of Terraform resources holding an address, by preference
+var terraformAddressAttrs = []string{
+	"public_ip", "public_ip_address", "ipv4_address", "access_ip_v4",
+	"ip_address", "public_dns", "fqdn",
+}
+
+/*
+readTerraformState - Hosts of the resources of a Terraform state file that
+have an address attribute, named by their Name tag or resource address
+*/
+func readTerraformState(r io.Reader) ([]InventoryHost, error) {
+	var state struct {
+		Resources []struct {
+			Mode      string `json:"mode"`
+			Type      string `json:"type"`
+			Name      string `json:"name"`
+			Instances []struct {
+				IndexKey   interface{}            `json:"index_key"`
+				Attributes map[string]interface{} `json:"attributes"`
+			} `json:"instances"`
+		} `json:"resources"`
+	}
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("Could not read Terraform state: %s", err)
+	}
+
+	var hosts []InventoryHost
+	for _, res := range state.Resources {
+		if res.Mode == "data" {
+			continue
+		}
+		for _, inst := range res.Instances {
+			target := ""
+			for _, attr := range terraformAddressAttrs {
+				if s, ok := inst.Attributes[attr].(string); ok && s != "" {
+					target = s
+					break
+				}
+			}
+			if target == "" {
+				continue
+			}
+
+			name := toString(lookupPath(inst.Attributes, "tags.Name"))
+			if name == "" {
+				name = res.Type + "." + res.Name
+				if inst.IndexKey != nil {
+					name += fmt.Sprintf("[%v]", inst.IndexKey)
+				}
+			}
+			hosts = append(hosts, InventoryHost{Name: name, Target: target})
+		}
+	}
+	return hosts, nil
+}
+
+/*
+readInventory - Hosts of the inventory at path. format is ini, yaml,
+terraform, stix or misp, or "" to guess it from the file name (and the start
+of JSON files).
+*/
+func readInventory(path, format string) ([]InventoryHost, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		switch ext := filepath.Ext(path); {
+		case ext == ".tfstate":
+			format = "terraform"
+		case ext == ".json":
+			format = sniffJSON(f)
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		case ext == ".csv":
+			format = "misp"
+		case ext == ".yml" || ext == ".yaml":
+			format = "yaml"
+		default:
+			format = "ini"
+		}
+	}
+
+	switch format {
+	case "ini":
+		return readAnsibleINI(f)
+	case "yaml":
+		return readAnsibleYAML(f)
+	case "terraform":
+		return readTerraformState(f)
+	case "stix":
+		return readSTIX(f)
+	case "misp":
+		return readMISPCSV(f)
+	}
+	return nil, fmt.Errorf("Unknown inventory format '%s'", format)
+}
+
+/*
+publicHosts - Hosts whose target is a hostname or a public IP Address
+*/
+func publicHosts(hosts []InventoryHost) []InventoryHost {
+	var public []InventoryHost
+	for _, host := range hosts {
+		if ip := net.ParseIP(host.Target); ip != nil && !isPublicIP(ip) {
+			continue
+		}
+		public = append(public, host)
+	}
+	return public
+}
+
+/*
+importHelp - What the usage message of import says after the synopsis
+*/
+const importHelp = `Locate and plot the public hosts of Ansible inventories or Terraform
+state files, labelled with their names, or the IP Addresses of STIX 2.1
+indicators or MISP attributes, colored by tag. <E> exports them back
+as a STIX bundle or MISP CSV.
+`
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	format := flags.String("format", "",
+		"Inventory format: ini, yaml (Ansible), terraform, stix (2.1 bundle) or misp (CSV export)\n(default: from the file name)")
+	sinks := addSinkFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s import [-format f] [sink flags] file...\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(importHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify an inventory file.")
+	}
+
+	var hosts []InventoryHost
+	for _, path := range flags.Args() {
+		found, err := readInventory(path, *format)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, publicHosts(found)...)
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("No public hosts in the inventory")
+	}
+	tags := make(map[string]bool)
+	for _, host := range hosts {
+		if host.Group != "" {
+			tags[host.Group] = true
+		}
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		return err
+	}
+	b := NewBatch(queue)
+	b.source = "inventory"
+	b.pipeline = pipeline
+	b.labels = make(map[string]string)
+	if len(tags) > 0 {
+		b.groupOf = make(map[string]string)
+		b.groupColor = make(map[string]int)
+		names := make([]string, 0, len(tags))
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+		for i, tag := range names {
+			b.groupColor[tag] = ansiColors[groupPalette[i%len(groupPalette)]]
+		}
+	}
+	var fresh []string
+	for _, host := range hosts {
+		if b.add(host.Target) {
+			if host.Name != "" {
+				b.labels[host.Target] = host.Name
+			}
+			if host.Group != "" {
+				b.groupOf[host.Target] = host.Group
+			}
+			fresh = append(fresh, host.Target)
+		}
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		go func() {
+			for _, target := range fresh {
+				b.lookup(target)
+			}
+		}()
+		go b.retry()
+		return nil
+	})
+}
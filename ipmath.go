@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net"
+)
+
+/*
+Arithmetic on IP Addresses and prefixes, through big integers so that IPv4
+and IPv6 share the code. Addresses are 4 bytes for IPv4, 16 for IPv6, as
+normalizePrefix makes them.
+*/
+
+/*
+ipToInt - ip as an integer
+*/
+func ipToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip)
+}
+
+/*
+intToIP - The address of length bytes (4 or 16) of i
+*/
+func intToIP(i *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	i.FillBytes(ip)
+	return ip
+}
+
+/*
+normalizeIP - ip with 4 bytes for IPv4
+*/
+func normalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+/*
+prefixSize - The number of addresses of prefix
+*/
+func prefixSize(prefix *net.IPNet) *big.Int {
+	ones, bits := prefix.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+/*
+lastAddress - The last address of prefix
+*/
+func lastAddress(prefix *net.IPNet) net.IP {
+	last := new(big.Int).Add(ipToInt(prefix.IP), prefixSize(prefix))
+	return intToIP(last.Sub(last, big.NewInt(1)), len(prefix.IP))
+}
+
+/*
+rangePrefixes - The fewest prefixes covering exactly the addresses from
+start to end
+*/
+func rangePrefixes(start, end net.IP) ([]*net.IPNet, error) {
+	start, end = normalizeIP(start), normalizeIP(end)
+	if len(start) != len(end) {
+		return nil, fmt.Errorf("The range mixes IPv4 and IPv6")
+	}
+	from, to := ipToInt(start), ipToInt(end)
+	if from.Cmp(to) > 0 {
+		return nil, fmt.Errorf("The range ends before it starts")
+	}
+	bits := len(start) * 8
+	one := big.NewInt(1)
+	var prefixes []*net.IPNet
+	for from.Cmp(to) <= 0 {
+		// The largest block aligned on from that does not go past to
+		size := int(from.TrailingZeroBits())
+		if from.Sign() == 0 {
+			size = bits
+		}
+		for ; size > 0; size-- {
+			last := new(big.Int).Lsh(one, uint(size))
+			last.Add(last, from).Sub(last, one)
+			if last.Cmp(to) <= 0 {
+				break
+			}
+		}
+		prefixes = append(prefixes, &net.IPNet{IP: intToIP(from, len(start)),
+			Mask: net.CIDRMask(bits-size, bits)})
+		from = new(big.Int).Add(from, new(big.Int).Lsh(one, uint(size)))
+	}
+	return prefixes, nil
+}
+
+/*
+shiftPrefix - The prefix of the same length n prefixes after prefix (before
+for a negative n)
+*/
+func shiftPrefix(prefix *net.IPNet, n int) (*net.IPNet, error) {
+	ones, bits := prefix.Mask.Size()
+	offset := new(big.Int).Mul(prefixSize(prefix), big.NewInt(int64(n)))
+	network := new(big.Int).Add(ipToInt(prefix.IP), offset)
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	if network.Sign() < 0 || network.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("No /%d %d away from %s", ones, n, prefix)
+	}
+	return &net.IPNet{IP: intToIP(network, len(prefix.IP)), Mask: prefix.Mask}, nil
+}
+
+/*
+sampleAddresses - n random addresses of prefix, distinct unless the prefix
+is smaller than n
+*/
+func sampleAddresses(prefix *net.IPNet, n int) ([]net.IP, error) {
+	size := prefixSize(prefix)
+	distinct := size.Cmp(big.NewInt(int64(n))) >= 0
+	seen := make(map[string]bool)
+	var ips []net.IP
+	for len(ips) < n {
+		offset, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return nil, err
+		}
+		ip := intToIP(offset.Add(offset, ipToInt(prefix.IP)), len(prefix.IP))
+		if distinct && seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
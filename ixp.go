@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Facility - An internet exchange (IXP) or major colocation PoP
+*/
+type Facility struct {
+	Name string
+	Kind string // IXP or PoP
+	City string
+	Point
+}
+
+/*
+facilities - Major exchanges and PoPs, after PeeringDB (located at the city
+they serve)
+*/
+var facilities = []Facility{
+	{"DE-CIX Frankfurt", "IXP", "Frankfurt", Point{50.11, 8.68}},
+	{"AMS-IX", "IXP", "Amsterdam", Point{52.37, 4.90}},
+	{"LINX LON1", "IXP", "London", Point{51.51, -0.13}},
+	{"France-IX Paris", "IXP", "Paris", Point{48.86, 2.35}},
+	{"ESPANIX", "IXP", "Madrid", Point{40.42, -3.70}},
+	{"MIX Milan", "IXP", "Milan", Point{45.46, 9.19}},
+	{"SwissIX", "IXP", "Zurich", Point{47.37, 8.54}},
+	{"VIX", "IXP", "Vienna", Point{48.21, 16.37}},
+	{"NIX.CZ", "IXP", "Prague", Point{50.08, 14.44}},
+	{"PLIX", "IXP", "Warsaw", Point{52.23, 21.01}},
+	{"Netnod Stockholm", "IXP", "Stockholm", Point{59.33, 18.07}},
+	{"FICIX", "IXP", "Helsinki", Point{60.17, 24.94}},
+	{"NIX", "IXP", "Oslo", Point{59.91, 10.75}},
+	{"DIX", "IXP", "Copenhagen", Point{55.68, 12.57}},
+	{"INEX", "IXP", "Dublin", Point{53.35, -6.26}},
+	{"UA-IX", "IXP", "Kyiv", Point{50.45, 30.52}},
+	{"MSK-IX", "IXP", "Moscow", Point{55.76, 37.62}},
+	{"DE-CIX Istanbul", "IXP", "Istanbul", Point{41.01, 28.98}},
+	{"UAE-IX", "IXP", "Dubai", Point{25.20, 55.27}},
+	{"NYIIX", "IXP", "New York", Point{40.71, -74.01}},
+	{"Equinix Ashburn", "PoP", "Ashburn", Point{39.04, -77.49}},
+	{"Equinix Chicago", "IXP", "Chicago", Point{41.88, -87.63}},
+	{"DE-CIX Dallas", "IXP", "Dallas", Point{32.78, -96.80}},
+	{"NOTA", "PoP", "Miami", Point{25.76, -80.19}},
+	{"Any2 Los Angeles", "IXP", "Los Angeles", Point{34.05, -118.24}},
+	{"SIX", "IXP", "Seattle", Point{47.61, -122.33}},
+	{"Equinix San Jose", "PoP", "San Jose", Point{37.34, -121.89}},
+	{"TorIX", "IXP", "Toronto", Point{43.65, -79.38}},
+	{"IX.br São Paulo", "IXP", "São Paulo", Point{-23.55, -46.63}},
+	{"CABASE", "IXP", "Buenos Aires", Point{-34.60, -58.38}},
+	{"PIT Chile", "IXP", "Santiago", Point{-33.45, -70.67}},
+	{"NAP Colombia", "IXP", "Bogotá", Point{4.71, -74.07}},
+	{"NAPAfrica Johannesburg", "IXP", "Johannesburg", Point{-26.20, 28.05}},
+	{"NAPAfrica Cape Town", "IXP", "Cape Town", Point{-33.92, 18.42}},
+	{"IXPN", "IXP", "Lagos", Point{6.52, 3.38}},
+	{"KIXP", "IXP", "Nairobi", Point{-1.29, 36.82}},
+	{"NIXI Mumbai", "IXP", "Mumbai", Point{19.08, 72.88}},
+	{"Equinix Singapore", "PoP", "Singapore", Point{1.35, 103.82}},
+	{"BKNIX", "IXP", "Bangkok", Point{13.76, 100.50}},
+	{"IIX", "IXP", "Jakarta", Point{-6.21, 106.85}},
+	{"HKIX", "IXP", "Hong Kong", Point{22.32, 114.17}},
+	{"TPIX", "IXP", "Taipei", Point{25.03, 121.57}},
+	{"KINX", "IXP", "Seoul", Point{37.57, 126.98}},
+	{"JPNAP Tokyo", "IXP", "Tokyo", Point{35.68, 139.69}},
+	{"IX Australia Sydney", "IXP", "Sydney", Point{-33.87, 151.21}},
+	{"IX Australia Melbourne", "IXP", "Melbourne", Point{-37.81, 144.96}},
+	{"AKL-IX", "IXP", "Auckland", Point{-36.85, 174.76}},
+}
+
+var (
+	ixpCount = flag.Int("ixp", 0,
+		"Show the n internet exchanges and PoPs nearest to the location")
+	ixpMarkers = flag.Bool("ixp-markers", false,
+		"Plot the exchanges shown by -ixp on the map")
+)
+
+/*
+NearbyFacility - A facility and its distance to a location
+*/
+type NearbyFacility struct {
+	Facility
+	DistanceKm float64
+}
+
+/*
+nearestFacilities - The n facilities nearest to p, nearest first
+*/
+func nearestFacilities(p Point, n int) []NearbyFacility {
+	nearby := make([]NearbyFacility, len(facilities))
+	for i, f := range facilities {
+		nearby[i] = NearbyFacility{f, distanceKm(p, f.Point)}
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+	if n < len(nearby) {
+		nearby = nearby[:n]
+	}
+	return nearby
+}
+
+/*
+addNearbyFacilities - Add the n facilities nearest to the location of res:
+the list under "ixps" and a line for the info pane under "ixps_text"
+*/
+func addNearbyFacilities(res IPInfoResult, n int) error {
+	lon, lat, err := res.GetLonLat()
+	if err != nil {
+		return err
+	}
+
+	var list []interface{}
+	var text []string
+	for _, f := range nearestFacilities(Point{Lat: lat, Lon: lon}, n) {
+		list = append(list, map[string]interface{}{
+			"name":        f.Name,
+			"kind":        f.Kind,
+			"city":        f.City,
+			"lat":         f.Lat,
+			"lon":         f.Lon,
+			"distance_km": f.DistanceKm,
+		})
+		text = append(text, fmt.Sprintf("%s (%s)", f.Name, formatDistance(f.DistanceKm)))
+	}
+	res["ixps"] = list
+	res["ixps_text"] = strings.Join(text, ", ")
+	return nil
+}
+
+/*
+showIXPField - Add the nearby exchanges to the info pane unless the config
+file already lists them
+*/
+func showIXPField() {
+	for _, field := range config.InfoFields {
+		if field.Path == "ixps_text" {
+			return
+		}
+	}
+	fields := append([]InfoField{}, config.InfoFields...)
+	config.InfoFields = append(fields, InfoField{"Nearby IXPs", "ixps_text"})
+}
+
+/*
+ixpMarkersOf - Secondary markers ('+') of the exchanges added to res
+*/
+func ixpMarkersOf(res IPInfoResult) []Marker {
+	list, _ := res["ixps"].([]interface{})
+	var markers []Marker
+	for _, item := range list {
+		f, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		lat, _ := toNumber(f["lat"])
+		lon, _ := toNumber(f["lon"])
+		markers = append(markers, Marker{Lon: lon, Lat: lat, Text: "+"})
+	}
+	return markers
+}
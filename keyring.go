@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+API tokens are kept in the OS keyring (Keychain on macOS, the Secret Service
+on Linux and BSD through secret-tool, the Credential Manager on Windows)
+rather than in the config file:
+
+	ip411 auth login ipinfo
+	ip411 auth logout ipinfo
+
+An environment variable set for a provider takes precedence over the keyring,
+and so do the token flags of the sinks.
+*/
+
+const keyringService = "ip411"
+
+/*
+tokenProviders - Services ip411 can authenticate to, with the environment
+variable overriding their token
+*/
+var tokenProviders = map[string]string{
+	"ipinfo": "IP411_IPINFO_TOKEN",
+	"ipapi":  "IP411_IPAPI_TOKEN",
+	"es":     "IP411_ES_API_KEY",
+	"influx": "IP411_INFLUX_TOKEN",
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(tokenProviders))
+	for name := range tokenProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var tokens = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+/*
+providerToken - The token of provider, from its environment variable or the
+keyring, "" if there is none. The keyring is only asked once.
+*/
+func providerToken(provider string) string {
+	if token := os.Getenv(tokenProviders[provider]); token != "" {
+		return token
+	}
+	tokens.Lock()
+	defer tokens.Unlock()
+	token, ok := tokens.m[provider]
+	if !ok {
+		var err error
+		if token, err = keyringGet(provider); err != nil {
+			warnf("Could not read the %s token from the keyring: %s", provider, err)
+		}
+		tokens.m[provider] = token
+	}
+	return token
+}
+
+/*
+readSecret - Read a line from stdin, without echoing it if stdin is a
+terminal that stty can control
+*/
+func readSecret(prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, prompt)
+		stty := func(arg string) error {
+			cmd := exec.Command("stty", arg)
+			cmd.Stdin = os.Stdin
+			return cmd.Run()
+		}
+		if stty("-echo") == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr, "")
+			}()
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+/*
+authHelp - What the usage message of auth says after the synopsis
+*/
+const authHelp = `Store the API token of a provider in the OS keyring, read from
+stdin, or remove it. Providers and the environment variables
+overriding their token:
+`
+
+func runAuth(args []string) error {
+	flags := flag.NewFlagSet("auth", flag.ExitOnError)
+	addQuietFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s auth login|logout provider\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(authHelp))
+		fmt.Fprintln(os.Stderr, "")
+		for _, name := range providerNames() {
+			fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, tokenProviders[name])
+		}
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify login or logout and a provider.")
+	}
+	command, provider := flags.Arg(0), flags.Arg(1)
+	if _, ok := tokenProviders[provider]; !ok {
+		return invalidInput("Unknown provider '%s', expected one of %s", provider,
+			strings.Join(providerNames(), ", "))
+	}
+
+	switch command {
+	case "login":
+		token, err := readSecret(fmt.Sprintf("Token for %s: ", provider))
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return invalidInput("Empty token")
+		}
+		if err := keyringSet(provider, token); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Stored the %s token in the keyring\n", provider)
+	case "logout":
+		if err := keyringDelete(provider); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Removed the %s token from the keyring\n", provider)
+	default:
+		flags.Usage()
+		return invalidInput("Unknown command '%s', expected login or logout", command)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+The macOS keyring is the login Keychain, through security(1)
+*/
+
+// Exit status of security(1) when the item does not exist
+const securityNotFound = 44
+
+func security(args ...string) (string, int, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("security", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", exitErr.ExitCode(), fmt.Errorf("security %s: %s", args[0],
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), 0, err
+}
+
+/*
+keyringGet - The token of provider, "" if there is none
+*/
+func keyringGet(provider string) (string, error) {
+	token, status, err := security("find-generic-password", "-s", keyringService,
+		"-a", provider, "-w")
+	if status == securityNotFound {
+		return "", nil
+	}
+	return token, err
+}
+
+/*
+keyringSet - Store the token of provider, replacing the previous one.
+security(1) only takes the password on its command line, where it is briefly
+visible to other processes of the user.
+*/
+func keyringSet(provider, token string) error {
+	_, _, err := security("add-generic-password", "-U", "-s", keyringService,
+		"-a", provider, "-l", "ip411 "+provider+" token", "-w", token)
+	return err
+}
+
+/*
+keyringDelete - Remove the token of provider. Removing a missing token is
+not an error.
+*/
+func keyringDelete(provider string) error {
+	_, status, err := security("delete-generic-password", "-s", keyringService,
+		"-a", provider)
+	if status == securityNotFound {
+		return nil
+	}
+	return err
+}
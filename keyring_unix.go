@@ -0,0 +1,67 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+Elsewhere the keyring is the freedesktop Secret Service (GNOME Keyring,
+KWallet), through secret-tool(1) from libsecret
+*/
+
+func secretTool(stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("secret-tool %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+/*
+keyringGet - The token of provider, "" if there is none
+*/
+func keyringGet(provider string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", nil
+	}
+	token, err := secretTool("", "lookup", "service", keyringService, "provider", provider)
+	if _, ok := err.(*exec.ExitError); ok {
+		// secret-tool exits with 1 and says nothing for a missing item
+		return "", nil
+	}
+	return token, err
+}
+
+/*
+keyringSet - Store the token of provider, replacing the previous one. The
+token is passed on stdin, never on a command line.
+*/
+func keyringSet(provider, token string) error {
+	_, err := secretTool(token, "store", "--label=ip411 "+provider+" token",
+		"service", keyringService, "provider", provider)
+	return err
+}
+
+/*
+keyringDelete - Remove the token of provider. Removing a missing token is
+not an error.
+*/
+func keyringDelete(provider string) error {
+	_, err := secretTool("", "clear", "service", keyringService, "provider", provider)
+	if _, ok := err.(*exec.ExitError); ok {
+		return nil
+	}
+	return err
+}
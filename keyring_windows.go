@@ -0,0 +1,115 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+/*
+The Windows keyring is the Credential Manager, generic credentials named
+ip411:<provider>
+*/
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+/*
+credential - CREDENTIALW
+*/
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credentialTarget(provider string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keyringService + ":" + provider)
+}
+
+/*
+keyringGet - The token of provider, "" if there is none
+*/
+func keyringGet(provider string) (string, error) {
+	target, err := credentialTarget(provider)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0,
+		uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if err == errorNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+/*
+keyringSet - Store the token of provider, replacing the previous one
+*/
+func keyringSet(provider, token string) error {
+	target, err := credentialTarget(provider)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(provider)
+	if err != nil {
+		return err
+	}
+	blob := []byte(token)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+/*
+keyringDelete - Remove the token of provider. Removing a missing token is
+not an error.
+*/
+func keyringDelete(provider string) error {
+	target, err := credentialTarget(provider)
+	if err != nil {
+		return err
+	}
+	r, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if r == 0 && err != errorNotFound {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"math"
+)
+
+/*
+Layer - Optional content drawn on the map under the markers, toggled with
+its key. Layers whose data is fetched have Load called in the background
+when they are shown.
+*/
+type Layer struct {
+	Name    string
+	Key     rune
+	Enabled bool
+	Draw    func(mc *MapCanvas)
+	Load    func() error
+}
+
+var layers = []*Layer{
+	{Name: "submarine cables", Key: 'c', Draw: drawCables},
+	{Name: "terrestrial backbone", Key: 'r', Draw: drawBackbone},
+	{Name: "Tor exits", Key: 't', Draw: drawTorExits, Load: loadTorLayer},
+	{Name: "time zones", Key: 'z', Draw: drawTimeZones}, // see timezones.go
+}
+
+var lastMarkers []Marker // markers of the last drawMap, protected by mu
+
+/*
+DottedLine - Plot every step-th point of a line, to tell it apart from the
+coastlines. A line spanning more than half the globe in longitude goes the
+short way, leaving one side of the map and coming back on the other.
+*/
+func (mc *MapCanvas) DottedLine(lonA, latA, lonB, latB float64, step int) {
+	if math.Abs(lonB-lonA) > 180 {
+		a, b := splitAtAntimeridian(Point{Lat: latA, Lon: lonA}, Point{Lat: latB, Lon: lonB})
+		mc.DottedLine(lonA, latA, a.Lon, a.Lat, step)
+		mc.DottedLine(b.Lon, b.Lat, lonB, latB, step)
+		return
+	}
+	xA, yA := mc.GetX(lonA), mc.GetY(latA)
+	xB, yB := mc.GetX(lonB), mc.GetY(latB)
+	n := int(math.Max(math.Abs(xB-xA), math.Abs(yB-yA))) / step
+	for i := 0; i <= n; i++ {
+		t := 1.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		x, y := xA+t*(xB-xA), yA+t*(yB-yA)
+		if mc.inside(x, y) {
+			mc.canvas.Set(int(x), int(y))
+		}
+	}
+}
+
+/*
+splitAtAntimeridian - Where the short way from a to b, whose longitudes are
+more than 180° apart, crosses the antimeridian: on the side of a, then on
+the side of b
+*/
+func splitAtAntimeridian(a, b Point) (Point, Point) {
+	edge, lonB := 180.0, b.Lon+360
+	if a.Lon < 0 {
+		edge, lonB = -180, b.Lon-360
+	}
+	lat := a.Lat + (edge-a.Lon)/(lonB-a.Lon)*(b.Lat-a.Lat)
+	return Point{Lat: lat, Lon: edge}, Point{Lat: lat, Lon: -edge}
+}
+
+/*
+Cable - A submarine or terrestrial cable, as the landing points or cities
+and the turns of its route
+*/
+type Cable struct {
+	Name  string
+	Route []Point
+}
+
+/*
+cables - Simplified routes of major submarine cables, after the open
+TeleGeography Submarine Cable Map
+*/
+var cables = []Cable{
+	{"MAREA", []Point{{36.85, -75.98}, {43.40, -2.95}}},
+	{"Grace Hopper", []Point{{40.58, -73.65}, {50.83, -4.55}}},
+	{"TAT-14", []Point{{40.10, -74.03}, {50.83, -4.55}}},
+	{"EllaLink", []Point{{-3.72, -38.54}, {28.10, -15.40}, {37.95, -8.87}}},
+	{"Monet", []Point{{26.35, -80.08}, {-3.72, -38.54}, {-23.96, -46.33}}},
+	{"SACS", []Point{{-3.72, -38.54}, {-9.53, 13.27}}},
+	{"SAIL", []Point{{-3.72, -38.54}, {2.94, 9.91}}},
+	{"WACS", []Point{{-33.35, 18.15}, {-22.68, 14.52}, {-8.84, 13.23},
+		{6.43, 3.42}, {5.55, -0.20}, {14.69, -17.45}, {28.10, -15.40},
+		{38.70, -9.50}, {51.21, -3.00}}},
+	{"EASSy", []Point{{-28.95, 31.75}, {-25.97, 32.57}, {-6.80, 39.30},
+		{-4.05, 39.67}, {2.04, 45.34}, {11.59, 43.15}, {19.62, 37.22}}},
+	{"SEA-ME-WE 5", []Point{{43.30, 5.37}, {37.28, 9.87}, {31.20, 29.92},
+		{29.97, 32.55}, {21.49, 39.19}, {12.60, 43.40}, {24.86, 67.01},
+		{19.08, 72.88}, {6.93, 79.85}, {1.29, 103.85}}},
+	{"Asia-Pacific Gateway", []Point{{1.29, 103.85}, {10.35, 107.08},
+		{22.28, 114.16}, {24.86, 121.83}, {31.62, 121.85}, {35.10, 129.04},
+		{35.00, 139.83}}},
+	{"FASTER", []Point{{43.97, -124.11}, {35.10, 140.10}}},
+	{"Southern Cross", []Point{{-33.87, 151.21}, {-18.14, 178.44},
+		{21.31, -157.86}, {34.00, -118.50}}},
+	{"Australia-Singapore Cable", []Point{{-31.95, 115.86}, {-10.49, 105.64},
+		{1.29, 103.85}}},
+	{"Curie", []Point{{33.92, -118.42}, {8.95, -79.57}, {-33.05, -71.62}}},
+}
+
+/*
+backbone - Simplified routes of major terrestrial long-haul fiber, between
+the cities they link, after the network maps their operators publish
+*/
+var backbone = []Cable{
+	{"Transcontinental US north", []Point{{40.71, -74.01}, {41.88, -87.63},
+		{39.74, -104.99}, {40.76, -111.89}, {38.58, -121.49}, {37.34, -121.89}}},
+	{"Transcontinental US south", []Point{{39.04, -77.49}, {33.75, -84.39},
+		{32.78, -96.80}, {33.45, -112.07}, {34.05, -118.24}}},
+	{"Trans-Canada", []Point{{45.50, -73.57}, {43.65, -79.38}, {49.90, -97.14},
+		{51.05, -114.07}, {49.28, -123.12}}},
+	{"Europe west", []Point{{38.72, -9.14}, {40.42, -3.70}, {43.30, 5.37},
+		{45.46, 9.19}, {50.11, 8.68}, {52.37, 4.90}, {51.51, -0.13}, {48.86, 2.35},
+		{50.11, 8.68}}},
+	{"Trans-Siberian", []Point{{50.11, 8.68}, {52.23, 21.01}, {55.76, 37.62},
+		{56.84, 60.60}, {55.03, 82.92}, {52.29, 104.30}, {48.48, 135.08},
+		{43.12, 131.89}}},
+	{"Europe-Persia Express Gateway", []Point{{50.11, 8.68}, {50.45, 30.52},
+		{44.72, 37.77}, {40.41, 49.87}, {35.69, 51.39}, {23.68, 57.89}}},
+	{"Eurasian Silk Road", []Point{{43.24, 76.89}, {43.83, 87.62},
+		{34.34, 108.94}, {39.90, 116.40}}},
+	{"China east", []Point{{39.90, 116.40}, {31.23, 121.47}, {23.13, 113.26},
+		{22.28, 114.16}}},
+	{"India", []Point{{28.61, 77.21}, {19.08, 72.88}, {12.97, 77.59},
+		{13.08, 80.27}}},
+	{"Trans-Saharan", []Point{{36.75, 3.06}, {22.79, 5.53}, {13.51, 2.11},
+		{9.08, 7.40}, {6.52, 3.38}}},
+	{"East Africa", []Point{{-4.05, 39.67}, {-1.29, 36.82}, {0.35, 32.58},
+		{-1.95, 30.06}}},
+	{"South Africa", []Point{{-33.92, 18.42}, {-26.20, 28.05}, {-29.86, 31.02}}},
+	{"South America", []Point{{-3.72, -38.54}, {-22.91, -43.17}, {-23.55, -46.63},
+		{-34.60, -58.38}, {-33.45, -70.67}}},
+	{"Australia", []Point{{-31.95, 115.86}, {-34.93, 138.60}, {-37.81, 144.96},
+		{-33.87, 151.21}, {-27.47, 153.03}}},
+}
+
+/*
+drawRoutes - Draw the routes of cables, dotted every step points
+*/
+func drawRoutes(mc *MapCanvas, cables []Cable, step int) {
+	for _, cable := range cables {
+		for i := 1; i < len(cable.Route); i++ {
+			a, b := cable.Route[i-1], cable.Route[i]
+			mc.DottedLine(a.Lon, a.Lat, b.Lon, b.Lat, step)
+		}
+	}
+}
+
+func drawCables(mc *MapCanvas) {
+	drawRoutes(mc, cables, 3)
+}
+
+func drawBackbone(mc *MapCanvas) {
+	drawRoutes(mc, backbone, 2)
+}
+
+/*
+drawLayers - Draw the enabled layers on the canvas
+*/
+func drawLayers(mc *MapCanvas) {
+	for _, layer := range layers {
+		if layer.Enabled {
+			layer.Draw(mc)
+		}
+	}
+}
+
+func toggleLayer(layer *Layer) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		layer.Enabled = !layer.Enabled
+		if layer.Enabled && layer.Load != nil {
+			guiShowStatus(g, "Loading the %s...", layer.Name)
+			go func() {
+				if err := layer.Load(); err != nil {
+					guiShowStatus(g, "Could not load the %s: %s", layer.Name, err)
+					return
+				}
+				guiLoadStatus(g)
+				g.Execute(redrawMap)
+			}()
+		}
+		return redrawMap(g)
+	}
+}
+
+/*
+setLayerKeybindings - Register the keys toggling the layers
+*/
+func setLayerKeybindings(g *Gui) error {
+	for _, layer := range layers {
+		if err := g.SetKeybinding("", layer.Key, ModNone,
+			undoable(toggleLayer(layer))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitAtAntimeridian(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		a, b Point
+		lat  float64 // where the route crosses
+		edge float64 // longitude leaving a
+	}{
+		{"FASTER", Point{43.97, -124.11}, Point{35.10, 140.10}, 38.79, -180},
+		{"Southern Cross", Point{-18.14, 178.44}, Point{21.31, -157.86}, -15.54, 180},
+	} {
+		out, in := splitAtAntimeridian(c.a, c.b)
+		if out.Lon != c.edge || in.Lon != -c.edge {
+			t.Errorf("%s: leaves at %g, comes back at %g, expected %g and %g",
+				c.name, out.Lon, in.Lon, c.edge, -c.edge)
+		}
+		if math.Abs(out.Lat-c.lat) > 0.01 || out.Lat != in.Lat {
+			t.Errorf("%s: crosses at %g and %g, expected %g", c.name, out.Lat, in.Lat, c.lat)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"container/list"
+	"flag"
+	"fmt"
+)
+
+/*
+The live modes (monitor, logs, capture) may run for days, so what they keep
+in memory is capped, the least recently seen going first:
+
+	-max-targets n    addresses on the map, with their results and traffic
+	-max-flows n      active conversations of the capture mode
+	-max-playback n   located addresses kept for stepping (see playback.go)
+
+An address forgotten and seen again is looked up again. The status bar shows
+how much of each cap is used, and the messages the sinks were too slow for
+(see bus.go).
+*/
+
+const (
+	defaultMaxTargets = 50000
+	defaultMaxFlows   = 100000
+)
+
+/*
+limitOptions - Command line flags of the caps
+*/
+type limitOptions struct {
+	targets  int
+	flows    int
+	playback int
+}
+
+func addLimitFlags(flags *flag.FlagSet) *limitOptions {
+	opts := &limitOptions{}
+	flags.IntVar(&opts.targets, "max-targets", defaultMaxTargets,
+		"Most addresses kept on the map, the least recently seen are forgotten")
+	flags.IntVar(&opts.flows, "max-flows", defaultMaxFlows,
+		"Most active conversations tracked by capture, the least recently active are forgotten")
+	flags.IntVar(&opts.playback, "max-playback", maxPlayback,
+		"Most located addresses kept for stepping through")
+	return opts
+}
+
+/*
+apply - Cap the state of b, and of its flow table if it has one
+*/
+func (opts *limitOptions) apply(b *Batch) error {
+	for _, limit := range []struct {
+		flag string
+		n    int
+	}{
+		{"-max-targets", opts.targets},
+		{"-max-flows", opts.flows},
+		{"-max-playback", opts.playback},
+	} {
+		if limit.n <= 0 {
+			return invalidInput("Invalid %s %d: Expected a positive number.", limit.flag, limit.n)
+		}
+	}
+	b.mu.Lock()
+	b.maxTargets = opts.targets
+	b.maxPlayback = opts.playback
+	b.traffic.max = opts.targets
+	b.mu.Unlock()
+	if b.flows != nil {
+		b.flows.mu.Lock()
+		b.flows.max = opts.flows
+		b.flows.mu.Unlock()
+	}
+	return nil
+}
+
+/*
+recentSet - Keys in the order they were last touched, for least recently
+used eviction. Not safe for concurrent use.
+*/
+type recentSet struct {
+	order *list.List // of string, the most recent first
+	elems map[string]*list.Element
+}
+
+func newRecentSet() *recentSet {
+	return &recentSet{order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+/*
+touch - Make key the most recent, adding it if need be. Returns whether it
+was there.
+*/
+func (s *recentSet) touch(key string) bool {
+	if elem, ok := s.elems[key]; ok {
+		s.order.MoveToFront(elem)
+		return true
+	}
+	s.elems[key] = s.order.PushFront(key)
+	return false
+}
+
+func (s *recentSet) has(key string) bool {
+	_, ok := s.elems[key]
+	return ok
+}
+
+func (s *recentSet) remove(key string) {
+	if elem, ok := s.elems[key]; ok {
+		s.order.Remove(elem)
+		delete(s.elems, key)
+	}
+}
+
+func (s *recentSet) len() int {
+	return len(s.elems)
+}
+
+/*
+trim - Remove the least recent keys beyond max (unlimited if 0), calling
+forget with each
+*/
+func (s *recentSet) trim(max int, forget func(key string)) {
+	for max > 0 && len(s.elems) > max {
+		key := s.order.Back().Value.(string)
+		s.remove(key)
+		if forget != nil {
+			forget(key)
+		}
+	}
+}
+
+/*
+forget - Drop target and everything known about it, to make room. Must be
+called with b.mu held.
+*/
+func (b *Batch) forget(target string) {
+	b.recent.remove(target)
+	for i, t := range b.targets {
+		if t == target {
+			b.targets = append(b.targets[:i], b.targets[i+1:]...)
+			break
+		}
+	}
+	delete(b.results, target)
+	delete(b.failed, target)
+	delete(b.groupOf, target)
+	delete(b.labels, target)
+	delete(b.countryOf, target)
+	delete(b.flashing, target)
+	b.traffic.forget(target)
+	b.forgotten++
+}
+
+/*
+memoryUsage - How full the caps are, for the status bar
+*/
+func (b *Batch) memoryUsage() string {
+	b.mu.Lock()
+	line := fmt.Sprintf(tr("Kept: %d/%d targets, %d/%d steps"), len(b.targets), b.maxTargets,
+		len(b.playback), b.maxPlayback)
+	if b.forgotten > 0 {
+		line += fmt.Sprintf(tr(" (%d forgotten)"), b.forgotten)
+	}
+	b.mu.Unlock()
+	if b.flows != nil {
+		active, max := b.flows.Usage()
+		line += fmt.Sprintf(tr(", %d/%d flows"), active, max)
+	}
+	if dropped := bus.Dropped(); dropped > 0 {
+		line += fmt.Sprintf(tr(", %d messages dropped"), dropped)
+	}
+	return line
+}
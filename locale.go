@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*
+Numbers shown to people (the info pane, the status bar, reports printed as
+text) follow the locale of the environment, LC_ALL, LC_NUMERIC or LANG: its
+decimal separator and digit grouping, and miles instead of kilometers where
+they are the usual unit. The config file's "locale" and "units" and -units
+override it. Machine-readable output (JSON, CSV, events) is left as is.
+
+Coordinates are rounded everywhere, as soon as a result is looked up, to the
+decimals of -precision or the config file's "precision", 2 by default: about
+a kilometer, already more than geolocation databases can tell. Fewer
+decimals blur the locations on the map and in every output (1 is about
+11 km, 0 about 111 km).
+*/
+
+const (
+	unitsKm    = "km"
+	unitsMiles = "mi"
+	kmPerMile  = 1.609344
+
+	defaultCoordPrecision = 2
+	maxCoordPrecision     = 6
+)
+
+// Decimals of the coordinates
+var coordPrecision = defaultCoordPrecision
+
+/*
+NumberFormat - How numbers and distances are written
+*/
+type NumberFormat struct {
+	Decimal string // separator of the decimals
+	Group   string // separator of the thousands
+	Units   string // of distances, unitsKm or unitsMiles
+}
+
+var numberFormat = localeNumberFormat(envLocale())
+
+// Separators by language, decimal then group
+var localeSeparators = map[string][2]string{
+	"de": {",", "."}, "es": {",", "."}, "it": {",", "."}, "nl": {",", "."},
+	"pt": {",", "."}, "id": {",", "."}, "tr": {",", "."}, "da": {",", "."},
+	"el": {",", "."}, "ro": {",", "."},
+	"fr": {",", " "}, "ru": {",", " "}, "pl": {",", " "},
+	"cs": {",", " "}, "sk": {",", " "}, "sv": {",", " "},
+	"nb": {",", " "}, "nn": {",", " "}, "fi": {",", " "},
+	"uk": {",", " "}, "hu": {",", " "}, "bg": {",", " "},
+}
+
+// Territories where distances are given in miles
+var milesTerritories = map[string]bool{"US": true, "GB": true, "LR": true, "MM": true}
+
+/*
+envLocale - The locale numbers are written in, from the environment
+*/
+func envLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+/*
+localeNumberFormat - The format of a POSIX locale name (de_CH.UTF-8), the
+one of the C locale if it is not known
+*/
+func localeNumberFormat(locale string) NumberFormat {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	lang, territory := locale, ""
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		lang, territory = locale[:i], strings.ToUpper(locale[i+1:])
+	}
+
+	f := NumberFormat{Decimal: ".", Group: ",", Units: unitsKm}
+	if seps, ok := localeSeparators[strings.ToLower(lang)]; ok {
+		f.Decimal, f.Group = seps[0], seps[1]
+	}
+	if territory == "CH" || territory == "LI" {
+		f.Decimal, f.Group = ".", "'"
+	}
+	if milesTerritories[territory] {
+		f.Units = unitsMiles
+	}
+	return f
+}
+
+/*
+setUnits - Give distances in units, "km" or "mi"
+*/
+func setUnits(units string) error {
+	switch units {
+	case unitsKm, unitsMiles:
+		numberFormat.Units = units
+		return nil
+	case "miles":
+		numberFormat.Units = unitsMiles
+		return nil
+	}
+	return invalidInput("Invalid units '%s': Expected km or mi.", units)
+}
+
+/*
+unitsFlag - -units, applied as soon as it is parsed
+*/
+type unitsFlag struct{}
+
+func (unitsFlag) String() string {
+	return numberFormat.Units
+}
+
+func (unitsFlag) Set(units string) error {
+	return setUnits(units)
+}
+
+/*
+setCoordPrecision - Round coordinates to decimals decimals
+*/
+func setCoordPrecision(decimals int) error {
+	if decimals < 0 || decimals > maxCoordPrecision {
+		return invalidInput("Invalid precision %d: Expected 0 to %d decimals.",
+			decimals, maxCoordPrecision)
+	}
+	coordPrecision = decimals
+	return nil
+}
+
+/*
+precisionFlag - -precision, applied as soon as it is parsed
+*/
+type precisionFlag struct{}
+
+func (precisionFlag) String() string {
+	return strconv.Itoa(coordPrecision)
+}
+
+func (precisionFlag) Set(value string) error {
+	decimals, err := strconv.Atoi(value)
+	if err != nil {
+		return invalidInput("Invalid precision '%s': Expected a number of decimals.", value)
+	}
+	return setCoordPrecision(decimals)
+}
+
+func addPrecisionFlag(flags *flag.FlagSet) {
+	flags.Var(precisionFlag{}, "precision",
+		"Decimals of the coordinates shown and exported, 0 to 6 (default 2)")
+}
+
+/*
+roundCoord - A latitude or longitude rounded to the precision
+*/
+func roundCoord(v float64) float64 {
+	p := math.Pow(10, float64(coordPrecision))
+	return math.Round(v*p) / p
+}
+
+/*
+formatLoc - A location as the "lat,lon" of the loc field, to the precision
+*/
+func formatLoc(lat, lon float64) string {
+	return strconv.FormatFloat(roundCoord(lat), 'f', coordPrecision, 64) + "," +
+		strconv.FormatFloat(roundCoord(lon), 'f', coordPrecision, 64)
+}
+
+/*
+roundLoc - Round the loc field of res to the precision
+*/
+func roundLoc(res IPInfoResult) {
+	if lon, lat, err := res.GetLonLat(); err == nil {
+		res["loc"] = formatLoc(lat, lon)
+	}
+}
+
+func addUnitsFlag(flags *flag.FlagSet) {
+	flags.Var(unitsFlag{}, "units",
+		"Units of the distances shown, km or mi (default from the locale)")
+}
+
+/*
+formatNumber - v with decimals decimals, in the separators of the locale
+*/
+func formatNumber(v float64, decimals int) string {
+	text := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	whole, frac := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, frac = text[:i], text[i+1:]
+	}
+
+	var b strings.Builder
+	if v < 0 && strings.Trim(text, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(numberFormat.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if frac != "" {
+		b.WriteString(numberFormat.Decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+/*
+formatCount - n with its thousands grouped
+*/
+func formatCount(n int) string {
+	return formatNumber(float64(n), 0)
+}
+
+/*
+formatCoords - A location as "lat, lon", separated by a semicolon where the
+decimals are after a comma
+*/
+func formatCoords(lat, lon float64) string {
+	sep := ", "
+	if numberFormat.Decimal == "," {
+		sep = "; "
+	}
+	return formatNumber(lat, coordPrecision) + sep + formatNumber(lon, coordPrecision)
+}
+
+/*
+distanceIn - km in the units distances are shown in
+*/
+func distanceIn(km float64) float64 {
+	if numberFormat.Units == unitsMiles {
+		return km / kmPerMile
+	}
+	return km
+}
+
+/*
+formatDistance - A distance in km, in the units distances are shown in
+*/
+func formatDistance(km float64) string {
+	d := distanceIn(km)
+	decimals := 0
+	if d < 10 {
+		decimals = 1
+	}
+	return fmt.Sprintf("%s %s", formatNumber(d, decimals), numberFormat.Units)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+IPMatch - An IP Address found in a line of text, at line[Start:End]
+*/
+type IPMatch struct {
+	Start int
+	End   int
+	IP    net.IP
+}
+
+func isAddrChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' ||
+		c == '.' || c == ':'
+}
+
+/*
+findIPs - Find the IPv4 and IPv6 addresses in line. A port glued to an IPv4
+Address (1.2.3.4:443) is not part of the match.
+*/
+func findIPs(line string) []IPMatch {
+	var matches []IPMatch
+	for i := 0; i < len(line); {
+		if !isAddrChar(line[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(line) && isAddrChar(line[j]) {
+			j++
+		}
+		// Words like "deadbeef" or "face" that merely run into an
+		// address-looking token must not be swallowed, so only tokens
+		// bounded by non-alphanumerics are considered
+		if (i == 0 || !isWordChar(line[i-1])) && (j == len(line) || !isWordChar(line[j])) {
+			if m, ok := matchIP(line[i:j]); ok {
+				m.Start += i
+				m.End += i
+				matches = append(matches, m)
+			}
+		}
+		i = j
+	}
+	return matches
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_'
+}
+
+func matchIP(token string) (IPMatch, bool) {
+	start, end := 0, len(token)
+	// Trailing punctuation, as in "from 1.2.3.4."
+	for end > start && (token[end-1] == '.' || token[end-1] == ':') &&
+		!strings.HasSuffix(token[start:end], "::") {
+		end--
+	}
+	if ip := net.ParseIP(token[start:end]); ip != nil {
+		return IPMatch{Start: start, End: end, IP: ip}, true
+	}
+	// IPv4 with a port
+	if colon := strings.LastIndexByte(token[start:end], ':'); colon > 0 &&
+		strings.Count(token[start:end], ":") == 1 {
+		if ip := net.ParseIP(token[start : start+colon]); ip != nil && ip.To4() != nil {
+			return IPMatch{Start: start, End: start + colon, IP: ip}, true
+		}
+	}
+	return IPMatch{}, false
+}
+
+/*
+followReader - An io.Reader that waits for more data at EOF, like tail -f
+*/
+type followReader struct {
+	r io.Reader
+}
+
+func (f followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+/*
+logsHelp - What the usage message of logs says after the synopsis
+*/
+const logsHelp = `Locate and plot every public IP Address found in a log file, or in
+stdin if no file is given.
+`
+
+func runLogs(args []string) error {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	follow := flags.Bool("follow", false, "Keep reading as the file grows, like tail -f")
+	format := addFormatFlag(flags)
+	intakeFlags := addIntakeFlags(flags)
+	limitFlags := addLimitFlags(flags)
+	sinks := addSinkFlags(flags)
+	alertFlags := addAlertFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s logs [-follow] [-format f] [intake flags] [limit flags] [alert flags] [sink flags] [file]\n",
+			os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(logsHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+	if err := checkFormat(*format); err != nil {
+		flags.Usage()
+		return err
+	}
+	intake, err := intakeFlags.open()
+	if err != nil {
+		return err
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	if *follow {
+		input = followReader{input}
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	alerts, err := alertFlags.open()
+	if err != nil {
+		return err
+	}
+	defer alerts.Close()
+
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		return err
+	}
+	b := NewBatch(queue)
+	b.source = "logs"
+	b.pipeline = pipeline
+	b.intake = intake
+	if err := limitFlags.apply(b); err != nil {
+		return err
+	}
+
+	// The reader only offers the addresses to the intake, so that a burst
+	// of lines cannot hold it up
+	read := func() {
+		err := scanLines(input, func(line string) {
+			for _, m := range findIPs(line) {
+				if !isPublicIP(m.IP) {
+					continue
+				}
+				// Counted as long as sampling keeps the address, even if
+				// the intake turns this sighting away
+				if intake.Keeps(m.IP.String()) {
+					b.count(m.IP.String(), Traffic{Hits: 1})
+				}
+				intake.Offer(m.IP.String())
+			}
+		})
+		if err != nil {
+			log.Println(err)
+		}
+		intake.Close()
+	}
+	lookup := func(ip string) {
+		if b.add(ip) {
+			b.lookup(ip)
+		}
+	}
+	if *format == formatJSONL {
+		events.sinks = append(events.sinks, NewStdoutSink(parseFieldList(sinks.fields)))
+		b.followAlerts(nil, alerts)
+		go b.retry()
+		go read()
+		intake.Run(lookup)
+		if n := intake.Dropped(); n > 0 {
+			warnf("%s", intake)
+		}
+		if n := queue.Len(); n > 0 {
+			warnf("%d lookups left for the next run to retry", n)
+		}
+		return nil
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setTalkersKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setPlaybackKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		b.followAlerts(gui, alerts)
+		go b.retry()
+		go b.expireWindow(gui)
+		go read()
+		go intake.Run(lookup)
+		return nil
+	})
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+/*
+The mail mode answers "can this IP Address send mail for this domain" with
+the checks receiving servers make: forward-confirmed reverse DNS, the SPF
+record of the domain and the DNS blocklists (see dnsbl.go).
+*/
+
+// Most DNS lookups an SPF evaluation may cause (RFC 7208, 4.6.4)
+const spfMaxLookups = 10
+
+/*
+MailCheck - Outcome of one check
+*/
+type MailCheck struct {
+	Name   string
+	Pass   bool
+	Detail string
+}
+
+func (c MailCheck) String() string {
+	verdict := "FAIL"
+	if c.Pass {
+		verdict = "PASS"
+	}
+	return fmt.Sprintf("[%s] %s: %s", verdict, c.Name, c.Detail)
+}
+
+/*
+checkFCrDNS - Whether a reverse DNS name of ip resolves back to ip
+*/
+func checkFCrDNS(ip net.IP) MailCheck {
+	check := MailCheck{Name: "Forward-confirmed reverse DNS"}
+	names, err := net.LookupAddr(ip.String())
+	if err != nil || len(names) == 0 {
+		check.Detail = "no reverse DNS name"
+		return check
+	}
+	for _, name := range names {
+		addrs, err := net.LookupIP(name)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.Equal(ip) {
+				check.Pass = true
+				check.Detail = strings.TrimSuffix(name, ".")
+				return check
+			}
+		}
+	}
+	check.Detail = fmt.Sprintf("%s does not resolve back to %s",
+		strings.TrimSuffix(names[0], "."), ip)
+	return check
+}
+
+/*
+spfEval - Evaluation of SPF records, counting DNS lookups
+*/
+type spfEval struct {
+	ip      net.IP
+	lookups int
+}
+
+/*
+spfRecord - The SPF record of domain, "" if it has none
+*/
+func spfRecord(domain string) (string, error) {
+	txts, err := net.LookupTXT(domain)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var record string
+	for _, txt := range txts {
+		if txt == "v=spf1" || strings.HasPrefix(txt, "v=spf1 ") {
+			if record != "" {
+				return "", fmt.Errorf("Several SPF records")
+			}
+			record = txt
+		}
+	}
+	return record, nil
+}
+
+/*
+inNetwork - Whether ip is within addr/bits, where the prefix length is
+written after addr or given as the default
+*/
+func inNetwork(ip net.IP, addr string, bits4, bits6 int) bool {
+	host := net.ParseIP(addr)
+	if host == nil {
+		return false
+	}
+	bits, size := bits6, 128
+	if host.To4() != nil {
+		bits, size = bits4, 32
+		host = host.To4()
+		if ip = ip.To4(); ip == nil {
+			return false
+		}
+	} else if ip.To4() != nil {
+		return false
+	}
+	return (&net.IPNet{IP: host.Mask(net.CIDRMask(bits, size)),
+		Mask: net.CIDRMask(bits, size)}).Contains(ip)
+}
+
+/*
+splitCIDR - Split the "/24" or "//64" (or "/24//64") prefix lengths off a
+mechanism argument
+*/
+func splitCIDR(arg string) (string, int, int) {
+	bits4, bits6 := 32, 128
+	if i := strings.Index(arg, "//"); i >= 0 {
+		fmt.Sscan(arg[i+2:], &bits6)
+		arg = arg[:i]
+	}
+	if i := strings.IndexByte(arg, '/'); i >= 0 {
+		fmt.Sscan(arg[i+1:], &bits4)
+		arg = arg[:i]
+	}
+	return arg, bits4, bits6
+}
+
+/*
+check - Evaluate the SPF record of domain: pass, fail, softfail, neutral,
+none or permerror. exists and ptr are not evaluated and never match.
+*/
+func (e *spfEval) check(domain string) (string, error) {
+	record, err := spfRecord(domain)
+	if err != nil {
+		return "permerror", err
+	}
+	if record == "" {
+		return "none", nil
+	}
+
+	redirect := ""
+	for _, term := range strings.Fields(record)[1:] {
+		if strings.HasPrefix(term, "redirect=") {
+			redirect = term[len("redirect="):]
+			continue
+		}
+		if strings.Contains(term, "=") {
+			// Other modifiers (exp=) do not change the result
+			continue
+		}
+
+		qualifier := "pass"
+		switch term[0] {
+		case '+':
+			term = term[1:]
+		case '-':
+			qualifier, term = "fail", term[1:]
+		case '~':
+			qualifier, term = "softfail", term[1:]
+		case '?':
+			qualifier, term = "neutral", term[1:]
+		}
+		mechanism, arg := term, ""
+		if i := strings.IndexAny(term, ":/"); i >= 0 {
+			mechanism, arg = term[:i], strings.TrimPrefix(term[i:], ":")
+		}
+
+		matched, err := e.match(domain, mechanism, arg)
+		if err != nil {
+			return "permerror", err
+		}
+		if matched {
+			return qualifier, nil
+		}
+	}
+	if redirect != "" {
+		if e.lookups++; e.lookups > spfMaxLookups {
+			return "permerror", fmt.Errorf("Too many DNS lookups")
+		}
+		return e.check(redirect)
+	}
+	return "neutral", nil
+}
+
+func (e *spfEval) match(domain, mechanism, arg string) (bool, error) {
+	switch mechanism {
+	case "all":
+		return true, nil
+	case "ip4", "ip6":
+		// A single prefix length, for the family of the mechanism
+		addr, bits := arg, -1
+		if i := strings.IndexByte(arg, '/'); i >= 0 {
+			addr = arg[:i]
+			if _, err := fmt.Sscan(arg[i+1:], &bits); err != nil {
+				return false, fmt.Errorf("Invalid %s:%s", mechanism, arg)
+			}
+		}
+		if mechanism == "ip4" {
+			if bits < 0 {
+				bits = 32
+			}
+			return inNetwork(e.ip, addr, bits, 128), nil
+		}
+		if bits < 0 {
+			bits = 128
+		}
+		return inNetwork(e.ip, addr, 32, bits), nil
+	case "include", "a", "mx", "exists", "ptr":
+		if e.lookups++; e.lookups > spfMaxLookups {
+			return false, fmt.Errorf("Too many DNS lookups")
+		}
+	default:
+		return false, fmt.Errorf("Unknown mechanism '%s'", mechanism)
+	}
+
+	target, bits4, bits6 := splitCIDR(arg)
+	if target == "" {
+		target = domain
+	}
+	switch mechanism {
+	case "include":
+		result, err := e.check(target)
+		if result == "none" {
+			return false, fmt.Errorf("include:%s has no SPF record", target)
+		}
+		return result == "pass", err
+	case "a":
+		return e.matchHost(target, bits4, bits6), nil
+	case "mx":
+		mxs, _ := net.LookupMX(target)
+		for _, mx := range mxs {
+			if e.matchHost(mx.Host, bits4, bits6) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (e *spfEval) matchHost(host string, bits4, bits6 int) bool {
+	addrs, _ := net.LookupIP(host)
+	for _, addr := range addrs {
+		if inNetwork(e.ip, addr.String(), bits4, bits6) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+checkSPF - Whether the SPF record of domain allows ip to send its mail
+*/
+func checkSPF(ip net.IP, domain string) MailCheck {
+	check := MailCheck{Name: "SPF of " + domain}
+	result, err := (&spfEval{ip: ip}).check(domain)
+	check.Pass = result == "pass"
+	check.Detail = result
+	if err != nil {
+		check.Detail += ": " + err.Error()
+	}
+	return check
+}
+
+/*
+mailChecks - Every check of ip, and of its SPF coverage if domain is set
+*/
+func mailChecks(ip net.IP, domain string) []MailCheck {
+	checks := []MailCheck{checkFCrDNS(ip)}
+	if domain != "" {
+		checks = append(checks, checkSPF(ip, domain))
+	}
+	rep := checkReputation(ip, dnsblLists())
+	checks = append(checks, MailCheck{Name: "Blocklists",
+		Pass: len(rep.Listed()) == 0, Detail: rep.Summary()})
+	return checks
+}
+
+/*
+mailSummary - The checks and their verdict, as text
+*/
+func mailSummary(ip net.IP, domain string, checks []MailCheck) (string, bool) {
+	var b strings.Builder
+	if domain != "" {
+		fmt.Fprintf(&b, "Mail from %s for %s\n\n", ip, domain)
+	} else {
+		fmt.Fprintf(&b, "Mail from %s\n\n", ip)
+	}
+	ok := true
+	for _, check := range checks {
+		fmt.Fprintln(&b, check)
+		ok = ok && check.Pass
+	}
+	if ok {
+		fmt.Fprintln(&b, "\nVerdict: should be accepted")
+	} else {
+		fmt.Fprintln(&b, "\nVerdict: likely to be rejected or marked as spam")
+	}
+	return b.String(), ok
+}
+
+/*
+mailHelp - What the usage message of mail says after the synopsis
+*/
+const mailHelp = `Check whether ip can send mail (for domain): forward-confirmed reverse
+DNS, the SPF record of domain and the DNS blocklists. When stdout is
+not a terminal the summary is printed, and a failed check exits with 5.
+`
+
+func runMail(args []string) error {
+	flags := flag.NewFlagSet("mail", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s mail ip [domain]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(mailHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify an IP Address and a domain.")
+	}
+	ip, err := makeIP(flags.Args()[:1])
+	if err != nil {
+		return withExitCode(exitInvalidInput, err)
+	}
+	domain := strings.TrimSuffix(flags.Arg(1), ".")
+
+	checks := mailChecks(ip, domain)
+	summary, ok := mailSummary(ip, domain, checks)
+	if checkTerminal() != nil {
+		fmt.Print(summary)
+		if !ok {
+			return &ExitError{exitPolicy, fmt.Errorf("%s failed the mail checks", ip)}
+		}
+		return nil
+	}
+
+	ipinfo, err := getIPInfo(ip)
+	if err != nil {
+		return withExitCode(exitLookupFailed, err)
+	}
+	status := "Mail checks passed"
+	if !ok {
+		status = "Mail checks failed"
+	}
+	return runGui(func(gui *Gui) error {
+		if _, _, err := ipinfo.GetLonLat(); err == nil {
+			go guiLoadMap(ipinfo, gui)
+		}
+		gui.Execute(func(g *Gui) error {
+			view, err := g.View("info")
+			if err != nil {
+				return err
+			}
+			view.Clear()
+			fmt.Fprint(view, summary)
+			fmt.Fprintf(view, "\nLocated in %s, %s (%s)\n", fieldValue(ipinfo, "city"),
+				fieldValue(ipinfo, "country"), fieldValue(ipinfo, "org"))
+			return nil
+		})
+		guiShowStatus(gui, "%s", status)
+		return nil
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// State of an established connection in /proc/net/tcp
+const tcpEstablished = "01"
+
+/*
+tcpPeers - Remote addresses of the established TCP connections of this host,
+read from /proc/net/tcp and /proc/net/tcp6. Loopback, private and link-local
+peers are left out since they can't be located.
+*/
+func tcpPeers() ([]net.IP, error) {
+	var peers []net.IP
+	seen := make(map[string]bool)
+	found := false
+
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 4 || fields[3] != tcpEstablished {
+				continue
+			}
+			ip, err := parseProcAddr(fields[2])
+			if err != nil || !isPublicIP(ip) || seen[ip.String()] {
+				continue
+			}
+			seen[ip.String()] = true
+			peers = append(peers, ip)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("No /proc/net/tcp on this system")
+	}
+	return peers, nil
+}
+
+/*
+parseProcAddr - Decode an address of /proc/net/tcp{,6} ("0100007F:0016"),
+which is hex encoded as native-endian (little-endian) 32 bit words
+*/
+func parseProcAddr(addr string) (net.IP, error) {
+	parts := strings.SplitN(addr, ":", 2)
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != net.IPv4len && len(raw) != net.IPv6len {
+		return nil, fmt.Errorf("Unexpected address length in '%s'", addr)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	return ip, nil
+}
+
+/*
+isPublicIP - Whether ip is routable on the internet and so can be located
+*/
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast())
+}
+
+/*
+monitorHelp - What the usage message of monitor says after the synopsis
+*/
+const monitorHelp = `Locate and plot the remote end of every TCP connection of this host,
+as connections come and go.
+`
+
+func runMonitor(args []string) error {
+	flags := flag.NewFlagSet("monitor", flag.ExitOnError)
+	addOutputFlags(flags)
+	addProviderFlag(flags)
+	interval := flags.Duration("interval", 10*time.Second,
+		"How often to look for new connections")
+	format := addFormatFlag(flags)
+	intakeFlags := addIntakeFlags(flags)
+	limitFlags := addLimitFlags(flags)
+	sinks := addSinkFlags(flags)
+	alertFlags := addAlertFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s monitor [-interval d] [-format f] [intake flags] [limit flags] [alert flags] [sink flags]\n",
+			os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(monitorHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if err := checkFormat(*format); err != nil {
+		flags.Usage()
+		return err
+	}
+	intake, err := intakeFlags.open()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tcpPeers(); err != nil {
+		return err
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	alerts, err := alertFlags.open()
+	if err != nil {
+		return err
+	}
+	defer alerts.Close()
+
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		return err
+	}
+	b := NewBatch(queue)
+	b.source = "monitor"
+	b.pipeline = pipeline
+	b.intake = intake
+	if err := limitFlags.apply(b); err != nil {
+		return err
+	}
+
+	poll := func() {
+		// Peers already queued are not offered again on every poll, as
+		// long as they are remembered
+		offered := newRecentSet()
+		for {
+			peers, err := tcpPeers()
+			if err != nil {
+				log.Println(err)
+			}
+			for _, peer := range peers {
+				if intake.Keeps(peer.String()) {
+					b.count(peer.String(), Traffic{Hits: 1})
+				}
+				if !offered.has(peer.String()) && intake.Offer(peer.String()) {
+					offered.touch(peer.String())
+					offered.trim(limitFlags.targets, nil)
+				}
+			}
+			time.Sleep(*interval)
+		}
+	}
+	lookup := func(ip string) {
+		if b.add(ip) {
+			b.lookup(ip)
+		}
+	}
+	if *format == formatJSONL {
+		events.sinks = append(events.sinks, NewStdoutSink(parseFieldList(sinks.fields)))
+		b.followAlerts(nil, alerts)
+		go b.retry()
+		go poll()
+		intake.Run(lookup) // until interrupted
+		return nil
+	}
+
+	return runGui(func(gui *Gui) error {
+		if err := b.setKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setTalkersKeybindings(gui); err != nil {
+			return err
+		}
+		if err := b.setPlaybackKeybindings(gui); err != nil {
+			return err
+		}
+		b.refresh(gui)
+		b.follow(gui)
+		b.followAlerts(gui, alerts)
+		go b.retry()
+		go b.expireWindow(gui)
+		go poll()
+		go intake.Run(lookup)
+		return nil
+	})
+}
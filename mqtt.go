@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	mqttKeepAlive   = 60 * time.Second
+	mqttDialTimeout = 10 * time.Second
+)
+
+/*
+mqttWriteTimeout - How long a packet may take to be written before the
+broker is given up on and the connection re-established
+*/
+var mqttWriteTimeout = 10 * time.Second
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPingreq    = 0xc0
+	mqttDisconnect = 0xe0
+)
+
+/*
+MQTTSink - Publishes events as JSON to a topic on an MQTT broker (QoS 0).
+Only the small subset of MQTT 3.1.1 needed to publish is implemented. The
+connection is re-established on the next publish if it drops.
+*/
+type MQTTSink struct {
+	mu       sync.Mutex
+	broker   *url.URL
+	topic    string
+	clientID string
+	conn     net.Conn
+	done     chan struct{}
+}
+
+/*
+NewMQTTSink - Connect to the broker at rawurl (tcp://, mqtt://, ssl://,
+tls:// or mqtts://, with optional user:password) and publish to topic
+*/
+func NewMQTTSink(rawurl, topic string) (*MQTTSink, error) {
+	broker, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch broker.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return nil, fmt.Errorf("Unsupported MQTT broker scheme '%s'", broker.Scheme)
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("Missing MQTT topic")
+	}
+
+	s := &MQTTSink{
+		broker:   broker,
+		topic:    topic,
+		clientID: fmt.Sprintf("ip411-%d", os.Getpid()),
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MQTTSink) connect() error {
+	secure := s.broker.Scheme == "ssl" || s.broker.Scheme == "tls" ||
+		s.broker.Scheme == "mqtts"
+
+	host := s.broker.Host
+	if s.broker.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(s.broker.Hostname(), "8883")
+		} else {
+			host = net.JoinHostPort(s.broker.Hostname(), "1883")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: mqttDialTimeout}
+	var conn net.Conn
+	var err error
+	if secure {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host,
+			&tls.Config{ServerName: s.broker.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return err
+	}
+
+	var payload bytes.Buffer
+	flags := byte(0x02) // clean session
+	writeMQTTString(&payload, s.clientID)
+	if user := s.broker.User; user != nil {
+		flags |= 0x80
+		writeMQTTString(&payload, user.Username())
+		if password, ok := user.Password(); ok {
+			flags |= 0x40
+			writeMQTTString(&payload, password)
+		}
+	}
+
+	var packet bytes.Buffer
+	writeMQTTString(&packet, "MQTT")
+	packet.WriteByte(4) // protocol level 3.1.1
+	packet.WriteByte(flags)
+	keepAlive := uint16(mqttKeepAlive / time.Second)
+	packet.Write([]byte{byte(keepAlive >> 8), byte(keepAlive)})
+	packet.Write(payload.Bytes())
+
+	conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+	if err := writeMQTTPacket(conn, mqttConnect, packet.Bytes()); err != nil {
+		conn.Close()
+		return err
+	}
+
+	header, ack, err := readMQTTPacket(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if header != mqttConnack || len(ack) != 2 {
+		conn.Close()
+		return fmt.Errorf("Unexpected MQTT packet 0x%02x instead of CONNACK", header)
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		return fmt.Errorf("MQTT broker refused connection (code %d)", ack[1])
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	s.done = make(chan struct{})
+	go s.keepAlive(conn, s.done)
+	// Drain PINGRESPs; the broker sends nothing else for QoS 0 publishes
+	go io.Copy(ioutil.Discard, conn)
+	return nil
+}
+
+func (s *MQTTSink) keepAlive(conn net.Conn, done chan struct{}) {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.conn == conn {
+				if err := s.write(mqttPingreq, nil); err != nil {
+					s.drop()
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// drop closes the current connection; callers must hold s.mu
+func (s *MQTTSink) drop() {
+	if s.conn == nil {
+		return
+	}
+	close(s.done)
+	s.conn.Close()
+	s.conn = nil
+}
+
+/*
+Publish - Send e as a JSON message to the sink's topic
+*/
+func (s *MQTTSink) Publish(e Event) error {
+	message, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var packet bytes.Buffer
+	writeMQTTString(&packet, s.topic)
+	packet.Write(message)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	err = s.write(mqttPublish, packet.Bytes())
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		// The broker stopped reading, and part of the packet may be sent
+		// already: only a new connection can get the message through
+		s.drop()
+		if err = s.connect(); err == nil {
+			err = s.write(mqttPublish, packet.Bytes())
+		}
+	}
+	if err != nil {
+		s.drop()
+		return err
+	}
+	return nil
+}
+
+// write sends a packet on the current connection, giving up after
+// mqttWriteTimeout; callers must hold s.mu
+func (s *MQTTSink) write(header byte, body []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(mqttWriteTimeout))
+	return writeMQTTPacket(s.conn, header, body)
+}
+
+/*
+Close - Disconnect from the broker
+*/
+func (s *MQTTSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	s.write(mqttDisconnect, nil)
+	s.drop()
+	return nil
+}
+
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	buf.Write([]byte{byte(len(s) >> 8), byte(len(s))})
+	buf.WriteString(s)
+}
+
+func writeMQTTPacket(w io.Writer, header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+	_, err := w.Write(packet)
+	return err
+}
+
+/*
+readMQTTPacket - Read a control packet, returning its first byte and its body
+*/
+func readMQTTPacket(r io.Reader) (byte, []byte, error) {
+	var fixed [1]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return 0, nil, err
+	}
+	length := 0
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("Malformed MQTT packet length")
+		}
+		var digit [1]byte
+		if _, err := io.ReadFull(r, digit[:]); err != nil {
+			return 0, nil, err
+		}
+		length |= int(digit[0]&0x7f) << (7 * i)
+		if digit[0]&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return fixed[0], body, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMQTTPacketLength(t *testing.T) {
+	for _, c := range []struct {
+		length int
+		want   []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{321, []byte{0xc1, 0x02}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+		{2097152, []byte{0x80, 0x80, 0x80, 0x01}},
+	} {
+		body := bytes.Repeat([]byte{'x'}, c.length)
+		var buf bytes.Buffer
+		if err := writeMQTTPacket(&buf, mqttPublish, body); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.Bytes()[1 : 1+len(c.want)]; !bytes.Equal(got, c.want) {
+			t.Errorf("length %d encoded as % x, expected % x", c.length, got, c.want)
+		}
+		header, decoded, err := readMQTTPacket(&buf)
+		if err != nil || header != mqttPublish || !bytes.Equal(decoded, body) {
+			t.Errorf("length %d decoded as 0x%02x, %d bytes, %v", c.length, header, len(decoded), err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("length %d: %d bytes left", c.length, buf.Len())
+		}
+	}
+}
+
+func TestReadMQTTPacketErrors(t *testing.T) {
+	for name, packet := range map[string][]byte{
+		"empty":           {},
+		"no length":       {mqttConnack},
+		"length too long": {mqttPublish, 0x80, 0x80, 0x80, 0x80, 0x01},
+		"short body":      {mqttConnack, 0x02, 0x00},
+	} {
+		if _, _, err := readMQTTPacket(bytes.NewReader(packet)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+/*
+mqttBroker - Accept connections on a local port, answer their CONNECT and
+pass them to handle
+*/
+func mqttBroker(t *testing.T, handle func(n int, conn net.Conn, connect []byte)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for n := 0; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+			header, connect, err := readMQTTPacket(conn)
+			if err != nil || header != mqttConnect {
+				t.Errorf("broker read 0x%02x, %v, expected CONNECT", header, err)
+				return
+			}
+			writeMQTTPacket(conn, mqttConnack, []byte{0, 0})
+			go handle(n, conn, connect)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestMQTTSinkPublish(t *testing.T) {
+	connects := make(chan []byte, 1)
+	publishes := make(chan []byte, 1)
+	addr := mqttBroker(t, func(n int, conn net.Conn, connect []byte) {
+		connects <- connect
+		if header, body, err := readMQTTPacket(conn); err == nil && header == mqttPublish {
+			publishes <- body
+		}
+	})
+
+	sink, err := NewMQTTSink("tcp://user:secret@"+addr, "ip411/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	var want bytes.Buffer
+	writeMQTTString(&want, "MQTT")
+	want.Write([]byte{4, 0x02 | 0x80 | 0x40, 0, 60})
+	writeMQTTString(&want, sink.clientID)
+	writeMQTTString(&want, "user")
+	writeMQTTString(&want, "secret")
+	if connect := <-connects; !bytes.Equal(connect, want.Bytes()) {
+		t.Fatalf("CONNECT % x, expected % x", connect, want.Bytes())
+	}
+
+	if err := sink.Publish(Event{Type: "lookup", IP: "1.1.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	body := <-publishes
+	topic := "\x00\x0cip411/events"
+	if !strings.HasPrefix(string(body), topic) {
+		t.Fatalf("PUBLISH %q, expected the topic first", body)
+	}
+	var e Event
+	if err := json.Unmarshal(body[len(topic):], &e); err != nil || e.Type != "lookup" || e.IP != "1.1.1.1" {
+		t.Fatalf("published %s, %v", body[len(topic):], err)
+	}
+}
+
+func TestMQTTSinkReconnectsOnWriteTimeout(t *testing.T) {
+	saved := mqttWriteTimeout
+	defer func() { mqttWriteTimeout = saved }()
+	mqttWriteTimeout = 100 * time.Millisecond
+
+	published := make(chan struct{}, 1)
+	addr := mqttBroker(t, func(n int, conn net.Conn, connect []byte) {
+		if n == 0 {
+			// A stuck broker: accepts, then never reads
+			return
+		}
+		for {
+			header, _, err := readMQTTPacket(conn)
+			if err != nil {
+				return
+			}
+			if header == mqttPublish {
+				select {
+				case published <- struct{}{}:
+				default:
+				}
+			}
+		}
+	})
+
+	sink, err := NewMQTTSink("tcp://"+addr, "ip411/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	// Fill the buffers of the stuck connection until a write times out
+	e := Event{Type: "lookup", Reason: strings.Repeat("x", 1<<20)}
+	for i := 0; i < 64; i++ {
+		if err := sink.Publish(e); err != nil {
+			t.Fatalf("publish %d: %s", i, err)
+		}
+		select {
+		case <-published:
+			return
+		default:
+		}
+	}
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no publish reached the broker after a write timeout")
+	}
+}
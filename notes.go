@@ -0,0 +1,452 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+Notes attach free text to IP Addresses, for the case notes of an
+investigation. A note may pin the result of the address as it was looked up
+then. Whenever the address is looked up again its result carries the note
+under "note", with what changed since it was pinned:
+
+	note.text     the note
+	note.pinned   when the result was pinned, if it was
+	note.changed  what changed since, "country was US, org was ..."
+
+The info pane shows the note, and in the single address view p pins the
+result shown with a note (an empty note removes it). There is no history
+database in ip411: notes are kept in notes.json next to the config file, and
+managed with the note mode:
+
+	note list               every note
+	note show ip            the note of ip and its pinned result
+	note add ip text...     attach a note to ip, replacing the one it had
+	note pin ip [text...]   look ip up and pin its result, with a note
+	note rm ip              remove the note of ip
+*/
+
+// Fields compared between a pinned result and a new one
+var noteFields = []string{"country", "region", "city", "org", "hostname", "bgp.origin"}
+
+/*
+Note - A note about an IP Address
+*/
+type Note struct {
+	IP      string       `json:"ip"`
+	Text    string       `json:"text"`
+	Pinned  time.Time    `json:"pinned,omitempty"`
+	Result  IPInfoResult `json:"result,omitempty"`
+	Updated time.Time    `json:"updated"`
+}
+
+/*
+NoteStore - The notes, written to disk on every change
+*/
+type NoteStore struct {
+	mu    sync.Mutex
+	path  string
+	notes map[string]*Note
+}
+
+var (
+	notesOnce sync.Once
+	notes     *NoteStore
+	notesErr  error
+)
+
+func notesPath() string {
+	if path := defaultConfigPath(); path != "" {
+		return filepath.Join(filepath.Dir(path), "notes.json")
+	}
+	return ""
+}
+
+/*
+loadNotes - The notes of notes.json, read once
+*/
+func loadNotes() (*NoteStore, error) {
+	notesOnce.Do(func() {
+		notes = &NoteStore{path: notesPath(), notes: make(map[string]*Note)}
+		if notes.path == "" {
+			return
+		}
+		data, err := ioutil.ReadFile(notes.path)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			notesErr = err
+			return
+		}
+		var list []*Note
+		if err := json.Unmarshal(data, &list); err != nil {
+			notesErr = fmt.Errorf("%s: %s", notes.path, err)
+			return
+		}
+		for _, note := range list {
+			notes.notes[note.IP] = note
+		}
+	})
+	return notes, notesErr
+}
+
+/*
+Get - The note of ip, nil if it has none
+*/
+func (s *NoteStore) Get(ip string) *Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if note, ok := s.notes[ip]; ok {
+		copied := *note
+		return &copied
+	}
+	return nil
+}
+
+/*
+List - The notes, by address
+*/
+func (s *NoteStore) List() []Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Note, 0, len(s.notes))
+	for _, note := range s.notes {
+		list = append(list, *note)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].IP < list[j].IP })
+	return list
+}
+
+/*
+Set - Attach text to ip, pinning res unless it is nil (a note keeps the
+result it pinned before otherwise)
+*/
+func (s *NoteStore) Set(ip, text string, res IPInfoResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	note, ok := s.notes[ip]
+	if !ok {
+		note = &Note{IP: ip}
+		s.notes[ip] = note
+	}
+	note.Text = text
+	note.Updated = time.Now()
+	if res != nil {
+		pinned := make(IPInfoResult, len(res))
+		for key, val := range res {
+			if key != "note" {
+				pinned[key] = val
+			}
+		}
+		note.Result, note.Pinned = pinned, note.Updated
+	}
+	return s.save()
+}
+
+/*
+Remove - Remove the note of ip. Returns whether it had one.
+*/
+func (s *NoteStore) Remove(ip string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.notes[ip]; !ok {
+		return false, nil
+	}
+	delete(s.notes, ip)
+	return true, s.save()
+}
+
+func (s *NoteStore) save() error {
+	if s.path == "" {
+		return fmt.Errorf("No config directory to store the notes in")
+	}
+	list := make([]*Note, 0, len(s.notes))
+	for _, note := range s.notes {
+		list = append(list, note)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].IP < list[j].IP })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, append(data, '\n'), 0600)
+}
+
+/*
+changes - What differs between the pinned result of the note and res, "" if
+nothing or nothing was pinned
+*/
+func (n *Note) changes(res IPInfoResult) string {
+	if n.Result == nil {
+		return ""
+	}
+	return resultChanges(n.Result, res)
+}
+
+/*
+resultChanges - What differs between two results of an address, as
+"country was US, city was ...", "" if nothing
+*/
+func resultChanges(previous, res IPInfoResult) string {
+	var changed []string
+	for _, field := range noteFields {
+		was, now := fieldValue(previous, field), fieldValue(res, field)
+		if was == now {
+			continue
+		}
+		if was == "" {
+			was = "none"
+		}
+		changed = append(changed, fmt.Sprintf("%s was %s", field, was))
+	}
+	return strings.Join(changed, ", ")
+}
+
+/*
+addNote - Add the note of the address of res under "note", if it has one
+*/
+func addNote(res IPInfoResult) {
+	store, err := loadNotes()
+	if err != nil {
+		return
+	}
+	note := store.Get(fieldValue(res, "ip"))
+	if note == nil {
+		return
+	}
+	field := map[string]interface{}{"text": note.Text}
+	if !note.Pinned.IsZero() {
+		field["pinned"] = note.Pinned.Format(time.RFC3339)
+		if changed := note.changes(res); changed != "" {
+			field["changed"] = changed
+		}
+	}
+	res["note"] = field
+}
+
+/*
+noteLine - The line of the info pane showing the note of res, "" if it has
+none
+*/
+func noteLine(res IPInfoResult) string {
+	text := fieldValue(res, "note.text")
+	if text == "" {
+		return ""
+	}
+	line := fmt.Sprintf(tr("Note: %s"), text)
+	if pinned, err := time.Parse(time.RFC3339, fieldValue(res, "note.pinned")); err == nil {
+		line += fmt.Sprintf(tr(" (pinned %s)"), pinned.Local().Format("2006-01-02"))
+	}
+	if changed := fieldValue(res, "note.changed"); changed != "" {
+		line += fmt.Sprintf(tr(", changed since: %s"), changed)
+	}
+	return line
+}
+
+// Pinning from the interface
+
+func openNotePrompt(g *Gui, v *View) error {
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+	ip := fieldValue(res, "ip")
+	if ip == "" {
+		guiShowStatus(g, "No IP Address to pin")
+		return nil
+	}
+	return inputDialog(g, "Note (enter pin, esc cancel)", fieldValue(res, "note.text"), nil,
+		confirmNote)
+}
+
+/*
+confirmNote - Pin the result with text, an empty text removing the note
+once confirmed
+*/
+func confirmNote(g *Gui, text string) error {
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+	if text == "" && fieldValue(res, "note.text") != "" {
+		return confirmDialog(g, func(g *Gui) error {
+			return pinNote(g, text)
+		}, "Remove the note of %s?", fieldValue(res, "ip"))
+	}
+	return pinNote(g, text)
+}
+
+func pinNote(g *Gui, text string) error {
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+	ip := fieldValue(res, "ip")
+	store, err := loadNotes()
+	if err == nil {
+		if text == "" {
+			_, err = store.Remove(ip)
+		} else {
+			err = store.Set(ip, text, res)
+		}
+	}
+	if err != nil {
+		guiShowStatus(g, "Could not save the note: %s", err)
+		return nil
+	}
+
+	// The result shown, as it will be on its next lookup
+	pinned := make(IPInfoResult, len(res))
+	for key, val := range res {
+		if key != "note" {
+			pinned[key] = val
+		}
+	}
+	addNote(pinned)
+	guiLoadInfo(pinned, g)
+	if text == "" {
+		guiShowStatus(g, "Note of %s removed", ip)
+	} else {
+		guiShowStatus(g, "Result of %s pinned", ip)
+	}
+	return nil
+}
+
+/*
+setNoteKeybindings - Register the keys pinning the result shown with a note
+*/
+func setNoteKeybindings(g *Gui) error {
+	return g.SetKeybinding("", 'p', ModNone, openNotePrompt)
+}
+
+// The note mode
+
+/*
+noteIP - The IP Address argument of the note mode
+*/
+func noteIP(arg string) (string, error) {
+	ip := net.ParseIP(arg)
+	if ip == nil {
+		return "", invalidInput("Invalid IP Address '%s'", arg)
+	}
+	return ip.String(), nil
+}
+
+func printNote(note Note) error {
+	fmt.Printf("%s: %s\n", note.IP, note.Text)
+	fmt.Printf("Updated: %s\n", note.Updated.Format(time.RFC3339))
+	if note.Result == nil {
+		return nil
+	}
+	fmt.Printf("Pinned: %s\n", note.Pinned.Format(time.RFC3339))
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(note.Result)
+}
+
+/*
+noteHelp - What the usage message of note says after the synopsis
+*/
+const noteHelp = `Keep case notes about IP Addresses, shown whenever they are looked up
+again:
+
+  list               every note
+  show ip            the note of ip and its pinned result
+  add ip text...     attach a note to ip, replacing the one it had
+  pin ip [text...]   look ip up and pin its result, with a note
+  rm ip              remove the note of ip
+`
+
+func runNote(args []string) error {
+	flags := flag.NewFlagSet("note", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s note [-provider list] list|show|add|pin|rm [ip] [text...]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(noteHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return invalidInput("Specify list, show, add, pin or rm.")
+	}
+	store, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+	if command == "list" {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, note := range store.List() {
+			pinned := ""
+			if note.Result != nil {
+				pinned = "pinned"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", note.IP,
+				note.Updated.Format("2006-01-02"), pinned, note.Text)
+		}
+		return tw.Flush()
+	}
+
+	if len(args) == 0 {
+		return invalidInput("Specify an IP Address.")
+	}
+	ip, err := noteIP(args[0])
+	if err != nil {
+		return err
+	}
+	text := strings.Join(args[1:], " ")
+	switch command {
+	case "show":
+		note := store.Get(ip)
+		if note == nil {
+			return fmt.Errorf("No note about %s", ip)
+		}
+		return printNote(*note)
+	case "add":
+		if text == "" {
+			return invalidInput("Specify the text of the note.")
+		}
+		return store.Set(ip, text, nil)
+	case "pin":
+		res, err := locateTarget([]string{ip})
+		if err != nil {
+			return err
+		}
+		if note := store.Get(ip); note != nil && text == "" {
+			text = note.Text
+		}
+		if err := store.Set(ip, text, res); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Result of %s pinned\n", ip)
+		return nil
+	case "rm":
+		removed, err := store.Remove(ip)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("No note about %s", ip)
+		}
+		return nil
+	}
+	flags.Usage()
+	return invalidInput("Unknown note command '%s'", command)
+}
@@ -0,0 +1,100 @@
+package main
+
+import "fmt"
+
+const notifyBacklog = 20
+
+// Event types notified by default: those worth interrupting someone for
+var defaultNotifyEvents = []string{EventAlert, EventIPChange}
+
+/*
+NotifySink - Shows events as desktop notifications: libnotify (notify-send)
+on Linux and the BSDs, Notification Center (osascript) on macOS. They are
+shown in the background, in order, so a slow notification daemon holds back
+no other sink.
+*/
+type NotifySink struct {
+	types   map[string]bool
+	backlog chan Event
+	done    chan struct{}
+}
+
+/*
+NewNotifySink - Create a sink notifying the event types listed in types,
+alerts and IP changes if types is empty
+*/
+func NewNotifySink(types []string) (*NotifySink, error) {
+	if err := notifyAvailable(); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		types = defaultNotifyEvents
+	}
+	s := &NotifySink{
+		types:   make(map[string]bool),
+		backlog: make(chan Event, notifyBacklog),
+		done:    make(chan struct{}),
+	}
+	for _, typ := range types {
+		s.types[typ] = true
+	}
+	go s.notifyLoop()
+	return s, nil
+}
+
+/*
+Publish - Queue the notification of e
+*/
+func (s *NotifySink) Publish(e Event) error {
+	if !s.types[e.Type] {
+		return nil
+	}
+	select {
+	case s.backlog <- e:
+		return nil
+	default:
+		return fmt.Errorf("Notification backlog full, dropping %s event", e.Type)
+	}
+}
+
+/*
+notification - Title and body of the notification of e, and whether it is
+urgent
+*/
+func notification(e Event) (string, string, bool) {
+	place := joinNonEmpty(fieldValue(e.Result, "city"), e.Country)
+	switch e.Type {
+	case EventAlert:
+		return fmt.Sprintf(tr("ip411 alert: %s"), e.Rule),
+			joinNonEmpty(e.IP, place, e.Reason), true
+	case EventIPChange:
+		body := e.IP
+		if e.Previous != "" {
+			body = fmt.Sprintf(tr("%s, was %s"), e.IP, e.Previous)
+		}
+		return tr("ip411: the public IP Address changed"), joinNonEmpty(body, place), false
+	case EventNewCountry:
+		return fmt.Sprintf(tr("ip411: first address from %s"), e.Country),
+			joinNonEmpty(e.IP, place), false
+	}
+	return "ip411: " + e.Type, joinNonEmpty(e.IP, place), false
+}
+
+func (s *NotifySink) notifyLoop() {
+	defer close(s.done)
+	for e := range s.backlog {
+		title, body, urgent := notification(e)
+		if err := desktopNotify(title, body, urgent); err != nil {
+			warnf("Could not show the notification of a %s event: %s", e.Type, err)
+		}
+	}
+}
+
+/*
+Close - Wait for the queued notifications to be shown
+*/
+func (s *NotifySink) Close() error {
+	close(s.backlog)
+	<-s.done
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+On macOS notifications go to the Notification Center, through osascript(1).
+The title and body are passed as arguments of the script rather than
+quoted into it.
+*/
+
+const notifyScript = `on run argv
+	display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+
+func notifyAvailable() error {
+	_, err := exec.LookPath("osascript")
+	return err
+}
+
+func desktopNotify(title, body string, urgent bool) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("osascript", "-e", notifyScript, title, body)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("osascript: %s", msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/*
+Elsewhere notifications go to the freedesktop notification daemon over
+D-Bus, through notify-send(1) from libnotify
+*/
+
+func notifyAvailable() error {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return fmt.Errorf("Desktop notifications need notify-send, from libnotify")
+	}
+	return nil
+}
+
+func desktopNotify(title, body string, urgent bool) error {
+	urgency := "normal"
+	if urgent {
+		urgency = "critical"
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("notify-send", "--app-name=ip411", "--urgency="+urgency, "--", title, body)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("notify-send: %s", msg)
+		}
+		return err
+	}
+	return nil
+}
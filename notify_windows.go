@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+/*
+Desktop notifications are not available on Windows yet
+*/
+
+func notifyAvailable() error {
+	return fmt.Errorf("Desktop notifications are not supported on Windows")
+}
+
+func desktopNotify(title, body string, urgent bool) error {
+	return notifyAvailable()
+}
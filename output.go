@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+/*
+Only requested data goes to stdout; diagnostics go to stderr through the log
+package. Warnings, which do not change the outcome, can be silenced with
+-quiet. The interface only starts when stdout is a terminal, so that
+`ip411 8.8.8.8 | jq` prints the result instead of drawing a map into the
+pipe, unless -tui forces it.
+*/
+
+var (
+	quiet    bool
+	forceTUI bool
+)
+
+/*
+addQuietFlag - Register -quiet, and -lang and -precision, on the flags of a
+mode
+*/
+func addQuietFlag(flags *flag.FlagSet) {
+	flags.BoolVar(&quiet, "quiet", false, "Do not print warnings, only errors")
+	addLangFlag(flags)
+	addPrecisionFlag(flags)
+}
+
+/*
+addOutputFlags - Register -quiet and -tui on the flags of a mode showing the
+interface
+*/
+func addOutputFlags(flags *flag.FlagSet) {
+	addQuietFlag(flags)
+	flags.BoolVar(&forceTUI, "tui", false,
+		"Start the interface even if stdout is not a terminal")
+	addGlyphsFlag(flags)
+	addUnitsFlag(flags)
+	addRegionFlag(flags)
+}
+
+/*
+warnf - Log a warning, unless -quiet
+*/
+func warnf(format string, args ...interface{}) {
+	if !quiet {
+		log.Printf(tr(format), args...)
+	}
+}
+
+/*
+isTerminal - Whether f is a terminal (a character device, at least)
+*/
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+checkTerminal - Fail unless the interface can be drawn on stdout
+*/
+func checkTerminal() error {
+	if forceTUI || isTerminal(os.Stdout) {
+		return nil
+	}
+	return invalidInput("Standard output is not a terminal, " +
+		"use -tui to start the interface anyway")
+}
+
+// Values of -format in the long-running modes
+const (
+	formatMap   = "map"
+	formatJSONL = "jsonl"
+)
+
+/*
+addFormatFlag - Register -format on the flags of a long-running mode
+*/
+func addFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", formatMap,
+		"map, or jsonl to stream one JSON event per line to stdout instead")
+}
+
+/*
+checkFormat - Fail unless format is a value of -format
+*/
+func checkFormat(format string) error {
+	switch format {
+	case formatMap, formatJSONL:
+		return nil
+	}
+	return invalidInput("Invalid format '%s': Expected %s or %s.", format, formatMap, formatJSONL)
+}
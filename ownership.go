@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+/*
+Owned prefixes are the address space of the user, registered in the config
+file with the AS and org it should be attributed to:
+
+	"owned_prefixes": [
+	  {"prefix": "203.0.113.0/24", "asn": "AS64500", "org": "Example Corp"}
+	]
+
+The built-in alert rule "ownership" (-alert ownership) fires for results of
+addresses in an owned prefix that lookups attribute to another AS (the AS
+number of the org, asn.asn or the BGP origin of -bgp) or to an org not
+containing the one registered: a hijack, or stale registry data. The recheck
+mode looks an address of every owned prefix up on its schedule, with -bgp
+asking RIPEstat which AS announces it.
+*/
+
+/*
+OwnedPrefix - A prefix of the user and who it belongs to
+*/
+type OwnedPrefix struct {
+	Prefix string `json:"prefix"`
+	ASN    string `json:"asn,omitempty"`
+	Org    string `json:"org,omitempty"`
+
+	network *net.IPNet
+}
+
+const alertOwnership = "ownership"
+
+var (
+	asnPrefix = regexp.MustCompile(`^(?i)AS(\d+)\b`) // of "AS64500 Example Corp"
+	asnNumber = regexp.MustCompile(`^\d+$`)
+)
+
+/*
+normalizeASN - "AS64500" from "AS64500", "as64500" or "64500", "" if asn is
+none of these
+*/
+func normalizeASN(asn string) string {
+	asn = strings.TrimSpace(asn)
+	if m := asnPrefix.FindStringSubmatch(asn); m != nil {
+		return "AS" + m[1]
+	}
+	if asnNumber.MatchString(asn) {
+		return "AS" + asn
+	}
+	return ""
+}
+
+/*
+prepareOwnedPrefixes - Parse the owned prefixes of the config file
+*/
+func prepareOwnedPrefixes(owned []OwnedPrefix) error {
+	for i := range owned {
+		o := &owned[i]
+		_, network, err := net.ParseCIDR(o.Prefix)
+		if err != nil {
+			return fmt.Errorf("Invalid owned prefix '%s': %s", o.Prefix, err)
+		}
+		if o.ASN != "" {
+			asn := normalizeASN(o.ASN)
+			if asn == "" {
+				return fmt.Errorf("Invalid AS '%s' of owned prefix %s: Expected AS64500.", o.ASN, o.Prefix)
+			}
+			o.ASN = asn
+		}
+		if o.ASN == "" && o.Org == "" {
+			return fmt.Errorf("Owned prefix %s has neither asn nor org", o.Prefix)
+		}
+		o.network = network
+	}
+	return nil
+}
+
+/*
+ownedPrefixOf - The most specific owned prefix holding ip, nil if none
+*/
+func ownedPrefixOf(ip net.IP) *OwnedPrefix {
+	var best *OwnedPrefix
+	bestOnes := -1
+	for i := range config.OwnedPrefixes {
+		o := &config.OwnedPrefixes[i]
+		if o.network == nil || !o.network.Contains(ip) {
+			continue
+		}
+		if ones, _ := o.network.Mask.Size(); ones > bestOnes {
+			best, bestOnes = o, ones
+		}
+	}
+	return best
+}
+
+/*
+sampleAddress - The address of an owned prefix the recheck mode looks up:
+its first host
+*/
+func (o *OwnedPrefix) sampleAddress() string {
+	ip := make(net.IP, len(o.network.IP))
+	copy(ip, o.network.IP)
+	if ones, bits := o.network.Mask.Size(); bits-ones >= 2 {
+		ip[len(ip)-1]++
+	}
+	return ip.String()
+}
+
+// Fields of the results naming the AS of the address
+var asnFields = []string{"org", "asn.asn", "bgp.origin"}
+
+/*
+attributedASNs - The AS numbers res attributes its address to, by the field
+saying so
+*/
+func attributedASNs(res IPInfoResult) map[string]string {
+	asns := make(map[string]string)
+	for _, field := range asnFields {
+		if asn := normalizeASN(fieldValue(res, field)); asn != "" {
+			asns[field] = asn
+		}
+	}
+	return asns
+}
+
+/*
+ownershipMismatch - What attributes the address of res to someone other than
+the owner of its prefix, "" if nothing or if it is not owned
+*/
+func ownershipMismatch(res IPInfoResult) string {
+	ip := net.ParseIP(fieldValue(res, "ip"))
+	if ip == nil {
+		return ""
+	}
+	o := ownedPrefixOf(ip)
+	if o == nil {
+		return ""
+	}
+	var wrong []string
+	if o.ASN != "" {
+		asns := attributedASNs(res)
+		for _, field := range asnFields {
+			if asn := asns[field]; asn != "" && asn != o.ASN {
+				wrong = append(wrong, fmt.Sprintf("%s (%s)", asn, field))
+			}
+		}
+	}
+	if o.Org != "" {
+		org := asnPrefix.ReplaceAllString(fieldValue(res, "org"), "")
+		if org = strings.TrimSpace(org); org != "" &&
+			!strings.Contains(strings.ToLower(org), strings.ToLower(o.Org)) {
+			wrong = append(wrong, fmt.Sprintf("%s (org)", org))
+		}
+	}
+	if len(wrong) == 0 {
+		return ""
+	}
+	owner := strings.TrimSpace(o.ASN + " " + o.Org)
+	return fmt.Sprintf("%s of %s attributed to %s, expected %s", ip, o.Prefix,
+		strings.Join(wrong, ", "), owner)
+}
+
+func matchOwnership(m Message) (bool, string) {
+	if m.Topic != MsgResultReady {
+		return false, ""
+	}
+	why := ownershipMismatch(m.Result)
+	return why != "", why
+}
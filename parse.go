@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+/*
+What ip411 reads from outside goes through the parsers of this file, which
+turn malformed or hostile input into errors instead of crashes or hangs of
+the long-running modes (proxy, monitor, logs, capture):
+
+	parseIPArg      targets typed or read from lists, before any DNS query
+	parseResponse   answers of the providers, the cache, the proxy and -replay
+	splitCommand    commands of -exec, -plugin and the alert rules
+	parseCoords     locations of provider answers, lat,lon targets, -region
+	checkRing       rings of the GeoJSON datasets of db update
+	scanLines       log lines and target lists, over-long lines skipped
+	newCSVReader    CSV records of target lists, datacenters and MISP exports
+
+The capture files are read by pcap.go, bound-checking every length they
+hold. Each parser has a fuzz target in parse_test.go:
+
+	go test -fuzz FuzzParseLatLon -fuzztime 1m
+*/
+
+// Longest line read from logs and lists, longer ones are skipped
+const maxLineLength = 1 << 20
+
+/*
+validCoords - Whether lat,lon is on the globe. NaN is not: hostile or broken
+answers must not reach the map, whose loops do not end on it.
+*/
+func validCoords(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+/*
+parseDegrees - A latitude or longitude as written, spaces around it allowed
+*/
+func parseDegrees(text string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", strings.TrimSpace(text))
+	}
+	return v, nil
+}
+
+/*
+parseCoords - The point at lat,lon, which must be on the globe
+*/
+func parseCoords(lat, lon string) (Point, error) {
+	la, err := parseDegrees(lat)
+	if err != nil {
+		return Point{}, err
+	}
+	lo, err := parseDegrees(lon)
+	if err != nil {
+		return Point{}, err
+	}
+	if !validCoords(la, lo) {
+		return Point{}, fmt.Errorf("%g,%g is out of range", la, lo)
+	}
+	return Point{Lat: la, Lon: lo}, nil
+}
+
+/*
+checkRing - Whether every lon,lat pair of a ring is on the globe
+*/
+func checkRing(ring [][2]float64) error {
+	for _, c := range ring {
+		if !validCoords(c[1], c[0]) {
+			return fmt.Errorf("%g,%g is out of range", c[1], c[0])
+		}
+	}
+	return nil
+}
+
+/*
+parseResponse - The fields of a provider answer, which must be a JSON object:
+the normalizers set fields of the map
+*/
+func parseResponse(body []byte) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("Expected a JSON object")
+	}
+	return raw, nil
+}
+
+/*
+parseIPArg - The IP Address arg stands for, else the hostname to resolve for
+it. A mistyped address is an error, with a hint, not worth a DNS query.
+*/
+func parseIPArg(arg string) (net.IP, string, error) {
+	target, err := commandTarget(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	if ip := parseTargetIP(target); ip != nil {
+		return ip, "", nil
+	}
+	if hint := suggestIP(target); hint != "" {
+		return nil, "", fmt.Errorf(tr("Invalid IP Address '%s': %s"), arg, hint)
+	}
+	if target == "" {
+		return nil, "", fmt.Errorf(tr("'%s' is neither an IP Address nor a hostname that resolves"), arg)
+	}
+	return nil, target, nil
+}
+
+/*
+scanLines - Call fn with every line of r, without its line ending. Lines
+longer than maxLineLength are skipped, where a bufio.Scanner would stop
+reading.
+*/
+func scanLines(r io.Reader, fn func(line string)) error {
+	br := bufio.NewReader(r)
+	var line []byte
+	long := false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if !long {
+			line = append(line, chunk...)
+			if len(line) > maxLineLength {
+				line, long = nil, true
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if !long && (err == nil || len(line) > 0) {
+			fn(strings.TrimRight(string(line), "\r\n"))
+		}
+		line, long = line[:0], false
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+/*
+splitCommand - The program and arguments of command, split on blanks as a
+shell would without running one: quotes ('...' or "...") keep blanks in an
+argument, and a backslash escapes the next character, except in single
+quotes. Nothing else is special, neither $ nor * nor ;.
+*/
+func splitCommand(command string) ([]string, error) {
+	var argv []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			// In double quotes, only a quote or a backslash is escaped
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				argv = append(argv, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated %c quote in '%s'", quote, command)
+	}
+	if escaped {
+		arg.WriteRune('\\')
+	}
+	if inArg {
+		argv = append(argv, arg.String())
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("Empty command")
+	}
+	return argv, nil
+}
+
+/*
+newCSVReader - A reader of CSV records of any number of fields, tolerating
+the stray quotes of hand-edited files
+*/
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
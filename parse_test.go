@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func FuzzMakeIP(f *testing.F) {
+	for _, seed := range []string{"8.8.8.8", "2001:db8::1", "fe80::1%eth0", "[::1]:53",
+		"1.2.3.4:443", "https://example.com/x", "010.001.002.003", "1,2,3,4", "256.1.1.1",
+		"1.2.3", "example.com", "@unknown", "", "​9.9.9.9"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, arg string) {
+		ip, host, err := parseIPArg(arg)
+		switch {
+		case err != nil:
+			if ip != nil || host != "" {
+				t.Fatalf("parseIPArg(%q) = %v, %q with error %s", arg, ip, host, err)
+			}
+		case ip == nil && host == "":
+			t.Fatalf("parseIPArg(%q) gave neither an address nor a hostname", arg)
+		case ip != nil && host != "":
+			t.Fatalf("parseIPArg(%q) gave both %v and %q", arg, ip, host)
+		}
+	})
+}
+
+func FuzzGetLonLat(f *testing.F) {
+	for _, seed := range []string{"37.4056,-122.0775", "-33.9,151.2", "NaN,0", "0,Inf",
+		"91,0", "0,181", "1e308,1e308", " 1 , 2 ", "1,2,3", "", ","} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, loc string) {
+		lon, lat, err := IPInfoResult{"loc": loc}.GetLonLat()
+		if err == nil && !validCoords(lat, lon) {
+			t.Fatalf("GetLonLat(%q) = %g,%g off the globe", loc, lat, lon)
+		}
+	})
+}
+
+func FuzzParseLatLon(f *testing.F) {
+	for _, seed := range []string{"48.85,2.35", "-90,-180", "90,180", "nan,1", "1,+Inf",
+		"0x1p3,4", "1_0,2", "48.85 2.35", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		p, err := parseLatLon(s)
+		if err == nil && (!validCoords(p.Lat, p.Lon) || math.IsNaN(p.Lat) || math.IsNaN(p.Lon)) {
+			t.Fatalf("parseLatLon(%q) = %+v off the globe", s, p)
+		}
+	})
+}
+
+func FuzzParseBBox(f *testing.F) {
+	for _, seed := range []string{"-10,35,30,60", "-180,-90,180,90", "1,1,1,1", "NaN,0,1,1",
+		"10,0,0,10", "a,b,c,d", "1,2,3"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		box, err := parseBBox(text)
+		if err != nil {
+			return
+		}
+		if !validCoords(box.MinLat, box.MinLon) || !validCoords(box.MaxLat, box.MaxLon) ||
+			box.MinLon >= box.MaxLon || box.MinLat >= box.MaxLat {
+			t.Fatalf("parseBBox(%q) = %+v", text, box)
+		}
+		box.Viewport()
+	})
+}
+
+func FuzzProviderResponse(f *testing.F) {
+	for _, seed := range []string{
+		`{"ip": "8.8.8.8", "city": "Mountain View", "country": "US", "loc": "37.4056,-122.0775", "org": "AS15169 Google LLC"}`,
+		`{"status": "success", "query": "1.1.1.1", "lat": -33.49, "lon": 143.21, "countryCode": "AU", "as": "AS13335"}`,
+		`{"ip": "1.1.1.1", "latitude": 1e308, "longitude": "x", "asn": 13335, "error": true, "reason": "RateLimited"}`,
+		`{"success": true, "connection": {"asn": 3320, "org": "DTAG"}, "timezone": {"id": "Europe/Berlin"}}`,
+		`{"error": {"title": "Wrong ip", "message": "Please provide a valid IP address"}}`,
+		`{"bogon": true, "ip": "10.0.0.1"}`,
+		`{"loc": "NaN,NaN", "cached_at": "2024-01-01T00:00:00Z"}`,
+		`null`, `[]`, `"8.8.8.8"`, `{}`, `{"loc": {"lat": 1}}`, `{`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, p := range providers {
+			raw, err := parseResponse(body)
+			if err != nil {
+				if raw != nil {
+					t.Fatalf("parseResponse(%q) = %v with error %s", body, raw, err)
+				}
+				return
+			}
+			res := p.result(raw, "replay", takeCachedAt(raw))
+			if res["source"] != p.Name || res["provenance"] == nil {
+				t.Fatalf("%s: result of %q not tagged: %v", p.Name, body, res)
+			}
+			if lon, lat, err := res.GetLonLat(); err == nil && !validCoords(lat, lon) {
+				t.Fatalf("%s: %q located off the globe at %g,%g", p.Name, body, lat, lon)
+			}
+		}
+	})
+}
+
+func TestSplitCommand(t *testing.T) {
+	for _, c := range []struct {
+		command string
+		want    []string
+	}{
+		{`notify-send "IP changed" {ip}`, []string{"notify-send", "IP changed", "{ip}"}},
+		{`  logger  -t ip411\ alert  `, []string{"logger", "-t", "ip411 alert"}},
+		{`sh -c 'echo "$1" >> log' - {ip}`, []string{"sh", "-c", `echo "$1" >> log`, "-", "{ip}"}},
+		{`echo "a \"b\" \c" '\n'`, []string{"echo", `a "b" \c`, `\n`}},
+		{`echo "" x""y`, []string{"echo", "", "xy"}},
+		{`echo *;`, []string{"echo", "*;"}},
+	} {
+		argv, err := splitCommand(c.command)
+		if err != nil || !reflect.DeepEqual(argv, c.want) {
+			t.Errorf("splitCommand(%q) = %q, %v, expected %q", c.command, argv, err, c.want)
+		}
+	}
+	for _, command := range []string{``, "  \t", `echo "unterminated`, `echo 'x`} {
+		if argv, err := splitCommand(command); err == nil {
+			t.Errorf("splitCommand(%q) = %q, expected an error", command, argv)
+		}
+	}
+}
+
+func FuzzSplitCommand(f *testing.F) {
+	for _, seed := range []string{`notify-send "IP changed" {ip}`, `a\ b 'c d' "e\"f"`, `x\`, `"`} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, command string) {
+		argv, err := splitCommand(command)
+		if err == nil && len(argv) == 0 {
+			t.Fatalf("splitCommand(%q) gave no program", command)
+		}
+	})
+}
+
+/*
+pcapFile - A capture file of one Ethernet frame carrying an IPv4 packet
+*/
+func pcapFile() []byte {
+	var buf bytes.Buffer
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], pcapMagicMicro)
+	binary.LittleEndian.PutUint32(header[16:20], 65535)
+	binary.LittleEndian.PutUint32(header[20:24], linkEthernet)
+	buf.Write(header)
+
+	frame := make([]byte, 14+20+4)
+	binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+	ip := frame[14:]
+	ip[0], ip[9] = 0x45, 6
+	copy(ip[12:16], net.IPv4(192, 0, 2, 1).To4())
+	copy(ip[16:20], net.IPv4(8, 8, 8, 8).To4())
+	binary.BigEndian.PutUint16(ip[20:22], 443)
+	record := make([]byte, 16)
+	binary.LittleEndian.PutUint32(record[8:12], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(frame)))
+	buf.Write(record)
+	buf.Write(frame)
+	return buf.Bytes()
+}
+
+func TestPcapReader(t *testing.T) {
+	pr, err := NewPcapReader(bytes.NewReader(pcapFile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := pr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, ok := decodePacket(pr.LinkType, p.Data)
+	if !ok || packet.Dst.String() != "8.8.8.8" {
+		t.Fatalf("decodePacket = %+v, %v", packet, ok)
+	}
+	if src, _ := packet.Ports(); src != 443 {
+		t.Fatalf("source port %d, expected 443", src)
+	}
+	if _, err := pr.Next(); err != io.EOF {
+		t.Fatalf("Next after the last packet: %v, expected EOF", err)
+	}
+}
+
+func FuzzPcapReader(f *testing.F) {
+	f.Add(pcapFile())
+	f.Add(pcapFile()[:30])
+	f.Add([]byte{0x0a, 0x0d, 0x0d, 0x0a})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pr, err := NewPcapReader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// Every packet takes a header, so reading must end by then
+		for i := 0; i <= len(data)/16; i++ {
+			p, err := pr.Next()
+			if err != nil {
+				return
+			}
+			if packet, ok := decodePacket(pr.LinkType, p.Data); ok {
+				packet.Ports()
+			}
+		}
+		t.Fatalf("more packets than the file can hold")
+	})
+}
+
+func FuzzFindIPs(f *testing.F) {
+	for _, seed := range []string{
+		"Oct 16 sshd[1]: Failed password for root from 203.0.113.7 port 22",
+		"GET / from 2001:db8::1:443 and 1.2.3.4:443.",
+		"deadbeef face ::1 ::ffff:1.2.3.4 fe80::1%eth0",
+		"1.2.3.4.5.6.7.8:::::",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, m := range findIPs(line) {
+			if m.Start < 0 || m.End > len(line) || m.Start >= m.End {
+				t.Fatalf("findIPs(%q): match at %d:%d", line, m.Start, m.End)
+			}
+			if ip := net.ParseIP(line[m.Start:m.End]); ip == nil || !ip.Equal(m.IP) {
+				t.Fatalf("findIPs(%q): %q is not %v", line, line[m.Start:m.End], m.IP)
+			}
+		}
+	})
+}
+
+func TestScanLinesSkipsLongLines(t *testing.T) {
+	input := "1.1.1.1\n" + strings.Repeat("x", maxLineLength+1) + "\n8.8.8.8\r\nlast"
+	var lines []string
+	if err := scanLines(strings.NewReader(input), func(line string) {
+		lines = append(lines, line)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(lines, "|") != "1.1.1.1|8.8.8.8|last" {
+		t.Fatalf("scanLines read %q", lines)
+	}
+}
+
+func FuzzReadTargets(f *testing.F) {
+	for _, seed := range []string{
+		"8.8.8.8\n# comment\n\nexample.com\n",
+		"1.1.1.1,cloudflare\n\"9.9.9.9\",quad9\n48.85, 2.35\n",
+		"\"unterminated,1\n,\n\"\"\n",
+	} {
+		f.Add(seed)
+	}
+	// The warnings of readPrefixes about bad lines would flood the fuzzer
+	quiet = true
+	f.Fuzz(func(t *testing.T, input string) {
+		targets, err := readTargets(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range targets {
+			if target == "" || strings.ContainsAny(target, "\n") {
+				t.Fatalf("readTargets(%q) gave target %q", input, target)
+			}
+		}
+		readPrefixes(strings.NewReader(input))
+	})
+}
+
+func FuzzReadMISPCSV(f *testing.F) {
+	f.Add("uuid,type,value,tags\n1,ip-dst,203.0.113.7,tlp:white\n2,ip-src|port,198.51.100.1|80,\n")
+	f.Add("type,value\n\"ip-dst,1.2.3.4\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		readMISPCSV(strings.NewReader(input))
+	})
+}
+
+func FuzzReadCountryShapes(f *testing.F) {
+	f.Add(`{"features":[{"properties":{"ISO_A2":"FR"},"geometry":{"type":"Polygon",` +
+		`"coordinates":[[[2,48],[3,48],[3,49],[2,48]]]}}]}`)
+	f.Add(`{"features":[{"properties":{"ISO_A2_EH":"XX"},"geometry":{"type":"MultiPolygon",` +
+		`"coordinates":[[[[1e300,0],[0,1],[1,1]]]]}}]}`)
+	f.Add(`{"features":[{"geometry":{"type":"Polygon","coordinates":[[]]}}]}`)
+	f.Fuzz(func(t *testing.T, input string) {
+		shapes, err := readCountryShapes(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+		for _, shape := range shapes {
+			for _, ring := range shape.Rings {
+				for _, p := range ring {
+					if !validCoords(p.Lat, p.Lon) {
+						t.Fatalf("%s has %+v off the globe", shape.Code, p)
+					}
+				}
+			}
+			shape.contains(2.5, 48.5)
+		}
+	})
+}
+
+func FuzzReadSubdivisions(f *testing.F) {
+	f.Add(`[{"country":"US","code":"US-CA","name":"California",` +
+		`"rings":[[[-124,42],[-114,42],[-114,32],[-124,42]]]}]`)
+	f.Add(`[{"name":"x","rings":[[[200,0]]]}]`)
+	f.Fuzz(func(t *testing.T, input string) {
+		subs, err := readSubdivisions([]byte(input))
+		if err != nil {
+			return
+		}
+		for _, sub := range subs {
+			sub.contains(-120, 37)
+		}
+		convertSubdivisions([]byte(input))
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+/*
+Reader of capture files in the classic pcap format (tcpdump -w), with
+micro or nanosecond timestamps in either byte order. pcapng files must be
+converted first: editcap -F pcap in.pcapng out.pcap
+*/
+
+const (
+	pcapMagicMicro = 0xa1b2c3d4
+	pcapMagicNano  = 0xa1b23c4d
+	pcapngMagic    = 0x0a0d0d0a
+)
+
+// Link types of the packets a capture file holds
+const (
+	linkNull     = 0   // BSD loopback
+	linkEthernet = 1   // Ethernet II
+	linkRaw      = 101 // raw IPv4 or IPv6
+	linkLinuxSLL = 113 // Linux cooked capture (tcpdump -i any)
+)
+
+/*
+PcapPacket - A packet of a capture file. Data may be truncated to the
+snapshot length of the capture, Length is the length on the wire.
+*/
+type PcapPacket struct {
+	Time   time.Time
+	Data   []byte
+	Length int
+}
+
+/*
+PcapReader - Reads the packets of a capture file one by one
+*/
+type PcapReader struct {
+	r        io.Reader
+	order    binary.ByteOrder
+	nano     bool
+	LinkType uint32
+}
+
+/*
+NewPcapReader - Read the header of the capture file r
+*/
+func NewPcapReader(r io.Reader) (*PcapReader, error) {
+	var header [24]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("Not a pcap file: %s", err)
+	}
+	pr := &PcapReader{r: r}
+	switch magic := binary.LittleEndian.Uint32(header[:4]); {
+	case magic == pcapMagicMicro || magic == pcapMagicNano:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header[:4]) == pcapMagicMicro ||
+		binary.BigEndian.Uint32(header[:4]) == pcapMagicNano:
+		pr.order = binary.BigEndian
+	case magic == pcapngMagic:
+		return nil, fmt.Errorf("pcapng files are not supported, " +
+			"convert with: editcap -F pcap in.pcapng out.pcap")
+	default:
+		return nil, fmt.Errorf("Not a pcap file: Unknown magic number %#x", magic)
+	}
+	pr.nano = pr.order.Uint32(header[:4]) == pcapMagicNano
+	pr.LinkType = pr.order.Uint32(header[20:24]) & 0xffff
+	switch pr.LinkType {
+	case linkNull, linkEthernet, linkRaw, linkLinuxSLL:
+	default:
+		return nil, fmt.Errorf("Unsupported link type %d", pr.LinkType)
+	}
+	return pr, nil
+}
+
+/*
+Next - The next packet, io.EOF after the last one
+*/
+func (pr *PcapReader) Next() (PcapPacket, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(pr.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("Truncated packet header")
+		}
+		return PcapPacket{}, err
+	}
+	sec := int64(pr.order.Uint32(header[0:4]))
+	frac := int64(pr.order.Uint32(header[4:8]))
+	if !pr.nano {
+		frac *= 1000
+	}
+	captured := pr.order.Uint32(header[8:12])
+	if captured > 1<<18 {
+		return PcapPacket{}, fmt.Errorf("Invalid packet length %d", captured)
+	}
+	p := PcapPacket{
+		Time:   time.Unix(sec, frac),
+		Data:   make([]byte, captured),
+		Length: int(pr.order.Uint32(header[12:16])),
+	}
+	if _, err := io.ReadFull(pr.r, p.Data); err != nil {
+		return PcapPacket{}, fmt.Errorf("Truncated packet: %s", err)
+	}
+	return p, nil
+}
+
+/*
+IPPacket - The network and transport layer of a packet: its addresses, the
+IP protocol number (6 TCP, 17 UDP) and what follows the IP header
+*/
+type IPPacket struct {
+	Src, Dst net.IP
+	Protocol uint8
+	Payload  []byte
+}
+
+/*
+Ports - Source and destination ports of a TCP or UDP packet, 0 for other
+protocols
+*/
+func (p IPPacket) Ports() (uint16, uint16) {
+	if (p.Protocol != 6 && p.Protocol != 17) || len(p.Payload) < 4 {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint16(p.Payload[0:2]), binary.BigEndian.Uint16(p.Payload[2:4])
+}
+
+/*
+decodePacket - The IPv4 or IPv6 packet of a frame of the given link type,
+false for other frames
+*/
+func decodePacket(linkType uint32, data []byte) (IPPacket, bool) {
+	switch linkType {
+	case linkEthernet:
+		if len(data) < 14 {
+			return IPPacket{}, false
+		}
+		etherType, data := binary.BigEndian.Uint16(data[12:14]), data[14:]
+		// 802.1Q VLAN tags
+		for etherType == 0x8100 && len(data) >= 4 {
+			etherType, data = binary.BigEndian.Uint16(data[2:4]), data[4:]
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return IPPacket{}, false
+		}
+		return decodeIP(data)
+	case linkLinuxSLL:
+		if len(data) < 16 {
+			return IPPacket{}, false
+		}
+		return decodeIP(data[16:])
+	case linkNull:
+		if len(data) < 4 {
+			return IPPacket{}, false
+		}
+		return decodeIP(data[4:])
+	}
+	return decodeIP(data)
+}
+
+/*
+decodeIP - Decode an IP packet by its version. IPv6 extension headers are
+not followed: their packets get the protocol of the first one.
+*/
+func decodeIP(data []byte) (IPPacket, bool) {
+	if len(data) == 0 {
+		return IPPacket{}, false
+	}
+	switch data[0] >> 4 {
+	case 4:
+		headerLen := int(data[0]&0x0f) * 4
+		if len(data) < 20 || headerLen < 20 || len(data) < headerLen {
+			return IPPacket{}, false
+		}
+		return IPPacket{Src: net.IP(data[12:16]), Dst: net.IP(data[16:20]),
+			Protocol: data[9], Payload: data[headerLen:]}, true
+	case 6:
+		if len(data) < 40 {
+			return IPPacket{}, false
+		}
+		return IPPacket{Src: net.IP(data[8:24]), Dst: net.IP(data[24:40]),
+			Protocol: data[6], Payload: data[40:]}, true
+	}
+	return IPPacket{}, false
+}
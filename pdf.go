@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+/*
+PDF - A minimal PDF writer for the reports: A4 portrait pages of text in the
+standard Helvetica fonts (no font is embedded, non Latin-1 characters become
+'?') and of line art, drawn top to bottom from a cursor
+*/
+type PDF struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	Y     float64 // cursor, from the top of the page
+}
+
+// A4 in points, and the margins
+const (
+	pdfWidth  = 595.0
+	pdfHeight = 842.0
+	pdfMargin = 50.0
+)
+
+/*
+NewPDF - A document with an empty first page
+*/
+func NewPDF() *PDF {
+	p := &PDF{}
+	p.NewPage()
+	return p
+}
+
+/*
+NewPage - Start a new page, the cursor at its top margin
+*/
+func (p *PDF) NewPage() {
+	p.page = &bytes.Buffer{}
+	p.pages = append(p.pages, p.page)
+	p.Y = pdfMargin
+}
+
+/*
+Need - Start a new page unless height fits below the cursor
+*/
+func (p *PDF) Need(height float64) {
+	if p.Y+height > pdfHeight-pdfMargin {
+		p.NewPage()
+	}
+}
+
+/*
+pdfString - s as a PDF literal string in WinAnsiEncoding
+*/
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r < 0x7f || r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+/*
+Text - Write s at x points from the left with its baseline at the cursor,
+bold or not
+*/
+func (p *PDF) Text(x float64, s string, size float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.page, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n",
+		font, size, x, pdfHeight-p.Y, pdfString(s))
+}
+
+/*
+Line - Write s at the left margin and move the cursor to the next line
+*/
+func (p *PDF) Line(s string, size float64, bold bool) {
+	p.Need(size * 1.4)
+	p.Y += size * 1.4
+	p.Text(pdfMargin, s, size, bold)
+}
+
+/*
+Paragraph - Write text wrapped to the width between the margins
+*/
+func (p *PDF) Paragraph(text string, size float64) {
+	// Helvetica averages a little over half an em per character
+	width := int((pdfWidth - 2*pdfMargin) / (size * 0.55))
+	for _, line := range wrapText(text, width) {
+		p.Line(line, size, false)
+	}
+}
+
+/*
+wrapText - The words of text in lines of at most width characters
+*/
+func wrapText(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+/*
+Color - Set the stroke and fill colors, components from 0 to 1
+*/
+func (p *PDF) Color(r, g, b float64) {
+	fmt.Fprintf(p.page, "%.2f %.2f %.2f RG %.2f %.2f %.2f rg\n", r, g, b, r, g, b)
+}
+
+/*
+Polyline - Stroke the lines between points given from the top left of the
+page, closing the shape if closed
+*/
+func (p *PDF) Polyline(points [][2]float64, closed bool, width float64) {
+	if len(points) < 2 {
+		return
+	}
+	fmt.Fprintf(p.page, "%.2f w\n", width)
+	for i, pt := range points {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(p.page, "%.2f %.2f %s\n", pt[0], pdfHeight-pt[1], op)
+	}
+	if closed {
+		fmt.Fprintln(p.page, "s")
+	} else {
+		fmt.Fprintln(p.page, "S")
+	}
+}
+
+/*
+Disc - Fill a circle centered at x,y from the top left of the page
+*/
+func (p *PDF) Disc(x, y, r float64) {
+	// Four Bézier curves, their control points k*r from the ends
+	k := 4 * (math.Sqrt2 - 1) / 3 * r
+	y = pdfHeight - y
+	fmt.Fprintf(p.page, "%.2f %.2f m\n", x+r, y)
+	fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+r, y+k, x+k, y+r, x, y+r)
+	fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-k, y+r, x-r, y+k, x-r, y)
+	fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-r, y-k, x-k, y-r, x, y-r)
+	fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f %.2f %.2f c f\n", x+k, y-r, x+r, y-k, x+r, y)
+}
+
+/*
+Rect - Stroke a rectangle whose top left corner is at x,y from the top left
+of the page
+*/
+func (p *PDF) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(p.page, "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, pdfHeight-y-h, w, h)
+}
+
+/*
+WriteTo - Write the document to w
+*/
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 catalog, 2 pages, 3 and 4 fonts, then a page and its content for
+	// every page
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfWidth, pdfHeight, 6+2*i))
+
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+/*
+The perf mode measures the rendering of the map under load. It draws a
+synthetic dataset of -targets markers, as many times as it can for
+-duration, then reports:
+
+	frames per second and the render time of a frame (median, p99)
+	the bytes and allocations of a frame, and the garbage collections
+	the hot paths: the functions the CPU was sampled in most
+
+The dataset is the same from run to run, so that reports before and after a
+change of the canvas or the pipeline compare. -cpuprofile keeps the whole
+profile for go tool pprof.
+
+The server modes (proxy) serve net/http/pprof on a separate -pprof address,
+off by default as profiles tell about the lookups served:
+
+	ip411 proxy -pprof localhost:6060
+	go tool pprof http://localhost:6060/debug/pprof/profile
+*/
+
+// Of the synthetic dataset, fixed so that runs compare
+const perfSeed = 411
+
+/*
+PerfReport - How fast the map was rendered
+*/
+type PerfReport struct {
+	Targets        int       `json:"targets"`
+	Width          int       `json:"width"`
+	Height         int       `json:"height"`
+	Frames         int       `json:"frames"`
+	FPS            float64   `json:"fps"`
+	MedianMs       float64   `json:"median_ms"`
+	P99Ms          float64   `json:"p99_ms"`
+	BytesPerFrame  float64   `json:"bytes_per_frame"`
+	AllocsPerFrame float64   `json:"allocs_per_frame"`
+	GCs            uint32    `json:"gcs"`
+	GCPauseMs      float64   `json:"gc_pause_ms"`
+	HotPaths       []HotPath `json:"hot_paths"`
+}
+
+/*
+HotPath - A function of the CPU profile and the fractions of the samples it
+was running in (flat) or on the stack of (cum)
+*/
+type HotPath struct {
+	Function string  `json:"function"`
+	Flat     float64 `json:"flat"`
+	Cum      float64 `json:"cum"`
+}
+
+/*
+perfMarkers - n markers spread over the land and sea like those of the live
+modes: mostly X, some labeled, alerted or colored, some weighted
+*/
+func perfMarkers(n int) []Marker {
+	r := rand.New(rand.NewSource(perfSeed))
+	markers := make([]Marker, n)
+	for i := range markers {
+		m := Marker{
+			Lon:     r.Float64()*360 - 180,
+			Lat:     math.Asin(r.Float64()*2-1) * 180 / math.Pi * 0.8,
+			Text:    "X",
+			Targets: []string{net.IPv4(10, byte(i>>16), byte(i>>8), byte(i)).String()},
+		}
+		switch r.Intn(10) {
+		case 0:
+			m.Text = fmt.Sprintf("host%d", i)
+		case 1:
+			m.Text, m.Color = "!", 31
+		case 2:
+			m.Color = 31 + r.Intn(7)
+		}
+		if r.Intn(4) == 0 {
+			m.Weight = r.Float64()
+		}
+		markers[i] = m
+	}
+	return markers
+}
+
+/*
+hotPaths - The n functions most sampled in prof, a CPU profile as written by
+runtime/pprof
+*/
+func hotPaths(prof []byte, n int) ([]HotPath, error) {
+	p, err := profile.ParseData(prof)
+	if err != nil {
+		return nil, fmt.Errorf("CPU profile: %s", err)
+	}
+
+	flat := make(map[string]uint64)
+	cum := make(map[string]uint64)
+	var total uint64
+	for _, sample := range p.Sample {
+		// The innermost function first, inlined ones before their callers
+		var stack []string
+		for _, loc := range sample.Location {
+			for _, line := range loc.Line {
+				if line.Function != nil {
+					stack = append(stack, line.Function.Name)
+				}
+			}
+		}
+		// The sample count, then the CPU time
+		if len(stack) == 0 || len(sample.Value) == 0 || sample.Value[0] <= 0 {
+			continue
+		}
+		count := uint64(sample.Value[0])
+		total += count
+		flat[stack[0]] += count
+		seen := make(map[string]bool)
+		for _, function := range stack {
+			if !seen[function] {
+				seen[function] = true
+				cum[function] += count
+			}
+		}
+	}
+
+	paths := make([]HotPath, 0, len(flat))
+	for function, samples := range flat {
+		paths = append(paths, HotPath{function, float64(samples) / float64(total),
+			float64(cum[function]) / float64(total)})
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Flat != paths[j].Flat {
+			return paths[i].Flat > paths[j].Flat
+		}
+		return paths[i].Function < paths[j].Function
+	})
+	if len(paths) > n {
+		paths = paths[:n]
+	}
+	return paths, nil
+}
+
+/*
+measureRendering - Render markers on a width x height canvas until duration
+has passed, profiling the CPU into prof
+*/
+func measureRendering(markers []Marker, width, height int, duration time.Duration, prof *bytes.Buffer) (PerfReport, error) {
+	r := PerfReport{Targets: len(markers), Width: width, Height: height}
+	// Loads the coastlines, which is not rendering
+	renderMap(width, height, markers)
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := runtimepprof.StartCPUProfile(prof); err != nil {
+		return r, fmt.Errorf("CPU profile: %s", err)
+	}
+	var frames []float64
+	start := time.Now()
+	for len(frames) == 0 || time.Since(start) < duration {
+		frameStart := time.Now()
+		renderMap(width, height, markers)
+		frames = append(frames, float64(time.Since(frameStart))/float64(time.Millisecond))
+	}
+	elapsed := time.Since(start)
+	runtimepprof.StopCPUProfile()
+	runtime.ReadMemStats(&after)
+
+	r.Frames = len(frames)
+	r.FPS = float64(r.Frames) / elapsed.Seconds()
+	sort.Float64s(frames)
+	r.MedianMs = percentile(frames, 50)
+	r.P99Ms = percentile(frames, 99)
+	r.BytesPerFrame = float64(after.TotalAlloc-before.TotalAlloc) / float64(r.Frames)
+	r.AllocsPerFrame = float64(after.Mallocs-before.Mallocs) / float64(r.Frames)
+	r.GCs = after.NumGC - before.NumGC
+	r.GCPauseMs = float64(after.PauseTotalNs-before.PauseTotalNs) / float64(time.Millisecond)
+	return r, nil
+}
+
+func writePerfReport(r PerfReport) error {
+	fmt.Printf("%d markers on %dx%d: %d frames, %.1f fps\n", r.Targets, r.Width, r.Height, r.Frames, r.FPS)
+	fmt.Printf("Frame: %.2f ms median, %.2f ms p99\n", r.MedianMs, r.P99Ms)
+	fmt.Printf("Allocated: %.0f KB in %.0f allocations per frame, %d GCs pausing %.1f ms\n",
+		r.BytesPerFrame/1000, r.AllocsPerFrame, r.GCs, r.GCPauseMs)
+	fmt.Println("")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Flat\tCum\tFunction\t")
+	for _, p := range r.HotPaths {
+		fmt.Fprintf(tw, "%.1f%%\t%.1f%%\t%s\t\n", 100*p.Flat, 100*p.Cum, p.Function)
+	}
+	return tw.Flush()
+}
+
+/*
+startPprof - Serve net/http/pprof on addr, apart from the mode's own socket
+*/
+func startPprof(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Profiling: %s", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	log.Printf("Profiling on http://%s/debug/pprof/", ln.Addr())
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			warnf("Profiling: %s", err)
+		}
+	}()
+	return nil
+}
+
+/*
+perfHelp - What the usage message of perf says after the synopsis
+*/
+const perfHelp = `Render the map of a large synthetic dataset repeatedly, and report the
+frames per second, the allocations per frame and the hot paths.
+`
+
+func runPerf(args []string) error {
+	flags := flag.NewFlagSet("perf", flag.ExitOnError)
+	addQuietFlag(flags)
+	addRegionFlag(flags)
+	targets := flags.Int("targets", 20000, "Markers of the synthetic dataset")
+	width := flags.Int("width", 200, "Columns of the canvas")
+	height := flags.Int("height", 60, "Rows of the canvas")
+	duration := flags.Duration("duration", 10*time.Second, "How long to render for")
+	color := flags.Bool("color", false, "Render in ANSI colors, as on color terminals")
+	ascii := flags.Bool("ascii", false, "Render with ascii characters instead of braille")
+	top := flags.Int("top", 15, "How many hot paths to list")
+	cpuProfile := flags.String("cpuprofile", "", "Write the CPU profile to this file, for go tool pprof")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s perf [-targets n] [-width n] [-height n] [-duration d] [-color] [-ascii] [-region r] [-cpuprofile file] [-json]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(perfHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return invalidInput("Invalid arguments: perf takes flags only.")
+	}
+	if *targets < 1 {
+		return invalidInput("Invalid -targets %d: Expected a positive number.", *targets)
+	}
+	if *width < 1 || *height < 1 {
+		return invalidInput("Invalid canvas %dx%d: Expected positive dimensions.", *width, *height)
+	}
+	if *duration <= 0 {
+		return invalidInput("Invalid -duration %s: Expected a positive duration.", *duration)
+	}
+
+	consoleColors, asciiMap = *color, *ascii
+	fmt.Fprintf(os.Stderr, "Rendering %d markers for %s...\n", *targets, *duration)
+	var prof bytes.Buffer
+	r, err := measureRendering(perfMarkers(*targets), *width, *height, *duration, &prof)
+	if err != nil {
+		return err
+	}
+	if *cpuProfile != "" {
+		if err := ioutil.WriteFile(*cpuProfile, prof.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	if r.HotPaths, err = hotPaths(prof.Bytes(), *top); err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	return writePerfReport(r)
+}
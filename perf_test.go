@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+/*
+cpuProfile - A CPU profile of the samples, each a count and a stack of
+functions, the innermost first
+*/
+func cpuProfile(t *testing.T, samples map[int64][]string) []byte {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10000000,
+	}
+	functions := make(map[string]*profile.Function)
+	for count, stack := range samples {
+		sample := &profile.Sample{Value: []int64{count, count * p.Period}}
+		for _, name := range stack {
+			fn := functions[name]
+			if fn == nil {
+				fn = &profile.Function{ID: uint64(len(functions) + 1), Name: name}
+				functions[name] = fn
+				p.Function = append(p.Function, fn)
+			}
+			loc := &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+			p.Location = append(p.Location, loc)
+			sample.Location = append(sample.Location, loc)
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHotPaths(t *testing.T) {
+	prof := cpuProfile(t, map[int64][]string{
+		6: {"main.cellOf", "main.renderMap"},
+		3: {"runtime.mallocgc", "main.cellOf", "main.renderMap"},
+		1: {"main.renderMap"},
+	})
+	paths, err := hotPaths(prof, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HotPath{
+		{"main.cellOf", 0.6, 0.9},
+		{"runtime.mallocgc", 0.3, 0.3},
+		{"main.renderMap", 0.1, 1},
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("hot paths %+v, expected %+v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("hot path %d: %+v, expected %+v", i, paths[i], want[i])
+		}
+	}
+
+	if paths, err := hotPaths(prof, 1); err != nil || len(paths) != 1 || paths[0].Function != "main.cellOf" {
+		t.Errorf("top 1: %+v, %v", paths, err)
+	}
+	if _, err := hotPaths([]byte("not a profile"), 5); err == nil {
+		t.Error("no error for an invalid profile")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+The field picker chooses which fields of the result appear in the info pane
+and in what order. It lists the configured fields first, then every other
+field of the current result, nested ones by their dotted path:
+
+	i         open the picker
+	up/down   select a field
+	space     show or hide the selected field
+	K/J       move the selected field up/down
+	enter     apply and save to the config file
+	esc       close without applying
+*/
+
+type pickerEntry struct {
+	InfoField
+	enabled bool
+}
+
+var pickerEntries []pickerEntry // only touched from the gui goroutine
+
+/*
+fieldValue - Display string of the field at path in res
+*/
+func fieldValue(res IPInfoResult, path string) string {
+	switch val := lookupPath(map[string]interface{}(res), path).(type) {
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return toString(val)
+	}
+}
+
+/*
+flattenPaths - Dotted paths of every leaf field of obj, sorted
+*/
+func flattenPaths(obj map[string]interface{}, prefix string) []string {
+	var paths []string
+	for key, val := range obj {
+		if nested, ok := val.(map[string]interface{}); ok {
+			paths = append(paths, flattenPaths(nested, prefix+key+".")...)
+		} else {
+			paths = append(paths, prefix+key)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func fieldLabel(path string) string {
+	label := path[strings.LastIndex(path, ".")+1:]
+	if label == "" {
+		return path
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+/*
+renderInfo - Write the configured fields of res into the info view. Must be
+called with mu held.
+*/
+func renderInfo(view *View, res IPInfoResult) {
+	view.Clear()
+	if infoTemplate != nil {
+		text, err := executeInfoTemplate(res)
+		if err != nil {
+			fmt.Fprintf(view, tr("Info template: %s\n"), err)
+		}
+		fmt.Fprint(view, text)
+		return
+	}
+	if compact {
+		renderCompactInfo(view, res)
+	} else {
+		for _, field := range config.InfoFields {
+			fmt.Fprintf(view, "%s: %s\n", tr(field.Label), displayValue(res, field.Path))
+		}
+	}
+	if line := noteLine(res); line != "" {
+		fmt.Fprintln(view, line)
+	}
+	if line := provenanceLine(res); line != "" {
+		fmt.Fprintln(view, line)
+	}
+}
+
+/*
+displayValue - fieldValue as shown in the info pane: locations, numbers and
+distances (fields ending in _km) in the format of the locale
+*/
+func displayValue(res IPInfoResult, path string) string {
+	if path == "loc" {
+		if lon, lat, err := res.GetLonLat(); err == nil {
+			return formatCoords(lat, lon)
+		}
+	}
+	if v, ok := lookupPath(map[string]interface{}(res), path).(float64); ok {
+		if strings.HasSuffix(path, "_km") {
+			return formatDistance(v)
+		}
+		return formatNumber(v, -1)
+	}
+	return fieldValue(res, path)
+}
+
+func renderPicker(view *View) {
+	view.Clear()
+	for _, entry := range pickerEntries {
+		mark := " "
+		if entry.enabled {
+			mark = "x"
+		}
+		fmt.Fprintf(view, "[%s] %s (%s)\n", mark, entry.Label, entry.Path)
+	}
+}
+
+func openPicker(g *Gui, v *View) error {
+	if _, err := g.View("picker"); err == nil {
+		return nil
+	}
+
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+
+	pickerEntries = nil
+	shown := make(map[string]bool)
+	for _, field := range config.InfoFields {
+		pickerEntries = append(pickerEntries, pickerEntry{field, true})
+		shown[field.Path] = true
+	}
+	for _, path := range flattenPaths(res, "") {
+		if !shown[path] {
+			pickerEntries = append(pickerEntries,
+				pickerEntry{InfoField{fieldLabel(path), path}, false})
+		}
+	}
+
+	maxX, maxY := g.Size()
+	view, err := g.SetView("picker", maxX/4, maxY/6, maxX*3/4, maxY*5/6)
+	if err != nil && err != ErrUnknownView {
+		return err
+	}
+	view.Title = tr("Info fields: space toggle, J/K move, enter save, esc cancel")
+	view.Highlight = true
+	view.SelBgColor = ColorGreen
+	view.SelFgColor = ColorBlack
+	renderPicker(view)
+	selectLine(view, 0, len(pickerEntries))
+	return g.SetCurrentView("picker")
+}
+
+func closePicker(g *Gui) error {
+	if err := g.DeleteView("picker"); err != nil {
+		return err
+	}
+	return g.SetCurrentView("map")
+}
+
+/*
+selectedLine - Line of a list view under the cursor
+*/
+func selectedLine(v *View) int {
+	_, oy := v.Origin()
+	_, cy := v.Cursor()
+	return oy + cy
+}
+
+/*
+selectLine - Move the cursor of a list view of n lines to line idx,
+scrolling as needed
+*/
+func selectLine(v *View, idx, n int) {
+	if idx < 0 || idx >= n {
+		return
+	}
+	_, height := v.Size()
+	_, oy := v.Origin()
+	if idx < oy {
+		oy = idx
+	} else if height > 0 && idx >= oy+height {
+		oy = idx - height + 1
+	}
+	v.SetOrigin(0, oy)
+	v.SetCursor(0, idx-oy)
+}
+
+func pickerMove(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		selectLine(v, selectedLine(v)+delta, len(pickerEntries))
+		return nil
+	}
+}
+
+// pickerShift moves the selected entry by delta positions
+func pickerShift(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		i := selectedLine(v)
+		j := i + delta
+		if i >= len(pickerEntries) || j < 0 || j >= len(pickerEntries) {
+			return nil
+		}
+		pickerEntries[i], pickerEntries[j] = pickerEntries[j], pickerEntries[i]
+		renderPicker(v)
+		selectLine(v, j, len(pickerEntries))
+		return nil
+	}
+}
+
+func pickerToggle(g *Gui, v *View) error {
+	i := selectedLine(v)
+	if i < len(pickerEntries) {
+		pickerEntries[i].enabled = !pickerEntries[i].enabled
+		renderPicker(v)
+	}
+	return nil
+}
+
+func pickerApply(g *Gui, v *View) error {
+	var fields []InfoField
+	for _, entry := range pickerEntries {
+		if entry.enabled {
+			fields = append(fields, entry.InfoField)
+		}
+	}
+	if len(fields) > 0 {
+		config.InfoFields = fields
+	}
+
+	if info, err := g.View("info"); err == nil {
+		mu.Lock()
+		if infoResult != nil {
+			renderInfo(info, infoResult)
+		}
+		mu.Unlock()
+	}
+	if err := config.Save(); err != nil {
+		if status, verr := g.View("status"); verr == nil {
+			status.Clear()
+			fmt.Fprintf(status, tr("Could not save config: %s"), err)
+		}
+	}
+	return closePicker(g)
+}
+
+func pickerCancel(g *Gui, v *View) error {
+	return closePicker(g)
+}
+
+/*
+setPickerKeybindings - Register the keys of the field picker
+*/
+func setPickerKeybindings(g *Gui) error {
+	bindings := []struct {
+		view    string
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{"", 'i', openPicker},
+		{"picker", KeyArrowUp, pickerMove(-1)},
+		{"picker", KeyArrowDown, pickerMove(1)},
+		{"picker", 'k', pickerMove(-1)},
+		{"picker", 'j', pickerMove(1)},
+		{"picker", 'K', pickerShift(-1)},
+		{"picker", 'J', pickerShift(1)},
+		{"picker", KeySpace, pickerToggle},
+		{"picker", KeyEnter, pickerApply},
+		{"picker", KeyEsc, pickerCancel},
+	}
+	for _, b := range bindings {
+		if err := g.SetKeybinding(b.view, b.key, ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+/*
+stringList - A flag that can be repeated, collecting every value
+*/
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+/*
+Set .
+*/
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+/*
+Pipeline - Processing applied to every lookup result before it is displayed
+or published: enrichment plugins first, then the script, so that scripts can
+use the fields plugins add. A nil *Pipeline keeps results as they are.
+*/
+type Pipeline struct {
+	plugins []*Plugin
+	script  *Script
+}
+
+/*
+Process - Run res through the pipeline, modifying it in place. Returns false
+if the result was dropped.
+*/
+func (p *Pipeline) Process(res IPInfoResult) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+	for _, plugin := range p.plugins {
+		if err := plugin.Enrich(res); err != nil {
+			return true, err
+		}
+	}
+	return p.script.Apply(res)
+}
+
+/*
+Close - Stop the plugins
+*/
+func (p *Pipeline) Close() {
+	if p == nil {
+		return
+	}
+	for _, plugin := range p.plugins {
+		plugin.Close()
+	}
+}
+
+/*
+pipelineOptions - Command line flags configuring the pipeline of a mode
+*/
+type pipelineOptions struct {
+	script     string
+	plugins    stringList
+	pluginMode string
+}
+
+func addPipelineFlags(flags *flag.FlagSet) *pipelineOptions {
+	opts := &pipelineOptions{}
+	flags.StringVar(&opts.script, "script", "",
+		"Post-process every result with the process function of this Starlark file")
+	flags.Var(&opts.plugins, "plugin",
+		"Enrich results with this program (repeatable), see -plugin-mode")
+	flags.StringVar(&opts.pluginMode, "plugin-mode", "once",
+		"once: run plugins per lookup, line: keep them running, one JSON line per lookup")
+	return opts
+}
+
+/*
+open - Load the script and set up the plugins selected on the command line
+*/
+func (opts *pipelineOptions) open() (*Pipeline, error) {
+	script, err := LoadScript(opts.script)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pipeline{script: script}
+	for _, command := range opts.plugins {
+		plugin, err := NewPlugin(command, opts.pluginMode)
+		if err != nil {
+			return nil, err
+		}
+		p.plugins = append(p.plugins, plugin)
+	}
+	return p, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+)
+
+/*
+The live modes (logs, monitor) keep the order in which addresses were
+located, so that the map can be paused and stepped through:
+
+	space   pause or resume the live updates
+	[ ]     step back or forward one located address (pausing first)
+	{ }     step by ten
+
+While paused, lookups go on but the map only shows the addresses located up
+to the current step, which is plotted as '@' and described in the info pane.
+*/
+
+// Located addresses kept for stepping by default, the oldest are forgotten
+// first (see -max-playback)
+const maxPlayback = 10000
+
+/*
+PlaybackEntry - An address as it was located
+*/
+type PlaybackEntry struct {
+	Target string
+	Time   string // 15:04:05
+}
+
+/*
+record - Remember that target was located. Must be called with b.mu held.
+*/
+func (b *Batch) record(target, at string) {
+	b.playback = append(b.playback, PlaybackEntry{target, at})
+	if len(b.playback) > b.maxPlayback {
+		drop := len(b.playback) - b.maxPlayback
+		b.playback = append([]PlaybackEntry(nil), b.playback[drop:]...)
+		if b.paused {
+			b.step -= drop
+			if b.step < 0 {
+				b.step = 0
+			}
+		}
+	}
+}
+
+/*
+playbackHidden - The targets hidden by the current step, nil unless
+paused. Targets forgotten by the playback are older than any step and stay
+visible. Must be called with b.mu held.
+*/
+func (b *Batch) playbackHidden() map[string]bool {
+	if !b.paused || len(b.playback) == 0 {
+		return nil
+	}
+	hidden := make(map[string]bool)
+	for _, entry := range b.playback[b.step+1:] {
+		hidden[entry.Target] = true
+	}
+	// A target located again (a retry) stays visible from its first step
+	for _, entry := range b.playback[:b.step+1] {
+		delete(hidden, entry.Target)
+	}
+	return hidden
+}
+
+/*
+playbackCurrent - Target of the current step, "" unless paused. Must be
+called with b.mu held.
+*/
+func (b *Batch) playbackCurrent() string {
+	if !b.paused || len(b.playback) == 0 {
+		return ""
+	}
+	return b.playback[b.step].Target
+}
+
+/*
+playbackSummary - Lines of the info pane describing the current step, nil
+unless paused. Must be called with b.mu held.
+*/
+func (b *Batch) playbackSummary() []string {
+	if !b.paused {
+		return nil
+	}
+	if len(b.playback) == 0 {
+		return []string{"Paused, nothing located yet (space resumes)"}
+	}
+	entry := b.playback[b.step]
+	res := b.results[entry.Target]
+	return []string{
+		fmt.Sprintf("Paused at %d of %d (space resumes, [ ] step): %s located at %s",
+			b.step+1, len(b.playback), entry.Target, entry.Time),
+		fmt.Sprintf("@ %s, %s  %s", fieldValue(res, "city"), fieldValue(res, "country"),
+			fieldValue(res, "org")),
+	}
+}
+
+func (b *Batch) togglePause(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.paused = !b.paused
+	b.step = len(b.playback) - 1
+	if b.step < 0 {
+		b.step = 0
+	}
+	b.mu.Unlock()
+
+	b.refresh(g)
+	return nil
+}
+
+func (b *Batch) stepPlayback(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		b.mu.Lock()
+		if !b.paused {
+			b.paused = true
+			b.step = len(b.playback) - 1
+		}
+		b.step += delta
+		if b.step >= len(b.playback) {
+			b.step = len(b.playback) - 1
+		}
+		if b.step < 0 {
+			b.step = 0
+		}
+		b.mu.Unlock()
+
+		b.refresh(g)
+		return nil
+	}
+}
+
+/*
+setPlaybackKeybindings - Register the keys pausing and stepping through the
+located addresses
+*/
+func (b *Batch) setPlaybackKeybindings(g *Gui) error {
+	bindings := []struct {
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{KeySpace, b.togglePause},
+		{'[', b.stepPlayback(-1)},
+		{']', b.stepPlayback(1)},
+		{'{', b.stepPlayback(-10)},
+		{'}', b.stepPlayback(10)},
+	}
+	for _, binding := range bindings {
+		if err := g.SetKeybinding("map", binding.key, ModNone,
+			binding.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
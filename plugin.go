@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const pluginTimeout = 10 * time.Second
+
+/*
+Plugin - An external program enriching lookup results. The result is
+written to the program's stdin as a JSON object and the program answers with
+a JSON object whose fields are merged into the result.
+
+In "once" mode the program is run for every lookup and reads a single object
+until EOF. In "line" mode it is started once and kept running: each request
+and answer is a single line of JSON.
+*/
+type Plugin struct {
+	mu     sync.Mutex
+	argv   []string
+	line   bool
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+/*
+NewPlugin - Create a plugin running command (a program and its arguments,
+split by splitCommand) in mode "once" or "line"
+*/
+func NewPlugin(command, mode string) (*Plugin, error) {
+	argv, err := splitCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid plugin command: %s", err)
+	}
+	switch mode {
+	case "once", "":
+		return &Plugin{argv: argv}, nil
+	case "line":
+		return &Plugin{argv: argv, line: true}, nil
+	}
+	return nil, fmt.Errorf("Unknown plugin mode '%s': use once or line", mode)
+}
+
+/*
+Enrich - Send res to the plugin and merge the fields it returns into res
+*/
+func (p *Plugin) Enrich(res IPInfoResult) error {
+	request, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	var answer []byte
+	if p.line {
+		answer, err = p.exchange(request)
+	} else {
+		answer, err = p.run(request)
+	}
+	if err != nil {
+		return fmt.Errorf("Plugin %s: %s", p.argv[0], err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(answer, &fields); err != nil {
+		return fmt.Errorf("Plugin %s returned invalid JSON: %s", p.argv[0], err)
+	}
+	for key, val := range fields {
+		res[key] = val
+	}
+	return nil
+}
+
+func (p *Plugin) run(request []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.argv[0], p.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(append(request, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Plugin) start() error {
+	cmd := exec.Command(p.argv[0], p.argv[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+func (p *Plugin) stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+	p.cmd = nil
+}
+
+// exchange sends one line to the long-lived plugin and reads one line back,
+// restarting the plugin if it died or timed out
+func (p *Plugin) exchange(request []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	type reply struct {
+		line []byte
+		err  error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		if _, err := p.stdin.Write(append(request, '\n')); err != nil {
+			done <- reply{nil, err}
+			return
+		}
+		line, err := p.stdout.ReadBytes('\n')
+		done <- reply{line, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			p.stop()
+			return nil, r.err
+		}
+		return r.line, nil
+	case <-time.After(pluginTimeout):
+		p.stop()
+		return nil, fmt.Errorf("No answer within %s", pluginTimeout)
+	}
+}
+
+/*
+Close - Stop a long-lived plugin
+*/
+func (p *Plugin) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop()
+}
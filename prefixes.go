@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The firewall rules and the exports cover the targets shown on the map with
+as few prefixes as possible: prefixes covered by another one are dropped,
+then two halves of a prefix are merged into it, repeatedly. The result
+covers exactly the same addresses, never more.
+*/
+
+/*
+targetPrefix - The BGP prefix of res if known, else the host prefix of target
+*/
+func targetPrefix(target string, res IPInfoResult) *net.IPNet {
+	if _, prefix, err := net.ParseCIDR(fieldValue(res, "bgp.prefix")); err == nil {
+		return prefix
+	}
+	ip := net.ParseIP(target)
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+/*
+parsePrefix - Parse a CIDR prefix, or an IP Address as its host prefix
+*/
+func parsePrefix(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, prefix, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid prefix '%s'", s)
+		}
+		return prefix, nil
+	}
+	if prefix := targetPrefix(s, nil); prefix != nil {
+		return prefix, nil
+	}
+	return nil, fmt.Errorf("Invalid IP Address '%s'", s)
+}
+
+/*
+sortPrefixes - Sort prefixes IPv4 first, by address then length
+*/
+func sortPrefixes(prefixes []*net.IPNet) {
+	sort.Slice(prefixes, func(i, j int) bool {
+		a, b := prefixes[i], prefixes[j]
+		if len(a.IP) != len(b.IP) {
+			return len(a.IP) < len(b.IP)
+		}
+		if c := bytes.Compare(a.IP, b.IP); c != 0 {
+			return c < 0
+		}
+		aOnes, _ := a.Mask.Size()
+		bOnes, _ := b.Mask.Size()
+		return aOnes < bOnes
+	})
+}
+
+/*
+normalizePrefix - prefix with a 4 byte address and mask for IPv4, IPv4-mapped
+IPv6 prefixes included, so that prefixes of a family compare byte for byte
+*/
+func normalizePrefix(prefix *net.IPNet) *net.IPNet {
+	ones, bits := prefix.Mask.Size()
+	if ip4 := prefix.IP.To4(); ip4 != nil && (bits == 32 || ones >= 96) {
+		if bits == 128 {
+			ones -= 96
+		}
+		return &net.IPNet{IP: ip4.Mask(net.CIDRMask(ones, 32)), Mask: net.CIDRMask(ones, 32)}
+	}
+	ip16 := prefix.IP.To16()
+	return &net.IPNet{IP: ip16.Mask(net.CIDRMask(ones, 128)), Mask: net.CIDRMask(ones, 128)}
+}
+
+/*
+parentPrefix - The prefix one bit shorter holding prefix, false for /0
+*/
+func parentPrefix(prefix *net.IPNet) (*net.IPNet, bool) {
+	ones, bits := prefix.Mask.Size()
+	if ones == 0 {
+		return nil, false
+	}
+	mask := net.CIDRMask(ones-1, bits)
+	return &net.IPNet{IP: prefix.IP.Mask(mask), Mask: mask}, true
+}
+
+/*
+siblings - Whether a and b are the two halves of the same prefix
+*/
+func siblings(a, b *net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	if aOnes != bOnes || aBits != bBits || a.IP.Equal(b.IP) {
+		return false
+	}
+	parent, ok := parentPrefix(a)
+	return ok && parent.Contains(b.IP)
+}
+
+/*
+dedupePrefixes - The prefixes not covered by another one, sorted by
+sortPrefixes. nftables rejects overlapping elements of an interval set.
+*/
+func dedupePrefixes(prefixes []*net.IPNet) []*net.IPNet {
+	normal := make([]*net.IPNet, len(prefixes))
+	for i, prefix := range prefixes {
+		normal[i] = normalizePrefix(prefix)
+	}
+	sortPrefixes(normal)
+	var res []*net.IPNet
+	for _, prefix := range normal {
+		// A covering prefix sorts first
+		if n := len(res); n > 0 && len(res[n-1].IP) == len(prefix.IP) &&
+			res[n-1].Contains(prefix.IP) {
+			continue
+		}
+		res = append(res, prefix)
+	}
+	return res
+}
+
+/*
+aggregatePrefixes - The fewest prefixes covering exactly the addresses of
+prefixes, sorted by sortPrefixes
+*/
+func aggregatePrefixes(prefixes []*net.IPNet) []*net.IPNet {
+	var res []*net.IPNet
+	for _, prefix := range dedupePrefixes(prefixes) {
+		res = append(res, prefix)
+		// In address order the halves of a prefix end up next to each other
+		for n := len(res); n >= 2 && siblings(res[n-2], res[n-1]); n = len(res) {
+			parent, _ := parentPrefix(res[n-2])
+			res = append(res[:n-2], parent)
+		}
+	}
+	return res
+}
+
+/*
+shownPrefixes - The aggregated prefixes of the shown targets and a
+description of the selection. Must be called with b.mu held.
+*/
+func (b *Batch) shownPrefixes() ([]*net.IPNet, string) {
+	targets := b.shown()
+	var prefixes []*net.IPNet
+	for _, target := range targets {
+		if prefix := targetPrefix(target, b.results[target]); prefix != nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return aggregatePrefixes(prefixes), b.shownDescription(len(targets))
+}
+
+/*
+shownDescription - Comment describing n shown targets, the filter selecting
+them and the providers they were looked up with. Must be called with b.mu
+held.
+*/
+func (b *Batch) shownDescription(n int) string {
+	desc := fmt.Sprintf("%d targets shown by ip411 at %s", n, time.Now().Format(time.RFC3339))
+	if b.filter != nil {
+		desc += ", filter: " + b.filter.String()
+	}
+	var results []IPInfoResult
+	for _, target := range b.shown() {
+		results = append(results, b.results[target])
+	}
+	if summary := provenanceSummary(results); summary != "" {
+		desc += ", looked up with " + summary
+	}
+	return desc
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import "fmt"
+
+/*
+dropPrivileges - Always fails, users cannot be switched on this platform
+*/
+func dropPrivileges(name string) error {
+	return fmt.Errorf("Switching users is not supported on this platform")
+}
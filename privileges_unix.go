@@ -0,0 +1,45 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+/*
+dropPrivileges - Switch the process to name, its primary group and its
+supplementary groups
+*/
+func dropPrivileges(name string) error {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("Cannot switch to user '%s': %s", name, err)
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	var groups []int
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if n, err := strconv.Atoi(id); err == nil {
+				groups = append(groups, n)
+			}
+		}
+	}
+	// Groups first, while still allowed to change them
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("Cannot switch to user '%s': %s", name, err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("Cannot switch to user '%s': %s", name, err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("Cannot switch to user '%s': %s", name, err)
+	}
+	log.Printf("Running as %s (uid %d, gid %d)", name, uid, gid)
+	return nil
+}
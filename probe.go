@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+With -probe ip411 connects to a few common ports of the target and reads
+what the services announce about themselves. This sends traffic to the
+target, hence the flag. The hints are added under "probe":
+
+	probe.summary   "ssh OpenSSH_8.9p1, http nginx/1.18.0, os Ubuntu"
+	probe.services  banner or server header, by port
+	probe.os        operating system the banners name, if any
+*/
+
+var probeFlag = flag.Bool("probe", false,
+	"Connect to ports 22, 80, 443 and 25 of the IP Address and show what the services announce")
+
+// Strict, as closed ports of firewalled hosts only time out
+const probeTimeout = 3 * time.Second
+
+var probeInfoFields = []InfoField{
+	{"Services", "probe.summary"},
+}
+
+/*
+probePort - A port to probe and how to make its service talk
+*/
+type probePort struct {
+	Port    int
+	Service string
+	grab    func(conn net.Conn, host string) (string, error)
+}
+
+var probePorts = []probePort{
+	{22, "ssh", grabGreeting},
+	{25, "smtp", grabGreeting},
+	{80, "http", grabServer},
+	{443, "https", grabTLS},
+}
+
+/*
+grabGreeting - The first line the server sends (SSH, SMTP)
+*/
+func grabGreeting(conn net.Conn, host string) (string, error) {
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	// "220 mx.example.com ESMTP Postfix (Ubuntu)"
+	if fields := strings.SplitN(line, " ", 3); len(fields) == 3 && fields[0] == "220" {
+		line = fields[2]
+	}
+	return line, nil
+}
+
+/*
+grabServer - The Server header of the answer to a HEAD request
+*/
+func grabServer(conn net.Conn, host string) (string, error) {
+	fmt.Fprintf(conn, "HEAD / HTTP/1.0\r\nHost: %s\r\nUser-Agent: ip411\r\n\r\n", host)
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	if _, err := reader.ReadLine(); err != nil {
+		return "", err
+	}
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && len(header) == 0 {
+		return "", err
+	}
+	if server := header.Get("Server"); server != "" {
+		return server, nil
+	}
+	return "no Server header", nil
+}
+
+/*
+grabTLS - The Server header over TLS, and the name of the certificate. The
+certificate is not verified: its name is only a hint.
+*/
+func grabTLS(conn net.Conn, host string) (string, error) {
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	server, err := grabServer(tlsConn, host)
+	if err != nil {
+		server = "TLS"
+	}
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		name := certs[0].Subject.CommonName
+		if name == "" && len(certs[0].DNSNames) > 0 {
+			name = certs[0].DNSNames[0]
+		}
+		if name != "" {
+			server += fmt.Sprintf(" (certificate %s)", name)
+		}
+	}
+	return server, nil
+}
+
+/*
+ProbeResult - What a port answered, Banner is empty if it did not
+*/
+type ProbeResult struct {
+	probePort
+	Banner string
+	Err    error
+}
+
+/*
+probe - Probe every port of ip, concurrently
+*/
+func probe(ip net.IP) []ProbeResult {
+	results := make([]ProbeResult, len(probePorts))
+	var wg sync.WaitGroup
+	for i, port := range probePorts {
+		wg.Add(1)
+		go func(i int, port probePort) {
+			defer wg.Done()
+			results[i] = probeOne(ip, port)
+		}(i, port)
+	}
+	wg.Wait()
+	return results
+}
+
+func probeOne(ip net.IP, port probePort) ProbeResult {
+	r := ProbeResult{probePort: port}
+	addr := net.JoinHostPort(ip.String(), fmt.Sprint(port.Port))
+	conn, err := net.DialTimeout("tcp", addr, probeTimeout)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(probeTimeout))
+	banner, err := port.grab(conn, ip.String())
+	// Some servers do not talk first or at all: the port is still open
+	if err != nil {
+		banner = "open"
+	}
+	r.Banner = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, banner)
+	return r
+}
+
+// Operating systems named in banners, as "OpenSSH_8.9p1 Ubuntu-3ubuntu0.1" or
+// "Apache/2.4.41 (Ubuntu)"
+var probeOSHints = []struct {
+	Token string
+	OS    string
+}{
+	{"ubuntu", "Ubuntu"},
+	{"debian", "Debian"},
+	{"raspbian", "Raspbian"},
+	{"centos", "CentOS"},
+	{"red hat", "Red Hat"},
+	{"rhel", "Red Hat"},
+	{"fedora", "Fedora"},
+	{"freebsd", "FreeBSD"},
+	{"openbsd", "OpenBSD"},
+	{"microsoft", "Windows"},
+	{"win32", "Windows"},
+	{"win64", "Windows"},
+	{"routeros", "MikroTik RouterOS"},
+	{"cisco", "Cisco"},
+}
+
+/*
+probeOS - The operating systems the banners name
+*/
+func probeOS(results []ProbeResult) []string {
+	found := make(map[string]bool)
+	for _, r := range results {
+		banner := strings.ToLower(r.Banner)
+		for _, hint := range probeOSHints {
+			if strings.Contains(banner, hint.Token) {
+				found[hint.OS] = true
+			}
+		}
+	}
+	var names []string
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+addProbe - Probe the IP Address of res and add the hints under "probe"
+*/
+func addProbe(res IPInfoResult) {
+	ip := net.ParseIP(fieldValue(res, "ip"))
+	if ip == nil {
+		return
+	}
+	results := probe(ip)
+
+	services := make(map[string]interface{})
+	var parts []string
+	for _, r := range results {
+		if r.Banner == "" {
+			continue
+		}
+		services[fmt.Sprint(r.Port)] = r.Banner
+		parts = append(parts, r.Service+" "+r.Banner)
+	}
+	osNames := probeOS(results)
+	if len(osNames) > 0 {
+		parts = append(parts, "os "+strings.Join(osNames, "/"))
+	}
+	summary := strings.Join(parts, ", ")
+	if summary == "" {
+		summary = "no open port among 22, 25, 80, 443"
+	}
+	res["probe"] = map[string]interface{}{
+		"summary":  summary,
+		"services": services,
+		"os":       strings.Join(osNames, "/"),
+	}
+}
+
+/*
+showProbeFields - Add the service hints to the info pane unless the config
+file already lists them
+*/
+func showProbeFields() {
+	for _, field := range config.InfoFields {
+		if field.Path == "probe.summary" {
+			return
+		}
+	}
+	fields := append([]InfoField{}, config.InfoFields...)
+	config.InfoFields = append(fields, probeInfoFields...)
+}
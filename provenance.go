@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Every lookup records where its result came from under "provenance":
+
+	"provenance": {
+	  "provider": "ipinfo",
+	  "cache": "hit",                       hit, miss, off or replay
+	  "cached_at": "2026-10-16T09:12:00Z",  when the answer was cached, on hits
+	  "database": "AS names of 2026-10-01", the offline dataset completing it
+	  "queried_at": "2026-10-16T11:40:05Z"
+	}
+
+The info pane shows it on a line under the fields, and it goes along with
+the result into the JSON output, the events of the sinks and the snapshots
+of watch. The exports of the batch modes tell the providers and the cache
+hits of the addresses they list.
+*/
+
+// Stamped on the answers kept in the cache, to tell their age on hits
+const cachedAtKey = "ip411_cached_at"
+
+/*
+stampCached - body to keep in the cache: raw with the time it was cached
+*/
+func stampCached(raw map[string]interface{}, body []byte) []byte {
+	stamped := make(map[string]interface{}, len(raw)+1)
+	for k, v := range raw {
+		stamped[k] = v
+	}
+	stamped[cachedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(stamped)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+/*
+takeCachedAt - Remove the stamp of stampCached from raw, returning its time,
+zero if there is none
+*/
+func takeCachedAt(raw map[string]interface{}) time.Time {
+	stamp, _ := raw[cachedAtKey].(string)
+	delete(raw, cachedAtKey)
+	at, _ := time.Parse(time.RFC3339, stamp)
+	return at
+}
+
+/*
+unstampCached - body of the cache without the stamp of stampCached
+*/
+func unstampCached(body []byte) []byte {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	if _, ok := raw[cachedAtKey]; !ok {
+		return body
+	}
+	delete(raw, cachedAtKey)
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+/*
+addProvenance - Record under "provenance" that res was answered by provider,
+from the cache as cache says, completed by database unless it is ""
+*/
+func addProvenance(res IPInfoResult, provider, cache string, cachedAt time.Time, database string) {
+	field := map[string]interface{}{
+		"provider":   provider,
+		"cache":      cache,
+		"queried_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if !cachedAt.IsZero() {
+		field["cached_at"] = cachedAt.Format(time.RFC3339)
+	}
+	if database != "" {
+		field["database"] = database
+	}
+	res["provenance"] = field
+}
+
+/*
+provenanceLine - The line of the info pane telling where res came from, ""
+if it does not say
+*/
+func provenanceLine(res IPInfoResult) string {
+	provider := fieldValue(res, "provenance.provider")
+	if provider == "" {
+		return ""
+	}
+	parts := []string{fmt.Sprintf(tr("From: %s"), provider)}
+	switch cache := fieldValue(res, "provenance.cache"); cache {
+	case "hit":
+		part := tr("cache hit")
+		if at, err := time.Parse(time.RFC3339, fieldValue(res, "provenance.cached_at")); err == nil {
+			part += fmt.Sprintf(tr(" (%s old)"), time.Since(at).Round(time.Second))
+		}
+		parts = append(parts, part)
+	case "replay":
+		parts = append(parts, tr("replayed"))
+	}
+	if database := fieldValue(res, "provenance.database"); database != "" {
+		parts = append(parts, database)
+	}
+	if at, err := time.Parse(time.RFC3339, fieldValue(res, "provenance.queried_at")); err == nil {
+		parts = append(parts, fmt.Sprintf(tr("queried %s"), at.Local().Format("2006-01-02 15:04:05")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+/*
+provenanceSummary - The providers results were answered by and how many
+came from the cache: "ipinfo 12, mock 3, 5 cache hits"
+*/
+func provenanceSummary(results []IPInfoResult) string {
+	counts := make(map[string]int)
+	var providers []string
+	hits := 0
+	for _, res := range results {
+		provider := fieldValue(res, "provenance.provider")
+		if provider == "" {
+			continue
+		}
+		if counts[provider] == 0 {
+			providers = append(providers, provider)
+		}
+		counts[provider]++
+		if fieldValue(res, "provenance.cache") == "hit" {
+			hits++
+		}
+	}
+	if len(providers) == 0 {
+		return ""
+	}
+	parts := make([]string, len(providers))
+	for i, provider := range providers {
+		parts[i] = fmt.Sprintf("%s %d", provider, counts[provider])
+	}
+	return fmt.Sprintf("%s, %d cache hits", strings.Join(parts, ", "), hits)
+}
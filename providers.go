@@ -0,0 +1,498 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+Lookups can be made with several providers. Every provider's response is
+normalized to the ipinfo shape the rest of ip411 works with, so that the
+map, the info pane, filters and sinks behave the same whichever answered:
+
+	ip        address looked up
+	hostname  reverse DNS name
+	city, region, postal, timezone
+	country   ISO 3166 alpha-2 code
+	loc       "lat,lon"
+	org       "AS<number> <name>"
+	source    provider that answered
+
+Errors a provider answers (private address, unknown address...) are kept in
+an "error" field rather than failing the lookup, as they will not go away by
+retrying. Bogon addresses (private, reserved) get one too. Answers saying
+the quota is exhausted fail the lookup as rate limited instead, leaving it to
+the next provider of -provider if any.
+*/
+
+/*
+commonFields - Fields of normalized results, in display order
+*/
+var commonFields = []string{"ip", "hostname", "city", "region", "country", "loc",
+	"org", "postal", "timezone"}
+
+/*
+Provider - A geolocation API
+*/
+type Provider struct {
+	Name string
+	// Common fields the provider fills in
+	Fields []string
+	// Whether it accepts an API token (see ip411 auth)
+	Tokens bool
+
+	url       func(ip net.IP, token string) string
+	normalize func(raw map[string]interface{}) IPInfoResult
+	// Answers without a request, for providers that are not an API
+	answer func(ip net.IP) map[string]interface{}
+
+	quota   Quota
+	breaker Breaker
+}
+
+var providers = []*Provider{
+	{
+		Name:   "ipinfo",
+		Fields: commonFields,
+		Tokens: true,
+		url: func(ip net.IP, token string) string {
+			base, public := ipinfoBase()
+			url := base + "/json"
+			if ip != nil {
+				url = fmt.Sprintf("%s/%s/json", base, ip)
+			}
+			if token != "" {
+				if public {
+					// Never send the token in the clear
+					url = strings.Replace(url, "http:", "https:", 1)
+				}
+				url += "?token=" + token
+			}
+			return url
+		},
+		normalize: func(raw map[string]interface{}) IPInfoResult {
+			res := IPInfoResult(raw)
+			if e, ok := raw["error"].(map[string]interface{}); ok {
+				res["error"] = toString(e["message"])
+			}
+			if bogon, _ := raw["bogon"].(bool); bogon {
+				res["error"] = "Bogon address (private, reserved or not routed), it has no location"
+			}
+			return res
+		},
+	},
+	{
+		Name:   "ip-api",
+		Fields: []string{"ip", "city", "region", "country", "loc", "org", "postal", "timezone"},
+		url: func(ip net.IP, token string) string {
+			// The free API is HTTP only
+			if ip == nil {
+				return "http://ip-api.com/json/"
+			}
+			return fmt.Sprintf("http://ip-api.com/json/%s", ip)
+		},
+		normalize: func(raw map[string]interface{}) IPInfoResult {
+			res := IPInfoResult{}
+			setString(res, "ip", raw["query"])
+			if raw["status"] != "success" {
+				res["error"] = toString(raw["message"])
+				return res
+			}
+			setString(res, "city", raw["city"])
+			setString(res, "region", raw["regionName"])
+			setString(res, "country", raw["countryCode"])
+			setLoc(res, raw["lat"], raw["lon"])
+			setString(res, "org", raw["as"])
+			setString(res, "postal", raw["zip"])
+			setString(res, "timezone", raw["timezone"])
+			return res
+		},
+	},
+	{
+		Name:   "ipapi",
+		Fields: []string{"ip", "city", "region", "country", "loc", "org", "postal", "timezone"},
+		Tokens: true,
+		url: func(ip net.IP, token string) string {
+			url := "https://ipapi.co/json/"
+			if ip != nil {
+				url = fmt.Sprintf("https://ipapi.co/%s/json/", ip)
+			}
+			if token != "" {
+				url += "?key=" + token
+			}
+			return url
+		},
+		normalize: func(raw map[string]interface{}) IPInfoResult {
+			res := IPInfoResult{}
+			setString(res, "ip", raw["ip"])
+			if raw["error"] == true {
+				res["error"] = toString(raw["reason"])
+				return res
+			}
+			setString(res, "city", raw["city"])
+			setString(res, "region", raw["region"])
+			setString(res, "country", raw["country_code"])
+			setLoc(res, raw["latitude"], raw["longitude"])
+			setString(res, "org", strings.TrimSpace(toString(raw["asn"])+" "+toString(raw["org"])))
+			setString(res, "postal", raw["postal"])
+			setString(res, "timezone", raw["timezone"])
+			return res
+		},
+	},
+	{
+		Name:   "ipwhois",
+		Fields: []string{"ip", "city", "region", "country", "loc", "org", "postal", "timezone"},
+		url: func(ip net.IP, token string) string {
+			if ip == nil {
+				return "https://ipwho.is/"
+			}
+			return fmt.Sprintf("https://ipwho.is/%s", ip)
+		},
+		normalize: func(raw map[string]interface{}) IPInfoResult {
+			res := IPInfoResult{}
+			setString(res, "ip", raw["ip"])
+			if raw["success"] != true {
+				res["error"] = toString(raw["message"])
+				return res
+			}
+			setString(res, "city", raw["city"])
+			setString(res, "region", raw["region"])
+			setString(res, "country", raw["country_code"])
+			setLoc(res, raw["latitude"], raw["longitude"])
+			if conn, ok := raw["connection"].(map[string]interface{}); ok {
+				org := toString(conn["org"])
+				if asn, ok := conn["asn"].(float64); ok && asn > 0 {
+					org = strings.TrimSpace(fmt.Sprintf("AS%.0f %s", asn, org))
+				}
+				setString(res, "org", org)
+			}
+			setString(res, "postal", raw["postal"])
+			if tz, ok := raw["timezone"].(map[string]interface{}); ok {
+				setString(res, "timezone", tz["id"])
+			}
+			return res
+		},
+	},
+	{
+		Name:   "mock",
+		Fields: []string{"ip", "hostname", "city", "country", "loc", "org"},
+		answer: mockAnswer,
+		normalize: func(raw map[string]interface{}) IPInfoResult {
+			return IPInfoResult(raw)
+		},
+	},
+}
+
+func setString(res IPInfoResult, key string, val interface{}) {
+	if s := toString(val); s != "" {
+		res[key] = s
+	}
+}
+
+func setLoc(res IPInfoResult, lat, lon interface{}) {
+	latF, okLat := lat.(float64)
+	lonF, okLon := lon.(float64)
+	if okLat && okLon {
+		res["loc"] = formatLoc(latF, lonF)
+	}
+}
+
+/*
+findProvider - The provider called name
+*/
+func findProvider(name string) (*Provider, error) {
+	var names []string
+	for _, p := range providers {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return nil, invalidInput("Unknown provider '%s', expected one of %s", name,
+		strings.Join(names, ", "))
+}
+
+/*
+providerFlag - -provider, selecting the providers as soon as it is parsed
+*/
+type providerFlag struct{}
+
+func (providerFlag) String() string {
+	return chain.Names()
+}
+
+/*
+Set .
+*/
+func (providerFlag) Set(list string) error {
+	chained, err := parseProviderChain(list)
+	if err != nil {
+		return err
+	}
+	chain.Providers = chained
+	return nil
+}
+
+func addProviderFlag(flags *flag.FlagSet) {
+	flags.Var(providerFlag{}, "provider",
+		"Geolocation API to look addresses up with, or mock (see ip411 providers).\n"+
+			"A comma separated list fails over from one to the next")
+	flags.Var(providerURLFlag{}, "provider-url",
+		"Base URL of a self-hosted ipinfo-compatible API, http(s)://host[:port] or\n"+
+			"unix:///path/to/socket, used by the ipinfo provider instead of ipinfo.io")
+	flags.BoolVar(&chain.RoundRobin, "round-robin", config.RoundRobin,
+		"Spread lookups over the -provider list instead of failing over in order")
+	flags.StringVar(&recordDir, "record", "",
+		"Save every provider response in this directory, for -replay")
+	flags.StringVar(&replayDir, "replay", "",
+		"Answer lookups with the responses saved by -record instead of the provider")
+	flags.BoolVar(&useHTTP3, "http3", false,
+		"Make HTTPS provider requests over HTTP/3 (QUIC), falling back to TCP where it fails")
+	addCacheFlags(flags)
+}
+
+/*
+Lookup - Look ip up (the client's IP Address if nil), also returning the
+round-trip time of the request that succeeded, not counting time spent
+waiting for the quota
+*/
+func (p *Provider) Lookup(ip net.IP) (IPInfoResult, time.Duration, error) {
+	return p.lookup(ip, true)
+}
+
+/*
+lookup - Lookup, failing with exitRateLimited instead of waiting for the
+quota unless wait is set
+*/
+func (p *Provider) lookup(ip net.IP, wait bool) (IPInfoResult, time.Duration, error) {
+	var raw map[string]interface{}
+	var rtt time.Duration
+	from := "off"
+	var cachedAt time.Time
+	if p.answer != nil {
+		raw = p.answer(ip)
+	} else {
+		var body []byte
+		var err error
+		// The answer for the client's own address depends on the client
+		cache := lookupCache.cache
+		if ip == nil {
+			cache = nil
+		}
+		cached := false
+		if replayDir != "" {
+			body, err = replayResponse(p, ip)
+		} else if cache != nil {
+			body, cached = cache.Get(cacheKey(p, ip))
+			countCacheLookup(cached)
+		}
+		if replayDir == "" && !cached {
+			body, rtt, err = p.fetch(ip, wait)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if raw, err = parseResponse(body); err != nil {
+			return nil, 0, fmt.Errorf("%s: Invalid response: %s", p.Name, err)
+		}
+		switch stamp := takeCachedAt(raw); {
+		case replayDir != "":
+			from = "replay"
+		case cached:
+			from, cachedAt = "hit", stamp
+		case cache != nil:
+			from = "miss"
+		}
+		if recordDir != "" {
+			if err := recordResponse(p, ip, body); err != nil {
+				warnf("Could not record the response: %s", err)
+			}
+		}
+		if _, failed := raw["error"]; cache != nil && !cached && !failed && replayDir == "" {
+			cache.Set(cacheKey(p, ip), stampCached(raw, body), lookupCache.ttl)
+		}
+	}
+	res := p.result(raw, from, cachedAt)
+	addNote(res)
+	return res, rtt, nil
+}
+
+/*
+result - The result of the answer raw, normalized and tagged with where it
+came from
+*/
+func (p *Provider) result(raw map[string]interface{}, from string, cachedAt time.Time) IPInfoResult {
+	res := p.normalize(raw)
+	res["source"] = p.Name
+	roundLoc(res)
+	addProvenance(res, p.Name, from, cachedAt, nameOrg(res))
+	tagCompliance(res)
+	return res
+}
+
+/*
+fetch - GET the response of the provider for ip
+*/
+func (p *Provider) fetch(ip net.IP, wait bool) ([]byte, time.Duration, error) {
+	token := ""
+	if p.Tokens {
+		token = providerToken(p.Name)
+	}
+	url := p.url(ip, token)
+
+	var resp *http.Response
+	var rtt time.Duration
+	rateLimited := withExitCode(exitRateLimited, fmt.Errorf("%s: Quota exhausted", p.Name))
+	for {
+		if wait {
+			if err := p.quota.Wait(); err != nil {
+				return nil, 0, err
+			}
+		} else if !p.quota.Ready() {
+			return nil, 0, rateLimited
+		}
+
+		span := telemetry.StartSpan("provider.request", spanClient, nil)
+		span.Set("provider", p.Name)
+		start := time.Now()
+		var err error
+		resp, err = providerHTTP().Get(url)
+		if err != nil {
+			telemetry.Count("ip411.provider.requests", 1, "provider", p.Name, "outcome", "error")
+			span.End(err)
+			return nil, 0, err
+		}
+		rtt = time.Since(start)
+		telemetry.Record("ip411.provider.duration", rtt, "provider", p.Name)
+		span.Set("http.status_code", resp.StatusCode)
+		if !p.quota.Update(resp) {
+			outcome := "ok"
+			if resp.StatusCode != http.StatusOK {
+				outcome = fmt.Sprint(resp.StatusCode)
+			}
+			telemetry.Count("ip411.provider.requests", 1, "provider", p.Name, "outcome", outcome)
+			span.End(nil)
+			break
+		}
+		telemetry.Count("ip411.provider.requests", 1, "provider", p.Name, "outcome", "rate_limited")
+		span.End(rateLimited)
+		resp.Body.Close()
+		if !wait {
+			return nil, 0, rateLimited
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rateLimitBody(body) {
+		p.quota.Exhaust()
+		return nil, 0, rateLimited
+	}
+	return body, rtt, nil
+}
+
+/*
+rateLimitBody - Whether body says the quota is exhausted rather than
+answering: ipinfo sends "Rate limit exceeded" as text, or as the title of a
+JSON error, sometimes with a 200 status
+*/
+func rateLimitBody(body []byte) bool {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return strings.Contains(strings.ToLower(string(body)), "rate limit")
+	}
+	text := toString(raw["error"])
+	if e, ok := raw["error"].(map[string]interface{}); ok {
+		text = toString(e["title"]) + " " + toString(e["message"])
+	}
+	return strings.Contains(strings.ToLower(text), "rate limit")
+}
+
+/*
+locationError - Why res cannot be placed on the map, nil if it can: the
+error answered by the provider if any, rather than a missing key
+*/
+func locationError(res IPInfoResult) error {
+	if _, _, err := res.GetLonLat(); err == nil {
+		return nil
+	}
+	ip := fieldValue(res, "ip")
+	if msg := fieldValue(res, "error"); msg != "" {
+		return fmt.Errorf("%s: %s", ip, tr(msg))
+	}
+	return fmt.Errorf(tr("%s: No location in the answer of %s"), ip, fieldValue(res, "source"))
+}
+
+/*
+supports - Whether the provider fills in field
+*/
+func (p *Provider) supports(field string) bool {
+	for _, f := range p.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+providersHelp - What the usage message of providers says after the synopsis
+*/
+const providersHelp = `List the geolocation providers and the fields each of them supports.
+`
+
+func runProviders(args []string) error {
+	flags := flag.NewFlagSet("providers", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s providers\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(providersHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "Field\t")
+	for _, p := range providers {
+		name := p.Name
+		if chain.includes(p) {
+			name += "*"
+		}
+		fmt.Fprintf(tw, "%s\t", name)
+	}
+	fmt.Fprintln(tw)
+
+	row := func(label string, has func(p *Provider) bool) {
+		fmt.Fprintf(tw, "%s\t", label)
+		for _, p := range providers {
+			mark := "-"
+			if has(p) {
+				mark = "yes"
+			}
+			fmt.Fprintf(tw, "%s\t", mark)
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, field := range commonFields {
+		field := field
+		row(field, func(p *Provider) bool { return p.supports(field) })
+	}
+	row("(token)", func(p *Provider) bool { return p.Tokens })
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\n* selected, with -provider or \"provider\" in the config file")
+	return nil
+}
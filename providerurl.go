@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+)
+
+/*
+-provider-url (or provider_url in the config file) points the ipinfo
+provider at a self-hosted ipinfo-compatible API, such as an internal mirror,
+instead of ipinfo.io. The API may listen on a Unix socket:
+
+	-provider-url http://geo.internal:8080
+	-provider-url unix:///run/geoip/api.sock
+
+Tokens are sent as given: over plain HTTP to a mirror if its URL says so.
+*/
+
+// Host of the requests routed to the Unix socket
+const socketHost = "provider.sock"
+
+var customProvider struct {
+	raw    string
+	base   string // URL the paths of the API are appended to
+	socket string // path of the Unix socket, "" for TCP
+}
+
+/*
+setProviderURL - Parse and use raw as the base URL of the ipinfo provider
+*/
+func setProviderURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return invalidInput("Invalid provider URL '%s': %s", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return invalidInput("Invalid provider URL '%s': Missing host.", raw)
+		}
+		customProvider.base = strings.TrimSuffix(raw, "/")
+		customProvider.socket = ""
+	case "unix":
+		if u.Path == "" {
+			return invalidInput("Invalid provider URL '%s': Missing socket path.", raw)
+		}
+		customProvider.base = "http://" + socketHost
+		customProvider.socket = u.Path
+	default:
+		return invalidInput("Invalid provider URL '%s': Expected http, https or unix.", raw)
+	}
+	customProvider.raw = raw
+	return nil
+}
+
+/*
+ipinfoBase - Base URL of the ipinfo provider and whether it is the default
+*/
+func ipinfoBase() (string, bool) {
+	if customProvider.base != "" {
+		return customProvider.base, false
+	}
+	return "http://ipinfo.io", true
+}
+
+/*
+providerURLFlag - -provider-url, checked as soon as it is parsed
+*/
+type providerURLFlag struct{}
+
+func (providerURLFlag) String() string {
+	return customProvider.raw
+}
+
+/*
+Set .
+*/
+func (providerURLFlag) Set(raw string) error {
+	return setProviderURL(raw)
+}
+
+/*
+dialProvider - Dial the Unix socket of the provider for its requests, TCP
+for anything else
+*/
+func dialProvider(ctx context.Context, network, addr string) (net.Conn, error) {
+	var d net.Dialer
+	if customProvider.socket != "" && addr == net.JoinHostPort(socketHost, "80") {
+		return d.DialContext(ctx, "unix", customProvider.socket)
+	}
+	return d.DialContext(ctx, network, addr)
+}
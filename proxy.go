@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+The proxy mode serves the ipinfo API to a team through one token: requests
+are answered from a cache when possible, else forwarded to ipinfo with the
+token of the proxy (from the keyring or $IPINFO_TOKEN, see ip411 auth) under
+the provider quota. Tokens sent by clients are ignored. Clients point
+-provider-url at the proxy:
+
+	GET /8.8.8.8/json   the lookup of an address (/8.8.8.8 too)
+	GET /json           the lookup of the client's address
+	GET /stats          cache and upstream statistics, as JSON
+	GET /healthz        liveness, /readyz readiness (see health.go)
+
+Answers carry X-Cache: HIT or MISS. Errors are not cached. Answers are
+cached stamped like those of lookups (see provenance.go), so that a cache
+server shared with ip411 clients holds one format, and are served without
+the stamp.
+*/
+
+/*
+Proxy - A caching, rate-limiting proxy in front of a provider
+*/
+type Proxy struct {
+	provider *Provider
+	cache    Cache
+	ttl      time.Duration
+	health   *Health
+
+	clientLimit  int // lookups per clientPeriod, unlimited if 0
+	clientPeriod time.Duration
+
+	mu       sync.Mutex
+	window   time.Time      // start of the current rate limit period
+	counts   map[string]int // lookups of each client in the period
+	upstream int            // requests forwarded
+	failed   int            // forwarded requests that failed
+	limited  int            // requests refused by the client rate limit
+}
+
+/*
+ProxyStats - The body of /stats
+*/
+type ProxyStats struct {
+	Cache    CacheStats `json:"cache"`
+	HitRate  float64    `json:"hit_rate"`
+	Upstream int        `json:"upstream_requests"`
+	Failed   int        `json:"upstream_failures"`
+	Limited  int        `json:"rate_limited"`
+	Quota    string     `json:"quota"`
+}
+
+/*
+allow - Whether client may make one more request in the current period
+*/
+func (px *Proxy) allow(client string) bool {
+	px.mu.Lock()
+	defer px.mu.Unlock()
+	if px.clientLimit == 0 {
+		return true
+	}
+	if now := time.Now(); now.Sub(px.window) >= px.clientPeriod {
+		px.window = now
+		px.counts = make(map[string]int)
+	}
+	if px.counts[client] >= px.clientLimit {
+		px.limited++
+		return false
+	}
+	px.counts[client]++
+	return true
+}
+
+/*
+lookup - The provider answer for ip, from the cache if there
+*/
+func (px *Proxy) lookup(ip net.IP) ([]byte, bool, error) {
+	key := cacheKey(px.provider, ip)
+	if body, ok := px.cache.Get(key); ok {
+		return unstampCached(body), true, nil
+	}
+
+	px.mu.Lock()
+	px.upstream++
+	px.mu.Unlock()
+	var raw map[string]interface{}
+	body, _, err := px.provider.fetch(ip, false)
+	if err == nil {
+		if raw, err = parseResponse(body); err != nil {
+			err = fmt.Errorf("Invalid answer: %s", err)
+		} else if _, failed := raw["error"]; failed {
+			err = fmt.Errorf("%s", toString(px.provider.normalize(raw)["error"]))
+		}
+	}
+	if err != nil {
+		px.mu.Lock()
+		px.failed++
+		px.mu.Unlock()
+		return body, false, err
+	}
+	px.cache.Set(key, stampCached(raw, body), px.ttl)
+	return body, false, nil
+}
+
+func (px *Proxy) stats() ProxyStats {
+	cache := px.cache.Stats()
+	px.mu.Lock()
+	defer px.mu.Unlock()
+	return ProxyStats{
+		Cache:    cache,
+		HitRate:  cache.HitRate(),
+		Upstream: px.upstream,
+		Failed:   px.failed,
+		Limited:  px.limited,
+		Quota:    px.provider.quota.String(),
+	}
+}
+
+/*
+proxyError - Answer status with message as a JSON error, as ipinfo does
+*/
+func proxyError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+/*
+statusWriter - A ResponseWriter remembering the status of the answer
+*/
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (px *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if telemetry == nil {
+		px.serve(w, r)
+		return
+	}
+	span := telemetry.StartSpan("proxy.request", spanServer, nil)
+	span.continueTrace(r.Header.Get("traceparent"))
+	span.Set("http.method", r.Method)
+	span.Set("http.target", r.URL.Path)
+	start := time.Now()
+	sw := &statusWriter{w, http.StatusOK}
+	px.serve(sw, r)
+
+	cache := strings.ToLower(w.Header().Get("X-Cache"))
+	span.Set("http.status_code", sw.status)
+	span.Set("cache", cache)
+	telemetry.Count("ip411.proxy.requests", 1, "status", fmt.Sprint(sw.status), "cache", cache)
+	telemetry.Record("ip411.proxy.duration", time.Since(start))
+	var err error
+	if sw.status >= 500 {
+		err = fmt.Errorf("%s", http.StatusText(sw.status))
+	}
+	span.End(err)
+}
+
+func (px *Proxy) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		proxyError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+		return
+	}
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "healthz" || path == "readyz" {
+		px.health.ServeHTTP(w, r)
+		return
+	}
+	if path == "stats" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(px.stats())
+		return
+	}
+	target := strings.TrimSuffix(strings.TrimSuffix(path, "json"), "/")
+	if target == "" {
+		target = client
+	}
+	ip := net.ParseIP(target)
+	if ip == nil {
+		proxyError(w, http.StatusBadRequest, fmt.Sprintf("Invalid IP Address '%s'", target))
+		return
+	}
+
+	if !px.allow(client) {
+		w.Header().Set("Retry-After", fmt.Sprint(int(px.clientPeriod.Seconds())+1))
+		proxyError(w, http.StatusTooManyRequests, "Rate limited")
+		return
+	}
+	body, hit, err := px.lookup(ip)
+	if err != nil {
+		status := http.StatusBadGateway
+		if exitCode(err) == exitRateLimited {
+			status = http.StatusTooManyRequests
+		}
+		if body == nil {
+			proxyError(w, status, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.Write(body)
+}
+
+/*
+proxyHelp - What the usage message of proxy says after the synopsis
+*/
+const proxyHelp = `Serve the ipinfo API to a team through the token of this host, caching
+the answers and rate-limiting the clients. Point clients at it with
+-provider-url http://host:8080, see /stats for the cache statistics
+and /healthz and /readyz for probes. SIGTERM drains the lookups in flight.
+With -cache redis://host the cache is shared with other proxies and ip411s.
+`
+
+func runProxy(args []string) error {
+	flags := flag.NewFlagSet("proxy", flag.ExitOnError)
+	addQuietFlag(flags)
+	server := addServerFlags(flags, ":8080")
+	addCacheFlags(flags)
+	size := flags.Int("cache-size", defaultCacheSize, "Most answers kept in memory without a -cache server")
+	clientRate := flags.String("client-rate", "",
+		"Serve at most n lookups per period to each client, as 100/m (default no limit)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s proxy [-listen addr] [-user name] [-pprof addr] [-cache url] [-cache-ttl d] [-cache-size n] [-client-rate n/d]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(proxyHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return invalidInput("Invalid arguments: The proxy takes flags only.")
+	}
+	if *size <= 0 {
+		return invalidInput("Invalid -cache-size %d: Expected a positive size.", *size)
+	}
+	// A provider_url of the config file may well point at this proxy
+	customProvider.base, customProvider.socket = "", ""
+	provider, err := findProvider("ipinfo")
+	if err != nil {
+		return err
+	}
+	px := &Proxy{provider: provider, cache: lookupCache.cache, ttl: lookupCache.ttl}
+	if px.cache == nil || lookupCache.raw == "memory" {
+		px.cache = instrumentCache(NewMemoryCache(*size), "memory")
+	}
+	if *clientRate != "" {
+		if px.clientLimit, px.clientPeriod, err = parseRate(*clientRate); err != nil {
+			return invalidInput("Invalid -client-rate '%s': %s.", *clientRate, err)
+		}
+	}
+	if providerToken(provider.Name) == "" {
+		warnf("No %s token, the proxy shares the free quota (see %s auth)", provider.Name, os.Args[0])
+	}
+
+	px.health = &Health{provider: provider, cache: px.cache}
+
+	log.Printf("Serving %s", provider.Name)
+	return serveGracefully(server, px, px.health)
+}
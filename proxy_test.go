@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProxySharesTheCacheFormat(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ip": "8.8.8.8", "country": "US", "loc": "37.4056,-122.0775"}`)
+	}))
+	defer upstream.Close()
+	provider := &Provider{Name: "test",
+		url:       func(ip net.IP, token string) string { return upstream.URL + "/" + ip.String() },
+		normalize: func(raw map[string]interface{}) IPInfoResult { return IPInfoResult(raw) },
+	}
+
+	saved := lookupCache.cache
+	defer func() { lookupCache.cache = saved }()
+	lookupCache.cache = NewMemoryCache(16)
+	px := &Proxy{provider: provider, cache: lookupCache.cache, ttl: time.Minute}
+	ip := net.ParseIP("8.8.8.8")
+
+	for _, wantHit := range []bool{false, true} {
+		body, hit, err := px.lookup(ip)
+		if err != nil || hit != wantHit {
+			t.Fatalf("proxy lookup: hit %v, %v, expected hit %v", hit, err, wantHit)
+		}
+		var raw map[string]interface{}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if _, stamped := raw[cachedAtKey]; stamped || raw["country"] != "US" {
+			t.Fatalf("proxy answered %s", body)
+		}
+	}
+
+	// A client sharing the cache reads the entry of the proxy as its own
+	res, _, err := provider.lookup(ip, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provenance, _ := res["provenance"].(map[string]interface{})
+	if provenance["cache"] != "hit" || provenance["cached_at"] == nil {
+		t.Fatalf("client lookup provenance %v, expected a stamped hit", provenance)
+	}
+	if _, stamped := res[cachedAtKey]; stamped {
+		t.Fatalf("stamp left in the result: %v", res)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minRetryBackoff = 2 * time.Second
+	maxRetryBackoff = 5 * time.Minute
+)
+
+/*
+QueuedLookup - A lookup that failed and is waiting to be retried
+*/
+type QueuedLookup struct {
+	Target   string    `json:"target"`
+	Attempts int       `json:"attempts"`
+	NextTry  time.Time `json:"next_try"`
+	Error    string    `json:"error"`
+}
+
+/*
+RetryQueue - Failed lookups waiting to be retried with exponential backoff.
+The queue is written to disk on every change so that pending lookups survive
+a restart and get picked up by the next run.
+*/
+type RetryQueue struct {
+	mu      sync.Mutex
+	path    string
+	pending []*QueuedLookup
+	wake    chan struct{}
+}
+
+/*
+defaultQueuePath - Location of the persisted retry queue of a list of
+targets, one per list so that a run only retries the lookups left pending by
+a run of the same list
+*/
+func defaultQueuePath(targets []string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(targets, "\n")))
+	return filepath.Join(dir, "ip411", fmt.Sprintf("queue-%x.json", sum[:8]))
+}
+
+/*
+NewRetryQueue - Create a queue persisted at path, loading any lookups left
+pending by a previous run. An empty path disables persistence.
+*/
+func NewRetryQueue(path string) (*RetryQueue, error) {
+	q := &RetryQueue{path: path, wake: make(chan struct{}, 1)}
+	if path == "" {
+		return q, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q.pending); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+/*
+Targets - Targets currently waiting in the queue
+*/
+func (q *RetryQueue) Targets() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	targets := make([]string, len(q.pending))
+	for i, job := range q.pending {
+		targets[i] = job.Target
+	}
+	return targets
+}
+
+/*
+Pending - Snapshot of the queued lookups
+*/
+func (q *RetryQueue) Pending() []QueuedLookup {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]QueuedLookup, len(q.pending))
+	for i, job := range q.pending {
+		jobs[i] = *job
+	}
+	return jobs
+}
+
+/*
+Len - Number of queued lookups
+*/
+func (q *RetryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+/*
+Fail - Record a failed attempt for target and schedule its next retry
+*/
+func (q *RetryQueue) Fail(target string, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := q.find(target)
+	if job == nil {
+		job = &QueuedLookup{Target: target}
+		q.pending = append(q.pending, job)
+	}
+	job.Attempts++
+	job.Error = cause.Error()
+	job.NextTry = time.Now().Add(retryBackoff(job.Attempts))
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return q.save()
+}
+
+/*
+Done - Remove target from the queue after a successful lookup
+*/
+func (q *RetryQueue) Done(target string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.pending {
+		if job.Target == target {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return q.save()
+		}
+	}
+	return nil
+}
+
+/*
+Next - Block until the queued lookup with the earliest retry time is due and
+return its target
+*/
+func (q *RetryQueue) Next() string {
+	for {
+		q.mu.Lock()
+		// A copy: Fail and Done change the jobs once the lock is released
+		var next QueuedLookup
+		found := false
+		for _, job := range q.pending {
+			if !found || job.NextTry.Before(next.NextTry) {
+				next, found = *job, true
+			}
+		}
+		q.mu.Unlock()
+
+		if !found {
+			<-q.wake
+			continue
+		}
+
+		wait := time.Until(next.NextTry)
+		if wait <= 0 {
+			return next.Target
+		}
+		select {
+		case <-time.After(wait):
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *RetryQueue) find(target string) *QueuedLookup {
+	for _, job := range q.pending {
+		if job.Target == target {
+			return job
+		}
+	}
+	return nil
+}
+
+func (q *RetryQueue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return err
+	}
+	if len(q.pending) == 0 {
+		err := os.Remove(q.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(q.pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(q.path, data, 0600)
+}
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := minRetryBackoff
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueuePathPerList(t *testing.T) {
+	a := defaultQueuePath([]string{"1.1.1.1", "8.8.8.8"})
+	if a == "" {
+		t.Skip("no user cache directory")
+	}
+	if b := defaultQueuePath([]string{"1.1.1.1", "8.8.8.8"}); b != a {
+		t.Errorf("same list in %s and %s", a, b)
+	}
+	if b := defaultQueuePath([]string{"1.1.1.1"}); b == a {
+		t.Errorf("different lists share %s", a)
+	}
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := NewRetryQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Fail("203.0.113.9", errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	if q, err = NewRetryQueue(path); err != nil {
+		t.Fatal(err)
+	}
+	if targets := q.Targets(); len(targets) != 1 || targets[0] != "203.0.113.9" {
+		t.Fatalf("pending after a restart: %v", targets)
+	}
+	if err := q.Done("203.0.113.9"); err != nil || q.Len() != 0 {
+		t.Fatalf("Done left %d pending, %v", q.Len(), err)
+	}
+}
+
+func TestPendingMarkers(t *testing.T) {
+	queue, err := NewRetryQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBatch(queue)
+	b.add("203.0.113.7")
+	b.results["203.0.113.7"] = IPInfoResult{"ip": "203.0.113.7", "loc": "48.8566,2.3522"}
+	for _, target := range []string{"203.0.113.9", "198.51.100.1", "example.com"} {
+		b.add(target)
+		queue.Fail(target, errors.New("timeout"))
+	}
+
+	var pending []Marker
+	for _, m := range b.markers() {
+		if m.Text == pendingLabel {
+			pending = append(pending, m)
+		}
+	}
+	// Only the address of a located /24 has a place on the map
+	if len(pending) != 1 || pending[0].Targets[0] != "203.0.113.9" || pending[0].Lat != 48.8566 {
+		t.Fatalf("pending markers %+v", pending)
+	}
+
+	approximate := 0
+	for _, line := range b.summary() {
+		if strings.HasSuffix(line, "(shown at 203.0.113.7, approximate)") {
+			approximate++
+		}
+	}
+	if approximate != 1 {
+		t.Errorf("%d pending lines marked approximate in %q", approximate, b.summary())
+	}
+
+	var mc MapCanvas
+	mc.Init(80, 24)
+	mc.view = Viewport{Zoom: 1}
+	stacked := stackMarkers(&mc, b.markers())
+	if len(stacked) != 1 || stacked[0].Text != pendingLabel || len(stacked[0].Targets) != 2 {
+		t.Fatalf("stacked markers %+v, expected one '?' for both", stacked)
+	}
+}
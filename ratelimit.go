@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Fraction of the provider quota below which lookups get spaced out
+	// evenly over the time left until the quota resets
+	throttleFraction = 10
+	// Backoff used when a provider answers 429 without telling us when to
+	// come back
+	defaultRetryAfter = 60 * time.Second
+)
+
+/*
+quotaMaxWait - Longest wait for a quota before lookups give up, 0 for no
+limit (-max-wait)
+*/
+var quotaMaxWait time.Duration
+
+/*
+RateLimit - Quota information reported by a provider in its response headers
+*/
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Known     bool
+}
+
+/*
+parseRateLimit - Read the X-RateLimit-* (or IETF draft RateLimit-*) headers of
+a provider response. Reset values are accepted both as a unix timestamp and
+as a number of seconds from now.
+*/
+func parseRateLimit(header http.Header, now time.Time) RateLimit {
+	var rl RateLimit
+
+	limit, okLimit := headerInt(header, "X-RateLimit-Limit", "RateLimit-Limit")
+	remaining, okRemaining := headerInt(header, "X-RateLimit-Remaining",
+		"RateLimit-Remaining")
+	if !okLimit && !okRemaining {
+		return rl
+	}
+	rl.Known = true
+	rl.Limit = limit
+	rl.Remaining = remaining
+
+	if reset, ok := headerInt(header, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		if reset > 1000000000 {
+			rl.Reset = time.Unix(int64(reset), 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl
+}
+
+/*
+parseRetryAfter - Parse a Retry-After header, which is either a number of
+seconds or an HTTP date
+*/
+func parseRetryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
+
+func headerInt(header http.Header, keys ...string) (int, bool) {
+	for _, key := range keys {
+		value := header.Get(key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+/*
+Quota - Tracks the provider quota across lookups. Lookups call Wait before
+issuing a request and Update with every response, so that batches slow down
+as the quota runs low and queue up when it is exhausted instead of failing.
+*/
+type Quota struct {
+	mu        sync.Mutex
+	limit     RateLimit
+	blocked   time.Time
+	lastQuery time.Time
+}
+
+/*
+Update - Record the rate-limit state carried by a provider response. Returns
+true if the response was a 429 and the request should be retried.
+*/
+func (q *Quota) Update(resp *http.Response) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if rl := parseRateLimit(resp.Header, now); rl.Known {
+		q.limit = rl
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if q.limit.Known && q.limit.Remaining <= 0 && q.limit.Reset.After(now) {
+			q.blocked = q.limit.Reset
+		}
+		return false
+	}
+
+	until, ok := parseRetryAfter(resp.Header, now)
+	if !ok {
+		until = q.limit.Reset
+	}
+	if !until.After(now) {
+		until = now.Add(defaultRetryAfter)
+	}
+	q.blocked = until
+	q.limit.Known = true
+	q.limit.Remaining = 0
+	return true
+}
+
+/*
+Exhaust - Record an answer saying the quota is exhausted without a 429
+status (ipinfo's "Rate limit exceeded" bodies), backing off as for a 429
+without Retry-After
+*/
+func (q *Quota) Exhaust() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	until := q.limit.Reset
+	if !until.After(now) {
+		until = now.Add(defaultRetryAfter)
+	}
+	q.blocked = until
+	q.limit.Known = true
+	q.limit.Remaining = 0
+}
+
+/*
+delay - How long the next lookup has to wait, either because the quota is
+exhausted or because it is running low and requests are being spread out
+*/
+func (q *Quota) delay(now time.Time) time.Duration {
+	if q.blocked.After(now) {
+		return q.blocked.Sub(now)
+	}
+	rl := q.limit
+	if !rl.Known || rl.Limit <= 0 || rl.Remaining <= 0 || !rl.Reset.After(now) {
+		return 0
+	}
+	if rl.Remaining*throttleFraction > rl.Limit {
+		return 0
+	}
+	spacing := rl.Reset.Sub(now) / time.Duration(rl.Remaining)
+	next := q.lastQuery.Add(spacing)
+	if next.After(now) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+/*
+Wait - Block until the quota allows another lookup. Fails instead if that
+would take longer than quotaMaxWait.
+*/
+func (q *Quota) Wait() error {
+	q.mu.Lock()
+	now := time.Now()
+	d := q.delay(now)
+	if quotaMaxWait > 0 && d > quotaMaxWait {
+		q.mu.Unlock()
+		return withExitCode(exitRateLimited, fmt.Errorf(
+			tr("Provider quota exhausted, next lookup allowed in %s"), d.Round(time.Second)))
+	}
+	q.lastQuery = now.Add(d)
+	q.mu.Unlock()
+
+	if d > 0 {
+		warnf("Provider quota exhausted or low, waiting %s",
+			d.Round(time.Second))
+		time.Sleep(d)
+	}
+	return nil
+}
+
+/*
+Ready - Take the next lookup if the quota allows it right away
+*/
+func (q *Quota) Ready() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	if q.delay(now) > 0 {
+		return false
+	}
+	q.lastQuery = now
+	return true
+}
+
+/*
+String - Human readable quota summary for the status bar
+*/
+func (q *Quota) String() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.limit.Known {
+		return tr("Quota: unknown")
+	}
+	s := fmt.Sprintf(tr("Quota: %d"), q.limit.Remaining)
+	if q.limit.Limit > 0 {
+		s += fmt.Sprintf("/%d", q.limit.Limit)
+	}
+	if !q.limit.Reset.IsZero() {
+		s += fmt.Sprintf(tr(" (resets %s)"), q.limit.Reset.Format("2006-01-02 15:04"))
+	}
+	if q.blocked.After(time.Now()) {
+		s += tr(" - rate limited, requests queued")
+	}
+	return s
+}
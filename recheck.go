@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The recheck mode keeps stored sets of addresses honest: the addresses of the
+notes (see notes.go), the bookmarks and an address of each owned prefix (see
+ownership.go) are located again, checked against the blocklists with -dnsbl
+and routed with -bgp, once their last check is older than the interval
+(-interval, or "recheck_interval" in the config file, 24h by default).
+Entries whose country, region, city, org, hostname, BGP origin or listings
+changed since their previous check are highlighted, and published as
+"changed" events to the sinks. The results also go through the alert rules
+(-alert), "ownership" alerting on owned prefixes attributed to someone else.
+
+	ip411 recheck            check what is due, then keep checking
+	ip411 recheck -once      check what is due and exit, for cron
+	ip411 recheck -list      show the state of the last checks
+
+The state of the checks is kept in recheck.json in the user's cache
+directory. A note's first check compares against the result it pinned.
+*/
+
+const defaultRecheckInterval = 24 * time.Hour
+
+/*
+RecheckEntry - The state of the checks of an address of a stored set
+*/
+type RecheckEntry struct {
+	Key       string       `json:"key"` // address of a note, @name of a bookmark, owned prefix
+	Target    string       `json:"target"`
+	Checked   time.Time    `json:"checked"`
+	Result    IPInfoResult `json:"result,omitempty"`
+	Listed    []string     `json:"listed,omitempty"` // blocklists, as of the last -dnsbl check
+	Changed   string       `json:"changed,omitempty"`
+	ChangedAt time.Time    `json:"changed_at,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+func defaultRecheckPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "recheck.json")
+}
+
+/*
+loadRecheck - The entries of the state file at path, by key
+*/
+func loadRecheck(path string) (map[string]*RecheckEntry, error) {
+	entries := make(map[string]*RecheckEntry)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*RecheckEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for _, entry := range list {
+		entries[entry.Key] = entry
+	}
+	return entries, nil
+}
+
+func saveRecheck(path string, entries map[string]*RecheckEntry) error {
+	list := make([]*RecheckEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+/*
+recheckSets - The entries of the stored sets, new ones with the result their
+note pinned, and the entries no longer in a set dropped
+*/
+func recheckSets(entries map[string]*RecheckEntry, useNotes, useBookmarks, useOwned bool) error {
+	keep := make(map[string]bool)
+	if useNotes {
+		store, err := loadNotes()
+		if err != nil {
+			return err
+		}
+		for _, note := range store.List() {
+			keep[note.IP] = true
+			if _, ok := entries[note.IP]; !ok {
+				entries[note.IP] = &RecheckEntry{Key: note.IP, Target: note.IP,
+					Checked: note.Pinned, Result: note.Result}
+			}
+		}
+	}
+	if useBookmarks {
+		for _, name := range bookmarkNames() {
+			target := config.Bookmarks[name]
+			if _, ok := targetPoint(target); ok {
+				continue
+			}
+			key := "@" + name
+			keep[key] = true
+			if entry, ok := entries[key]; !ok || entry.Target != target {
+				entries[key] = &RecheckEntry{Key: key, Target: target}
+			}
+		}
+	}
+	if useOwned {
+		for i := range config.OwnedPrefixes {
+			o := &config.OwnedPrefixes[i]
+			keep[o.Prefix] = true
+			if _, ok := entries[o.Prefix]; !ok {
+				entries[o.Prefix] = &RecheckEntry{Key: o.Prefix, Target: o.sampleAddress()}
+			}
+		}
+	}
+	for key := range entries {
+		if !keep[key] {
+			delete(entries, key)
+		}
+	}
+	return nil
+}
+
+/*
+listingChanges - What changed between two lists of blocklists, as "listed on
+x, delisted from y"
+*/
+func listingChanges(previous, listed []string) string {
+	was := make(map[string]bool)
+	for _, list := range previous {
+		was[list] = true
+	}
+	now := make(map[string]bool)
+	var changed []string
+	for _, list := range listed {
+		now[list] = true
+		if !was[list] {
+			changed = append(changed, "listed on "+list)
+		}
+	}
+	for _, list := range previous {
+		if !now[list] {
+			changed = append(changed, "delisted from "+list)
+		}
+	}
+	return strings.Join(changed, ", ")
+}
+
+/*
+check - Locate the target of the entry again, and check it against the
+blocklists if dnsbl and route it if bgp. Returns what changed since its
+previous check, "" if nothing or if it was never checked.
+*/
+func (entry *RecheckEntry) check(dnsbl, bgp bool) string {
+	res, err := locateTarget([]string{entry.Target})
+	if err == nil {
+		err = locationError(res)
+	}
+	if err == nil && bgp {
+		_, err = addBGP(res)
+	}
+	entry.Checked = time.Now()
+	if err != nil {
+		entry.Error = err.Error()
+		return ""
+	}
+	entry.Error = ""
+	delete(res, "note")
+
+	var changes []string
+	if entry.Result != nil {
+		if changed := resultChanges(entry.Result, res); changed != "" {
+			changes = append(changes, changed)
+		}
+	}
+	entry.Result = res
+	if dnsbl {
+		if rep := reputationOf(res); rep != nil {
+			var listed []string
+			for _, r := range rep.Listed() {
+				listed = append(listed, r.List)
+			}
+			if changed := listingChanges(entry.Listed, listed); changed != "" {
+				changes = append(changes, changed)
+			}
+			entry.Listed = listed
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	entry.Changed = strings.Join(changes, ", ")
+	entry.ChangedAt = entry.Checked
+	return entry.Changed
+}
+
+/*
+recheckLine - A line about the entry, highlighted if it changed since
+*/
+func recheckLine(entry *RecheckEntry, since time.Time, color bool) string {
+	status := "unchanged"
+	switch {
+	case entry.Error != "":
+		status = "failed: " + entry.Error
+	case entry.Checked.IsZero():
+		status = "never checked"
+	case !entry.ChangedAt.IsZero():
+		status = fmt.Sprintf("changed %s: %s", entry.ChangedAt.Local().Format("2006-01-02 15:04"),
+			entry.Changed)
+	}
+	mark := " "
+	if !entry.ChangedAt.IsZero() && !entry.ChangedAt.Before(since) {
+		mark = "!"
+	}
+	checked := "-"
+	if !entry.Checked.IsZero() {
+		checked = time.Since(entry.Checked).Round(time.Minute).String()
+	}
+	line := fmt.Sprintf("%s %-24s %-10s %s", mark, entry.Key, checked, status)
+	if mark == "!" && color {
+		line = "\x1b[1;31m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+/*
+recheckHelp - What the usage message of recheck says after the synopsis
+*/
+const recheckHelp = `Locate the addresses of the notes, the bookmarks and the owned prefixes
+again once their last check is older than the interval, highlighting (!)
+the ones whose location, org, hostname, BGP origin or blocklist listings
+changed. -alert ownership alerts on owned prefixes attributed to another
+AS or org.
+`
+
+func runRecheck(args []string) error {
+	flags := flag.NewFlagSet("recheck", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	interval := defaultRecheckInterval
+	if config.RecheckInterval != "" {
+		var err error
+		if interval, err = time.ParseDuration(config.RecheckInterval); err != nil {
+			return invalidInput("Invalid recheck_interval '%s': %s", config.RecheckInterval, err)
+		}
+	}
+	flags.DurationVar(&interval, "interval", interval,
+		"Check an address again once its last check is older than this")
+	statePath := flags.String("state", defaultRecheckPath(), "File keeping the state of the checks")
+	once := flags.Bool("once", false, "Check what is due and exit")
+	list := flags.Bool("list", false, "Show the state of the last checks without checking")
+	dnsbl := flags.Bool("dnsbl", false, "Also check the addresses against the DNS blocklists")
+	useNotes := flags.Bool("notes", true, "Check the addresses of the notes")
+	useBookmarks := flags.Bool("bookmarks", true, "Check the bookmarks")
+	useOwned := flags.Bool("owned", true, "Check an address of each owned prefix of the config file")
+	bgp := flags.Bool("bgp", false, "Also query the BGP origin of the addresses (RIPEstat)")
+	alertFlags := addAlertFlags(flags)
+	sinks := addSinkFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s recheck [-interval d] [-once] [-list] [-dnsbl] [-bgp] [alert flags] [sink flags]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(recheckHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: recheck takes none.")
+	}
+	if interval <= 0 {
+		return invalidInput("Invalid interval %s: Expected a positive duration.", interval)
+	}
+	if *statePath == "" {
+		return fmt.Errorf("No cache directory to keep the state of the checks in")
+	}
+
+	entries, err := loadRecheck(*statePath)
+	if err != nil {
+		return err
+	}
+	color := isTerminal(os.Stdout)
+	if *list {
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Println(recheckLine(entries[key], time.Now().Add(-interval), color))
+		}
+		return nil
+	}
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+	var alerts *Alerts
+	stop := bus.Subscribe(func(m Message) {
+		line := fmt.Sprintf(tr("Alert %s: %s, %s"), m.Alert, m.Target, m.Reason)
+		if color {
+			line = "\x1b[1;31m" + line + "\x1b[0m"
+		}
+		fmt.Println(line)
+		alerts.Ring(nil, m)
+	}, MsgAlert)
+	defer stop()
+	// Closed first, so that its alerts are printed
+	alerts, err = alertFlags.open()
+	if err != nil {
+		return err
+	}
+	defer alerts.Close()
+
+	for {
+		if err := recheckSets(entries, *useNotes, *useBookmarks, *useOwned); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("No notes, bookmarks or owned prefixes to check")
+		}
+
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		start := time.Now()
+		next := start.Add(interval)
+		for _, key := range keys {
+			entry := entries[key]
+			if due := entry.Checked.Add(interval); due.After(start) {
+				if due.Before(next) {
+					next = due
+				}
+				continue
+			}
+			if changed := entry.check(*dnsbl, *bgp); changed != "" {
+				e := NewEvent(EventChanged, entry.Result)
+				e.Reason = changed
+				events.Emit(e)
+			}
+			if entry.Error == "" {
+				bus.Publish(Message{Topic: MsgResultReady, Source: "recheck", Target: entry.Target,
+					Result: entry.Result})
+			}
+			fmt.Println(recheckLine(entry, start, color))
+			if err := saveRecheck(*statePath, entries); err != nil {
+				return err
+			}
+		}
+
+		if *once {
+			return nil
+		}
+		time.Sleep(time.Until(next))
+	}
+}
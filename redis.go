@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisDialTimeout = 5 * time.Second
+	// A slow cache must not slow lookups down more than this
+	redisTimeout = time.Second
+	// Time without trying again after the server could not be reached
+	redisBackoff = 30 * time.Second
+)
+
+// Failure of the commands sent during redisBackoff, warned about once
+var errRedisBackoff = fmt.Errorf("Redis server unreachable")
+
+/*
+RedisCache - A cache in Redis or Valkey, shared by every ip411 pointed at
+it. Only the few commands needed are implemented, over one connection that
+is re-established on the next command if it drops. A cache that fails only
+misses: lookups go on without it.
+*/
+type RedisCache struct {
+	mu     sync.Mutex
+	server *url.URL
+	conn   net.Conn
+	reader *bufio.Reader
+	retry  time.Time // no connection attempt before
+	stats  CacheStats
+}
+
+/*
+NewRedisCache - A cache on the server at rawurl (redis:// or rediss:// for
+TLS, with optional user:password and /db). Connects on first use.
+*/
+func NewRedisCache(rawurl string) (*RedisCache, error) {
+	server, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if server.Scheme != "redis" && server.Scheme != "rediss" {
+		return nil, fmt.Errorf("Unsupported Redis scheme '%s'", server.Scheme)
+	}
+	if db := strings.Trim(server.Path, "/"); db != "" {
+		if _, err := strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("Invalid Redis database '%s'", db)
+		}
+	}
+	return &RedisCache{server: server}, nil
+}
+
+func (c *RedisCache) connect() error {
+	host := c.server.Host
+	if c.server.Port() == "" {
+		host = net.JoinHostPort(c.server.Hostname(), "6379")
+	}
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var conn net.Conn
+	var err error
+	if c.server.Scheme == "rediss" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host,
+			&tls.Config{ServerName: c.server.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	if user := c.server.User; user != nil {
+		// redis://:password@ and redis://password@ both mean no user name
+		args := []string{"AUTH", user.Username()}
+		if password, ok := user.Password(); ok {
+			args = append(args, password)
+			if user.Username() == "" {
+				args = []string{"AUTH", password}
+			}
+		}
+		if _, err := c.do(args...); err != nil {
+			c.drop()
+			return fmt.Errorf("Redis authentication failed: %s", err)
+		}
+	}
+	if db := strings.Trim(c.server.Path, "/"); db != "" {
+		if _, err := c.do("SELECT", db); err != nil {
+			c.drop()
+			return err
+		}
+	}
+	return nil
+}
+
+// drop closes the current connection; callers must hold c.mu
+func (c *RedisCache) drop() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+/*
+do - Send a command and read its reply: a string, nil for a nil bulk string,
+or an error the server answered. Callers must hold c.mu.
+*/
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if _, err := io.WriteString(c.conn, cmd.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *RedisCache) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("Invalid Redis reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return nil, &redisError{line[1:]}
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Redis reply '%s'", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	}
+	return nil, fmt.Errorf("Unexpected Redis reply '%s'", line)
+}
+
+/*
+redisError - An error answered by the server, which leaves the connection
+usable
+*/
+type redisError struct {
+	message string
+}
+
+func (e *redisError) Error() string {
+	return e.message
+}
+
+/*
+command - do, connecting first if needed and dropping the connection if it
+failed
+*/
+func (c *RedisCache) command(args ...string) (interface{}, error) {
+	if c.conn == nil {
+		if time.Now().Before(c.retry) {
+			return nil, errRedisBackoff
+		}
+		if err := c.connect(); err != nil {
+			c.retry = time.Now().Add(redisBackoff)
+			return nil, err
+		}
+	}
+	reply, err := c.do(args...)
+	if _, answered := err.(*redisError); err != nil && !answered {
+		c.drop()
+	}
+	return reply, err
+}
+
+/*
+Get - The value of key, false if absent or if the server failed
+*/
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reply, err := c.command("GET", key)
+	if err != nil && err != errRedisBackoff {
+		warnf("Redis cache: %s", err)
+	}
+	value, ok := reply.(string)
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	return []byte(value), true
+}
+
+/*
+Set - Keep value for key during ttl
+*/
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seconds := int(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if _, err := c.command("SET", key, string(value), "EX", strconv.Itoa(seconds)); err != nil && err != errRedisBackoff {
+		warnf("Redis cache: %s", err)
+	}
+}
+
+/*
+Ping - Check that the server answers
+*/
+func (c *RedisCache) Ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.command("PING")
+	return err
+}
+
+/*
+Stats - Hits and misses of this process. The entries are shared with other
+processes, and not counted (-1).
+*/
+func (c *RedisCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = -1
+	return stats
+}
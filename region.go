@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+/*
+Latency estimate from distance: light in fiber covers about 200 km per ms
+and routes are about 1.5 times longer than the great circle
+*/
+const (
+	fiberKmPerMs   = 200.0
+	routeInflation = 1.5
+)
+
+/*
+estimatedRTT - Round trip time in ms expected over a distance
+*/
+func estimatedRTT(km float64) float64 {
+	return 2 * km * routeInflation / fiberKmPerMs
+}
+
+/*
+percentile - The p-th percentile (0-100) of sorted values, nearest rank
+*/
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+/*
+RegionScore - How well a candidate location serves a set of clients
+*/
+type RegionScore struct {
+	Candidate string  `json:"candidate"`
+	MedianKm  float64 `json:"median_km"`
+	MeanKm    float64 `json:"mean_km"`
+	P90Km     float64 `json:"p90_km"`
+	MedianRTT float64 `json:"median_rtt_ms"`
+	Nearest   int     `json:"nearest_clients"` // clients for which it is the nearest candidate
+}
+
+/*
+scoreRegions - Score every candidate for the clients, best (lowest median
+distance) first
+*/
+func scoreRegions(clients []Point, candidates []Datacenter) []RegionScore {
+	scores := make([]RegionScore, len(candidates))
+	for i, c := range candidates {
+		dists := make([]float64, len(clients))
+		sum := 0.0
+		for j, p := range clients {
+			dists[j] = distanceKm(p, c.Point)
+			sum += dists[j]
+		}
+		sort.Float64s(dists)
+		scores[i] = RegionScore{
+			Candidate: c.Name,
+			MedianKm:  percentile(dists, 50),
+			P90Km:     percentile(dists, 90),
+		}
+		if len(clients) > 0 {
+			scores[i].MeanKm = sum / float64(len(clients))
+		}
+		scores[i].MedianRTT = estimatedRTT(scores[i].MedianKm)
+	}
+
+	for _, p := range clients {
+		best, _ := nearestDatacenter(p, candidates)
+		for i := range scores {
+			if scores[i].Candidate == best.Name {
+				scores[i].Nearest++
+			}
+		}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].MedianKm < scores[j].MedianKm
+	})
+	return scores
+}
+
+func writeRegionScores(w io.Writer, located, total int, scores []RegionScore) error {
+	fmt.Fprintf(w, "Clients located: %s of %s\n\n", formatCount(located), formatCount(total))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	units := numberFormat.Units
+	fmt.Fprintf(tw, "Candidate\tMedian %s\tMean %s\tP90 %s\tEst. RTT ms\tNearest for\t\n",
+		units, units, units)
+	for _, s := range scores {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", s.Candidate,
+			formatNumber(distanceIn(s.MedianKm), 0), formatNumber(distanceIn(s.MeanKm), 0),
+			formatNumber(distanceIn(s.P90Km), 0), formatNumber(s.MedianRTT, 0),
+			formatCount(s.Nearest))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(scores) > 0 {
+		fmt.Fprintf(w, "\nBest: %s (median %s, ~%s ms)\n", scores[0].Candidate,
+			formatDistance(scores[0].MedianKm), formatNumber(scores[0].MedianRTT, 0))
+	}
+	return nil
+}
+
+/*
+regionHelp - What the usage message of region says after the synopsis
+*/
+const regionHelp = `Locate the clients listed in file (or stdin), then compare how far
+each candidate location is from them, best median distance first.
+Latencies are estimated from distance.
+`
+
+func runRegion(args []string) error {
+	flags := flag.NewFlagSet("region", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	addUnitsFlag(flags)
+	candidatesPath := flags.String("candidates", "",
+		"File of candidate server locations (name,lat,lon or name,ip)")
+	asJSON := flags.Bool("json", false, "Print the comparison as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s region -candidates file [-json] [file]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(regionHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if *candidatesPath == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Specify a candidates file and at most one clients file.")
+	}
+	candidates, err := readDatacenters(*candidatesPath)
+	if err != nil {
+		return err
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	targets, err := readTargets(input)
+	if err != nil {
+		return err
+	}
+
+	var clients []Point
+	for _, target := range targets {
+		res, err := locateTarget([]string{target})
+		if err != nil {
+			warnf("%s: %s", target, err)
+			continue
+		}
+		lon, lat, err := res.GetLonLat()
+		if err != nil {
+			warnf("%s: %s", target, err)
+			continue
+		}
+		clients = append(clients, Point{Lat: lat, Lon: lon})
+	}
+	if len(clients) == 0 {
+		return fmt.Errorf("No client could be located")
+	}
+
+	scores := scoreRegions(clients, candidates)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(scores)
+	}
+	return writeRegionScores(os.Stdout, len(clients), len(targets), scores)
+}
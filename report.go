@@ -0,0 +1,525 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The report mode locates a batch of addresses and writes what an audit asks
+about them: how many could be located, their breakdown by country and ASN,
+how many fall under each compliance tag (see compliance.go), how the data was
+obtained, and a world map of their locations. Markdown reports embed the map
+as a PNG data URI so that they stay a single file; PDF reports draw it.
+*/
+
+// Size of the map of the Markdown reports, in pixels
+const (
+	reportMapWidth  = 1024
+	reportMapHeight = 512
+)
+
+var complianceLabels = map[string]string{
+	"eu":             "European Union",
+	"eea":            "European Economic Area, GDPR",
+	"uk":             "United Kingdom, UK GDPR",
+	"adequacy":       "EU adequacy decision",
+	"us_privacy_law": "US state with a privacy law",
+}
+
+/*
+ReportRow - Number of located addresses sharing a key
+*/
+type ReportRow struct {
+	Key     string
+	Count   int
+	Percent float64
+}
+
+/*
+Report - The located addresses of a batch, summarized
+*/
+type Report struct {
+	Title     string
+	Generated time.Time
+	Providers string
+	Targets   int
+	Located   int
+	Dropped   int // by the -script
+	Countries []ReportRow
+	ASNs      []ReportRow
+	// Tags, then the addresses without any as "none"
+	Compliance []ReportRow
+	// Located addresses by location
+	Locations map[Point]int
+}
+
+/*
+reportRows - Rows of counts out of total, largest first, the ones after top
+summed up as "Other" (all of them if top is 0)
+*/
+func reportRows(counts map[string]int, total, top int) []ReportRow {
+	rows := make([]ReportRow, 0, len(counts))
+	for key, n := range counts {
+		rows = append(rows, ReportRow{Key: key, Count: n})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	if top > 0 && len(rows) > top {
+		other := ReportRow{Key: "Other"}
+		for _, row := range rows[top:] {
+			other.Count += row.Count
+		}
+		rows = append(rows[:top], other)
+	}
+	for i := range rows {
+		if total > 0 {
+			rows[i].Percent = 100 * float64(rows[i].Count) / float64(total)
+		}
+	}
+	return rows
+}
+
+/*
+NewReport - Summarize the results of a batch. top limits the rows of the
+country and ASN breakdowns.
+*/
+func NewReport(title string, targets int, results []IPInfoResult, dropped, top int) *Report {
+	r := &Report{
+		Title:     title,
+		Generated: time.Now(),
+		Providers: chain.Names(),
+		Targets:   targets,
+		Located:   len(results),
+		Dropped:   dropped,
+		Locations: make(map[Point]int),
+	}
+	countries := make(map[string]int)
+	asns := make(map[string]int)
+	tags := make(map[string]int)
+	for _, res := range results {
+		countries[statKey(statDimensions[0].key, res)]++
+		asns[statKey(statDimensions[1].key, res)]++
+		list, _ := res["compliance"].([]interface{})
+		for _, tag := range list {
+			tags[toString(tag)]++
+		}
+		if len(list) == 0 {
+			tags["none"]++
+		}
+		if lon, lat, err := res.GetLonLat(); err == nil {
+			r.Locations[Point{Lat: lat, Lon: lon}]++
+		}
+	}
+	r.Countries = reportRows(countries, r.Located, top)
+	r.ASNs = reportRows(asns, r.Located, top)
+
+	// Tags in a fixed order, for reports to be compared
+	for _, tag := range []string{"eu", "eea", "uk", "adequacy", "us_privacy_law", "none"} {
+		row := ReportRow{Key: tag, Count: tags[tag]}
+		if r.Located > 0 {
+			row.Percent = 100 * float64(row.Count) / float64(r.Located)
+		}
+		r.Compliance = append(r.Compliance, row)
+	}
+	return r
+}
+
+/*
+methodology - How the data of the report was obtained and what it is worth
+*/
+func (r *Report) methodology() string {
+	return fmt.Sprintf("Each address was located with ip411 using %s. Geolocation "+
+		"databases place an address where its network is registered or announced, "+
+		"usually right at the country level and often wrong at the city level; "+
+		"VPNs, proxies, mobile carriers and anycast addresses may be located far "+
+		"from the user. ASNs are those of the org field of the results. Compliance "+
+		"tags are derived from the country (and for US states, the region) of "+
+		"each result: EU and EEA membership, the United Kingdom, the countries "+
+		"with an EU adequacy decision (the United States only cover companies "+
+		"certified under the Data Privacy Framework and are not tagged), and the "+
+		"US states with a comprehensive consumer privacy law. They are an aid "+
+		"to the review, not legal advice.", r.Providers)
+}
+
+/*
+summaryLines - The lines of the report before its tables
+*/
+func (r *Report) summaryLines() []string {
+	lines := []string{
+		fmt.Sprintf("Generated: %s", r.Generated.Format(time.RFC3339)),
+		fmt.Sprintf("Provider: %s", r.Providers),
+		fmt.Sprintf("Addresses: %s, located: %s", formatCount(r.Targets), formatCount(r.Located)),
+	}
+	if r.Dropped > 0 {
+		lines = append(lines, fmt.Sprintf("Dropped by the script: %s", formatCount(r.Dropped)))
+	}
+	return lines
+}
+
+/*
+mapXY - The position of lon,lat on an equirectangular map of width by height
+*/
+func mapXY(lon, lat, width, height float64) (float64, float64) {
+	return (lon + 180) / 360 * width, (90 - lat) / 180 * height
+}
+
+/*
+mapRadius - Radius of the disc of a location with n of the max addresses
+*/
+func mapRadius(n, max int, smallest, largest float64) float64 {
+	return smallest + (largest-smallest)*markerWeight(n, max)
+}
+
+func (r *Report) maxLocation() int {
+	max := 0
+	for _, n := range r.Locations {
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+/*
+coastlineSegments - The coastlines as polylines, split where they cross the
+antimeridian
+*/
+func coastlineSegments() [][]Point {
+	var segments [][]Point
+	for _, shape := range CreateWorldMap() {
+		var segment []Point
+		for i, p := range shape {
+			if i > 0 && math.Abs(p.Lon-shape[i-1].Lon) > 180 {
+				segments = append(segments, segment)
+				segment = nil
+			}
+			segment = append(segment, Point{Lat: p.Lat, Lon: p.Lon})
+		}
+		if len(shape) > 1 && math.Abs(shape[0].Lon-shape[len(shape)-1].Lon) <= 180 {
+			segment = append(segment, Point{Lat: shape[0].Lat, Lon: shape[0].Lon})
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+/*
+MapImage - The coastlines and the located addresses as an image
+*/
+func (r *Report) MapImage() image.Image {
+	palette := color.Palette{
+		color.White,
+		color.RGBA{0x99, 0x99, 0x99, 0xff},
+		color.RGBA{0xd6, 0x27, 0x28, 0xff},
+	}
+	img := image.NewPaletted(image.Rect(0, 0, reportMapWidth, reportMapHeight), palette)
+	w, h := float64(reportMapWidth), float64(reportMapHeight)
+
+	for _, segment := range coastlineSegments() {
+		for i := 1; i < len(segment); i++ {
+			x0, y0 := mapXY(segment[i-1].Lon, segment[i-1].Lat, w, h)
+			x1, y1 := mapXY(segment[i].Lon, segment[i].Lat, w, h)
+			drawImageLine(img, int(x0), int(y0), int(x1), int(y1), 1)
+		}
+	}
+	max := r.maxLocation()
+	for loc, n := range r.Locations {
+		x, y := mapXY(loc.Lon, loc.Lat, w, h)
+		radius := mapRadius(n, max, 3, 12)
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if dx*dx+dy*dy <= radius*radius {
+					img.SetColorIndex(int(x+dx), int(y+dy), 2)
+				}
+			}
+		}
+	}
+	return img
+}
+
+/*
+drawImageLine - Draw the line from x0,y0 to x1,y1 in the color at index c
+(Bresenham)
+*/
+func drawImageLine(img *image.Paletted, x0, y0, x1, y1 int, c uint8) {
+	dx, dy := x1-x0, y1-y0
+	sx, sy := 1, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+	err := dx - dy
+	for {
+		img.SetColorIndex(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e := 2 * err
+		if e > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func writeMarkdownTable(w io.Writer, header string, rows []ReportRow, label func(string) string) {
+	fmt.Fprintf(w, "| %s | Addresses | %% |\n|---|---:|---:|\n", header)
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s | %s | %s |\n", strings.Replace(label(row.Key), "|", "\\|", -1),
+			formatCount(row.Count), formatNumber(row.Percent, 1))
+	}
+	fmt.Fprintln(w)
+}
+
+func complianceLabel(tag string) string {
+	if label, ok := complianceLabels[tag]; ok {
+		return fmt.Sprintf("%s (%s)", label, tag)
+	}
+	if tag == "none" {
+		return "None of these"
+	}
+	return tag
+}
+
+/*
+WriteMarkdown - Write the report as Markdown
+*/
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	fmt.Fprintf(w, "# %s\n\n", r.Title)
+	for _, line := range r.summaryLines() {
+		fmt.Fprintf(w, "- %s\n", line)
+	}
+	fmt.Fprintln(w)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, r.MapImage()); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "## Map\n\n![Located addresses](data:image/png;base64,%s)\n\n",
+		base64.StdEncoding.EncodeToString(img.Bytes()))
+
+	same := func(key string) string { return key }
+	fmt.Fprint(w, "## Compliance regions\n\n")
+	writeMarkdownTable(w, "Region", r.Compliance, complianceLabel)
+	fmt.Fprint(w, "## Countries\n\n")
+	writeMarkdownTable(w, "Country", r.Countries, same)
+	fmt.Fprint(w, "## ASNs\n\n")
+	writeMarkdownTable(w, "ASN", r.ASNs, same)
+	_, err := fmt.Fprintf(w, "## Methodology\n\n%s\n", r.methodology())
+	return err
+}
+
+func writePDFTable(doc *PDF, title, header string, rows []ReportRow, label func(string) string) {
+	doc.Need(60)
+	doc.Y += 10
+	doc.Line(title, 13, true)
+	doc.Y += 4
+	columns := func(key, count, percent string, bold bool) {
+		doc.Need(14)
+		doc.Y += 14
+		doc.Text(pdfMargin, key, 10, bold)
+		doc.Text(pdfWidth-pdfMargin-110, count, 10, bold)
+		doc.Text(pdfWidth-pdfMargin-40, percent, 10, bold)
+	}
+	columns(header, "Addresses", "%", true)
+	for _, row := range rows {
+		key := label(row.Key)
+		if len(key) > 70 {
+			key = key[:69] + "..."
+		}
+		columns(key, formatCount(row.Count), formatNumber(row.Percent, 1), false)
+	}
+}
+
+/*
+WritePDF - Write the report as a PDF document
+*/
+func (r *Report) WritePDF(w io.Writer) error {
+	doc := NewPDF()
+	doc.Line(r.Title, 18, true)
+	doc.Y += 6
+	for _, line := range r.summaryLines() {
+		doc.Line(line, 10, false)
+	}
+
+	// The map across the page
+	width := pdfWidth - 2*pdfMargin
+	height := width / 2
+	doc.Y += 12
+	left, top := pdfMargin, doc.Y
+	doc.Color(0.6, 0.6, 0.6)
+	doc.Rect(left, top, width, height, 0.5)
+	for _, segment := range coastlineSegments() {
+		points := make([][2]float64, len(segment))
+		for i, p := range segment {
+			x, y := mapXY(p.Lon, p.Lat, width, height)
+			points[i] = [2]float64{left + x, top + y}
+		}
+		doc.Polyline(points, false, 0.3)
+	}
+	doc.Color(0.84, 0.15, 0.16)
+	max := r.maxLocation()
+	for loc, n := range r.Locations {
+		x, y := mapXY(loc.Lon, loc.Lat, width, height)
+		doc.Disc(left+x, top+y, mapRadius(n, max, 1.5, 6))
+	}
+	doc.Color(0, 0, 0)
+	doc.Y += height
+
+	same := func(key string) string { return key }
+	writePDFTable(doc, "Compliance regions", "Region", r.Compliance, complianceLabel)
+	writePDFTable(doc, "Countries", "Country", r.Countries, same)
+	writePDFTable(doc, "ASNs", "ASN", r.ASNs, same)
+
+	doc.Need(60)
+	doc.Y += 10
+	doc.Line("Methodology", 13, true)
+	doc.Y += 4
+	doc.Paragraph(r.methodology(), 10)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+/*
+Write - Write the report to w in format, md or pdf
+*/
+func (r *Report) Write(w io.Writer, format string) error {
+	if format == "pdf" {
+		return r.WritePDF(w)
+	}
+	return r.WriteMarkdown(w)
+}
+
+/*
+Save - Write the report to path in format
+*/
+func (r *Report) Save(path, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = r.Write(f, format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+/*
+reportHelp - What the usage message of report says after the synopsis
+*/
+const reportHelp = `Locate the addresses listed in file (or stdin) and write a timestamped
+report for audits: breakdown by country and ASN, compliance regions
+(EU/EEA, UK, adequacy, US state privacy laws), a methodology note and
+a map of the locations.
+`
+
+func runReport(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	addQuietFlag(flags)
+	addProviderFlag(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	format := flags.String("format", "md", "Format of the report: md or pdf")
+	output := flags.String("o", "",
+		"File to write the report to, - for stdout (default ip411-report-<time>.<format>)")
+	title := flags.String("title", "IP Address report", "Title of the report")
+	top := flags.Int("top", 20, "Rows of the country and ASN breakdowns, the rest summed up (0 for all)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s report [-format md|pdf] [-o file] [-title t] [-top n] [file]\n",
+			os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(reportHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one file.")
+	}
+	if *format != "md" && *format != "pdf" {
+		return invalidInput("Invalid format '%s': Expected md or pdf.", *format)
+	}
+	if *top < 0 {
+		return invalidInput("Invalid number of rows %d: Expected 0 or more.", *top)
+	}
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+	targets, err := readTargets(input)
+	if err != nil {
+		return err
+	}
+
+	var results []IPInfoResult
+	dropped := 0
+	for _, target := range targets {
+		res, err := locateTarget([]string{target})
+		if err == nil {
+			err = locationError(res)
+		}
+		if err != nil {
+			warnf("%s: %s", target, err)
+			continue
+		}
+		keep, err := pipeline.Process(res)
+		if err != nil {
+			warnf("%s: %s", target, err)
+		}
+		if !keep {
+			dropped++
+			continue
+		}
+		results = append(results, res)
+	}
+	report := NewReport(*title, len(targets), results, dropped, *top)
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("ip411-report-%s.%s", report.Generated.Format("20060102-150405"), *format)
+	}
+	if path == "-" {
+		return report.Write(os.Stdout, *format)
+	}
+	if err := report.Save(path, *format); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Report of %d addresses written to %s\n", len(targets), path)
+	return nil
+}
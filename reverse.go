@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+/*
+Reverse geocoding places a location by the nearest city of the embedded
+gazetteer (see cities.go). It is as coarse as the gazetteer: the country is
+the one of the nearest city, which can be wrong near borders.
+*/
+
+var countryRegions = map[string]string{
+	"AT": "Europe", "BE": "Europe", "CH": "Europe", "CZ": "Europe",
+	"DE": "Europe", "DK": "Europe", "ES": "Europe", "FI": "Europe",
+	"FR": "Europe", "GB": "Europe", "GR": "Europe", "HU": "Europe",
+	"IE": "Europe", "IS": "Europe", "IT": "Europe", "NL": "Europe",
+	"NO": "Europe", "PL": "Europe", "PT": "Europe", "RU": "Europe",
+	"SE": "Europe", "TR": "Europe", "UA": "Europe",
+	"CA": "North America", "MX": "North America", "US": "North America",
+	"AR": "South America", "BR": "South America", "CL": "South America",
+	"CO": "South America", "PE": "South America",
+	"AO": "Africa", "CD": "Africa", "EG": "Africa", "ET": "Africa",
+	"GH": "Africa", "KE": "Africa", "MA": "Africa", "NG": "Africa",
+	"SD": "Africa", "TZ": "Africa", "ZA": "Africa",
+	"AE": "Middle East", "IQ": "Middle East", "IR": "Middle East",
+	"SA": "Middle East",
+	"BD": "Asia", "CN": "Asia", "HK": "Asia", "ID": "Asia", "IN": "Asia",
+	"JP": "Asia", "KR": "Asia", "MY": "Asia", "PH": "Asia", "PK": "Asia",
+	"SG": "Asia", "TH": "Asia", "TW": "Asia", "VN": "Asia",
+	"AU": "Oceania", "NZ": "Oceania",
+}
+
+/*
+Place - What reverse geocoding knows about a location
+*/
+type Place struct {
+	Point
+	City       string
+	Country    string
+	Region     string
+	DistanceKm float64 // to the city
+}
+
+func (p Place) String() string {
+	return fmt.Sprintf(tr("%s: near %s, %s (%s), %s away"), formatCoords(p.Lat, p.Lon),
+		p.City, p.Country, p.Region, formatDistance(p.DistanceKm))
+}
+
+/*
+reverseGeocode - Place of p, by its nearest city
+*/
+func reverseGeocode(p Point) Place {
+	place := Place{Point: p, DistanceKm: math.Inf(1)}
+	for _, city := range cities {
+		if d := distanceKm(p, city.Point); d < place.DistanceKm {
+			place.City, place.Country, place.DistanceKm = city.Name, city.Country, d
+		}
+	}
+	place.Region = countryRegions[place.Country]
+	return place
+}
+
+/*
+parseLatLon - Parse a "lat,lon" pair in degrees
+*/
+func parseLatLon(s string) (Point, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Point{}, fmt.Errorf("Could not read '%s' as lat,lon", s)
+	}
+	p, err := parseCoords(parts[0], parts[1])
+	if err != nil {
+		return Point{}, fmt.Errorf("Could not read '%s' as lat,lon: %s", s, err)
+	}
+	return p, nil
+}
+
+/*
+geoHelp - What the usage message of geo says after the synopsis
+*/
+const geoHelp = `Print the nearest city, country and region of locations, from an
+embedded list of major cities.
+`
+
+func runGeo(args []string) error {
+	flags := flag.NewFlagSet("geo", flag.ExitOnError)
+	addQuietFlag(flags)
+	addUnitsFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s geo lat,lon...\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(geoHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify a location.")
+	}
+	for _, arg := range flags.Args() {
+		p, err := parseLatLon(arg)
+		if err != nil {
+			return withExitCode(exitInvalidInput, err)
+		}
+		fmt.Println(reverseGeocode(p))
+	}
+	return nil
+}
+
+// Crosshair
+
+var crosshair *Point // only touched from the gui goroutine
+
+func showCrosshair(g *Gui) error {
+	guiShowStatus(g, "%s", reverseGeocode(*crosshair))
+	if err := redrawMap(g); err != nil {
+		return err
+	}
+	return showStack(g)
+}
+
+/*
+toggleCrosshair - Show a crosshair at the center of the map, moved by the
+arrows, reverse geocoding where it points
+*/
+func toggleCrosshair(g *Gui, v *View) error {
+	if crosshair != nil {
+		crosshair = nil
+		guiLoadStatus(g)
+		if err := redrawMap(g); err != nil {
+			return err
+		}
+		return showStack(g)
+	}
+	minLon, maxLon, minLat, maxLat := viewport.Bounds()
+	crosshair = &Point{Lat: (minLat + maxLat) / 2, Lon: (minLon + maxLon) / 2}
+	return showCrosshair(g)
+}
+
+/*
+moveCrosshair - Move the crosshair by a fraction of the viewport
+*/
+func moveCrosshair(g *Gui, dx, dy float64) error {
+	minLon, maxLon, minLat, maxLat := viewport.Bounds()
+	crosshair.Lon = math.Max(minLon, math.Min(maxLon, crosshair.Lon+dx*(maxLon-minLon)))
+	crosshair.Lat = math.Max(minLat, math.Min(maxLat, crosshair.Lat+dy*(maxLat-minLat)))
+	return showCrosshair(g)
+}
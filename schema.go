@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+JSON Schema (draft 2020-12) of the machine outputs. The definitions are the
+contract with downstream consumers: fields may be added, but not renamed or
+removed without a new schema version.
+
+	result     a lookup result (piped output, -jsonl event results)
+	event      a line of -jsonl or -format jsonl, an MQTT message or a webhook
+	           body
+	bench      the output of bench -json
+	region     the output of region -json
+	aggregate  the output of aggregate -json
+	distances  a .json distance report of batch -distances
+
+There is no GeoJSON output: the GeoJSON ip411 knows is what db update reads,
+and locations are written as the loc of a result or the lat and lon of the
+other outputs. schema_test.go checks real outputs against the definitions.
+*/
+
+const schemaVersion = "1"
+
+const schemaJSON = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/cruatta/ip411/schema/v1",
+  "title": "ip411 machine outputs",
+  "$defs": {
+    "result": {
+      "description": "A lookup result, normalized to the fields of ipinfo.io whatever the provider. Scripts and plugins may add fields.",
+      "type": "object",
+      "properties": {
+        "ip": {"type": "string"},
+        "hostname": {"type": "string"},
+        "city": {"type": "string"},
+        "region": {"type": "string"},
+        "country": {"type": "string", "description": "ISO 3166-1 alpha-2 code"},
+        "loc": {"type": "string", "pattern": "^-?[0-9]+(\\.[0-9]+)?,-?[0-9]+(\\.[0-9]+)?$", "description": "latitude,longitude"},
+        "org": {"type": "string", "description": "AS number and name, as AS3320 Deutsche Telekom AG"},
+        "postal": {"type": "string"},
+        "timezone": {"type": "string"},
+        "source": {"type": "string", "description": "Provider that answered"},
+        "error": {"type": "string", "description": "Error answered by the provider"},
+        "bgp": {
+          "type": "object",
+          "properties": {
+            "prefix": {"type": "string"},
+            "origin": {"type": "string"},
+            "origin_name": {"type": "string"},
+            "rpki": {"type": "string"}
+          }
+        },
+        "tor": {
+          "type": "object",
+          "properties": {
+            "exit": {"type": "boolean"},
+            "nickname": {"type": "string"}
+          },
+          "required": ["exit"]
+        },
+        "reputation": {
+          "type": "object",
+          "properties": {
+            "summary": {"type": "string"},
+            "listed": {"type": "array", "items": {"type": "string"}},
+            "checked": {"type": "number"}
+          },
+          "required": ["summary", "listed", "checked"]
+        },
+        "probe": {
+          "type": "object",
+          "properties": {
+            "summary": {"type": "string"},
+            "services": {"type": "object", "additionalProperties": {"type": "string"}},
+            "os": {"type": "string"}
+          },
+          "required": ["summary", "services"]
+        },
+        "connect": {
+          "type": "object",
+          "properties": {
+            "summary": {"type": "string"},
+            "family": {"type": "string", "enum": ["IPv4", "IPv6"]},
+            "address": {"type": "string"},
+            "handshake_ms": {"type": "number"},
+            "error": {"type": "string"}
+          },
+          "required": ["summary"]
+        },
+        "ixps": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {
+              "name": {"type": "string"},
+              "kind": {"type": "string"},
+              "city": {"type": "string"},
+              "lat": {"type": "number"},
+              "lon": {"type": "number"},
+              "distance_km": {"type": "number"}
+            }
+          }
+        },
+        "ixps_text": {"type": "string"},
+        "compliance": {
+          "type": "array",
+          "items": {"enum": ["eu", "eea", "uk", "adequacy", "us_privacy_law"]}
+        },
+        "note": {
+          "description": "Case note kept with ip411 note",
+          "type": "object",
+          "properties": {
+            "text": {"type": "string"},
+            "pinned": {"type": "string", "format": "date-time"},
+            "changed": {"type": "string", "description": "What changed since the result was pinned"}
+          }
+        },
+        "provenance": {
+          "description": "Where the result came from",
+          "type": "object",
+          "properties": {
+            "provider": {"type": "string"},
+            "cache": {"enum": ["hit", "miss", "off", "replay"]},
+            "cached_at": {"type": "string", "format": "date-time"},
+            "database": {"type": "string", "description": "Offline dataset completing the result"},
+            "queried_at": {"type": "string", "format": "date-time"}
+          },
+          "required": ["provider", "cache", "queried_at"]
+        }
+      },
+      "additionalProperties": true
+    },
+    "event": {
+      "description": "Something that happened in a long-running mode. -fields may trim the result.",
+      "type": "object",
+      "properties": {
+        "type": {"enum": ["lookup", "ip_change", "new_country", "alert", "changed"]},
+        "time": {"type": "string", "format": "date-time"},
+        "ip": {"type": "string"},
+        "previous": {"type": "string"},
+        "rule": {"type": "string", "description": "Alert rule that matched"},
+        "reason": {"type": "string"},
+        "country": {"type": "string"},
+        "lat": {"type": "number", "minimum": -90, "maximum": 90},
+        "lon": {"type": "number", "minimum": -180, "maximum": 180},
+        "rtt_ns": {"type": "integer", "minimum": 0},
+        "result": {"$ref": "#/$defs/result"}
+      },
+      "required": ["type", "time"],
+      "additionalProperties": true
+    },
+    "bench": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "provider": {"type": "string"},
+          "lookups": {"type": "integer", "minimum": 0},
+          "errors": {"type": "integer", "minimum": 0},
+          "error_rate": {"type": "number", "minimum": 0, "maximum": 1},
+          "median_ms": {"type": "number"},
+          "p90_ms": {"type": "number"},
+          "completeness": {"type": "number", "minimum": 0, "maximum": 1}
+        },
+        "required": ["provider", "lookups", "errors", "error_rate", "median_ms", "p90_ms", "completeness"]
+      }
+    },
+    "region": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "candidate": {"type": "string"},
+          "median_km": {"type": "number"},
+          "mean_km": {"type": "number"},
+          "p90_km": {"type": "number"},
+          "median_rtt_ms": {"type": "number"},
+          "nearest_clients": {"type": "integer", "minimum": 0}
+        },
+        "required": ["candidate", "median_km", "mean_km", "p90_km", "median_rtt_ms", "nearest_clients"]
+      }
+    },
+    "aggregate": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "prefix": {"type": "string"},
+          "entries": {"type": "integer", "minimum": 0},
+          "located": {"type": "integer", "minimum": 0},
+          "countries": {"type": "object", "additionalProperties": {"type": "integer"}},
+          "top_org": {"type": "string"}
+        },
+        "required": ["prefix", "entries", "located", "countries"]
+      }
+    },
+    "point": {
+      "type": "object",
+      "properties": {
+        "lat": {"type": "number", "minimum": -90, "maximum": 90},
+        "lon": {"type": "number", "minimum": -180, "maximum": 180}
+      },
+      "required": ["lat", "lon"]
+    },
+    "distances": {
+      "type": "object",
+      "properties": {
+        "centroid": {"$ref": "#/$defs/point"},
+        "cluster_km": {"type": "number"},
+        "clusters": {"type": "integer", "minimum": 0},
+        "targets": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {
+              "target": {"type": "string"},
+              "lat": {"type": "number"},
+              "lon": {"type": "number"},
+              "cluster": {"type": "integer"},
+              "nearest": {"type": "string"},
+              "nearest_km": {"type": "number"},
+              "datacenter": {"type": "string"},
+              "datacenter_km": {"type": "number"}
+            },
+            "required": ["target", "lat", "lon", "cluster"]
+          }
+        },
+        "distances_km": {"type": "array", "items": {"type": "array", "items": {"type": "number"}}}
+      },
+      "required": ["centroid", "cluster_km", "clusters", "targets", "distances_km"]
+    }
+  }
+}
+`
+
+/*
+schemaDefs - Names of the definitions of the schema
+*/
+func schemaDefs() (map[string]interface{}, []string, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil, nil, err
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return schema, names, nil
+}
+
+/*
+schemaHelp - What the usage message of schema says after the synopsis, given
+the version of the schema and the names of its definitions
+*/
+const schemaHelp = `Print the JSON Schema (version %s) of the JSON outputs, or only of one of
+them: %s
+`
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	flags.Usage = func() {
+		_, names, _ := schemaDefs()
+		fmt.Fprintf(os.Stderr, "usage: %s schema [name]\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintf(os.Stderr, tr(schemaHelp), schemaVersion, strings.Join(names, ", "))
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Expected at most a name.")
+	}
+	if flags.NArg() == 0 {
+		fmt.Print(schemaJSON)
+		return nil
+	}
+
+	schema, names, err := schemaDefs()
+	if err != nil {
+		return err
+	}
+	name := flags.Arg(0)
+	if _, ok := schema["$defs"].(map[string]interface{})[name]; !ok {
+		return invalidInput("Unknown schema '%s': Expected one of %s.", name, strings.Join(names, ", "))
+	}
+	// The whole document, rooted at the definition, so that its references
+	// still resolve
+	schema["$id"] = fmt.Sprintf("%s/%s", schema["$id"], name)
+	schema["title"] = "ip411 " + name
+	schema["$ref"] = "#/$defs/" + name
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+checkSchema - The errors of value, as decoded from JSON, against def, with
+references resolved in defs. Only the keywords schema.go uses are known.
+Objects with properties must not hold any other key, even where
+additionalProperties allows it: those are for scripts, plugins and what
+ipinfo adds, and a key ip411 writes without the schema declaring it is drift.
+*/
+func checkSchema(value interface{}, def map[string]interface{}, defs map[string]interface{}, path string) []string {
+	if ref, ok := def["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		target, ok := defs[name].(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown reference %s", path, ref)}
+		}
+		return checkSchema(value, target, defs, path)
+	}
+
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	if enum, ok := def["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range enum {
+			if v == value {
+				found = true
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+	if typ, ok := def["type"].(string); ok {
+		var is bool
+		switch typ {
+		case "object":
+			_, is = value.(map[string]interface{})
+		case "array":
+			_, is = value.([]interface{})
+		case "string":
+			_, is = value.(string)
+		case "boolean":
+			_, is = value.(bool)
+		case "number":
+			_, is = value.(float64)
+		case "integer":
+			v, ok := value.(float64)
+			is = ok && v == math.Trunc(v)
+		}
+		if !is {
+			fail("%v (%T) is not of type %s", value, value, typ)
+			return errs
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := def["properties"].(map[string]interface{})
+		for _, key := range sortedKeys(v) {
+			if prop, ok := props[key].(map[string]interface{}); ok {
+				errs = append(errs, checkSchema(v[key], prop, defs, path+"."+key)...)
+			} else if props != nil {
+				fail("%s is not declared", key)
+			} else if additional, ok := def["additionalProperties"].(map[string]interface{}); ok {
+				errs = append(errs, checkSchema(v[key], additional, defs, path+"."+key)...)
+			}
+		}
+		required, _ := def["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				fail("%s is required", key)
+			}
+		}
+	case []interface{}:
+		if items, ok := def["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, checkSchema(item, items, defs, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case float64:
+		if min, ok := def["minimum"].(float64); ok && v < min {
+			fail("%g is below %g", v, min)
+		}
+		if max, ok := def["maximum"].(float64); ok && v > max {
+			fail("%g is above %g", v, max)
+		}
+	case string:
+		if pattern, ok := def["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			fail("%q does not match %s", v, pattern)
+		}
+		if def["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				fail("%q is not a date-time", v)
+			}
+		}
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/*
+validate - Fail t unless value, marshaled, is valid against the definition
+name of the schema
+*/
+func validate(t *testing.T, name string, value interface{}) {
+	t.Helper()
+	schema, _, err := schemaDefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	def, ok := defs[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no definition %s", name)
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range checkSchema(decoded, def, defs, name) {
+		t.Errorf("%s\n%s", e, out)
+	}
+}
+
+func TestSchemaMatchesOutputs(t *testing.T) {
+	answers := map[string]string{
+		"ipinfo": `{"ip": "8.8.8.8", "hostname": "dns.google", "city": "Mountain View",
+			"region": "California", "country": "US", "loc": "37.4056,-122.0775",
+			"org": "AS15169 Google LLC", "postal": "94043", "timezone": "America/Los_Angeles"}`,
+		"ip-api": `{"status": "success", "query": "8.8.8.8", "city": "Ashburn", "regionName": "Virginia",
+			"countryCode": "US", "lat": 39.03, "lon": -77.5, "as": "AS15169 Google LLC", "zip": "20149",
+			"timezone": "America/New_York"}`,
+		"ipapi": `{"ip": "8.8.8.8", "city": "Mountain View", "region": "California", "country_code": "US",
+			"latitude": 37.42, "longitude": -122.08, "asn": "AS15169", "org": "GOOGLE", "postal": "94043",
+			"timezone": "America/Los_Angeles"}`,
+		"ipwhois": `{"success": true, "ip": "8.8.8.8", "city": "Mountain View", "region": "California",
+			"country_code": "US", "latitude": 37.39, "longitude": -122.08,
+			"connection": {"asn": 15169, "org": "Google LLC"}, "postal": "94039",
+			"timezone": {"id": "America/Los_Angeles"}}`,
+		"mock": `{"ip": "8.8.8.8", "hostname": "mock.example", "city": "Mountain View", "country": "US",
+			"loc": "37.4056,-122.0775", "org": "AS15169 Google LLC"}`,
+	}
+	for _, p := range providers {
+		raw, err := parseResponse([]byte(answers[p.Name]))
+		if err != nil {
+			t.Fatalf("%s: %s", p.Name, err)
+		}
+		validate(t, "result", p.result(raw, "miss", time.Time{}))
+	}
+	for name, answer := range map[string]string{
+		"ipinfo":  `{"error": {"title": "Wrong ip", "message": "Please provide a valid IP address"}}`,
+		"ip-api":  `{"status": "fail", "message": "invalid query"}`,
+		"ipapi":   `{"error": true, "reason": "Invalid IP Address"}`,
+		"ipwhois": `{"success": false, "message": "Invalid IP address"}`,
+	} {
+		p, _ := findProvider(name)
+		raw, _ := parseResponse([]byte(answer))
+		validate(t, "result", p.result(raw, "replay", time.Now()))
+	}
+
+	raw, _ := parseResponse([]byte(answers["ipinfo"]))
+	e := NewEvent(EventChanged, providers[0].result(raw, "hit", time.Now()))
+	e.Previous, e.Rule, e.Reason, e.RTT = "8.8.4.4", "us", "moved", 42*time.Millisecond
+	validate(t, "event", e)
+
+	validate(t, "bench", []BenchResult{{Provider: "ipinfo", Lookups: 10, Errors: 1,
+		ErrorRate: 0.1, MedianMs: 41.5, P90Ms: 80, Completeness: 0.9}})
+	validate(t, "region", []RegionScore{{Candidate: "eu-west", MedianKm: 420.5,
+		MeanKm: 600, P90Km: 1200, MedianRTT: 12.3, Nearest: 3}})
+	validate(t, "aggregate", []AggregateRow{{Prefix: "8.8.8.0/24", Entries: 3, Located: 2,
+		Countries: map[string]int{"US": 2}, TopOrg: "AS15169 Google LLC"}})
+
+	targets := []LocatedTarget{
+		{Target: "8.8.8.8", Point: Point{Lat: 37.4, Lon: -122.1}},
+		{Target: "1.1.1.1", Point: Point{Lat: -33.5, Lon: 143.2}},
+	}
+	dcs := []Datacenter{{Name: "us-west", Point: Point{Lat: 45.6, Lon: -121.2}}}
+	validate(t, "distances", NewDistanceReport(targets, dcs, 500))
+}
+
+func TestSchemaCatchesDrift(t *testing.T) {
+	schema, _, err := schemaDefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	event := defs["event"].(map[string]interface{})
+	for name, line := range map[string]string{
+		"renamed":  `{"type": "lookup", "time": "2024-01-01T00:00:00Z", "rtt": 42}`,
+		"type":     `{"type": "lookup", "time": "2024-01-01T00:00:00Z", "rtt_ns": "42ms"}`,
+		"missing":  `{"type": "lookup"}`,
+		"enum":     `{"type": "lookedup", "time": "2024-01-01T00:00:00Z"}`,
+		"range":    `{"type": "lookup", "time": "2024-01-01T00:00:00Z", "lat": 91}`,
+		"nested":   `{"type": "lookup", "time": "2024-01-01T00:00:00Z", "result": {"loc": "north"}}`,
+		"datetime": `{"type": "lookup", "time": "yesterday"}`,
+	} {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if errs := checkSchema(decoded, event, defs, "event"); len(errs) == 0 {
+			t.Errorf("%s: %s passed the schema", name, line)
+		}
+	}
+}
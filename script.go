@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+/*
+Script - Post-processing hook applied to every lookup result before it is
+displayed or published. A script is a Starlark file (a dialect of Python)
+defining process, called with each result as a dict that it may change:
+
+	def process(result):
+	    # Drop results nobody cares about
+	    if result.get("org", "").startswith("AS15169 "):
+	        return False
+	    # Add fields
+	    if result.get("country") in ["AT", "DE", "FR"]:
+	        result["continent"] = "EU"
+	    # Rewrite the map label (defaults to "X")
+	    result["label"] = "%s, %s" % (result.get("city"), result.get("country"))
+
+Returning False drops the result, anything else (None included) keeps it
+with the changes made to the dict. The fields of the result are JSON values,
+their numbers floats (ints set by the script included).
+*/
+type Script struct {
+	path    string
+	process starlark.Callable
+}
+
+// Steps a script may take per result, so that a loop never ends the lookups
+const scriptMaxSteps = 1000000
+
+/*
+LoadScript - Read and run the script at path, which must define process. An
+empty path gives a nil *Script, which leaves results untouched.
+*/
+func LoadScript(path string) (*Script, error) {
+	if path == "" {
+		return nil, nil
+	}
+	thread := &starlark.Thread{Name: path}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{Set: true, While: true,
+		TopLevelControl: true, GlobalReassign: true}, thread, path, nil, nil)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	process, ok := globals["process"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s: Expected a function process(result)", path)
+	}
+	globals.Freeze()
+	return &Script{path: path, process: process}, nil
+}
+
+/*
+scriptError - err with the Starlark backtrace of an evaluation error
+*/
+func scriptError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
+
+/*
+Apply - Run the script on res, modifying it in place. Returns false if the
+result was dropped.
+*/
+func (s *Script) Apply(res IPInfoResult) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	dict, err := toStarlark(map[string]interface{}(res))
+	if err != nil {
+		return true, fmt.Errorf("Script %s: %s", s.path, err)
+	}
+	thread := &starlark.Thread{Name: s.path}
+	thread.SetMaxExecutionSteps(scriptMaxSteps)
+	ret, err := starlark.Call(thread, s.process, starlark.Tuple{dict}, nil)
+	if err != nil {
+		return true, fmt.Errorf("Script %s", scriptError(err))
+	}
+	if ret == starlark.False {
+		return false, nil
+	}
+
+	fields, err := fromStarlark(dict)
+	if err != nil {
+		return true, fmt.Errorf("Script %s: %s", s.path, err)
+	}
+	for key := range res {
+		delete(res, key)
+	}
+	for key, val := range fields.(map[string]interface{}) {
+		res[key] = val
+	}
+	return true, nil
+}
+
+/*
+toStarlark - The Starlark value of a JSON value
+*/
+func toStarlark(val interface{}) (starlark.Value, error) {
+	switch val := val.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case float64:
+		return starlark.Float(val), nil
+	case string:
+		return starlark.String(val), nil
+	case []string:
+		list := make([]starlark.Value, len(val))
+		for i, s := range val {
+			list[i] = starlark.String(s)
+		}
+		return starlark.NewList(list), nil
+	case []interface{}:
+		list := make([]starlark.Value, len(val))
+		for i, item := range val {
+			v, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return starlark.NewList(list), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(val))
+		for _, key := range keys {
+			v, err := toStarlark(val[key])
+			if err != nil {
+				return nil, err
+			}
+			dict.SetKey(starlark.String(key), v)
+		}
+		return dict, nil
+	}
+	// Fields set by ip411 itself, as the JSON they are output as
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return toStarlark(decoded)
+}
+
+/*
+fromStarlark - The JSON value of a Starlark value
+*/
+func fromStarlark(val starlark.Value) (interface{}, error) {
+	switch val := val.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.Int:
+		f := float64(val.Float())
+		if math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%s is too big for JSON", val)
+		}
+		return f, nil
+	case starlark.Float:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return nil, fmt.Errorf("%s is not a JSON number", val)
+		}
+		return float64(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Indexable: // lists and tuples
+		list := make([]interface{}, val.Len())
+		for i := range list {
+			v, err := fromStarlark(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case *starlark.Dict:
+		obj := make(map[string]interface{}, val.Len())
+		for _, item := range val.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("Key %s is not a string", item[0])
+			}
+			v, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			obj[string(key)] = v
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("Cannot set a %s in a result", val.Type())
+}
+
+/*
+markerLabel - Text plotted for res on the map: its label field if a script
+set one, "X" otherwise
+*/
+func markerLabel(res IPInfoResult) string {
+	if label, ok := res["label"].(string); ok && label != "" {
+		return label
+	}
+	return "X"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScript(t *testing.T, source string) string {
+	path := filepath.Join(t.TempDir(), "script.star")
+	if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScriptApply(t *testing.T) {
+	script, err := LoadScript(writeScript(t, `
+EU = ["AT", "DE", "FR"]
+
+def process(result):
+    if result.get("org", "").startswith("AS15169 "):
+        return False
+    if result.get("country") in EU:
+        result["continent"] = "EU"
+    result["label"] = "%s, %s" % (result["city"], result["country"])
+    result["asn"]["rank"] = 1
+    result.pop("postal", None)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := IPInfoResult{"ip": "1.1.1.1", "city": "Vienna", "country": "AT", "postal": "1010",
+		"asn": map[string]interface{}{"asn": "AS13335"}, "compliance": []interface{}{"eu"}}
+	kept, err := script.Apply(res)
+	if err != nil || !kept {
+		t.Fatalf("Apply = %v, %v", kept, err)
+	}
+	if res["continent"] != "EU" || markerLabel(res) != "Vienna, AT" {
+		t.Errorf("fields not set: %v", res)
+	}
+	if _, ok := res["postal"]; ok {
+		t.Errorf("postal not removed: %v", res)
+	}
+	if rank := res["asn"].(map[string]interface{})["rank"]; rank != 1.0 {
+		t.Errorf("asn.rank = %#v, expected 1.0", rank)
+	}
+	if list, _ := res["compliance"].([]interface{}); len(list) != 1 || list[0] != "eu" {
+		t.Errorf("compliance = %#v", res["compliance"])
+	}
+
+	dropped := IPInfoResult{"ip": "8.8.8.8", "org": "AS15169 Google LLC", "city": "x", "country": "US"}
+	if kept, err := script.Apply(dropped); err != nil || kept {
+		t.Fatalf("Apply = %v, %v, expected the result dropped", kept, err)
+	}
+	if _, ok := dropped["label"]; ok {
+		t.Errorf("dropped result changed: %v", dropped)
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	if script, err := LoadScript(""); script != nil || err != nil {
+		t.Fatalf("LoadScript(\"\") = %v, %v", script, err)
+	}
+	var none *Script
+	if kept, err := none.Apply(IPInfoResult{}); !kept || err != nil {
+		t.Fatalf("nil script: %v, %v", kept, err)
+	}
+
+	for _, c := range []struct{ name, source, load, apply string }{
+		{name: "syntax", source: "def process(result)\n", load: "got newline"},
+		{name: "no process", source: "x = 1\n", load: "Expected a function process(result)"},
+		{name: "failing", source: "fail('at load')\n", load: "at load"},
+		{name: "key error", source: "def process(result):\n    result['missing']\n",
+			apply: "key \"missing\" not in dict"},
+		{name: "endless", source: "def process(result):\n    while True:\n        pass\n",
+			apply: "too many steps"},
+		{name: "unset value", source: "def process(result):\n    result['f'] = len\n",
+			apply: "Cannot set a builtin_function_or_method"},
+		{name: "nan", source: "def process(result):\n    result['f'] = float('nan')\n",
+			apply: "not a JSON number"},
+	} {
+		script, err := LoadScript(writeScript(t, c.source))
+		if c.load != "" {
+			if err == nil || !strings.Contains(err.Error(), c.load) {
+				t.Errorf("%s: LoadScript error %v, expected %q", c.name, err, c.load)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		res := IPInfoResult{"ip": "1.1.1.1"}
+		_, err = script.Apply(res)
+		if err == nil || !strings.Contains(err.Error(), c.apply) {
+			t.Errorf("%s: Apply error %v, expected %q", c.name, err, c.apply)
+		}
+		if len(res) != 1 {
+			t.Errorf("%s: result changed by a failed script: %v", c.name, res)
+		}
+	}
+}
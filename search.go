@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Search finds the results of the multi-IP modes whose IP Address, hostname,
+org or city contain a pattern (ignoring case). The current match is plotted
+as '@', centered when the map is zoomed, and described in the info pane:
+
+	/      search
+	n/N    next/previous match
+	enter  run the search, an empty pattern clears it
+	esc    close the prompt
+*/
+
+var searchFields = []string{"ip", "hostname", "org", "city"}
+
+/*
+matchSearch - Whether a field of res contains pattern, which must be lower
+case
+*/
+func matchSearch(res IPInfoResult, pattern string) bool {
+	for _, field := range searchFields {
+		if strings.Contains(strings.ToLower(fieldValue(res, field)), pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+currentMatch - Target of the current search match, "" if none. Must be
+called with b.mu held.
+*/
+func (b *Batch) currentMatch() string {
+	if len(b.matches) == 0 {
+		return ""
+	}
+	return b.matches[b.matchIdx]
+}
+
+/*
+centerOnMatch - Move a zoomed map over the current match
+*/
+func (b *Batch) centerOnMatch() {
+	b.mu.Lock()
+	res, ok := b.results[b.currentMatch()]
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+	if lon, lat, err := res.GetLonLat(); err == nil {
+		centerOn(lon, lat)
+	}
+}
+
+func (b *Batch) openSearch(g *Gui, v *View) error {
+	b.mu.Lock()
+	text := b.search
+	b.mu.Unlock()
+	return inputDialog(g, "Search IP, hostname, org, city (enter, esc)", text, nil, b.runSearch)
+}
+
+func (b *Batch) runSearch(g *Gui, pattern string) error {
+	lower := strings.ToLower(pattern)
+
+	b.mu.Lock()
+	b.search = pattern
+	b.matches = nil
+	b.matchIdx = 0
+	if pattern != "" {
+		for _, target := range b.targets {
+			res, ok := b.results[target]
+			if ok && b.matchFilter(res) && matchSearch(res, lower) {
+				b.matches = append(b.matches, target)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	b.centerOnMatch()
+	b.refresh(g)
+	return nil
+}
+
+func (b *Batch) nextMatch(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		b.mu.Lock()
+		if n := len(b.matches); n > 0 {
+			b.matchIdx = (b.matchIdx + delta + n) % n
+		}
+		b.mu.Unlock()
+
+		b.centerOnMatch()
+		b.refresh(g)
+		return nil
+	}
+}
+
+/*
+searchSummary - Line of the info pane describing the current match. Must be
+called with b.mu held.
+*/
+func (b *Batch) searchSummary() string {
+	if len(b.matches) == 0 {
+		return fmt.Sprintf("/%s: no match", b.search)
+	}
+	target := b.currentMatch()
+	res := b.results[target]
+	return fmt.Sprintf("/%s: match %d/%d @ %s  %s, %s  %s", b.search,
+		b.matchIdx+1, len(b.matches), target, fieldValue(res, "city"),
+		fieldValue(res, "country"), fieldValue(res, "org"))
+}
+
+/*
+setSearchKeybindings - Register the keys of the search prompt
+*/
+func (b *Batch) setSearchKeybindings(g *Gui) error {
+	bindings := []struct {
+		view    string
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{"", '/', b.openSearch},
+		{"", 'n', b.nextMatch(1)},
+		{"", 'N', b.nextMatch(-1)},
+	}
+	for _, binding := range bindings {
+		if err := g.SetKeybinding(binding.view, binding.key, ModNone,
+			binding.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
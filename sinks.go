@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+/*
+eventFields - Flatten e into a JSON object. Without fields, the whole event is
+returned. Otherwise only the named fields are kept, looked up first among the
+event's own fields (type, time, ip, previous, country, lat, lon) and then in the
+lookup result.
+*/
+func eventFields(e Event, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return all, nil
+	}
+
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if field == "result" {
+			continue
+		}
+		if val, ok := all[field]; ok {
+			selected[field] = val
+		} else if val, ok := e.Result[field]; ok {
+			selected[field] = val
+		}
+	}
+	return selected, nil
+}
+
+/*
+parseFieldList - Split a comma separated list of field names
+*/
+func parseFieldList(list string) []string {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+/*
+JSONLSink - Writes one JSON object per event and line to a file or a UNIX
+socket
+*/
+type JSONLSink struct {
+	mu     sync.Mutex
+	w      io.WriteCloser
+	fields []string
+}
+
+/*
+NewJSONLSink - Open dest for writing JSON lines. dest is either a file path,
+appended to, or unix:/path/to/socket.
+*/
+func NewJSONLSink(dest string, fields []string) (*JSONLSink, error) {
+	var w io.WriteCloser
+	var err error
+	if strings.HasPrefix(dest, "unix:") {
+		path := strings.TrimPrefix(strings.TrimPrefix(dest, "unix:"), "//")
+		w, err = net.Dial("unix", path)
+		if err != nil {
+			w, err = net.Dial("unixgram", path)
+		}
+	} else {
+		w, err = os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLSink{w: w, fields: fields}, nil
+}
+
+/*
+NewStdoutSink - Write JSON lines to stdout, for -format jsonl. Every event is
+written at once, so that readers of the pipe get it without delay.
+*/
+func NewStdoutSink(fields []string) *JSONLSink {
+	return &JSONLSink{w: stdout{}, fields: fields}
+}
+
+// stdout - os.Stdout, left open when the sink is closed
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdout) Close() error                { return nil }
+
+/*
+Publish - Write e as a single JSON line
+*/
+func (s *JSONLSink) Publish(e Event) error {
+	obj, err := eventFields(e, s.fields)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+/*
+Close - Close the file or socket
+*/
+func (s *JSONLSink) Close() error {
+	return s.w.Close()
+}
+
+/*
+syslogTarget - Split a -syslog flag value into the network and address
+understood by log/syslog. "local" means the local syslog daemon.
+*/
+func syslogTarget(dest string) (network, addr string, err error) {
+	if dest == "local" {
+		return "", "", nil
+	}
+	parts := strings.SplitN(dest, "://", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Invalid syslog destination '%s': use local, "+
+			"udp://host:port or tcp://host:port", dest)
+	}
+	switch parts[0] {
+	case "udp", "tcp", "unix", "unixgram":
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("Unsupported syslog network '%s'", parts[0])
+}
@@ -0,0 +1,133 @@
+package main
+
+import "fmt"
+
+/*
+Markers of addresses landing on the same cell of the map would overdraw each
+other, so drawMap stacks them into one marker: "*", a single cell so that
+stacks next to each other stay apart, unless one of them is the current
+address (@), flashing (!) or waiting for a retry (?), which keeps its label
+in that order. With the crosshair (x) on a stacked marker, a panel lists the
+addresses it stands for.
+*/
+
+// Targets of the stacked marker under the crosshair, as of the last drawMap,
+// only touched from the gui goroutine
+var hoveredStack []string
+
+/*
+markerCell - The cell of the canvas a marker at lon,lat is drawn at
+*/
+func (mc *MapCanvas) markerCell(lon, lat float64) cellPos {
+	pos, _ := cellOf(int(mc.GetX(lon)), int(mc.GetY(lat)))
+	return pos
+}
+
+// Labels of the stacked markers and of the lookups waiting for a retry
+const (
+	stackLabel   = "*"
+	pendingLabel = "?"
+)
+
+/*
+stackMarkers - The markers with the ones of addresses sharing a cell merged,
+in the order of the first marker of each cell. Markers of no address (the
+exchanges of -ixp-markers...) are kept as they are.
+*/
+func stackMarkers(mc *MapCanvas, markers []Marker) []Marker {
+	cells := make(map[cellPos]int, len(markers))
+	var stacked []Marker
+	for _, m := range markers {
+		if len(m.Targets) == 0 {
+			stacked = append(stacked, m)
+			continue
+		}
+		pos := mc.markerCell(m.Lon, m.Lat)
+		i, ok := cells[pos]
+		if !ok {
+			cells[pos] = len(stacked)
+			stacked = append(stacked, m)
+			continue
+		}
+		s := &stacked[i]
+		s.Targets = append(append([]string(nil), s.Targets...), m.Targets...)
+		s.Weight = maxWeight(s.Weight, m.Weight)
+		switch {
+		case s.Text == "!":
+		case m.Text == "!" || m.Text == "@":
+			s.Text, s.Color = m.Text, m.Color
+		case s.Text == "@":
+		case m.Text == pendingLabel:
+			s.Text, s.Color = m.Text, m.Color
+		case s.Text == pendingLabel:
+		default:
+			s.Text = stackLabel
+			if s.Color == 0 {
+				s.Color = m.Color
+			}
+		}
+	}
+	return stacked
+}
+
+func maxWeight(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+/*
+stackAt - The targets of the marker at the cell of lon,lat if it stands for
+more than one
+*/
+func (mc *MapCanvas) stackAt(markers []Marker, lon, lat float64) []string {
+	pos := mc.markerCell(lon, lat)
+	for _, m := range markers {
+		if len(m.Targets) > 1 && mc.markerCell(m.Lon, m.Lat) == pos {
+			return m.Targets
+		}
+	}
+	return nil
+}
+
+/*
+showStack - Show the addresses of the stacked marker under the crosshair in
+a panel, or close it if there is none
+*/
+func showStack(g *Gui) error {
+	if len(hoveredStack) == 0 {
+		if err := g.DeleteView("stack"); err != nil && err != ErrUnknownView {
+			return err
+		}
+		return nil
+	}
+	_, mapY0, _, mapY1, err := g.ViewPosition("map")
+	if err != nil {
+		return err
+	}
+	maxX, _ := g.Size()
+	width := 24
+	for _, target := range hoveredStack {
+		if len(target)+3 > width {
+			width = len(target) + 3
+		}
+	}
+	if width > maxX {
+		width = maxX
+	}
+	height := len(hoveredStack) + 1
+	if mapY0+1+height > mapY1-1 {
+		height = mapY1 - 2 - mapY0
+	}
+	view, err := g.SetView("stack", 0, mapY0+1, width-1, mapY0+1+height)
+	if err != nil && err != ErrUnknownView {
+		return err
+	}
+	view.Title = fmt.Sprintf(tr("%d addresses here"), len(hoveredStack))
+	view.Clear()
+	for _, target := range hoveredStack {
+		fmt.Fprintln(view, target)
+	}
+	return nil
+}
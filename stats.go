@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+The statistics panel of the multi-IP modes (batch, logs, monitor) counts the
+located results passing the filter and in the time window by country, ASN,
+org or subdivision of the country (state, province..., see subdivisions.go):
+
+	s         show or hide the panel
+	tab       next dimension (country, ASN, org, subdivision)
+	o         sort by count or by name
+	up/down   select a row
+	enter     only plot the results of the selected row (again to clear)
+	esc       close the panel
+*/
+
+type statDimension struct {
+	name string
+	key  func(res IPInfoResult) string
+}
+
+var statDimensions = []statDimension{
+	{"Country", func(res IPInfoResult) string { return fieldValue(res, "country") }},
+	{"ASN", func(res IPInfoResult) string { return asnLabel(asnFromOrg(fieldValue(res, "org"))) }},
+	{"Org", func(res IPInfoResult) string { return fieldValue(res, "org") }},
+	{"Subdivision", subdivisionKey},
+}
+
+/*
+StatRow - Number of results sharing a key
+*/
+type StatRow struct {
+	Key     string
+	Count   int
+	Percent float64
+}
+
+/*
+statSelection - Restricts the plotted results to one row of the panel
+*/
+type statSelection struct {
+	dim int
+	key string
+}
+
+func (sel *statSelection) match(res IPInfoResult) bool {
+	return sel == nil || statKey(statDimensions[sel.dim].key, res) == sel.key
+}
+
+func statKey(key func(IPInfoResult) string, res IPInfoResult) string {
+	if k := key(res); k != "" {
+		return k
+	}
+	return "(unknown)"
+}
+
+/*
+countBy - Rows counting results by key, sorted by descending count (or by
+key if byName)
+*/
+func countBy(results []IPInfoResult, key func(IPInfoResult) string, byName bool) []StatRow {
+	counts := make(map[string]int)
+	for _, res := range results {
+		counts[statKey(key, res)]++
+	}
+
+	rows := make([]StatRow, 0, len(counts))
+	for k, n := range counts {
+		rows = append(rows, StatRow{Key: k, Count: n,
+			Percent: 100 * float64(n) / float64(len(results))})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if byName || rows[i].Count == rows[j].Count {
+			return rows[i].Key < rows[j].Key
+		}
+		return rows[i].Count > rows[j].Count
+	})
+	return rows
+}
+
+/*
+statRows - Rows of the panel for the current dimension and sort order
+*/
+func (b *Batch) statRows() []StatRow {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen := b.windowTraffic()
+	results := make([]IPInfoResult, 0, len(b.results))
+	for target, res := range b.results {
+		if b.matchFilter(res) && b.inWindow(target, seen) {
+			results = append(results, res)
+		}
+	}
+	return countBy(results, statDimensions[b.statsDim].key, b.statsByName)
+}
+
+/*
+renderStats - Fill the statistics view. Must be called from the gui
+goroutine with mu held.
+*/
+func (b *Batch) renderStats(view *View) []StatRow {
+	rows := b.statRows()
+
+	b.mu.Lock()
+	dim := statDimensions[b.statsDim]
+	sel := b.selection
+	order := "count"
+	if b.statsByName {
+		order = "name"
+	}
+	b.mu.Unlock()
+
+	view.Title = fmt.Sprintf(tr("%s by %s (tab, o, enter)"), dim.name, order)
+	view.Clear()
+	for _, row := range rows {
+		mark := " "
+		if sel != nil && statDimensions[sel.dim].name == dim.name && sel.key == row.Key {
+			mark = "*"
+		}
+		fmt.Fprintf(view, "%s%-22.22s %6d %5.1f%%\n", mark, row.Key, row.Count, row.Percent)
+	}
+	return rows
+}
+
+func (b *Batch) statsLayout(g *Gui) (*View, error) {
+	maxX, _ := g.Size()
+	_, mapY0, _, mapY1, err := g.ViewPosition("map")
+	if err != nil {
+		return nil, err
+	}
+	width := 40
+	if width > maxX {
+		width = maxX
+	}
+	view, err := g.SetView("stats", maxX-width, mapY0+1, maxX-1, mapY1-1)
+	if err != nil && err != ErrUnknownView {
+		return nil, err
+	}
+	view.Highlight = true
+	view.SelBgColor = ColorGreen
+	view.SelFgColor = ColorBlack
+	return view, nil
+}
+
+func (b *Batch) toggleStats(g *Gui, v *View) error {
+	if _, err := g.View("stats"); err == nil {
+		return b.closeStats(g, v)
+	}
+	view, err := b.statsLayout(g)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	rows := b.renderStats(view)
+	mu.Unlock()
+	selectLine(view, 0, len(rows))
+	return g.SetCurrentView("stats")
+}
+
+func (b *Batch) closeStats(g *Gui, v *View) error {
+	if err := g.DeleteView("stats"); err != nil {
+		return err
+	}
+	return g.SetCurrentView("map")
+}
+
+func (b *Batch) statsMove(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		selectLine(v, selectedLine(v)+delta, len(b.statRows()))
+		return nil
+	}
+}
+
+func (b *Batch) statsNextDimension(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.statsDim = (b.statsDim + 1) % len(statDimensions)
+	b.mu.Unlock()
+
+	mu.Lock()
+	rows := b.renderStats(v)
+	mu.Unlock()
+	selectLine(v, 0, len(rows))
+	return nil
+}
+
+func (b *Batch) statsToggleOrder(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.statsByName = !b.statsByName
+	b.mu.Unlock()
+
+	mu.Lock()
+	rows := b.renderStats(v)
+	mu.Unlock()
+	selectLine(v, 0, len(rows))
+	return nil
+}
+
+func (b *Batch) statsSelect(g *Gui, v *View) error {
+	rows := b.statRows()
+	i := selectedLine(v)
+	if i >= len(rows) {
+		return nil
+	}
+
+	b.mu.Lock()
+	sel := &statSelection{dim: b.statsDim, key: rows[i].Key}
+	if b.selection != nil && *b.selection == *sel {
+		sel = nil
+	}
+	b.selection = sel
+	b.mu.Unlock()
+
+	mu.Lock()
+	b.renderStats(v)
+	mu.Unlock()
+
+	mapView, err := g.View("map")
+	if err != nil {
+		return err
+	}
+	drawMap(mapView, b.markers())
+	return nil
+}
+
+/*
+setStatsKeybindings - Register the keys of the statistics panel
+*/
+func (b *Batch) setStatsKeybindings(g *Gui) error {
+	bindings := []struct {
+		view    string
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{"", 's', b.toggleStats},
+		{"stats", KeyArrowUp, b.statsMove(-1)},
+		{"stats", KeyArrowDown, b.statsMove(1)},
+		{"stats", 'k', b.statsMove(-1)},
+		{"stats", 'j', b.statsMove(1)},
+		{"stats", KeyTab, b.statsNextDimension},
+		{"stats", 'o', b.statsToggleOrder},
+		{"stats", KeyEnter, b.statsSelect},
+		{"stats", KeyEsc, b.closeStats},
+	}
+	for _, binding := range bindings {
+		if err := g.SetKeybinding(binding.view, binding.key, ModNone,
+			binding.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
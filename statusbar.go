@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+/*
+The bottom line of the screen is a status bar that stays put, whatever the
+messages above it say: the mode, the providers lookups are made with, the
+cache and how many lookups it answered, the quota left and, in the live
+modes, how full their caps are. Over the bottom
+right corner of the map, the legend (<L> to expand or collapse it) tells
+what the markers, their colors and discs stand for in the current mode.
+*/
+
+// The mode shown in the status bar, set by main
+var guiMode = "single"
+
+// Lookups the cache answered and missed, see providers.go
+var cacheHits, cacheMisses uint64
+
+/*
+countCacheLookup - Count a lookup of the cache for the status bar
+*/
+func countCacheLookup(hit bool) {
+	if hit {
+		atomic.AddUint64(&cacheHits, 1)
+	} else {
+		atomic.AddUint64(&cacheMisses, 1)
+	}
+}
+
+/*
+cacheStatus - The cache backend and its hits and misses, without the
+password of a Redis URL
+*/
+func cacheStatus() string {
+	if lookupCache.cache == nil {
+		return tr("Cache: off")
+	}
+	backend := lookupCache.raw
+	if backend != "memory" {
+		backend = "redis"
+	}
+	return fmt.Sprintf(tr("Cache: %s %d/%d hits"), backend,
+		atomic.LoadUint64(&cacheHits),
+		atomic.LoadUint64(&cacheHits)+atomic.LoadUint64(&cacheMisses))
+}
+
+// How full the caps of the live modes are (see limits.go), set by them.
+// Only called from the gui goroutine.
+var memorySource func() string
+
+/*
+statusBarText - The line of the status bar
+*/
+func statusBarText() string {
+	parts := []string{
+		guiMode,
+		fmt.Sprintf(tr("Provider: %s"), chain.Names()),
+		cacheStatus(),
+		chain.QuotaString(),
+	}
+	if memorySource != nil {
+		parts = append(parts, memorySource())
+	}
+	return strings.Join(append(parts, tr("<L> legend")), " | ")
+}
+
+// Colors of the lines of the legend, not taking room
+var ansiColor = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Whether the legend is expanded, only touched from the gui goroutine
+var legendOpen bool
+
+// Lines of the legend specific to the mode, set by the modes with more
+// markers than the target's. Only called from the gui goroutine.
+var legendSource func() []string
+
+/*
+legendLines - What the markers of the map stand for
+*/
+func legendLines() []string {
+	lines := []string{tr("X   a located address, or its label")}
+	if *ixpMarkers {
+		lines = append(lines, tr("+   an exchange near the target"))
+	}
+	lines = append(lines, tr("+   the crosshair <x>"))
+	if legendSource != nil {
+		lines = append(lines, legendSource()...)
+	}
+	var shown []string
+	for _, layer := range layers {
+		if layer.Enabled {
+			shown = append(shown, fmt.Sprintf("%s <%c>", layer.Name, layer.Key))
+		}
+	}
+	if len(shown) > 0 {
+		lines = append(lines, fmt.Sprintf(tr("Layers: %s"), strings.Join(shown, ", ")))
+	}
+	return lines
+}
+
+/*
+legendLayout - Place the status bar along the bottom line, and the legend
+in the bottom right corner of the map, expanded or as a tab. Called by
+layout.
+*/
+func legendLayout(g *Gui, mapBottom int) error {
+	maxX, maxY := g.Size()
+	bar, err := g.SetView("statusbar", -1, maxY-2, maxX, maxY)
+	if err != nil && err != ErrUnknownView {
+		return err
+	}
+	bar.Frame = false
+	bar.Clear()
+	fmt.Fprint(bar, statusBarText())
+
+	title := tr("Legend <L>")
+	var lines []string
+	width := len([]rune(title)) + 4
+	if legendOpen {
+		lines = legendLines()
+		for _, line := range lines {
+			if n := len([]rune(ansiColor.ReplaceAllString(line, ""))) + 2; n > width {
+				width = n
+			}
+		}
+	}
+	if width > maxX-1 {
+		width = maxX - 1
+	}
+	height := len(lines) + 1
+	if width < 2 || height > mapBottom-1 {
+		// No room on the map
+		if err := g.DeleteView("legend"); err != nil && err != ErrUnknownView {
+			return err
+		}
+		return nil
+	}
+	view, err := g.SetView("legend", maxX-1-width, mapBottom-1-height, maxX-1, mapBottom-1)
+	if err != nil && err != ErrUnknownView {
+		return err
+	}
+	view.Title = title
+	view.Clear()
+	for _, line := range lines {
+		fmt.Fprintln(view, line)
+	}
+	return nil
+}
+
+func toggleLegend(g *Gui, v *View) error {
+	legendOpen = !legendOpen
+	return nil
+}
+
+func setLegendKeybindings(g *Gui) error {
+	return g.SetKeybinding("", 'L', ModNone, toggleLegend)
+}
+
+/*
+legendLines - What the markers of the batch stand for, beyond the target's
+*/
+func (b *Batch) legendLines() []string {
+	lines := []string{
+		tr("@   the current address: search match, top talker, playback"),
+		tr("!   an address flashing on an alert"),
+		tr("*   addresses sharing a cell, <x> on it lists them"),
+		tr("?   a lookup to retry, shown at an address of its network (approximate)"),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.showTalkers {
+		lines = append(lines, tr("1-9 the top talkers <T>, + the ones after"))
+	}
+	if legend := b.weightLegend(); legend != "" {
+		lines = append(lines, tr("Discs grow with the metric <m>"), legend)
+	}
+	if legend := b.choroplethLegend(); legend != "" {
+		lines = append(lines, legend)
+	}
+	names := make([]string, 0, len(b.groupColor))
+	for name := range b.groupColor {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("\x1b[%dmX\x1b[0m   %s", b.groupColor[name], name))
+	}
+	return lines
+}
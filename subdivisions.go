@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/*
+First-level subdivisions of the countries (states, provinces, regions...),
+from the Natural Earth 1:10m admin 1 dataset (public domain) downloaded by
+"ip411 db update" and stored simplified. When the map is zoomed into a
+country its subdivisions are drawn as dotted lines, and the statistics panel
+counts the results by subdivision (see stats.go).
+*/
+
+const subdivisionsURL = "https://raw.githubusercontent.com/nvkelso/natural-earth-vector/master/geojson/ne_10m_admin_1_states_provinces.geojson"
+
+// Zoom from which the subdivisions of the country at the center are drawn
+const subdivisionZoom = 4
+
+// Points of the borders closer than this to the previous one, in degrees,
+// are dropped when storing them
+const subdivisionTolerance = 0.02
+
+/*
+Subdivision - A first-level subdivision of a country
+*/
+type Subdivision struct {
+	Country string // ISO 3166-1 alpha-2
+	Code    string // ISO 3166-2 (US-CA)
+	Name    string
+	Borders
+}
+
+/*
+subdivisionRecord - A subdivision as stored in the cache directory
+*/
+type subdivisionRecord struct {
+	Country string         `json:"country"`
+	Code    string         `json:"code"`
+	Name    string         `json:"name"`
+	Rings   [][][2]float64 `json:"rings"`
+}
+
+func subdivisionsPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "subdivisions.json")
+}
+
+/*
+simplifyRing - The ring without the points closer than tolerance to the
+last one kept, rounded to a thousandth of a degree. nil if too little is
+left of it to enclose anything.
+*/
+func simplifyRing(ring [][2]float64, tolerance float64) [][2]float64 {
+	round := func(v float64) float64 {
+		return math.Round(v*1000) / 1000
+	}
+	var kept [][2]float64
+	for i, p := range ring {
+		if n := len(kept); n > 0 && i < len(ring)-1 &&
+			math.Hypot(p[0]-kept[n-1][0], p[1]-kept[n-1][1]) < tolerance {
+			continue
+		}
+		kept = append(kept, [2]float64{round(p[0]), round(p[1])})
+	}
+	if len(kept) < 4 {
+		return nil
+	}
+	return kept
+}
+
+/*
+convertSubdivisions - The subdivisions of a Natural Earth admin 1 GeoJSON
+feature collection, simplified into records
+*/
+func convertSubdivisions(data []byte) ([]subdivisionRecord, error) {
+	var collection geoJSONFeatures
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, err
+	}
+
+	var records []subdivisionRecord
+	for _, feature := range collection.Features {
+		record := subdivisionRecord{
+			Country: toString(feature.Properties["iso_a2"]),
+			Code:    toString(feature.Properties["iso_3166_2"]),
+			Name:    toString(feature.Properties["name"]),
+		}
+		if record.Country == "" || record.Country == "-99" || record.Name == "" {
+			continue
+		}
+		borders, ok, err := geoJSONBorders(feature.Geometry.Type, feature.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid borders of %s: %s", record.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		for _, ring := range borders.Rings {
+			coords := make([][2]float64, len(ring))
+			for i, p := range ring {
+				coords[i] = [2]float64{p.Lon, p.Lat}
+			}
+			if coords = simplifyRing(coords, subdivisionTolerance); coords != nil {
+				record.Rings = append(record.Rings, coords)
+			}
+		}
+		if len(record.Rings) > 0 {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+var (
+	subdivisionsOnce sync.Once
+	subdivisions     []Subdivision
+	subdivisionsErr  error
+
+	subdivisionsMu    sync.Mutex
+	subdivisionByLoc  = make(map[Point]*Subdivision) // of the located results
+	subdivisionsShown = make(map[string][]*Subdivision)
+)
+
+/*
+loadSubdivisions - The subdivisions downloaded by "ip411 db update", read
+once
+*/
+func loadSubdivisions() ([]Subdivision, error) {
+	subdivisionsOnce.Do(func() {
+		path := subdivisionsPath()
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			subdivisionsErr = errors.New(tr("No subdivisions: run ip411 db update"))
+			return
+		}
+		if subdivisions, err = readSubdivisions(data); err != nil {
+			subdivisionsErr = fmt.Errorf("%s: %s", path, err)
+		}
+	})
+	return subdivisions, subdivisionsErr
+}
+
+/*
+readSubdivisions - The subdivisions of the records saved by db update
+*/
+func readSubdivisions(data []byte) ([]Subdivision, error) {
+	var records []subdivisionRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	var subs []Subdivision
+	for _, record := range records {
+		sub := Subdivision{Country: record.Country, Code: record.Code, Name: record.Name}
+		for _, ring := range record.Rings {
+			if err := checkRing(ring); err != nil {
+				return nil, fmt.Errorf("Invalid borders of %s: %s", record.Name, err)
+			}
+			sub.addRing(ring)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+/*
+subdivisionAt - The subdivision at lon,lat, nil at sea or without the
+dataset
+*/
+func subdivisionAt(lon, lat float64) *Subdivision {
+	subs, _ := loadSubdivisions()
+	for i := range subs {
+		if subs[i].contains(lon, lat) {
+			return &subs[i]
+		}
+	}
+	return nil
+}
+
+/*
+subdivisionKey - The subdivision of a located result as "US-CA California",
+"" if unknown
+*/
+func subdivisionKey(res IPInfoResult) string {
+	lon, lat, err := res.GetLonLat()
+	if err != nil {
+		return ""
+	}
+	loc := Point{Lat: lat, Lon: lon}
+
+	subdivisionsMu.Lock()
+	defer subdivisionsMu.Unlock()
+	sub, ok := subdivisionByLoc[loc]
+	if !ok {
+		sub = subdivisionAt(lon, lat)
+		subdivisionByLoc[loc] = sub
+	}
+	if sub == nil {
+		return ""
+	}
+	if sub.Code == "" || sub.Code == "-99" {
+		return sub.Name
+	}
+	return sub.Code + " " + sub.Name
+}
+
+/*
+countrySubdivisions - The subdivisions of the country of the given code
+*/
+func countrySubdivisions(country string) []*Subdivision {
+	subdivisionsMu.Lock()
+	defer subdivisionsMu.Unlock()
+	if subs, ok := subdivisionsShown[country]; ok {
+		return subs
+	}
+	all, _ := loadSubdivisions()
+	var subs []*Subdivision
+	for i := range all {
+		if all[i].Country == country {
+			subs = append(subs, &all[i])
+		}
+	}
+	subdivisionsShown[country] = subs
+	return subs
+}
+
+/*
+drawSubdivisions - Draw the subdivisions of the country at the center of the
+map in dotted lines, when zoomed enough
+*/
+func drawSubdivisions(mc *MapCanvas) {
+	if mc.view.Zoom < subdivisionZoom {
+		return
+	}
+	if _, err := loadSubdivisions(); err != nil {
+		return
+	}
+	country := ""
+	if sub := subdivisionAt(mc.view.CenterLon, mc.view.CenterLat); sub != nil {
+		country = sub.Country
+	} else if country = countryAt(mc.view.CenterLon, mc.view.CenterLat); country == "" {
+		return
+	}
+
+	minLon, maxLon, minLat, maxLat := mc.view.Bounds()
+	view := BBox{minLon, minLat, maxLon, maxLat}
+	for _, sub := range countrySubdivisions(country) {
+		if !view.crosses(sub.Box.MinLon, sub.Box.MaxLon, sub.Box.MinLat, sub.Box.MaxLat) {
+			continue
+		}
+		for _, ring := range sub.Rings {
+			for i := 1; i < len(ring); i++ {
+				mc.DottedLine(ring[i-1].Lon, ring[i-1].Lat, ring[i].Lon, ring[i].Lat, 2)
+			}
+		}
+	}
+}
+
+/*
+updateSubdivisions - Download the subdivisions of the countries and store
+them simplified
+*/
+func updateSubdivisions(url string) error {
+	path := subdivisionsPath()
+	if path == "" {
+		return fmt.Errorf("No cache directory to store the subdivisions in")
+	}
+	var count int
+	err := downloadAs(url, path, func(data []byte) ([]byte, error) {
+		records, err := convertSubdivisions(data)
+		if err != nil {
+			return nil, err
+		}
+		if count = len(records); count == 0 {
+			return nil, fmt.Errorf("No subdivisions found")
+		}
+		var out bytes.Buffer
+		err = json.NewEncoder(&out).Encode(records)
+		return out.Bytes(), err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d subdivisions saved to %s\n", count, path)
+	return nil
+}
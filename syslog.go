@@ -0,0 +1,54 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+/*
+SyslogSink - Sends one JSON message per event to syslog
+*/
+type SyslogSink struct {
+	w      *syslog.Writer
+	fields []string
+}
+
+/*
+NewSyslogSink - Connect to the syslog daemon at dest (see syslogTarget)
+*/
+func NewSyslogSink(dest string, fields []string) (*SyslogSink, error) {
+	network, addr, err := syslogTarget(dest)
+	if err != nil {
+		return nil, err
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, "ip411")
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w, fields: fields}, nil
+}
+
+/*
+Publish - Log e as JSON
+*/
+func (s *SyslogSink) Publish(e Event) error {
+	obj, err := eventFields(e, s.fields)
+	if err != nil {
+		return err
+	}
+	msg, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(msg))
+}
+
+/*
+Close - Close the connection to syslog
+*/
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
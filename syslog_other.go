@@ -0,0 +1,32 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import "fmt"
+
+/*
+SyslogSink - Not available on this platform
+*/
+type SyslogSink struct{}
+
+/*
+NewSyslogSink - Always fails, there is no syslog on this platform
+*/
+func NewSyslogSink(dest string, fields []string) (*SyslogSink, error) {
+	return nil, fmt.Errorf("Syslog is not supported on this platform")
+}
+
+/*
+Publish .
+*/
+func (s *SyslogSink) Publish(e Event) error {
+	return nil
+}
+
+/*
+Close .
+*/
+func (s *SyslogSink) Close() error {
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+The server modes run under systemd without wrapper scripts:
+
+  - Socket activation: started by a .socket unit, the server serves on the
+    socket systemd passes (LISTEN_FDS) instead of binding -listen, so that
+    it may listen on a privileged port without privileges.
+  - Readiness: with Type=notify the server reports READY=1 once it serves
+    and STOPPING=1 when it drains, and pings the watchdog (WatchdogSec=).
+  - With -user the server switches to that user once its socket is bound,
+    for when it is started as root to bind a privileged port.
+
+A unit may then be as short as:
+
+	[Service]
+	Type=notify
+	ExecStart=/usr/local/bin/ip411 proxy -user ip411
+	WatchdogSec=30
+*/
+
+// First file descriptor passed by systemd
+const listenFDsStart = 3
+
+type serverOptions struct {
+	listen string
+	user   string
+	pprof  string
+}
+
+func addServerFlags(flags *flag.FlagSet, listen string) *serverOptions {
+	opts := &serverOptions{}
+	flags.StringVar(&opts.listen, "listen", listen,
+		"Address to serve on, unless systemd passes a socket")
+	flags.StringVar(&opts.user, "user", "",
+		"Switch to this user once the socket is bound (when started as root)")
+	flags.StringVar(&opts.pprof, "pprof", "",
+		"Serve net/http/pprof on this address, as localhost:6060 (default off)")
+	return opts
+}
+
+/*
+activatedListener - The socket passed by systemd socket activation, nil if
+none
+*/
+func activatedListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+	if fds > 1 {
+		warnf("systemd passed %d sockets, serving on the first only", fds)
+	}
+	// Not for the processes started by this one
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(listenFDsStart, "LISTEN_FD_3")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("Socket passed by systemd: %s", err)
+	}
+	return ln, nil
+}
+
+/*
+open - The socket to serve on, passed by systemd or bound to -listen, with
+the privileges dropped afterwards if -user is set
+*/
+func (opts *serverOptions) open() (net.Listener, error) {
+	ln, err := activatedListener()
+	if err != nil {
+		return nil, err
+	}
+	if ln != nil {
+		log.Printf("Listening on %s, passed by systemd", ln.Addr())
+	} else if ln, err = net.Listen("tcp", opts.listen); err != nil {
+		return nil, err
+	} else {
+		log.Printf("Listening on %s", ln.Addr())
+	}
+	if opts.user != "" {
+		if err := dropPrivileges(opts.user); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+/*
+sdNotify - Send state to the service manager ("READY=1"), if ip411 runs
+under systemd with Type=notify
+*/
+func sdNotify(state string) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return
+	}
+	if strings.HasPrefix(addr, "@") {
+		// Abstract socket
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.Dial("unixgram", addr)
+	if err != nil {
+		warnf("systemd notification: %s", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		warnf("systemd notification: %s", err)
+	}
+}
+
+/*
+watchdogInterval - How often the systemd watchdog must be pinged, 0 if
+there is no watchdog
+*/
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	// Twice as often as required, as systemd recommends
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+/*
+startWatchdog - Ping the systemd watchdog until done is closed
+*/
+func startWatchdog(done <-chan struct{}) {
+	interval := watchdogInterval()
+	if interval == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sdNotify("WATCHDOG=1")
+			case <-done:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/*
+The top talkers table of logs, monitor and capture ranks the remote
+addresses by their traffic: hits (log lines, polls with an open connection
+or conversations), and bytes and packets for the inputs that count them. While it is open the markers of
+its rows show their rank on the map.
+
+	T         show or hide the table
+	o         sort by the next column
+	up/down   select a row
+	enter     mark the selected address as '@' on the map (again to clear)
+	esc       close the table
+*/
+
+/*
+Traffic - What was seen of a remote address
+*/
+type Traffic struct {
+	Hits    int
+	Bytes   int
+	Packets int
+}
+
+var talkerColumns = []struct {
+	name  string
+	value func(t Traffic) int
+}{
+	{"hits", func(t Traffic) int { return t.Hits }},
+	{"bytes", func(t Traffic) int { return t.Bytes }},
+	{"packets", func(t Traffic) int { return t.Packets }},
+}
+
+/*
+TalkerRow - A row of the table
+*/
+type TalkerRow struct {
+	Target string
+	Traffic
+}
+
+/*
+count - Add traffic of target: a sighting, or the bytes and packets it
+carried
+*/
+func (b *Batch) count(target string, delta Traffic) {
+	b.mu.Lock()
+	b.traffic.Add(target, delta, time.Now())
+	if b.recent.has(target) {
+		b.recent.touch(target)
+	}
+	b.mu.Unlock()
+	if delta.Hits > 0 {
+		bus.Publish(Message{Topic: MsgHit, Source: b.source, Target: target, Hits: delta.Hits})
+	}
+}
+
+/*
+talkerRows - Rows of the table for the current window (see window.go), by
+descending value of the sort column
+*/
+func (b *Batch) talkerRows() []TalkerRow {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	traffic := b.windowTraffic()
+	rows := make([]TalkerRow, 0, len(traffic))
+	for target, t := range traffic {
+		rows = append(rows, TalkerRow{target, t})
+	}
+	value := talkerColumns[b.talkersSort].value
+	sort.Slice(rows, func(i, j int) bool {
+		vi, vj := value(rows[i].Traffic), value(rows[j].Traffic)
+		if vi == vj {
+			return rows[i].Target < rows[j].Target
+		}
+		return vi > vj
+	})
+	return rows
+}
+
+/*
+talkerRanks - Map label of the targets listed by the open table: their rank,
+'+' past the ninth. nil if the table is closed.
+*/
+func (b *Batch) talkerRanks() map[string]string {
+	b.mu.Lock()
+	open := b.showTalkers
+	b.mu.Unlock()
+	if !open {
+		return nil
+	}
+	ranks := make(map[string]string)
+	for i, row := range b.talkerRows() {
+		if i < 9 {
+			ranks[row.Target] = strconv.Itoa(i + 1)
+		} else {
+			ranks[row.Target] = "+"
+		}
+	}
+	return ranks
+}
+
+/*
+talkerCount - A cell of the table, "-" for none
+*/
+func talkerCount(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return formatCount(n)
+}
+
+/*
+renderTalkers - Fill the table view. Must be called from the gui goroutine
+with mu held.
+*/
+func (b *Batch) renderTalkers(view *View) []TalkerRow {
+	rows := b.talkerRows()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	view.Title = fmt.Sprintf(tr("Top talkers by %s, %s (o, enter)"), talkerColumns[b.talkersSort].name,
+		timeWindows[b.window].name)
+	view.Clear()
+	fmt.Fprintf(view, "  # %-39s %7s %10s %8s %s\n", tr("Address"), tr("Hits"), tr("Bytes"), tr("Packets"), tr("Country"))
+	for i, row := range rows {
+		mark := " "
+		if row.Target == b.talker {
+			mark = "@"
+		}
+		fmt.Fprintf(view, "%s%2d %-39.39s %7s %10s %8s %s\n", mark, i+1, row.Target,
+			talkerCount(row.Hits), talkerCount(row.Bytes), talkerCount(row.Packets),
+			fieldValue(b.results[row.Target], "country"))
+	}
+	return rows
+}
+
+func (b *Batch) talkersLayout(g *Gui) (*View, error) {
+	maxX, _ := g.Size()
+	_, mapY0, _, mapY1, err := g.ViewPosition("map")
+	if err != nil {
+		return nil, err
+	}
+	width := 80
+	if width > maxX {
+		width = maxX
+	}
+	view, err := g.SetView("talkers", 0, mapY0+1, width-1, mapY1-1)
+	if err != nil && err != ErrUnknownView {
+		return nil, err
+	}
+	view.Highlight = true
+	view.SelBgColor = ColorGreen
+	view.SelFgColor = ColorBlack
+	return view, nil
+}
+
+/*
+redrawTalkers - Redraw the table, keeping the selection off its header, and
+the markers whose ranks changed
+*/
+func (b *Batch) redrawTalkers(g *Gui, view *View, line int) error {
+	mu.Lock()
+	rows := b.renderTalkers(view)
+	mu.Unlock()
+	selectLine(view, line, len(rows)+1)
+	if selectedLine(view) == 0 {
+		selectLine(view, 1, len(rows)+1)
+	}
+
+	mapView, err := g.View("map")
+	if err != nil {
+		return err
+	}
+	drawMap(mapView, b.markers())
+	return nil
+}
+
+func (b *Batch) toggleTalkers(g *Gui, v *View) error {
+	if _, err := g.View("talkers"); err == nil {
+		return b.closeTalkers(g, v)
+	}
+	view, err := b.talkersLayout(g)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.showTalkers = true
+	b.mu.Unlock()
+	if err := b.redrawTalkers(g, view, 1); err != nil {
+		return err
+	}
+	return g.SetCurrentView("talkers")
+}
+
+func (b *Batch) closeTalkers(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.showTalkers = false
+	b.mu.Unlock()
+	if err := g.DeleteView("talkers"); err != nil {
+		return err
+	}
+	mapView, err := g.View("map")
+	if err != nil {
+		return err
+	}
+	drawMap(mapView, b.markers())
+	return g.SetCurrentView("map")
+}
+
+func (b *Batch) talkersMove(delta int) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		line := selectedLine(v) + delta
+		if line < 1 {
+			return nil
+		}
+		selectLine(v, line, len(b.talkerRows())+1)
+		return nil
+	}
+}
+
+func (b *Batch) talkersNextSort(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.talkersSort = (b.talkersSort + 1) % len(talkerColumns)
+	b.mu.Unlock()
+	return b.redrawTalkers(g, v, 1)
+}
+
+func (b *Batch) talkersSelect(g *Gui, v *View) error {
+	rows := b.talkerRows()
+	line := selectedLine(v)
+	if line < 1 || line > len(rows) {
+		return nil
+	}
+
+	b.mu.Lock()
+	if target := rows[line-1].Target; b.talker != target {
+		b.talker = target
+	} else {
+		b.talker = ""
+	}
+	b.mu.Unlock()
+	return b.redrawTalkers(g, v, line)
+}
+
+/*
+setTalkersKeybindings - Register the keys of the top talkers table, of the
+scaling of the markers by its metrics and of the time window
+*/
+func (b *Batch) setTalkersKeybindings(g *Gui) error {
+	bindings := []struct {
+		view    string
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{"", 'T', b.toggleTalkers},
+		{"talkers", KeyArrowUp, b.talkersMove(-1)},
+		{"talkers", KeyArrowDown, b.talkersMove(1)},
+		{"talkers", 'k', b.talkersMove(-1)},
+		{"talkers", 'j', b.talkersMove(1)},
+		{"talkers", 'o', b.talkersNextSort},
+		{"talkers", KeyEnter, b.talkersSelect},
+		{"talkers", KeyEsc, b.closeTalkers},
+		{"", 'm', b.nextWeightMetric},
+		{"", 'w', b.nextWindow},
+	}
+	for _, binding := range bindings {
+		if err := g.SetKeybinding(binding.view, binding.key, ModNone,
+			binding.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
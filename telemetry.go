@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ip411 reports traces and metrics of its own work over OTLP (HTTP with JSON
+bodies) when the standard OpenTelemetry variables point at a collector:
+
+	OTEL_EXPORTER_OTLP_ENDPOINT          http://collector:4318, for both signals
+	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT   full URL of the traces, as .../v1/traces
+	OTEL_EXPORTER_OTLP_METRICS_ENDPOINT  full URL of the metrics, as .../v1/metrics
+	OTEL_EXPORTER_OTLP_HEADERS           key=value,... sent with every export
+	OTEL_SERVICE_NAME                    defaults to ip411
+
+Provider requests, cache lookups and the requests served by the proxy are
+instrumented. Telemetry is exported every telemetryInterval and when ip411
+exits; nothing is collected without an endpoint.
+
+	ip411.provider.requests  counter   {provider, outcome: ok, rate_limited, http status or error}
+	ip411.provider.duration  histogram {provider}, seconds
+	ip411.cache.lookups      counter   {backend, result: hit or miss}
+	ip411.proxy.requests     counter   {status, cache}
+	ip411.proxy.duration     histogram {}, seconds
+*/
+
+const (
+	telemetryInterval = 10 * time.Second
+	telemetryTimeout  = 5 * time.Second
+	// Spans kept between exports, later ones are dropped
+	telemetryBacklog = 10000
+)
+
+// Bucket bounds of the duration histograms, in seconds
+var durationBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Span kinds of OTLP
+const (
+	spanInternal = 1
+	spanServer   = 2
+	spanClient   = 3
+)
+
+/*
+Telemetry - Spans and metrics waiting to be exported to an OTLP collector.
+A nil Telemetry collects nothing, so that instrumented code needs no checks.
+*/
+type Telemetry struct {
+	tracesURL  string
+	metricsURL string
+	headers    map[string]string
+	service    string
+	client     *http.Client
+	start      time.Time
+
+	mu         sync.Mutex
+	spans      []*Span
+	dropped    int
+	counters   map[string]*counterPoint
+	histograms map[string]*histogramPoint
+	done       chan struct{}
+	stopped    sync.WaitGroup
+}
+
+type counterPoint struct {
+	name  string
+	attrs []string
+	value int64
+}
+
+type histogramPoint struct {
+	name    string
+	attrs   []string
+	count   int64
+	sum     float64
+	buckets []int64
+}
+
+var telemetry = openTelemetry()
+
+/*
+openTelemetry - The telemetry the environment configures, nil if none
+*/
+func openTelemetry() *Telemetry {
+	base := strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	t := &Telemetry{
+		tracesURL:  os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		metricsURL: os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"),
+		headers:    make(map[string]string),
+		service:    os.Getenv("OTEL_SERVICE_NAME"),
+		client:     &http.Client{Timeout: telemetryTimeout},
+		start:      time.Now(),
+		counters:   make(map[string]*counterPoint),
+		histograms: make(map[string]*histogramPoint),
+		done:       make(chan struct{}),
+	}
+	if base != "" && t.tracesURL == "" {
+		t.tracesURL = base + "/v1/traces"
+	}
+	if base != "" && t.metricsURL == "" {
+		t.metricsURL = base + "/v1/metrics"
+	}
+	if t.tracesURL == "" && t.metricsURL == "" {
+		return nil
+	}
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" {
+		warnf("OTEL_EXPORTER_OTLP_PROTOCOL %s is not supported, exporting with http/json", protocol)
+	}
+	if t.service == "" {
+		t.service = "ip411"
+	}
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if kv := strings.SplitN(header, "=", 2); len(kv) == 2 {
+			t.headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	t.stopped.Add(1)
+	go func() {
+		defer t.stopped.Done()
+		ticker := time.NewTicker(telemetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.export()
+			case <-t.done:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+/*
+Shutdown - Export what is left, before exiting
+*/
+func (t *Telemetry) Shutdown() {
+	if t == nil {
+		return
+	}
+	close(t.done)
+	t.stopped.Wait()
+	t.export()
+}
+
+/*
+metricKey - name and its attributes (key, value pairs) as a map key
+*/
+func metricKey(name string, attrs []string) string {
+	return name + "\x00" + strings.Join(attrs, "\x00")
+}
+
+/*
+Count - Add n to the counter name with the attributes attrs (key, value, ...)
+*/
+func (t *Telemetry) Count(name string, n int64, attrs ...string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := metricKey(name, attrs)
+	point, ok := t.counters[key]
+	if !ok {
+		point = &counterPoint{name: name, attrs: attrs}
+		t.counters[key] = point
+	}
+	point.value += n
+}
+
+/*
+Record - Add the duration d to the histogram name
+*/
+func (t *Telemetry) Record(name string, d time.Duration, attrs ...string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := metricKey(name, attrs)
+	point, ok := t.histograms[key]
+	if !ok {
+		point = &histogramPoint{name: name, attrs: attrs, buckets: make([]int64, len(durationBounds)+1)}
+		t.histograms[key] = point
+	}
+	seconds := d.Seconds()
+	point.count++
+	point.sum += seconds
+	point.buckets[sort.SearchFloat64s(durationBounds, seconds)]++
+}
+
+/*
+Span - An operation being traced
+*/
+type Span struct {
+	telemetry *Telemetry
+	traceID   string
+	spanID    string
+	parentID  string
+	name      string
+	kind      int
+	start     time.Time
+	end       time.Time
+	attrs     []string
+	err       error
+}
+
+func randomID(bytes int) string {
+	id := make([]byte, bytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+/*
+StartSpan - Start tracing the operation name, as a child of parent if it is
+not nil
+*/
+func (t *Telemetry) StartSpan(name string, kind int, parent *Span) *Span {
+	if t == nil {
+		return nil
+	}
+	span := &Span{telemetry: t, spanID: randomID(8), name: name, kind: kind, start: time.Now()}
+	if parent != nil {
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	} else {
+		span.traceID = randomID(16)
+	}
+	return span
+}
+
+/*
+continueTrace - Make span part of the trace of a W3C traceparent header
+("00-traceid-spanid-flags"), as sent by instrumented clients
+*/
+func (span *Span) continueTrace(traceparent string) {
+	if span == nil {
+		return
+	}
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return
+	}
+	if _, err := hex.DecodeString(parts[1] + parts[2]); err != nil {
+		return
+	}
+	span.traceID, span.parentID = parts[1], parts[2]
+}
+
+/*
+Set - Add the attribute key to the span
+*/
+func (span *Span) Set(key string, value interface{}) {
+	if span == nil {
+		return
+	}
+	span.attrs = append(span.attrs, key, fmt.Sprint(value))
+}
+
+/*
+End - Finish the span, failed if err is not nil
+*/
+func (span *Span) End(err error) {
+	if span == nil {
+		return
+	}
+	span.end, span.err = time.Now(), err
+	t := span.telemetry
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= telemetryBacklog {
+		t.dropped++
+		return
+	}
+	t.spans = append(t.spans, span)
+}
+
+// OTLP/JSON encoding, see opentelemetry-proto
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+func otlpAttributes(attrs []string) []otlpAttribute {
+	list := []otlpAttribute{}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		list = append(list, otlpAttribute{attrs[i], otlpValue{attrs[i+1]}})
+	}
+	return list
+}
+
+func otlpTime(t time.Time) string {
+	return fmt.Sprint(t.UnixNano())
+}
+
+func (t *Telemetry) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"attributes": otlpAttributes([]string{"service.name", t.service}),
+	}
+}
+
+var otlpScope = map[string]string{"name": "ip411"}
+
+/*
+export - Send the spans collected since the last export and the metrics so
+far (cumulative)
+*/
+func (t *Telemetry) export() {
+	now := time.Now()
+	t.mu.Lock()
+	spans, dropped := t.spans, t.dropped
+	t.spans, t.dropped = nil, 0
+	var metrics []map[string]interface{}
+	for _, point := range t.counters {
+		metrics = append(metrics, map[string]interface{}{
+			"name": point.name,
+			"sum": map[string]interface{}{
+				"dataPoints": []map[string]interface{}{{
+					"attributes":        otlpAttributes(point.attrs),
+					"startTimeUnixNano": otlpTime(t.start),
+					"timeUnixNano":      otlpTime(now),
+					"asInt":             fmt.Sprint(point.value),
+				}},
+				"aggregationTemporality": 2, // cumulative
+				"isMonotonic":            true,
+			},
+		})
+	}
+	for _, point := range t.histograms {
+		buckets := make([]string, len(point.buckets))
+		for i, count := range point.buckets {
+			buckets[i] = fmt.Sprint(count)
+		}
+		metrics = append(metrics, map[string]interface{}{
+			"name": point.name,
+			"unit": "s",
+			"histogram": map[string]interface{}{
+				"dataPoints": []map[string]interface{}{{
+					"attributes":        otlpAttributes(point.attrs),
+					"startTimeUnixNano": otlpTime(t.start),
+					"timeUnixNano":      otlpTime(now),
+					"count":             fmt.Sprint(point.count),
+					"sum":               point.sum,
+					"bucketCounts":      buckets,
+					"explicitBounds":    durationBounds,
+				}},
+				"aggregationTemporality": 2,
+			},
+		})
+	}
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		warnf("Telemetry: %d spans dropped", dropped)
+	}
+	if len(spans) > 0 && t.tracesURL != "" {
+		var list []map[string]interface{}
+		for _, span := range spans {
+			status := map[string]interface{}{"code": 1} // ok
+			if span.err != nil {
+				status = map[string]interface{}{"code": 2, "message": span.err.Error()}
+			}
+			list = append(list, map[string]interface{}{
+				"traceId":           span.traceID,
+				"spanId":            span.spanID,
+				"parentSpanId":      span.parentID,
+				"name":              span.name,
+				"kind":              span.kind,
+				"startTimeUnixNano": otlpTime(span.start),
+				"endTimeUnixNano":   otlpTime(span.end),
+				"attributes":        otlpAttributes(span.attrs),
+				"status":            status,
+			})
+		}
+		t.post(t.tracesURL, map[string]interface{}{
+			"resourceSpans": []map[string]interface{}{{
+				"resource":   t.resource(),
+				"scopeSpans": []map[string]interface{}{{"scope": otlpScope, "spans": list}},
+			}},
+		})
+	}
+	if len(metrics) > 0 && t.metricsURL != "" {
+		t.post(t.metricsURL, map[string]interface{}{
+			"resourceMetrics": []map[string]interface{}{{
+				"resource":     t.resource(),
+				"scopeMetrics": []map[string]interface{}{{"scope": otlpScope, "metrics": metrics}},
+			}},
+		})
+	}
+}
+
+func (t *Telemetry) post(url string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		warnf("Telemetry: %s", err)
+		return
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		warnf("Telemetry: %s", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		warnf("Telemetry: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		warnf("Telemetry: %s answered %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+}
+
+/*
+instrumentedCache - A Cache counting its hits and misses in telemetry
+*/
+type instrumentedCache struct {
+	Cache
+	backend string
+}
+
+func instrumentCache(cache Cache, backend string) Cache {
+	if telemetry == nil {
+		return cache
+	}
+	return instrumentedCache{cache, backend}
+}
+
+func (c instrumentedCache) Get(key string) ([]byte, bool) {
+	value, ok := c.Cache.Get(key)
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+	telemetry.Count("ip411.cache.lookups", 1, "backend", c.backend, "result", result)
+	return value, ok
+}
@@ -0,0 +1,797 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+The time zones layer draws the boundaries of the civil time zones, after
+timezone-boundary-builder simplified by hand to a few points a zone (see
+timeZoneRings), with the offset of every zone wide enough for it, and
+annotates the zone of the located target. That zone is the one whose
+polygon holds the target, else the time zone of its result; at sea, the
+nautical band of its longitude (15 degrees centered on the multiples of
+15). Where the polygon and the result name different zones with the same
+clock, the name of the result is kept; where their clocks differ, both are
+named.
+*/
+
+/*
+TimeZoneShape - The area of an IANA time zone
+*/
+type TimeZoneShape struct {
+	Name string
+	Borders
+}
+
+var (
+	timeZoneOnce   sync.Once
+	timeZoneShapes []TimeZoneShape
+)
+
+/*
+loadTimeZones - The shapes of timeZoneRings, built once
+*/
+func loadTimeZones() []TimeZoneShape {
+	timeZoneOnce.Do(func() {
+		for _, zone := range timeZoneRings {
+			shape := TimeZoneShape{Name: zone.Name}
+			for _, ring := range zone.Rings {
+				shape.addRing(ring)
+			}
+			timeZoneShapes = append(timeZoneShapes, shape)
+		}
+	})
+	return timeZoneShapes
+}
+
+/*
+zoneAt - The time zone whose polygon holds lon,lat, "" at sea or where the
+simplified polygons leave a gap. Polygons overlapping where they were
+simplified are listed smallest first, so that the first one holding the
+point wins.
+*/
+func zoneAt(lon, lat float64) string {
+	for _, shape := range loadTimeZones() {
+		if shape.contains(lon, lat) {
+			return shape.Name
+		}
+	}
+	return ""
+}
+
+/*
+nauticalOffset - The offset in hours of the nautical zone of a longitude
+*/
+func nauticalOffset(lon float64) int {
+	return int(math.Round(lon / 15))
+}
+
+/*
+formatOffset - An offset in seconds as UTC+05:30
+*/
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, seconds/3600, seconds/60%60)
+}
+
+/*
+zoneOffset - The offset of the time zone name at now, false if the zone is
+unknown to the system
+*/
+func zoneOffset(name string, now time.Time) (int, bool) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return 0, false
+	}
+	_, offset := now.In(loc).Zone()
+	return offset, true
+}
+
+/*
+zoneAnnotation - The label of the zone of res: the zone its location falls
+in, else its time zone, else the band of its longitude, "" if neither is
+known
+*/
+func zoneAnnotation(res IPInfoResult, now time.Time) string {
+	given := fieldValue(res, "timezone")
+	name := ""
+	lon, lat, err := res.GetLonLat()
+	if err == nil {
+		name = zoneAt(lon, lat)
+	}
+	if name == "" {
+		name = given
+	}
+	if name == "" {
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf(tr("band %s"), formatOffset(nauticalOffset(lon)*3600))
+	}
+	offset, ok := zoneOffset(name, now)
+	if given != "" && given != name {
+		// Merged zones keep the name of the result when its clock agrees
+		if theirs, known := zoneOffset(given, now); known && ok && theirs == offset {
+			name, given = given, ""
+		}
+	}
+	label := name
+	if ok {
+		label += " " + formatOffset(offset)
+	}
+	if given != "" && given != name {
+		label += fmt.Sprintf(tr(" (result: %s)"), given)
+	}
+	return label
+}
+
+func drawTimeZones(mc *MapCanvas) {
+	minLon, maxLon, minLat, maxLat := mc.view.Bounds()
+	now := time.Now()
+	for _, shape := range loadTimeZones() {
+		box := shape.Box
+		if box.MaxLon < minLon || box.MinLon > maxLon || box.MaxLat < minLat || box.MinLat > maxLat {
+			continue
+		}
+		for _, ring := range shape.Rings {
+			for i := range ring {
+				a, b := ring[i], ring[(i+1)%len(ring)]
+				mc.DottedLine(a.Lon, a.Lat, b.Lon, b.Lat, 4)
+			}
+		}
+
+		// The offset in the middle of the zones wide enough for it
+		offset, ok := zoneOffset(shape.Name, now)
+		if !ok {
+			continue
+		}
+		label := fmt.Sprintf("%+g", float64(offset)/3600)
+		if offset == 0 {
+			label = "0"
+		}
+		cells := (box.MaxLon - box.MinLon) * mc.width / (maxLon - minLon) / 2
+		lon, lat := (box.MinLon+box.MaxLon)/2, (box.MinLat+box.MaxLat)/2
+		if cells >= float64(len(label)+2) && shape.contains(lon, lat) {
+			mc.PlotText(lon, lat, label)
+		}
+	}
+
+	mu.Lock()
+	res := infoResult
+	mu.Unlock()
+	if res == nil {
+		return
+	}
+	lon, _, err := res.GetLonLat()
+	if err != nil {
+		return
+	}
+	if label := zoneAnnotation(res, now); label != "" {
+		bottom := minLat + (maxLat-minLat)/mc.height*4
+		mc.PlotText(math.Max(minLon, math.Min(maxLon, lon)), bottom, "^ "+label)
+	}
+}
+
+/*
+timeZoneRings - The polygons of the time zones of the populated land, as
+lon,lat pairs, simplified from timezone-boundary-builder (open data, after
+OpenStreetMap) to a few points a zone. Zones sharing the offset and rules
+of a neighbor within a country are merged into it, and small islands are
+boxes.
+*/
+var timeZoneRings = []struct {
+	Name  string
+	Rings [][][2]float64
+}{
+	// North and Central America
+	{"Pacific/Honolulu", [][][2]float64{{{-160.6, 22.4}, {-154.6, 22.4}, {-154.6, 18.8}, {-160.6, 18.8}}}},
+	{"America/St_Johns", [][][2]float64{{{-59.4, 47.6}, {-55.5, 46.6}, {-52.6, 46.6}, {-52.6, 50.0},
+		{-55.5, 51.6}, {-57.4, 50.7}}}},
+	{"America/Goose_Bay", [][][2]float64{{{-57.1, 51.4}, {-63.6, 52.0}, {-67.0, 54.5}, {-64.5, 58.0},
+		{-64.4, 60.3}, {-60.0, 56.0}, {-55.7, 52.2}}}},
+	{"America/Halifax", [][][2]float64{{{-67.0, 44.9}, {-67.8, 45.7}, {-67.8, 47.1}, {-69.2, 47.4},
+		{-68.0, 47.9}, {-66.5, 48.0}, {-64.5, 47.9}, {-61.0, 47.5}, {-59.7, 46.0}, {-65.8, 43.4}}}},
+	{"America/Tijuana", [][][2]float64{{{-117.1, 32.5}, {-114.7, 32.7}, {-114.8, 31.8}, {-112.8, 28.0},
+		{-114.2, 28.0}}}},
+	{"America/Phoenix", [][][2]float64{{{-114.05, 37.0}, {-109.05, 37.0}, {-109.05, 31.33}, {-111.07, 31.33},
+		{-114.8, 32.5}, {-114.7, 32.7}, {-114.6, 35.0}, {-114.05, 36.2}}}},
+	{"America/Hermosillo", [][][2]float64{{{-114.8, 32.5}, {-111.07, 31.33}, {-108.2, 31.33}, {-108.5, 28.0},
+		{-108.6, 26.9}, {-109.4, 26.3}, {-110.9, 27.9}, {-112.8, 30.5}, {-114.8, 31.8}}}},
+	{"America/Mazatlan", [][][2]float64{
+		{{-108.6, 26.9}, {-107.6, 26.5}, {-105.5, 23.5}, {-104.3, 22.0}, {-104.8, 21.0}, {-105.3, 20.7},
+			{-105.7, 22.5}, {-106.4, 23.2}, {-108.5, 25.5}, {-109.4, 26.3}},
+		{{-114.2, 28.0}, {-112.8, 28.0}, {-112.0, 26.0}, {-110.3, 24.2}, {-109.4, 23.1}, {-110.0, 22.9},
+			{-112.1, 24.8}, {-114.0, 27.0}}}},
+	{"America/Cancun", [][][2]float64{{{-87.5, 21.6}, {-86.7, 21.2}, {-87.4, 18.5}, {-88.3, 18.5},
+		{-89.1, 17.8}, {-89.1, 19.6}, {-88.0, 20.9}}}},
+	{"America/Belize", [][][2]float64{{{-89.1, 17.8}, {-88.3, 18.5}, {-87.8, 17.0}, {-88.9, 15.9},
+		{-89.2, 15.9}}}},
+	{"America/Guatemala", [][][2]float64{{{-92.2, 14.5}, {-92.2, 15.3}, {-91.4, 16.1}, {-91.4, 17.8},
+		{-89.1, 17.8}, {-89.2, 15.9}, {-88.2, 15.7}, {-89.2, 14.4}, {-90.1, 13.7}}}},
+	{"America/El_Salvador", [][][2]float64{{{-90.1, 13.7}, {-89.2, 14.4}, {-87.7, 13.9}, {-87.7, 13.2},
+		{-89.0, 13.3}}}},
+	{"America/Tegucigalpa", [][][2]float64{{{-89.2, 14.4}, {-88.2, 15.7}, {-85.5, 16.0}, {-83.2, 15.0},
+		{-84.7, 14.8}, {-86.0, 13.8}, {-87.3, 13.0}, {-87.7, 13.2}, {-87.7, 13.9}}}},
+	{"America/Managua", [][][2]float64{{{-87.3, 13.0}, {-86.0, 13.8}, {-84.7, 14.8}, {-83.2, 15.0},
+		{-83.6, 11.0}, {-85.7, 11.1}}}},
+	{"America/Costa_Rica", [][][2]float64{{{-85.7, 11.1}, {-83.6, 11.0}, {-82.6, 9.6}, {-82.9, 8.0},
+		{-85.8, 9.8}}}},
+	{"America/Panama", [][][2]float64{{{-82.6, 9.6}, {-79.5, 9.6}, {-77.4, 8.7}, {-77.9, 7.2},
+		{-80.4, 7.2}, {-82.9, 8.0}}}},
+	{"America/Havana", [][][2]float64{{{-85.0, 21.9}, {-80.0, 23.2}, {-74.1, 20.2}, {-77.7, 19.8},
+		{-84.9, 21.8}}}},
+	{"America/Jamaica", [][][2]float64{{{-78.4, 18.5}, {-76.2, 18.5}, {-76.2, 17.7}, {-78.4, 17.7}}}},
+	{"America/Port-au-Prince", [][][2]float64{{{-74.5, 20.1}, {-71.7, 20.0}, {-71.7, 18.0}, {-74.5, 18.0}}}},
+	{"America/Santo_Domingo", [][][2]float64{{{-71.7, 20.0}, {-68.3, 19.0}, {-68.3, 18.2}, {-71.7, 18.0}}}},
+	{"America/Puerto_Rico", [][][2]float64{{{-67.3, 18.6}, {-65.2, 18.6}, {-65.2, 17.9}, {-67.3, 17.9}}}},
+	{"America/Regina", [][][2]float64{{{-110.0, 49.0}, {-110.0, 60.0}, {-102.0, 60.0}, {-101.4, 49.0}}}},
+	{"America/Whitehorse", [][][2]float64{{{-141.0, 69.6}, {-141.0, 60.3}, {-139.0, 60.0}, {-124.0, 60.0},
+		{-128.0, 63.0}, {-133.0, 65.0}, {-136.5, 69.0}, {-139.0, 69.5}}}},
+	{"America/Vancouver", [][][2]float64{{{-120.0, 60.0}, {-139.0, 60.0}, {-137.5, 59.2}, {-133.4, 58.4},
+		{-130.0, 56.0}, {-130.0, 54.7}, {-133.0, 54.0}, {-131.0, 52.0}, {-128.5, 50.5}, {-125.5, 48.5},
+		{-123.3, 48.3}, {-123.3, 49.0}, {-114.1, 49.0}, {-120.0, 53.8}}}},
+	{"America/Edmonton", [][][2]float64{{{-110.0, 49.0}, {-114.1, 49.0}, {-120.0, 53.8}, {-120.0, 60.0},
+		{-124.0, 60.0}, {-128.0, 63.0}, {-133.0, 65.0}, {-136.5, 69.0}, {-128.0, 70.5}, {-120.0, 74.0},
+		{-102.0, 74.0}, {-102.0, 60.0}, {-110.0, 60.0}}}},
+	{"America/Winnipeg", [][][2]float64{{{-101.4, 49.0}, {-102.0, 60.0}, {-102.0, 70.0}, {-88.0, 70.0},
+		{-89.0, 56.8}, {-90.0, 52.0}, {-89.6, 48.0}, {-95.15, 49.0}}}},
+	{"America/Toronto", [][][2]float64{{{-89.6, 48.0}, {-88.0, 48.2}, {-84.5, 46.5}, {-82.5, 45.3},
+		{-82.4, 43.0}, {-83.1, 42.3}, {-79.0, 42.5}, {-79.05, 43.1}, {-76.3, 44.2}, {-74.7, 45.0},
+		{-71.5, 45.0}, {-70.6, 45.6}, {-69.2, 47.4}, {-68.0, 47.9}, {-66.5, 48.0}, {-64.2, 48.5},
+		{-64.0, 50.0}, {-57.1, 51.4}, {-63.6, 52.0}, {-67.0, 54.5}, {-64.5, 58.0}, {-64.4, 60.3},
+		{-61.0, 66.5}, {-68.0, 70.5}, {-75.0, 73.0}, {-88.0, 74.0}, {-88.0, 70.0}, {-89.0, 56.8},
+		{-90.0, 52.0}}}},
+	{"America/Anchorage", [][][2]float64{{{-141.0, 69.6}, {-141.0, 60.3}, {-137.5, 59.2}, {-133.4, 58.4},
+		{-130.0, 56.0}, {-130.0, 54.7}, {-133.5, 54.6}, {-136.0, 57.5}, {-140.0, 59.7}, {-145.0, 60.3},
+		{-150.0, 59.3}, {-154.0, 57.0}, {-158.0, 56.0}, {-164.0, 54.5}, {-162.0, 58.5}, {-165.0, 60.5},
+		{-168.0, 65.6}, {-166.2, 68.9}, {-156.8, 71.4}}}},
+	{"America/Los_Angeles", [][][2]float64{{{-124.7, 48.4}, {-123.3, 49.0}, {-116.05, 49.0}, {-114.6, 45.6},
+		{-116.9, 45.5}, {-117.0, 44.3}, {-117.0, 42.0}, {-114.0, 42.0}, {-114.05, 36.2}, {-114.6, 35.0},
+		{-114.7, 32.7}, {-117.1, 32.5}, {-120.6, 34.5}, {-122.5, 37.8}, {-124.4, 40.4}, {-124.1, 46.3}}}},
+	{"America/Denver", [][][2]float64{{{-104.05, 49.0}, {-116.05, 49.0}, {-114.6, 45.6}, {-116.9, 45.5},
+		{-117.0, 44.3}, {-117.0, 42.0}, {-114.0, 42.0}, {-114.05, 37.0}, {-109.05, 37.0}, {-109.05, 31.33},
+		{-108.2, 31.33}, {-106.5, 31.8}, {-104.5, 29.6}, {-104.9, 31.0}, {-103.0, 32.0}, {-103.0, 37.0},
+		{-101.5, 37.0}, {-101.3, 40.0}, {-101.0, 42.9}, {-100.3, 44.4}, {-100.5, 45.7}, {-101.2, 46.0},
+		{-101.8, 47.0}, {-104.05, 47.5}}}},
+	{"America/Chicago", [][][2]float64{{{-88.0, 48.2}, {-89.6, 48.0}, {-95.15, 49.0}, {-104.05, 49.0},
+		{-104.05, 47.5}, {-101.8, 47.0}, {-101.2, 46.0}, {-100.5, 45.7}, {-100.3, 44.4}, {-101.0, 42.9},
+		{-101.3, 40.0}, {-101.5, 37.0}, {-103.0, 37.0}, {-103.0, 32.0}, {-104.9, 31.0}, {-104.5, 29.6},
+		{-101.4, 29.8}, {-99.5, 27.5}, {-97.1, 25.9}, {-97.4, 27.8}, {-94.8, 29.3}, {-89.4, 29.0},
+		{-88.0, 30.6}, {-85.2, 29.7}, {-85.0, 31.0}, {-85.1, 32.0}, {-85.6, 35.0}, {-85.5, 36.6},
+		{-86.5, 38.0}, {-87.1, 38.2}, {-86.8, 41.2}, {-87.5, 41.7}, {-87.6, 45.1}}}},
+	{"America/New_York", [][][2]float64{{{-88.0, 48.2}, {-87.6, 45.1}, {-87.5, 41.7}, {-86.8, 41.2},
+		{-87.1, 38.2}, {-86.5, 38.0}, {-85.5, 36.6}, {-85.6, 35.0}, {-85.1, 32.0}, {-85.0, 31.0},
+		{-85.2, 29.7}, {-83.0, 29.0}, {-82.6, 27.0}, {-81.0, 25.2}, {-80.1, 25.8}, {-80.0, 27.0},
+		{-81.3, 30.5}, {-81.0, 31.8}, {-75.5, 35.2}, {-76.0, 37.0}, {-74.0, 39.5}, {-70.0, 41.5},
+		{-70.5, 43.0}, {-67.0, 44.9}, {-67.8, 45.7}, {-67.8, 47.1}, {-69.2, 47.4}, {-70.6, 45.6},
+		{-71.5, 45.0}, {-74.7, 45.0}, {-76.3, 44.2}, {-79.05, 43.1}, {-79.0, 42.5}, {-83.1, 42.3},
+		{-82.4, 43.0}, {-82.5, 45.3}, {-84.5, 46.5}}}},
+	{"America/Mexico_City", [][][2]float64{{{-108.2, 31.33}, {-106.5, 31.8}, {-104.5, 29.6}, {-101.4, 29.8},
+		{-99.5, 27.5}, {-97.1, 25.9}, {-97.7, 22.0}, {-96.1, 19.2}, {-94.5, 18.2}, {-92.0, 18.6},
+		{-90.4, 21.0}, {-87.5, 21.6}, {-88.0, 20.9}, {-89.1, 19.6}, {-89.1, 17.8}, {-91.4, 17.8},
+		{-91.4, 16.1}, {-92.2, 15.3}, {-92.2, 14.5}, {-94.0, 16.0}, {-96.5, 15.7}, {-99.9, 16.8},
+		{-102.2, 17.9}, {-105.3, 20.7}, {-104.8, 21.0}, {-104.3, 22.0}, {-105.5, 23.5}, {-107.6, 26.5},
+		{-108.6, 26.9}, {-108.5, 28.0}}}},
+
+	// South America
+	{"America/Bogota", [][][2]float64{{{-77.9, 7.2}, {-77.4, 8.7}, {-75.5, 10.5}, {-72.0, 12.4}, {-71.3, 11.8},
+		{-72.8, 9.1}, {-72.4, 7.4}, {-70.1, 7.0}, {-67.5, 6.2}, {-67.8, 4.5}, {-67.3, 2.0}, {-70.0, 1.5},
+		{-69.4, -1.0}, {-70.0, -4.2}, {-73.5, -1.2}, {-75.3, -0.1}, {-77.8, 0.8}, {-78.9, 1.8}, {-77.3, 4.0}}}},
+	{"America/Caracas", [][][2]float64{{{-71.3, 11.8}, {-68.0, 10.6}, {-64.0, 10.7}, {-61.8, 10.7}, {-60.0, 8.5},
+		{-61.4, 5.9}, {-60.7, 5.2}, {-62.8, 4.0}, {-64.0, 4.1}, {-64.7, 1.3}, {-66.9, 1.2}, {-67.3, 2.0},
+		{-67.8, 4.5}, {-67.5, 6.2}, {-70.1, 7.0}, {-72.4, 7.4}, {-72.8, 9.1}}}},
+	{"America/Guyana", [][][2]float64{{{-60.0, 8.5}, {-57.2, 6.0}, {-57.9, 4.0}, {-58.0, 1.5}, {-59.8, 1.3},
+		{-60.7, 5.2}, {-61.4, 5.9}}}},
+	{"America/Paramaribo", [][][2]float64{{{-57.2, 6.0}, {-54.0, 5.8}, {-54.0, 2.1}, {-56.0, 1.9}, {-58.0, 1.5},
+		{-57.9, 4.0}}}},
+	{"America/Cayenne", [][][2]float64{{{-54.0, 5.8}, {-51.6, 4.2}, {-52.5, 2.2}, {-54.0, 2.1}}}},
+	{"America/Guayaquil", [][][2]float64{{{-80.1, 0.8}, {-78.9, 1.8}, {-77.8, 0.8}, {-75.3, -0.1}, {-75.2, -0.9},
+		{-77.0, -3.0}, {-78.4, -4.5}, {-80.3, -4.4}, {-80.3, -3.4}, {-81.0, -2.2}}}},
+	{"America/Lima", [][][2]float64{{{-80.3, -3.4}, {-81.3, -4.7}, {-79.0, -8.0}, {-77.2, -12.0}, {-75.2, -15.2},
+		{-71.3, -17.7}, {-70.4, -18.3}, {-69.5, -17.5}, {-69.0, -16.6}, {-69.0, -15.0}, {-68.7, -12.5},
+		{-69.5, -11.0}, {-70.5, -11.0}, {-72.8, -9.4}, {-73.0, -7.3}, {-70.0, -4.2}, {-73.5, -1.2},
+		{-75.3, -0.1}, {-75.2, -0.9}, {-77.0, -3.0}, {-78.4, -4.5}, {-80.3, -4.4}}}},
+	{"America/Rio_Branco", [][][2]float64{{{-73.0, -7.3}, {-72.8, -9.4}, {-70.5, -11.0}, {-69.5, -11.0},
+		{-66.6, -9.9}}}},
+	{"America/La_Paz", [][][2]float64{{{-69.5, -11.0}, {-66.6, -9.9}, {-65.3, -9.8}, {-60.2, -13.6}, {-60.2, -16.3},
+		{-58.0, -17.5}, {-58.2, -20.2}, {-62.3, -20.5}, {-62.6, -22.2}, {-65.0, -22.1}, {-67.2, -22.8},
+		{-68.2, -21.5}, {-68.4, -19.4}, {-69.5, -17.5}, {-69.0, -16.6}, {-69.0, -15.0}, {-68.7, -12.5}}}},
+	{"America/Asuncion", [][][2]float64{{{-62.6, -22.2}, {-62.3, -20.5}, {-58.2, -20.2}, {-57.9, -22.1},
+		{-55.7, -22.6}, {-54.3, -24.0}, {-54.6, -25.6}, {-58.6, -27.3}, {-57.7, -25.4}}}},
+	{"America/Montevideo", [][][2]float64{{{-57.6, -30.2}, {-55.9, -30.9}, {-53.4, -33.7}, {-54.9, -34.9},
+		{-57.9, -34.4}, {-58.4, -33.9}}}},
+	{"America/Santiago", [][][2]float64{{{-70.4, -18.3}, {-69.5, -17.5}, {-68.4, -19.4}, {-68.2, -21.5},
+		{-67.2, -22.8}, {-68.3, -25.0}, {-69.8, -30.0}, {-70.5, -35.0}, {-71.5, -40.0}, {-71.9, -46.0},
+		{-72.3, -50.5}, {-68.4, -52.4}, {-68.6, -54.9}, {-71.0, -55.5}, {-75.5, -50.0}, {-73.5, -41.0},
+		{-71.6, -33.0}, {-70.3, -27.0}}}},
+	{"America/Argentina/Buenos_Aires", [][][2]float64{{{-65.0, -22.1}, {-62.6, -22.2}, {-57.7, -25.4},
+		{-58.6, -27.3}, {-54.6, -25.6}, {-53.8, -27.2}, {-55.8, -28.2}, {-57.6, -30.2}, {-58.4, -33.9},
+		{-58.4, -34.4}, {-57.5, -38.0}, {-62.3, -38.8}, {-65.0, -42.0}, {-67.6, -46.4}, {-69.0, -50.0},
+		{-65.2, -54.7}, {-68.6, -54.9}, {-68.4, -52.4}, {-72.3, -50.5}, {-71.9, -46.0}, {-71.5, -40.0},
+		{-70.5, -35.0}, {-69.8, -30.0}, {-68.3, -25.0}, {-67.2, -22.8}}}},
+	{"America/Manaus", [][][2]float64{{{-69.4, -1.0}, {-70.0, 1.5}, {-67.3, 2.0}, {-66.9, 1.2}, {-64.7, 1.3},
+		{-64.0, 4.1}, {-62.8, 4.0}, {-60.7, 5.2}, {-59.8, 1.3}, {-58.5, -2.5}, {-58.2, -7.3}, {-58.0, -9.8},
+		{-50.2, -9.8}, {-50.7, -15.0}, {-53.0, -18.0}, {-51.0, -20.0}, {-53.0, -22.5}, {-54.3, -24.0},
+		{-55.7, -22.6}, {-57.9, -22.1}, {-58.2, -20.2}, {-58.0, -17.5}, {-60.2, -16.3}, {-60.2, -13.6},
+		{-65.3, -9.8}, {-66.6, -9.9}, {-73.0, -7.3}, {-70.0, -4.2}}}},
+	{"America/Sao_Paulo", [][][2]float64{{{-59.8, 1.3}, {-58.0, 1.5}, {-56.0, 1.9}, {-54.0, 2.1}, {-52.5, 2.2},
+		{-51.6, 4.2}, {-50.0, 1.8}, {-48.5, -1.4}, {-44.0, -2.5}, {-38.5, -3.7}, {-35.2, -5.8}, {-34.9, -8.0},
+		{-39.0, -15.0}, {-39.7, -19.5}, {-41.0, -22.0}, {-43.2, -22.9}, {-46.3, -24.0}, {-48.5, -26.5},
+		{-48.8, -28.5}, {-50.5, -31.0}, {-53.4, -33.7}, {-55.9, -30.9}, {-57.6, -30.2}, {-55.8, -28.2},
+		{-53.8, -27.2}, {-54.6, -25.6}, {-54.3, -24.0}, {-53.0, -22.5}, {-51.0, -20.0}, {-53.0, -18.0},
+		{-50.7, -15.0}, {-50.2, -9.8}, {-58.0, -9.8}, {-58.2, -7.3}, {-58.5, -2.5}}}},
+
+	// Europe
+	{"Atlantic/Reykjavik", [][][2]float64{{{-24.5, 66.6}, {-13.5, 66.6}, {-13.5, 63.3}, {-24.5, 63.3}}}},
+	{"Atlantic/Canary", [][][2]float64{{{-18.2, 29.4}, {-13.3, 29.4}, {-13.3, 27.6}, {-18.2, 27.6}}}},
+	{"Europe/Malta", [][][2]float64{{{14.2, 36.1}, {14.6, 36.1}, {14.6, 35.8}, {14.2, 35.8}}}},
+	{"Asia/Nicosia", [][][2]float64{{{32.2, 35.7}, {34.6, 35.7}, {34.6, 34.5}, {32.2, 34.5}}}},
+	{"Europe/Luxembourg", [][][2]float64{{{5.8, 49.5}, {6.4, 49.5}, {6.5, 49.8}, {6.1, 50.1}}}},
+	{"Europe/Dublin", [][][2]float64{{{-10.5, 51.5}, {-6.2, 52.2}, {-6.1, 53.4}, {-6.3, 54.05}, {-7.5, 54.1},
+		{-8.2, 54.4}, {-7.3, 55.3}, {-8.5, 55.2}, {-10.2, 54.2}, {-10.3, 53.0}}}},
+	{"Europe/London", [][][2]float64{
+		{{-5.7, 50.0}, {-3.0, 50.6}, {1.4, 51.1}, {1.8, 52.5}, {0.2, 53.5}, {-1.6, 55.6}, {-2.0, 57.7},
+			{-3.0, 58.7}, {-5.0, 58.6}, {-6.2, 57.5}, {-5.7, 55.3}, {-4.8, 54.7}, {-3.2, 54.0}, {-4.7, 53.3},
+			{-4.2, 52.0}, {-5.3, 51.7}, {-4.2, 51.2}},
+		{{-8.2, 54.4}, {-7.3, 55.3}, {-5.5, 55.1}, {-5.4, 54.2}, {-6.3, 54.05}, {-7.5, 54.1}}}},
+	{"Europe/Lisbon", [][][2]float64{{{-8.9, 41.9}, {-6.5, 41.95}, {-6.9, 41.0}, {-7.0, 39.7}, {-7.3, 38.4},
+		{-7.4, 37.2}, {-9.0, 36.9}, {-9.6, 38.7}, {-9.0, 40.5}}}},
+	{"Europe/Madrid", [][][2]float64{{{-9.3, 43.2}, {-8.9, 41.9}, {-6.5, 41.95}, {-6.9, 41.0}, {-7.0, 39.7},
+		{-7.3, 38.4}, {-7.4, 37.2}, {-5.4, 36.1}, {-2.0, 36.7}, {-0.5, 38.2}, {0.2, 38.8}, {-0.3, 39.5},
+		{0.9, 40.8}, {3.2, 42.4}, {1.7, 42.5}, {-0.7, 42.9}, {-1.8, 43.4}, {-4.0, 43.5}, {-8.0, 43.8}}}},
+	{"Europe/Paris", [][][2]float64{
+		{{-1.8, 43.4}, {-0.7, 42.9}, {1.7, 42.5}, {3.2, 42.4}, {4.0, 43.4}, {6.0, 43.0}, {7.5, 43.8},
+			{6.9, 44.4}, {7.0, 45.9}, {6.1, 46.2}, {6.9, 47.5}, {7.6, 47.6}, {8.2, 49.0}, {6.4, 49.5},
+			{5.8, 49.5}, {4.8, 50.1}, {2.5, 51.1}, {1.6, 50.9}, {0.1, 49.6}, {-1.6, 49.7}, {-1.8, 48.6},
+			{-4.8, 48.4}, {-2.5, 47.3}, {-1.2, 46.0}, {-1.3, 44.6}},
+		{{8.5, 43.0}, {9.6, 43.0}, {9.6, 41.3}, {8.5, 41.3}}}},
+	{"Europe/Brussels", [][][2]float64{{{2.5, 51.1}, {4.8, 50.1}, {5.8, 49.5}, {6.1, 50.1}, {6.4, 50.3},
+		{6.0, 50.75}, {5.7, 50.75}, {5.8, 51.2}, {4.4, 51.4}, {3.4, 51.4}}}},
+	{"Europe/Amsterdam", [][][2]float64{{{3.4, 51.4}, {4.4, 51.4}, {5.8, 51.2}, {5.7, 50.75}, {6.0, 50.75},
+		{6.2, 51.5}, {5.9, 51.85}, {6.8, 52.1}, {7.0, 52.6}, {7.2, 53.3}, {6.8, 53.5}, {4.8, 53.1},
+		{4.5, 52.4}, {4.0, 51.9}}}},
+	{"Europe/Zurich", [][][2]float64{{{6.1, 46.2}, {7.0, 45.9}, {8.4, 46.4}, {9.0, 45.8}, {10.5, 46.6},
+		{10.5, 47.0}, {9.6, 47.5}, {8.6, 47.8}, {7.6, 47.6}, {6.9, 47.5}}}},
+	{"Europe/Berlin", [][][2]float64{{{7.6, 47.6}, {8.2, 49.0}, {6.4, 49.5}, {6.5, 49.8}, {6.1, 50.1},
+		{6.4, 50.3}, {6.0, 50.75}, {6.2, 51.5}, {5.9, 51.85}, {6.8, 52.1}, {7.0, 52.6}, {7.2, 53.3},
+		{8.5, 53.6}, {8.6, 54.9}, {9.4, 54.8}, {11.0, 54.4}, {12.5, 54.4}, {14.2, 53.9}, {14.4, 53.3},
+		{14.6, 52.6}, {14.8, 50.85}, {12.1, 50.3}, {13.8, 48.8}, {13.7, 48.5}, {13.0, 47.5}, {10.5, 47.5},
+		{9.6, 47.5}, {8.6, 47.8}}}},
+	{"Europe/Rome", [][][2]float64{
+		{{7.5, 43.8}, {6.9, 44.4}, {7.0, 45.9}, {8.4, 46.4}, {9.0, 45.8}, {10.5, 46.6}, {10.5, 46.9},
+			{12.2, 47.1}, {13.7, 46.5}, {13.6, 45.6}, {12.3, 45.2}, {12.4, 44.2}, {13.6, 43.6}, {16.0, 41.4},
+			{18.5, 40.1}, {16.6, 38.4}, {15.6, 38.0}, {15.8, 40.0}, {14.2, 40.8}, {12.2, 41.8}, {10.5, 42.9},
+			{10.2, 43.9}, {8.8, 44.4}},
+		{{12.3, 38.3}, {15.7, 38.3}, {15.1, 36.6}, {12.3, 37.6}},
+		{{8.1, 41.3}, {9.8, 41.3}, {9.6, 38.9}, {8.4, 38.9}}}},
+	{"Europe/Vienna", [][][2]float64{{{9.6, 47.5}, {10.5, 47.0}, {12.2, 47.1}, {13.7, 46.5}, {16.0, 46.7},
+		{16.1, 47.7}, {17.1, 48.0}, {16.9, 48.6}, {15.0, 49.0}, {13.8, 48.8}, {13.7, 48.5}, {13.0, 47.5},
+		{10.5, 47.5}}}},
+	{"Europe/Prague", [][][2]float64{{{12.1, 50.3}, {14.8, 50.85}, {16.5, 50.3}, {18.8, 49.9}, {20.0, 49.2},
+		{22.6, 49.1}, {22.1, 48.4}, {18.8, 47.8}, {17.1, 48.0}, {16.9, 48.6}, {15.0, 49.0}, {13.8, 48.8}}}},
+	{"Europe/Budapest", [][][2]float64{{{16.1, 47.7}, {16.0, 46.7}, {16.6, 46.5}, {17.3, 45.9}, {18.8, 45.9},
+		{20.3, 46.1}, {21.1, 46.4}, {22.1, 47.6}, {22.1, 48.4}, {18.8, 47.8}, {17.1, 48.0}}}},
+	{"Europe/Warsaw", [][][2]float64{{{14.2, 53.9}, {14.4, 53.3}, {14.6, 52.6}, {14.8, 50.85}, {16.5, 50.3},
+		{18.8, 49.9}, {20.0, 49.2}, {22.6, 49.1}, {24.1, 50.6}, {23.6, 52.0}, {23.9, 53.2}, {23.5, 54.2},
+		{22.8, 54.4}, {19.6, 54.4}, {18.6, 54.6}, {16.5, 54.5}}}},
+	{"Europe/Copenhagen", [][][2]float64{{{8.6, 54.9}, {9.4, 54.8}, {11.0, 54.6}, {12.6, 55.0}, {12.7, 56.0},
+		{10.6, 57.8}, {8.1, 56.8}, {8.0, 55.5}}}},
+	{"Europe/Oslo", [][][2]float64{{{11.1, 58.9}, {12.5, 61.0}, {12.3, 63.0}, {14.3, 65.0}, {16.5, 67.5},
+		{20.55, 69.06}, {22.4, 68.7}, {25.0, 68.6}, {26.5, 69.9}, {28.9, 69.0}, {30.9, 69.6}, {31.0, 70.3},
+		{25.8, 71.1}, {18.0, 70.0}, {13.0, 67.5}, {10.5, 64.5}, {5.0, 62.0}, {5.0, 59.0}, {7.0, 58.0},
+		{10.5, 59.0}}}},
+	{"Europe/Stockholm", [][][2]float64{{{11.1, 58.9}, {12.5, 61.0}, {12.3, 63.0}, {14.3, 65.0}, {16.5, 67.5},
+		{20.55, 69.06}, {23.7, 68.0}, {24.1, 65.8}, {21.0, 64.0}, {17.5, 62.5}, {18.9, 59.9}, {16.5, 57.0},
+		{14.3, 55.4}, {12.9, 55.4}, {12.7, 56.0}}}},
+	{"Europe/Helsinki", [][][2]float64{{{20.55, 69.06}, {22.4, 68.7}, {25.0, 68.6}, {26.5, 69.9}, {28.9, 69.0},
+		{30.0, 67.7}, {29.0, 66.0}, {30.0, 64.0}, {31.5, 62.9}, {27.8, 60.5}, {25.0, 60.0}, {22.0, 59.8},
+		{21.0, 61.5}, {21.5, 63.5}, {24.1, 65.8}, {23.7, 68.0}}}},
+	{"Europe/Tallinn", [][][2]float64{{{23.4, 59.2}, {28.0, 59.5}, {27.5, 58.0}, {27.4, 57.5}, {25.9, 57.8},
+		{24.3, 57.9}, {23.4, 58.5}}}},
+	{"Europe/Riga", [][][2]float64{{{21.0, 56.1}, {22.0, 56.4}, {24.5, 56.3}, {26.6, 55.7}, {28.2, 56.1},
+		{27.4, 57.5}, {25.9, 57.8}, {24.3, 57.9}, {24.1, 57.0}, {21.7, 57.6}, {21.0, 56.8}}}},
+	{"Europe/Kaliningrad", [][][2]float64{{{19.6, 54.4}, {22.8, 54.4}, {22.6, 55.1}, {21.2, 55.2}, {19.9, 54.9}}}},
+	{"Europe/Vilnius", [][][2]float64{{{21.0, 56.1}, {21.2, 55.2}, {22.6, 55.1}, {22.8, 54.4}, {23.5, 54.2},
+		{25.8, 54.1}, {26.8, 55.1}, {26.6, 55.7}, {24.5, 56.3}, {22.0, 56.4}}}},
+	{"Europe/Minsk", [][][2]float64{{{23.5, 54.2}, {23.9, 53.2}, {23.6, 52.0}, {23.6, 51.5}, {25.0, 51.9},
+		{28.0, 51.6}, {30.6, 51.3}, {31.8, 52.1}, {32.0, 53.0}, {31.0, 54.0}, {31.0, 55.5}, {28.2, 56.1},
+		{26.6, 55.7}, {26.8, 55.1}, {25.8, 54.1}}}},
+	{"Europe/Chisinau", [][][2]float64{{{26.6, 48.3}, {27.5, 48.5}, {29.2, 47.9}, {30.1, 46.4}, {28.2, 45.5},
+		{28.0, 46.9}}}},
+	{"Europe/Kyiv", [][][2]float64{{{22.6, 49.1}, {22.1, 48.4}, {22.9, 47.95}, {24.9, 47.7}, {26.6, 48.3},
+		{27.5, 48.5}, {29.2, 47.9}, {30.1, 46.4}, {28.2, 45.5}, {29.7, 45.2}, {30.7, 46.5}, {33.5, 46.0},
+		{35.0, 46.3}, {38.2, 47.1}, {40.0, 48.0}, {40.1, 49.6}, {38.0, 50.0}, {35.5, 50.4}, {34.4, 51.3},
+		{33.2, 52.4}, {31.8, 52.1}, {30.6, 51.3}, {28.0, 51.6}, {25.0, 51.9}, {23.6, 51.5}, {24.1, 50.6}}}},
+	{"Europe/Bucharest", [][][2]float64{{{22.9, 47.95}, {24.9, 47.7}, {26.6, 48.3}, {28.0, 46.9}, {28.2, 45.5},
+		{29.7, 45.2}, {28.6, 43.75}, {27.0, 44.1}, {24.5, 43.7}, {22.7, 44.0}, {22.4, 44.6}, {21.4, 44.8},
+		{20.3, 46.1}, {21.1, 46.4}, {22.1, 47.6}}}},
+	{"Europe/Sofia", [][][2]float64{{{22.7, 44.0}, {24.5, 43.7}, {27.0, 44.1}, {28.6, 43.75}, {27.9, 42.0},
+		{26.4, 41.8}, {24.0, 41.5}, {22.9, 41.3}, {22.4, 42.3}, {22.9, 43.2}}}},
+	{"Europe/Tirane", [][][2]float64{{{19.4, 41.9}, {20.0, 42.5}, {20.6, 41.9}, {20.5, 41.0}, {21.0, 40.85},
+		{20.0, 39.6}, {19.3, 40.4}}}},
+	{"Europe/Belgrade", [][][2]float64{{{13.6, 45.6}, {13.7, 46.5}, {16.0, 46.7}, {16.6, 46.5}, {17.3, 45.9},
+		{18.8, 45.9}, {20.3, 46.1}, {21.4, 44.8}, {22.4, 44.6}, {22.7, 44.0}, {22.9, 43.2}, {22.4, 42.3},
+		{22.9, 41.3}, {21.0, 40.85}, {20.5, 41.0}, {20.6, 41.9}, {20.0, 42.5}, {19.4, 41.9}, {18.5, 42.4},
+		{16.0, 43.5}, {15.2, 44.3}, {13.6, 45.0}}}},
+	{"Europe/Athens", [][][2]float64{
+		{{20.0, 39.6}, {21.0, 40.85}, {22.9, 41.3}, {24.0, 41.5}, {26.3, 41.7}, {26.6, 41.0}, {25.8, 40.1},
+			{26.6, 38.9}, {27.0, 37.6}, {27.1, 37.0}, {28.3, 36.2}, {24.5, 36.6}, {23.0, 36.4}, {21.7, 36.8},
+			{21.0, 38.3}, {20.2, 39.0}},
+		{{23.5, 35.7}, {26.3, 35.7}, {26.3, 34.9}, {23.5, 34.9}}}},
+	{"Europe/Istanbul", [][][2]float64{{{26.3, 41.7}, {28.0, 42.0}, {31.0, 41.2}, {35.0, 42.1}, {38.0, 41.0},
+		{41.5, 41.5}, {42.8, 41.6}, {43.5, 41.1}, {44.8, 39.7}, {44.8, 37.2}, {42.4, 37.1}, {40.0, 36.8},
+		{38.0, 36.8}, {36.6, 36.8}, {36.2, 35.9}, {35.7, 35.9}, {35.9, 36.5}, {34.0, 36.2}, {32.0, 36.0}, {30.5, 36.2},
+		{28.3, 36.2}, {27.1, 37.0}, {27.0, 37.6}, {26.6, 38.9}, {25.8, 40.1}, {26.6, 41.0}}}},
+	{"Asia/Tbilisi", [][][2]float64{{{40.0, 43.4}, {43.0, 42.8}, {45.0, 42.6}, {46.5, 41.9}, {45.3, 41.2},
+		{43.5, 41.1}, {42.8, 41.6}, {41.5, 41.5}}}},
+	{"Asia/Yerevan", [][][2]float64{{{43.5, 41.1}, {45.3, 41.2}, {45.6, 40.2}, {46.6, 38.9}, {44.8, 39.7}}}},
+	{"Asia/Baku", [][][2]float64{{{45.3, 41.2}, {46.5, 41.9}, {47.8, 41.2}, {48.6, 41.8}, {49.6, 40.4},
+		{48.9, 38.4}, {48.0, 39.2}, {46.6, 38.9}, {45.6, 40.2}}}},
+	{"Europe/Samara", [][][2]float64{
+		{{51.2, 58.5}, {54.5, 58.5}, {54.5, 56.0}, {51.2, 56.0}},
+		{{45.8, 55.0}, {49.0, 54.3}, {52.6, 54.0}, {52.6, 51.8}, {50.8, 51.5}, {50.0, 49.8}, {46.0, 49.8},
+			{42.5, 51.0}, {43.5, 52.8}, {45.8, 53.2}}}},
+	{"Europe/Moscow", [][][2]float64{{{27.8, 60.5}, {31.5, 62.9}, {30.0, 64.0}, {29.0, 66.0}, {30.0, 67.7},
+		{28.9, 69.0}, {30.9, 69.6}, {33.0, 69.4}, {41.0, 67.7}, {44.0, 68.5}, {53.0, 68.3}, {62.0, 69.8},
+		{66.0, 68.0}, {59.5, 64.0}, {59.0, 61.5}, {55.5, 61.5}, {54.0, 60.5}, {54.5, 58.5}, {54.5, 56.0},
+		{53.2, 56.0}, {53.2, 54.2}, {52.6, 54.0}, {52.6, 51.8}, {50.8, 51.5}, {48.8, 50.5}, {47.0, 49.2},
+		{46.7, 48.5}, {47.4, 45.6}, {47.5, 43.0}, {48.6, 41.8}, {47.8, 41.2}, {46.5, 41.9}, {45.0, 42.6},
+		{43.0, 42.8}, {40.0, 43.4}, {37.5, 44.7}, {38.0, 46.0}, {39.3, 47.0}, {38.2, 47.1}, {40.0, 48.0},
+		{40.1, 49.6}, {38.0, 50.0}, {35.5, 50.4}, {34.4, 51.3}, {33.2, 52.4}, {31.8, 52.1}, {32.0, 53.0},
+		{31.0, 54.0}, {31.0, 55.5}, {28.2, 56.1}, {27.4, 57.5}, {27.5, 58.0}, {28.0, 59.5}}}},
+
+	// Middle East
+	{"Asia/Bahrain", [][][2]float64{{{50.3, 26.35}, {50.7, 26.35}, {50.7, 25.8}, {50.3, 25.8}}}},
+	{"Asia/Qatar", [][][2]float64{{{50.7, 26.2}, {51.7, 26.2}, {51.7, 24.5}, {50.7, 24.5}}}},
+	{"Asia/Beirut", [][][2]float64{{{35.1, 34.7}, {36.6, 34.6}, {35.6, 33.1}, {35.1, 33.1}}}},
+	{"Asia/Kuwait", [][][2]float64{{{46.5, 29.1}, {47.7, 30.1}, {48.5, 29.9}, {48.4, 28.5}}}},
+	{"Asia/Jerusalem", [][][2]float64{{{34.2, 31.3}, {34.9, 29.5}, {35.0, 29.5}, {35.5, 31.1}, {35.6, 32.7},
+		{35.6, 33.1}, {35.1, 33.1}}}},
+	{"Asia/Amman", [][][2]float64{{{35.0, 29.5}, {36.1, 29.2}, {37.0, 31.5}, {39.2, 32.2}, {38.8, 33.4},
+		{36.8, 32.3}, {35.6, 32.7}, {35.5, 31.1}}}},
+	{"Asia/Damascus", [][][2]float64{{{35.7, 35.9}, {36.2, 35.9}, {36.6, 36.8}, {38.0, 36.8}, {40.0, 36.8},
+		{42.4, 37.1}, {41.0, 34.4}, {38.8, 33.4}, {36.8, 32.3}, {35.6, 32.7}, {35.6, 33.1}, {36.6, 34.6},
+		{35.8, 34.7}}}},
+	{"Asia/Baghdad", [][][2]float64{{{42.4, 37.1}, {44.8, 37.2}, {45.5, 35.9}, {46.0, 35.0}, {45.5, 34.0},
+		{46.1, 33.0}, {47.7, 32.0}, {48.0, 30.5}, {48.5, 29.9}, {47.7, 30.1}, {46.5, 29.1}, {44.7, 29.2},
+		{42.1, 31.1}, {39.2, 32.2}, {38.8, 33.4}, {41.0, 34.4}}}},
+	{"Asia/Dubai", [][][2]float64{{{51.6, 24.3}, {55.2, 22.7}, {55.9, 24.2}, {56.4, 24.9}, {56.4, 25.7},
+		{55.6, 25.8}, {54.3, 24.6}, {52.0, 24.3}}}},
+	{"Asia/Muscat", [][][2]float64{{{55.2, 22.7}, {55.7, 22.0}, {52.0, 19.0}, {53.1, 16.6}, {55.0, 17.0},
+		{57.8, 18.9}, {59.8, 22.5}, {58.6, 23.6}, {56.4, 24.9}, {55.9, 24.2}}}},
+	{"Asia/Aden", [][][2]float64{{{42.8, 16.4}, {44.2, 17.4}, {47.0, 17.0}, {52.0, 19.0}, {53.1, 16.6},
+		{49.0, 14.0}, {45.0, 12.8}, {43.5, 12.6}, {42.8, 15.0}}}},
+	{"Asia/Riyadh", [][][2]float64{{{36.1, 29.2}, {34.6, 28.1}, {37.0, 25.0}, {39.1, 21.3}, {41.5, 18.0},
+		{42.8, 16.4}, {44.2, 17.4}, {47.0, 17.0}, {52.0, 19.0}, {55.7, 22.0}, {55.2, 22.7}, {51.6, 24.3},
+		{50.8, 24.7}, {50.2, 26.2}, {49.5, 27.0}, {48.4, 28.5}, {46.5, 29.1}, {44.7, 29.2}, {42.1, 31.1},
+		{39.2, 32.2}, {37.0, 31.5}}}},
+	{"Asia/Tehran", [][][2]float64{{{44.8, 39.7}, {46.6, 38.9}, {48.0, 39.2}, {48.9, 38.4}, {50.0, 37.4},
+		{54.0, 36.9}, {54.0, 37.4}, {57.0, 38.2}, {60.5, 36.6}, {61.2, 35.6}, {60.9, 34.3}, {60.6, 33.1},
+		{60.9, 31.4}, {61.8, 30.8}, {62.5, 29.4}, {61.5, 27.0}, {61.6, 25.2}, {57.3, 25.7}, {56.3, 27.1},
+		{54.0, 26.5}, {51.3, 27.9}, {50.1, 30.0}, {48.5, 29.9}, {48.0, 30.5}, {47.7, 32.0}, {46.1, 33.0},
+		{45.5, 34.0}, {46.0, 35.0}, {45.5, 35.9}, {44.8, 37.2}}}},
+
+	// Africa
+	{"Indian/Mauritius", [][][2]float64{{{57.3, -19.9}, {57.8, -19.9}, {57.8, -20.6}, {57.3, -20.6}}}},
+	{"Indian/Reunion", [][][2]float64{{{55.2, -20.8}, {55.9, -20.8}, {55.9, -21.4}, {55.2, -21.4}}}},
+	{"Africa/Banjul", [][][2]float64{{{-16.8, 13.8}, {-13.8, 13.8}, {-13.8, 13.1}, {-16.8, 13.1}}}},
+	{"Africa/Djibouti", [][][2]float64{{{41.8, 12.7}, {43.4, 12.7}, {43.4, 11.0}, {41.8, 11.0}}}},
+	{"Africa/Bissau", [][][2]float64{{{-16.7, 12.3}, {-13.7, 12.3}, {-13.7, 10.9}, {-16.7, 10.9}}}},
+	{"Africa/Malabo", [][][2]float64{
+		{{9.8, 2.3}, {11.3, 2.2}, {11.3, 1.0}, {9.8, 1.0}},
+		{{8.4, 3.8}, {9.0, 3.8}, {9.0, 3.2}, {8.4, 3.2}}}},
+	{"Africa/Kigali", [][][2]float64{{{29.6, -1.4}, {30.5, -1.0}, {30.9, -2.4}, {29.0, -2.8}, {29.2, -1.7}}}},
+	{"Africa/Bujumbura", [][][2]float64{{{29.0, -2.8}, {30.9, -2.4}, {30.8, -3.3}, {30.2, -4.3}, {29.4, -4.5}}}},
+	{"Africa/Lome", [][][2]float64{{{0.0, 11.0}, {0.9, 11.0}, {1.6, 9.0}, {1.8, 6.2}, {1.2, 6.1}, {0.6, 8.0}}}},
+	{"Africa/Porto-Novo", [][][2]float64{{{0.9, 11.0}, {2.4, 12.2}, {3.6, 11.7}, {2.7, 9.0}, {2.7, 6.3},
+		{1.8, 6.2}, {1.6, 9.0}}}},
+	{"Africa/Cairo", [][][2]float64{{{25.0, 31.6}, {25.0, 22.0}, {36.9, 22.0}, {35.5, 24.0}, {33.8, 27.0},
+		{32.6, 29.9}, {34.3, 27.7}, {34.9, 29.5}, {34.2, 31.3}, {32.0, 31.2}, {29.9, 31.3}}}},
+	{"Africa/Tripoli", [][][2]float64{{{11.5, 33.2}, {10.0, 30.2}, {9.5, 26.4}, {11.9, 23.5}, {14.0, 22.5},
+		{15.0, 23.0}, {24.0, 19.5}, {24.0, 20.0}, {25.0, 20.0}, {25.0, 31.6}, {20.0, 32.2}, {19.0, 30.3},
+		{15.5, 31.5}, {13.0, 32.9}}}},
+	{"Africa/Tunis", [][][2]float64{{{8.6, 36.9}, {11.1, 37.1}, {10.5, 36.0}, {11.0, 35.6}, {10.1, 34.3},
+		{11.5, 33.2}, {10.0, 30.2}, {9.0, 32.0}, {8.3, 34.6}, {8.4, 35.9}}}},
+	{"Africa/Algiers", [][][2]float64{{{-1.8, 35.1}, {-1.2, 32.1}, {-3.6, 31.6}, {-8.7, 28.0}, {-8.7, 27.3},
+		{-4.8, 25.0}, {1.2, 21.0}, {4.2, 19.2}, {5.8, 19.4}, {11.9, 23.5}, {9.5, 26.4}, {10.0, 30.2},
+		{9.0, 32.0}, {8.3, 34.6}, {8.4, 35.9}, {8.6, 36.9}, {3.0, 36.9}}}},
+	{"Africa/Casablanca", [][][2]float64{{{-6.0, 35.9}, {-1.8, 35.1}, {-1.2, 32.1}, {-3.6, 31.6}, {-8.7, 28.0},
+		{-8.7, 27.7}, {-13.2, 27.7}, {-10.0, 29.5}, {-9.8, 31.5}, {-8.8, 33.0}, {-7.0, 34.2}}}},
+	{"Africa/El_Aaiun", [][][2]float64{{{-8.7, 27.7}, {-8.7, 26.0}, {-12.0, 26.0}, {-12.0, 23.5}, {-13.0, 21.3},
+		{-17.0, 21.3}, {-14.5, 26.0}, {-13.2, 27.7}}}},
+	{"Africa/Nouakchott", [][][2]float64{{{-17.0, 21.3}, {-13.0, 21.3}, {-12.0, 23.5}, {-12.0, 26.0},
+		{-8.7, 26.0}, {-8.7, 27.3}, {-4.8, 25.0}, {-6.5, 24.9}, {-5.3, 16.4}, {-5.5, 15.5}, {-11.4, 15.6},
+		{-12.2, 14.7}, {-16.5, 16.0}, {-16.1, 18.0}}}},
+	{"Africa/Dakar", [][][2]float64{{{-16.5, 16.0}, {-12.2, 14.7}, {-11.4, 12.4}, {-16.7, 12.3}, {-17.5, 14.7}}}},
+	{"Africa/Bamako", [][][2]float64{{{-11.4, 15.6}, {-5.5, 15.5}, {-5.3, 16.4}, {-6.5, 24.9}, {-4.8, 25.0},
+		{1.2, 21.0}, {4.2, 19.2}, {4.2, 16.4}, {3.5, 15.3}, {1.0, 15.0}, {-0.5, 15.1}, {-2.0, 14.2},
+		{-5.5, 11.2}, {-8.0, 10.1}, {-11.3, 12.3}}}},
+	{"Africa/Conakry", [][][2]float64{{{-13.7, 12.3}, {-11.3, 12.3}, {-8.0, 10.1}, {-8.2, 7.6}, {-10.3, 8.5},
+		{-11.2, 10.0}, {-12.4, 9.9}, {-13.3, 9.1}, {-13.7, 10.9}}}},
+	{"Africa/Freetown", [][][2]float64{{{-13.3, 9.1}, {-12.4, 9.9}, {-11.2, 10.0}, {-10.3, 8.5}, {-11.5, 6.9}}}},
+	{"Africa/Monrovia", [][][2]float64{{{-11.5, 6.9}, {-10.3, 8.5}, {-8.2, 7.6}, {-7.5, 4.4}}}},
+	{"Africa/Abidjan", [][][2]float64{{{-8.2, 7.6}, {-8.0, 10.1}, {-6.5, 10.5}, {-5.5, 10.4}, {-2.7, 9.5},
+		{-3.2, 5.1}, {-7.5, 4.4}}}},
+	{"Africa/Ouagadougou", [][][2]float64{{{-5.5, 11.2}, {-2.0, 14.2}, {-0.5, 15.1}, {1.0, 15.0}, {2.2, 12.7},
+		{0.9, 11.0}, {-2.7, 11.0}, {-2.7, 9.5}, {-5.5, 10.4}}}},
+	{"Africa/Accra", [][][2]float64{{{-3.2, 5.1}, {-2.7, 9.5}, {-2.7, 11.0}, {0.0, 11.0}, {0.6, 8.0}, {1.2, 6.1},
+		{-1.0, 5.0}}}},
+	{"Africa/Lagos", [][][2]float64{{{2.7, 6.3}, {2.7, 9.0}, {3.6, 11.7}, {4.1, 13.5}, {7.8, 13.3}, {10.0, 13.3},
+		{12.5, 13.1}, {14.1, 13.1}, {14.6, 12.2}, {13.6, 10.0}, {12.8, 8.5}, {11.7, 7.0}, {9.8, 6.2},
+		{8.6, 4.6}, {6.1, 4.3}, {4.5, 6.2}}}},
+	{"Africa/Niamey", [][][2]float64{{{1.0, 15.0}, {3.5, 15.3}, {4.2, 16.4}, {4.2, 19.2}, {5.8, 19.4},
+		{11.9, 23.5}, {14.0, 22.5}, {15.5, 20.9}, {15.3, 17.5}, {13.5, 14.4}, {14.1, 13.1}, {12.5, 13.1},
+		{10.0, 13.3}, {7.8, 13.3}, {4.1, 13.5}, {3.6, 11.7}, {2.4, 12.2}, {2.2, 12.7}}}},
+	{"Africa/Ndjamena", [][][2]float64{{{15.0, 23.0}, {24.0, 19.5}, {24.0, 15.7}, {22.4, 14.1}, {22.9, 10.9},
+		{18.9, 8.8}, {15.5, 7.5}, {15.0, 10.0}, {14.6, 12.2}, {14.1, 13.1}, {13.5, 14.4}, {15.3, 17.5},
+		{15.5, 20.9}, {14.0, 22.5}}}},
+	{"Africa/Khartoum", [][][2]float64{{{25.0, 22.0}, {25.0, 20.0}, {24.0, 20.0}, {24.0, 15.7}, {22.4, 14.1},
+		{22.9, 10.9}, {24.0, 8.7}, {27.0, 9.6}, {30.0, 9.8}, {33.0, 10.0}, {34.0, 9.5}, {35.0, 11.5},
+		{36.5, 14.3}, {37.0, 17.0}, {38.6, 18.0}, {37.2, 21.0}, {36.9, 22.0}}}},
+	{"Africa/Juba", [][][2]float64{{{24.0, 8.7}, {27.0, 9.6}, {30.0, 9.8}, {33.0, 10.0}, {34.0, 9.5}, {34.0, 8.6},
+		{33.0, 7.8}, {35.9, 4.6}, {34.0, 4.2}, {31.2, 3.8}, {30.8, 3.6}, {29.0, 4.5}, {27.4, 5.1}, {25.0, 7.0}}}},
+	{"Africa/Asmara", [][][2]float64{{{37.0, 17.0}, {36.5, 14.3}, {37.9, 14.9}, {39.0, 14.6}, {40.2, 14.4},
+		{41.7, 13.1}, {42.4, 12.5}, {43.1, 12.7}, {39.7, 15.8}, {38.6, 18.0}}}},
+	{"Africa/Addis_Ababa", [][][2]float64{{{34.0, 8.6}, {34.0, 9.5}, {35.0, 11.5}, {36.5, 14.3}, {37.9, 14.9},
+		{39.0, 14.6}, {40.2, 14.4}, {41.7, 13.1}, {42.4, 12.5}, {42.8, 11.0}, {44.0, 9.0}, {48.0, 8.0},
+		{45.0, 5.0}, {41.9, 3.9}, {39.5, 3.5}, {35.9, 4.6}, {33.0, 7.8}}}},
+	{"Africa/Mogadishu", [][][2]float64{{{42.8, 11.0}, {44.0, 10.4}, {48.0, 11.2}, {51.3, 11.8}, {51.0, 10.4},
+		{48.0, 5.0}, {45.6, 2.0}, {41.6, -1.7}, {41.0, -0.9}, {41.0, 2.8}, {41.9, 3.9}, {45.0, 5.0},
+		{48.0, 8.0}, {44.0, 9.0}}}},
+	{"Africa/Nairobi", [][][2]float64{{{41.9, 3.9}, {41.0, 2.8}, {41.0, -0.9}, {41.6, -1.7}, {39.2, -4.7},
+		{37.6, -3.0}, {33.9, -1.0}, {34.0, 1.2}, {35.0, 3.0}, {34.0, 4.2}, {35.9, 4.6}, {39.5, 3.5}}}},
+	{"Africa/Kampala", [][][2]float64{{{31.2, 3.8}, {34.0, 4.2}, {35.0, 3.0}, {34.0, 1.2}, {33.9, -1.0},
+		{30.5, -1.0}, {29.6, -1.4}, {29.9, 0.6}, {31.2, 2.2}, {30.8, 3.6}}}},
+	{"Africa/Dar_es_Salaam", [][][2]float64{{{33.9, -1.0}, {37.6, -3.0}, {39.2, -4.7}, {39.5, -6.8},
+		{40.4, -10.5}, {37.5, -11.6}, {34.6, -11.5}, {32.9, -9.4}, {30.8, -8.3}, {30.5, -7.0}, {29.4, -4.5},
+		{30.2, -4.3}, {30.8, -3.3}, {30.9, -2.4}, {30.5, -1.0}}}},
+	{"Africa/Bangui", [][][2]float64{{{15.5, 7.5}, {18.9, 8.8}, {22.9, 10.9}, {24.0, 8.7}, {25.0, 7.0},
+		{27.4, 5.1}, {25.0, 5.2}, {23.0, 4.3}, {22.5, 4.2}, {19.4, 5.1}, {18.6, 3.6}, {16.2, 3.7}, {15.0, 4.0},
+		{14.6, 5.5}}}},
+	{"Africa/Douala", [][][2]float64{{{8.6, 4.6}, {9.8, 6.2}, {11.7, 7.0}, {12.8, 8.5}, {13.6, 10.0},
+		{14.6, 12.2}, {15.0, 10.0}, {15.5, 7.5}, {14.6, 5.5}, {15.0, 4.0}, {16.2, 3.7}, {16.1, 2.2},
+		{14.5, 2.2}, {13.3, 2.2}, {11.3, 2.2}, {9.8, 2.3}, {9.6, 3.8}}}},
+	{"Africa/Libreville", [][][2]float64{{{9.3, 0.4}, {8.7, -0.7}, {11.1, -3.9}, {12.5, -2.5}, {14.3, -1.9},
+		{14.4, -0.2}, {13.3, 2.2}, {11.3, 2.2}, {11.3, 1.0}, {9.8, 1.0}}}},
+	{"Africa/Brazzaville", [][][2]float64{{{11.1, -3.9}, {11.8, -4.8}, {12.0, -5.0}, {13.1, -4.7}, {15.2, -4.35},
+		{16.2, -2.5}, {17.7, -0.6}, {18.5, 2.0}, {18.6, 3.6}, {16.2, 3.7}, {16.1, 2.2}, {14.5, 2.2},
+		{13.3, 2.2}, {14.4, -0.2}, {14.3, -1.9}, {12.5, -2.5}}}},
+	{"Africa/Kinshasa", [][][2]float64{{{12.2, -6.0}, {13.1, -4.7}, {15.2, -4.35}, {16.2, -2.5}, {17.7, -0.6},
+		{18.5, 2.0}, {18.6, 3.6}, {19.4, 5.1}, {22.5, 4.2}, {23.0, 4.3}, {22.0, 1.0}, {20.6, -2.0},
+		{20.0, -6.0}, {19.4, -8.0}, {17.5, -8.1}, {16.6, -7.3}, {16.6, -5.9}, {13.0, -6.0}}}},
+	{"Africa/Lubumbashi", [][][2]float64{{{23.0, 4.3}, {25.0, 5.2}, {27.4, 5.1}, {29.0, 4.5}, {30.8, 3.6},
+		{31.2, 2.2}, {29.9, 0.6}, {29.6, -1.4}, {29.2, -1.7}, {29.0, -2.8}, {29.4, -4.5}, {30.5, -7.0},
+		{30.8, -8.3}, {28.9, -8.5}, {28.4, -9.5}, {28.7, -10.9}, {29.8, -12.2}, {29.5, -13.4}, {27.5, -12.3},
+		{25.3, -11.2}, {24.0, -11.0}, {22.0, -11.0}, {22.0, -9.5}, {21.8, -7.3}, {19.4, -8.0}, {20.0, -6.0},
+		{20.6, -2.0}, {22.0, 1.0}}}},
+	{"Africa/Luanda", [][][2]float64{
+		{{12.2, -6.0}, {13.0, -6.0}, {16.6, -5.9}, {16.6, -7.3}, {17.5, -8.1}, {19.4, -8.0}, {21.8, -7.3},
+			{22.0, -9.5}, {22.0, -11.0}, {24.0, -11.0}, {24.0, -13.0}, {22.0, -13.0}, {22.0, -16.2}, {23.4, -17.6},
+			{20.8, -18.0}, {18.5, -17.4}, {13.9, -17.4}, {11.8, -17.3}, {12.0, -15.0}, {13.5, -12.0}, {13.0, -8.8}},
+		{{12.0, -5.0}, {13.1, -4.7}, {12.2, -5.8}}}},
+	{"Africa/Lusaka", [][][2]float64{{{22.0, -13.0}, {24.0, -13.0}, {24.0, -11.0}, {25.3, -11.2}, {27.5, -12.3},
+		{29.5, -13.4}, {29.8, -12.2}, {28.7, -10.9}, {28.4, -9.5}, {28.9, -8.5}, {30.8, -8.3}, {32.9, -9.4},
+		{33.3, -12.3}, {33.0, -14.0}, {30.4, -15.6}, {28.0, -17.0}, {25.3, -17.8}, {23.4, -17.6}, {22.0, -16.2}}}},
+	{"Africa/Blantyre", [][][2]float64{{{32.9, -9.4}, {34.0, -9.5}, {34.6, -11.5}, {35.3, -14.0}, {35.8, -16.0},
+		{35.2, -17.1}, {34.4, -16.3}, {33.0, -14.0}, {33.3, -12.3}}}},
+	{"Africa/Maputo", [][][2]float64{{{40.4, -10.5}, {37.5, -11.6}, {34.6, -11.5}, {35.3, -14.0}, {35.8, -16.0},
+		{35.2, -17.1}, {34.4, -16.3}, {33.0, -14.0}, {30.4, -15.6}, {32.9, -16.7}, {32.8, -19.0}, {32.5, -21.0},
+		{31.3, -22.4}, {32.0, -24.5}, {32.1, -26.8}, {32.9, -26.8}, {35.5, -24.0}, {35.0, -21.0}, {36.8, -18.5},
+		{40.6, -15.0}}}},
+	{"Africa/Harare", [][][2]float64{{{28.0, -17.0}, {30.4, -15.6}, {32.9, -16.7}, {32.8, -19.0}, {32.5, -21.0},
+		{31.3, -22.4}, {29.4, -22.2}, {28.0, -21.5}, {26.2, -19.5}, {25.3, -17.8}}}},
+	{"Africa/Gaborone", [][][2]float64{{{20.0, -18.3}, {23.4, -18.0}, {25.3, -17.8}, {26.2, -19.5}, {28.0, -21.5},
+		{29.4, -22.2}, {27.0, -24.0}, {25.5, -25.7}, {22.5, -26.0}, {20.8, -26.8}, {20.0, -24.8}, {20.0, -22.0}}}},
+	{"Africa/Windhoek", [][][2]float64{{{11.8, -17.3}, {13.9, -17.4}, {18.5, -17.4}, {20.8, -18.0}, {23.4, -17.6},
+		{25.3, -17.8}, {23.4, -18.0}, {20.0, -18.3}, {20.0, -22.0}, {20.0, -24.8}, {20.0, -28.4}, {16.5, -28.6},
+		{14.5, -22.9}}}},
+	{"Africa/Johannesburg", [][][2]float64{{{16.5, -28.6}, {20.0, -28.4}, {20.0, -24.8}, {20.8, -26.8},
+		{22.5, -26.0}, {25.5, -25.7}, {27.0, -24.0}, {29.4, -22.2}, {31.3, -22.4}, {32.0, -24.5}, {32.1, -26.8},
+		{32.9, -26.8}, {32.4, -28.6}, {30.0, -31.3}, {27.0, -33.6}, {22.0, -34.3}, {18.4, -34.4}, {18.0, -32.5}}}},
+	{"Indian/Antananarivo", [][][2]float64{{{49.3, -12.0}, {50.5, -15.5}, {47.1, -25.1}, {44.0, -25.0},
+		{43.3, -22.0}, {44.4, -16.2}, {47.0, -13.6}}}},
+
+	// Asia
+	{"Asia/Hong_Kong", [][][2]float64{{{113.8, 22.5}, {114.5, 22.5}, {114.5, 22.1}, {113.8, 22.1}}}},
+	{"Asia/Macau", [][][2]float64{{{113.5, 22.22}, {113.6, 22.22}, {113.6, 22.1}, {113.5, 22.1}}}},
+	{"Asia/Singapore", [][][2]float64{{{103.6, 1.47}, {104.1, 1.47}, {104.1, 1.15}, {103.6, 1.15}}}},
+	{"Asia/Brunei", [][][2]float64{{{114.1, 5.1}, {115.4, 5.1}, {115.4, 4.0}, {114.1, 4.0}}}},
+	{"Asia/Dili", [][][2]float64{{{124.9, -8.1}, {127.3, -8.1}, {127.3, -9.5}, {124.9, -9.5}}}},
+	{"Asia/Colombo", [][][2]float64{{{79.7, 9.9}, {80.3, 9.8}, {81.9, 7.0}, {81.0, 5.9}, {80.0, 5.9}, {79.7, 7.5}}}},
+	{"Asia/Thimphu", [][][2]float64{{{88.9, 27.3}, {89.5, 28.1}, {91.7, 27.9}, {92.1, 26.8}, {89.8, 26.7}}}},
+	{"Asia/Kathmandu", [][][2]float64{{{80.1, 28.8}, {81.3, 30.1}, {84.0, 29.3}, {88.1, 27.9}, {88.1, 26.4},
+		{85.5, 26.8}, {83.3, 27.3}}}},
+	{"Asia/Dhaka", [][][2]float64{{{89.0, 21.8}, {88.9, 23.0}, {88.6, 24.3}, {88.1, 24.5}, {88.4, 25.2},
+		{88.2, 26.4}, {89.8, 26.2}, {89.8, 25.3}, {92.2, 25.1}, {91.4, 24.1}, {92.3, 23.7}, {92.2, 21.0},
+		{91.6, 22.4}, {90.5, 21.8}}}},
+	{"Asia/Taipei", [][][2]float64{{{120.1, 23.0}, {121.0, 25.2}, {121.6, 25.4}, {122.1, 25.0}, {120.8, 21.8}}}},
+	{"Asia/Sakhalin", [][][2]float64{{{141.6, 45.9}, {142.4, 45.9}, {143.6, 49.3}, {143.3, 53.0}, {142.6, 54.4},
+		{141.6, 53.0}, {142.0, 49.0}}}},
+	{"Asia/Pyongyang", [][][2]float64{{{124.4, 40.0}, {126.0, 41.4}, {129.0, 42.4}, {130.7, 42.3}, {129.8, 41.0},
+		{128.0, 39.5}, {128.3, 38.6}, {126.7, 37.8}, {125.0, 37.7}, {124.6, 38.5}}}},
+	{"Asia/Seoul", [][][2]float64{{{126.7, 37.8}, {128.3, 38.6}, {129.5, 36.5}, {129.5, 35.4}, {129.2, 35.0},
+		{126.5, 34.3}, {126.1, 35.2}, {126.4, 37.4}}}},
+	{"Asia/Tokyo", [][][2]float64{
+		{{139.8, 42.5}, {141.5, 45.5}, {145.8, 43.4}, {143.2, 42.0}, {140.9, 41.7}, {140.0, 41.4}},
+		{{140.0, 41.3}, {141.4, 41.4}, {141.6, 40.4}, {142.1, 39.5}, {140.9, 37.5}, {141.0, 36.0}, {140.9, 35.6},
+			{140.0, 35.0}, {139.0, 34.6}, {137.0, 34.5}, {136.8, 33.5}, {135.7, 33.4}, {135.1, 34.3}, {134.0, 34.5},
+			{132.0, 33.8}, {131.0, 34.0}, {131.0, 34.5}, {133.0, 35.5}, {135.8, 35.6}, {136.7, 37.3}, {138.5, 37.5},
+			{139.5, 38.3}, {140.0, 40.0}},
+		{{132.4, 34.0}, {134.6, 34.2}, {134.7, 33.8}, {133.6, 33.4}, {132.5, 32.8}},
+		{{129.8, 33.5}, {131.0, 33.9}, {132.0, 33.0}, {131.3, 31.3}, {130.2, 31.0}, {129.7, 32.7}},
+		{{127.6, 26.9}, {128.4, 26.9}, {128.4, 26.0}, {127.6, 26.0}}}},
+	{"Asia/Hovd", [][][2]float64{{{87.3, 49.1}, {89.7, 50.4}, {92.0, 50.6}, {97.0, 49.8}, {98.3, 50.5},
+		{98.0, 47.0}, {99.0, 44.8}, {97.5, 42.7}, {95.5, 44.3}, {90.9, 45.3}}}},
+	{"Asia/Ulaanbaatar", [][][2]float64{{{87.3, 49.1}, {89.7, 50.4}, {92.0, 50.6}, {97.0, 49.8}, {98.3, 50.5},
+		{102.0, 50.3}, {106.0, 50.3}, {108.0, 49.5}, {114.5, 50.2}, {116.7, 49.8}, {119.7, 46.7}, {116.0, 45.0},
+		{112.0, 43.7}, {110.0, 42.6}, {105.0, 41.6}, {101.0, 42.5}, {97.5, 42.7}, {95.5, 44.3}, {90.9, 45.3}}}},
+	{"Asia/Urumqi", [][][2]float64{{{74.9, 37.2}, {75.0, 38.5}, {73.7, 39.4}, {76.0, 40.4}, {80.2, 42.0},
+		{79.2, 42.8}, {80.3, 45.0}, {82.5, 45.4}, {83.0, 47.2}, {85.5, 47.1}, {87.3, 49.1}, {90.9, 45.3},
+		{95.5, 44.3}, {96.4, 42.7}, {93.0, 40.0}, {90.5, 38.5}, {90.5, 36.0}, {80.0, 35.6}, {77.8, 35.5}}}},
+	{"Asia/Shanghai", [][][2]float64{
+		{{74.9, 37.2}, {75.0, 38.5}, {73.7, 39.4}, {76.0, 40.4}, {80.2, 42.0}, {79.2, 42.8}, {80.3, 45.0},
+			{82.5, 45.4}, {83.0, 47.2}, {85.5, 47.1}, {87.3, 49.1}, {90.9, 45.3}, {95.5, 44.3}, {97.5, 42.7},
+			{101.0, 42.5}, {105.0, 41.6}, {110.0, 42.6}, {112.0, 43.7}, {116.0, 45.0}, {119.7, 46.7}, {116.7, 49.8},
+			{119.5, 50.4}, {121.0, 53.3}, {123.0, 53.5}, {127.5, 49.8}, {130.5, 48.9}, {132.0, 47.7}, {134.7, 48.3},
+			{134.0, 47.3}, {133.0, 45.0}, {132.0, 45.0}, {131.0, 44.0}, {131.2, 42.6}, {130.7, 42.3}, {129.0, 42.4},
+			{126.0, 41.4}, {124.4, 40.0}, {122.0, 40.5}, {119.8, 39.8}, {117.9, 38.8}, {118.9, 37.5}, {122.5, 37.0},
+			{120.3, 36.0}, {119.2, 35.0}, {120.8, 32.0}, {122.0, 31.0}, {121.9, 30.0}, {120.0, 26.6}, {119.8, 26.0},
+			{118.5, 24.3}, {116.5, 22.9}, {114.4, 22.4}, {113.5, 22.0}, {111.0, 21.5}, {110.3, 20.3}, {109.7, 21.5},
+			{108.0, 21.5}, {106.7, 22.9}, {104.0, 22.8}, {102.2, 22.4}, {101.7, 22.4}, {100.1, 21.4}, {99.5, 22.1},
+			{98.0, 24.1}, {98.7, 27.5}, {97.3, 28.2}, {92.0, 27.9}, {91.7, 27.9}, {89.5, 28.1}, {88.9, 27.3},
+			{88.1, 27.9}, {84.0, 29.3}, {81.3, 30.1}, {80.2, 30.3}, {78.7, 31.0}, {79.5, 32.5}, {77.8, 35.5}},
+		{{108.6, 19.2}, {109.2, 20.1}, {111.0, 19.7}, {110.0, 18.2}}}},
+	{"Asia/Manila", [][][2]float64{{{120.0, 18.6}, {122.3, 18.6}, {122.2, 16.2}, {124.3, 12.5}, {126.5, 9.5},
+		{126.6, 7.0}, {125.5, 5.6}, {122.0, 6.9}, {123.0, 8.6}, {121.0, 10.5}, {117.2, 8.3}, {119.7, 11.5},
+		{120.5, 14.0}, {119.8, 16.3}}}},
+	{"Asia/Kuala_Lumpur", [][][2]float64{{{100.1, 6.5}, {101.0, 5.8}, {102.1, 6.2}, {103.4, 4.5}, {104.3, 1.5},
+		{103.4, 1.3}, {101.3, 2.8}, {100.3, 4.9}}}},
+	{"Asia/Kuching", [][][2]float64{{{109.6, 1.9}, {111.0, 1.0}, {114.5, 1.5}, {115.5, 3.5}, {116.0, 4.2},
+		{117.6, 4.2}, {119.3, 5.2}, {116.8, 7.0}, {115.2, 5.0}, {113.9, 4.5}, {111.2, 2.5}}}},
+	{"Asia/Pontianak", [][][2]float64{{{109.6, 1.9}, {111.0, 1.0}, {114.5, 1.5}, {114.8, 0.0}, {114.5, -3.4},
+		{111.7, -3.5}, {110.2, -3.0}, {110.1, -1.7}, {109.0, 0.0}, {109.0, 1.5}}}},
+	{"Asia/Jakarta", [][][2]float64{
+		{{95.2, 5.6}, {97.5, 5.2}, {100.4, 2.2}, {103.5, 1.0}, {104.5, -1.0}, {106.0, -3.2}, {105.8, -5.9},
+			{104.5, -5.9}, {101.0, -2.8}, {100.0, -0.9}, {98.7, 1.7}, {96.0, 4.0}},
+		{{105.2, -6.8}, {106.0, -5.9}, {106.8, -5.9}, {110.4, -6.8}, {112.8, -6.9}, {114.4, -7.7}, {114.5, -8.7},
+			{110.0, -8.2}, {106.4, -7.4}, {105.3, -6.9}}}},
+	{"Asia/Makassar", [][][2]float64{
+		{{114.5, 1.5}, {115.5, 3.5}, {116.0, 4.2}, {117.6, 4.2}, {118.0, 1.0}, {117.4, -0.5}, {117.0, -1.3},
+			{116.5, -3.0}, {116.0, -4.0}, {114.5, -3.4}, {114.8, 0.0}},
+		{{118.8, -2.6}, {119.7, 0.0}, {120.5, 0.9}, {124.5, 1.7}, {125.3, 1.7}, {124.9, 1.0}, {121.0, 0.4},
+			{120.3, -1.0}, {123.5, -0.8}, {121.5, -1.9}, {123.0, -4.5}, {122.0, -5.6}, {121.0, -3.0}, {120.4, -5.6},
+			{119.3, -5.6}, {119.3, -3.5}},
+		{{114.6, -8.0}, {119.0, -8.0}, {119.0, -9.1}, {114.6, -9.1}},
+		{{119.8, -8.0}, {123.0, -8.0}, {125.0, -9.0}, {124.0, -10.4}, {123.2, -10.4}, {119.8, -9.0}}}},
+	{"Asia/Jayapura", [][][2]float64{{{126.0, 2.5}, {129.0, 2.5}, {131.0, 0.0}, {134.0, -0.6}, {135.5, -3.0},
+		{137.5, -1.3}, {141.0, -2.6}, {141.0, -9.1}, {139.0, -8.1}, {137.6, -5.4}, {134.5, -6.5}, {131.0, -8.0},
+		{127.0, -4.0}, {125.8, -1.5}}}},
+	{"Asia/Phnom_Penh", [][][2]float64{{{102.3, 13.6}, {105.0, 14.3}, {106.0, 13.9}, {107.6, 14.5}, {107.6, 12.5},
+		{106.0, 11.5}, {105.0, 10.5}, {104.4, 10.4}, {103.0, 11.0}, {102.6, 12.2}}}},
+	{"Asia/Vientiane", [][][2]float64{{{100.1, 21.4}, {101.7, 22.4}, {102.2, 22.4}, {103.0, 21.7}, {104.2, 20.5},
+		{104.5, 19.5}, {105.2, 18.5}, {106.6, 17.3}, {107.6, 15.6}, {107.6, 14.5}, {106.0, 13.9}, {105.0, 14.3},
+		{105.6, 15.7}, {104.8, 17.4}, {102.0, 17.9}, {100.9, 17.5}, {101.2, 19.5}, {100.1, 20.4}}}},
+	{"Asia/Ho_Chi_Minh", [][][2]float64{{{102.2, 22.4}, {104.0, 22.8}, {106.7, 22.9}, {108.0, 21.5}, {106.5, 20.4},
+		{105.8, 19.0}, {106.5, 17.5}, {108.2, 16.1}, {109.3, 13.5}, {109.2, 11.6}, {107.0, 10.4}, {105.0, 8.6},
+		{104.8, 9.0}, {104.4, 10.4}, {105.0, 10.5}, {106.0, 11.5}, {107.6, 12.5}, {107.6, 14.5}, {107.6, 15.6},
+		{106.6, 17.3}, {105.2, 18.5}, {104.5, 19.5}, {104.2, 20.5}, {103.0, 21.7}}}},
+	{"Asia/Bangkok", [][][2]float64{{{97.4, 18.5}, {98.0, 19.7}, {100.1, 20.4}, {101.2, 19.5}, {100.9, 17.5},
+		{102.0, 17.9}, {104.8, 17.4}, {105.6, 15.7}, {105.0, 14.3}, {102.3, 13.6}, {102.6, 12.2}, {101.0, 12.6},
+		{100.9, 13.4}, {100.2, 13.4}, {99.9, 12.0}, {99.2, 10.0}, {100.2, 8.4}, {102.1, 6.2}, {101.0, 5.8},
+		{100.1, 6.5}, {98.3, 7.8}, {98.3, 9.0}, {98.5, 10.0}, {99.6, 10.3}, {98.2, 15.0}, {98.9, 16.4}}}},
+	{"Asia/Yangon", [][][2]float64{{{92.6, 21.5}, {93.3, 22.0}, {94.5, 24.0}, {95.0, 26.6}, {97.3, 28.2},
+		{98.7, 27.5}, {98.0, 24.1}, {99.5, 22.1}, {100.1, 21.4}, {100.1, 20.4}, {98.0, 19.7}, {97.4, 18.5},
+		{98.9, 16.4}, {98.2, 15.0}, {99.6, 10.3}, {98.5, 10.0}, {98.0, 13.0}, {97.6, 16.5}, {94.2, 16.0},
+		{94.5, 19.0}, {92.6, 20.5}}}},
+	{"Asia/Kolkata", [][][2]float64{{{68.8, 24.0}, {70.5, 25.7}, {69.5, 26.5}, {71.0, 28.0}, {73.9, 30.0},
+		{74.6, 31.0}, {74.6, 32.5}, {74.0, 34.0}, {75.0, 34.5}, {77.8, 35.5}, {79.5, 32.5}, {78.7, 31.0},
+		{80.2, 30.3}, {81.3, 30.1}, {84.0, 29.3}, {88.1, 27.9}, {88.9, 27.3}, {89.5, 28.1}, {91.7, 27.9},
+		{92.0, 27.9}, {97.3, 28.2}, {95.0, 26.6}, {94.5, 24.0}, {93.3, 22.0}, {92.6, 21.5}, {89.0, 21.6},
+		{87.0, 21.0}, {86.5, 20.0}, {84.8, 19.2}, {82.3, 16.6}, {80.3, 15.5}, {80.5, 13.1}, {79.8, 10.3},
+		{78.2, 8.1}, {77.5, 8.0}, {76.0, 10.0}, {74.8, 12.9}, {73.5, 16.0}, {72.6, 19.0}, {72.6, 21.2},
+		{72.5, 22.3}, {70.0, 22.0}, {69.0, 22.4}}}},
+	{"Asia/Karachi", [][][2]float64{{{61.6, 25.2}, {61.5, 27.0}, {62.5, 29.4}, {66.5, 29.9}, {69.5, 31.6},
+		{69.3, 33.9}, {71.0, 34.5}, {71.2, 36.0}, {71.5, 36.8}, {74.9, 37.2}, {77.8, 35.5}, {75.0, 34.5},
+		{74.0, 34.0}, {74.6, 32.5}, {74.6, 31.0}, {73.9, 30.0}, {71.0, 28.0}, {69.5, 26.5}, {70.5, 25.7},
+		{68.8, 24.0}, {67.0, 24.5}, {66.5, 25.4}, {64.0, 25.2}}}},
+	{"Asia/Kabul", [][][2]float64{{{61.2, 35.6}, {62.5, 35.3}, {64.8, 37.1}, {66.5, 37.4}, {67.8, 37.2},
+		{70.0, 37.5}, {71.5, 36.8}, {71.2, 36.0}, {71.0, 34.5}, {69.3, 33.9}, {69.5, 31.6}, {66.5, 29.9},
+		{62.5, 29.4}, {61.8, 30.8}, {60.9, 31.4}, {60.6, 33.1}, {60.9, 34.3}}}},
+	{"Asia/Dushanbe", [][][2]float64{{{67.8, 37.2}, {68.4, 38.2}, {68.0, 39.4}, {70.5, 39.6}, {73.7, 39.4},
+		{75.0, 38.5}, {74.9, 37.2}, {71.5, 36.8}, {70.0, 37.5}}}},
+	{"Asia/Bishkek", [][][2]float64{{{70.5, 42.0}, {71.2, 42.8}, {74.0, 43.2}, {76.0, 43.0}, {79.2, 42.8},
+		{80.2, 42.0}, {76.0, 40.4}, {73.7, 39.4}, {70.5, 39.6}, {73.2, 40.8}, {71.8, 41.5}}}},
+	{"Asia/Ashgabat", [][][2]float64{{{52.8, 42.0}, {54.0, 42.3}, {56.0, 41.3}, {58.5, 42.3}, {60.0, 42.0},
+		{61.5, 41.2}, {64.0, 39.5}, {66.5, 37.4}, {64.8, 37.1}, {62.5, 35.3}, {61.2, 35.6}, {60.5, 36.6},
+		{57.0, 38.2}, {54.0, 37.4}, {53.9, 38.9}, {53.0, 40.0}, {52.8, 41.0}}}},
+	{"Asia/Tashkent", [][][2]float64{{{56.0, 45.0}, {56.0, 41.3}, {58.5, 42.3}, {60.0, 42.0}, {61.5, 41.2},
+		{64.0, 39.5}, {66.5, 37.4}, {67.8, 37.2}, {68.4, 38.2}, {68.0, 39.4}, {70.5, 39.6}, {73.2, 40.8},
+		{71.8, 41.5}, {70.5, 42.0}, {69.0, 41.7}, {68.0, 41.0}, {66.0, 42.0}, {66.0, 42.9}, {64.9, 43.7},
+		{62.0, 43.5}, {61.0, 44.4}, {58.6, 45.5}}}},
+	{"Asia/Almaty", [][][2]float64{{{50.8, 51.5}, {48.8, 50.5}, {47.0, 49.2}, {46.7, 48.5}, {49.0, 46.4},
+		{51.0, 47.0}, {53.0, 46.8}, {53.0, 45.3}, {51.3, 44.5}, {51.0, 43.2}, {52.8, 42.0}, {54.0, 42.3},
+		{56.0, 41.3}, {56.0, 45.0}, {58.6, 45.5}, {61.0, 44.4}, {62.0, 43.5}, {64.9, 43.7}, {66.0, 42.9},
+		{66.0, 42.0}, {68.0, 41.0}, {69.0, 41.7}, {70.5, 42.0}, {71.2, 42.8}, {74.0, 43.2}, {76.0, 43.0},
+		{79.2, 42.8}, {80.3, 45.0}, {82.5, 45.4}, {83.0, 47.2}, {85.5, 47.1}, {87.3, 49.1}, {83.0, 51.0},
+		{81.0, 50.8}, {78.0, 53.0}, {76.0, 54.3}, {73.5, 54.0}, {70.5, 55.2}, {69.0, 55.4}, {65.0, 54.6},
+		{61.0, 53.9}, {61.5, 52.0}, {61.0, 50.8}, {55.0, 50.6}}}},
+	{"Asia/Yekaterinburg", [][][2]float64{{{62.0, 69.8}, {66.0, 68.0}, {59.5, 64.0}, {59.0, 61.5}, {55.5, 61.5},
+		{54.0, 60.5}, {54.5, 58.5}, {54.5, 56.0}, {53.2, 56.0}, {53.2, 54.2}, {52.6, 54.0}, {52.6, 51.8},
+		{50.8, 51.5}, {55.0, 50.6}, {61.0, 50.8}, {61.5, 52.0}, {61.0, 53.9}, {65.0, 54.6}, {69.0, 55.4},
+		{70.5, 55.2}, {71.0, 58.5}, {75.5, 58.5}, {77.0, 60.5}, {86.0, 61.5}, {84.0, 65.0}, {85.5, 70.0},
+		{80.0, 73.5}, {70.0, 73.5}, {66.0, 69.5}}}},
+	{"Asia/Omsk", [][][2]float64{{{70.5, 55.2}, {73.5, 54.0}, {76.0, 54.3}, {75.8, 56.5}, {75.5, 58.5},
+		{71.0, 58.5}}}},
+	{"Asia/Novosibirsk", [][][2]float64{{{75.8, 56.5}, {76.0, 54.3}, {78.0, 53.0}, {82.0, 53.5}, {84.0, 54.5},
+		{85.0, 55.5}, {81.0, 57.2}}}},
+	{"Asia/Krasnoyarsk", [][][2]float64{{{75.5, 58.5}, {75.8, 56.5}, {81.0, 57.2}, {85.0, 55.5}, {84.0, 54.5},
+		{82.0, 53.5}, {78.0, 53.0}, {81.0, 50.8}, {83.0, 51.0}, {87.3, 49.1}, {89.7, 50.4}, {92.0, 50.6},
+		{97.0, 49.8}, {98.3, 50.5}, {98.5, 52.0}, {97.2, 55.0}, {100.0, 58.0}, {104.0, 60.0}, {106.0, 62.0},
+		{108.0, 65.0}, {112.0, 70.0}, {112.0, 73.5}, {104.0, 77.8}, {97.0, 76.0}, {87.0, 74.0}, {80.0, 73.5},
+		{85.5, 70.0}, {84.0, 65.0}, {86.0, 61.5}, {77.0, 60.5}}}},
+	{"Asia/Irkutsk", [][][2]float64{{{98.3, 50.5}, {102.0, 50.3}, {106.0, 50.3}, {108.0, 49.5}, {110.0, 51.5},
+		{112.0, 54.5}, {116.0, 56.5}, {114.0, 58.5}, {109.0, 61.0}, {106.0, 62.0}, {104.0, 60.0}, {100.0, 58.0},
+		{97.2, 55.0}, {98.5, 52.0}}}},
+	{"Asia/Chita", [][][2]float64{{{108.0, 49.5}, {114.5, 50.2}, {116.7, 49.8}, {119.5, 50.4}, {121.0, 53.3},
+		{120.0, 56.0}, {116.0, 56.5}, {112.0, 54.5}, {110.0, 51.5}}}},
+	{"Asia/Yakutsk", [][][2]float64{{{106.0, 62.0}, {109.0, 61.0}, {114.0, 58.5}, {116.0, 56.5}, {120.0, 56.0},
+		{121.0, 53.3}, {123.0, 53.5}, {127.5, 49.8}, {130.5, 48.9}, {132.0, 50.0}, {134.0, 52.0}, {134.5, 57.0},
+		{135.0, 60.0}, {138.0, 63.5}, {140.0, 70.0}, {140.0, 73.0}, {130.0, 71.5}, {113.0, 73.5}, {112.0, 73.5},
+		{112.0, 70.0}, {108.0, 65.0}}}},
+	{"Asia/Vladivostok", [][][2]float64{{{130.5, 48.9}, {132.0, 47.7}, {134.7, 48.3}, {134.0, 47.3}, {133.0, 45.0},
+		{132.0, 45.0}, {131.0, 44.0}, {131.2, 42.6}, {130.7, 42.3}, {132.3, 42.8}, {135.0, 43.5}, {138.0, 46.0},
+		{140.5, 48.5}, {141.5, 52.0}, {141.0, 53.5}, {137.5, 54.0}, {140.0, 57.0}, {143.0, 59.3}, {147.0, 60.0},
+		{147.0, 62.0}, {150.0, 64.0}, {150.0, 72.0}, {140.0, 73.0}, {140.0, 70.0}, {138.0, 63.5}, {135.0, 60.0},
+		{134.5, 57.0}, {134.0, 52.0}, {132.0, 50.0}}}},
+	{"Asia/Srednekolymsk", [][][2]float64{{{150.0, 64.0}, {152.0, 64.5}, {156.0, 64.0}, {160.0, 66.0},
+		{161.0, 69.6}, {150.0, 72.0}}}},
+	{"Asia/Magadan", [][][2]float64{{{143.0, 59.3}, {151.0, 59.3}, {155.0, 60.0}, {160.0, 61.5}, {160.0, 64.0},
+		{160.0, 66.0}, {156.0, 64.0}, {152.0, 64.5}, {150.0, 64.0}, {147.0, 62.0}, {147.0, 60.0}}}},
+	{"Asia/Kamchatka", [][][2]float64{{{155.0, 60.0}, {156.0, 57.5}, {156.5, 51.0}, {158.7, 52.8}, {162.0, 55.0},
+		{163.0, 57.8}, {170.0, 60.0}, {174.0, 61.8}, {165.0, 62.5}, {160.0, 61.5}}}},
+	{"Asia/Anadyr", [][][2]float64{
+		{{161.0, 69.6}, {160.0, 66.0}, {160.0, 64.0}, {160.0, 61.5}, {165.0, 62.5}, {174.0, 61.8}, {179.0, 62.5},
+			{180.0, 65.0}, {180.0, 69.0}, {170.0, 70.0}},
+		{{-180.0, 65.0}, {-172.0, 64.3}, {-169.6, 66.0}, {-172.0, 67.0}, {-180.0, 69.0}}}},
+
+	// Oceania
+	{"Pacific/Guam", [][][2]float64{{{144.6, 13.7}, {145.0, 13.7}, {145.0, 13.2}, {144.6, 13.2}}}},
+	{"Pacific/Port_Moresby", [][][2]float64{{{141.0, -2.6}, {144.5, -3.8}, {147.5, -5.9}, {148.0, -8.0},
+		{150.5, -10.6}, {148.0, -10.3}, {147.0, -9.7}, {146.0, -8.2}, {143.5, -9.0}, {141.0, -9.1}}}},
+	{"Pacific/Noumea", [][][2]float64{{{163.9, -20.0}, {165.0, -20.5}, {167.2, -22.4}, {166.3, -22.5}}}},
+	{"Pacific/Fiji", [][][2]float64{{{177.2, -17.3}, {178.8, -17.0}, {179.0, -18.3}, {177.2, -18.3}}}},
+	{"Pacific/Tongatapu", [][][2]float64{{{-175.4, -21.0}, {-175.0, -21.0}, {-175.0, -21.3}, {-175.4, -21.3}}}},
+	{"Pacific/Apia", [][][2]float64{{{-172.8, -13.4}, {-171.4, -13.4}, {-171.4, -14.1}, {-172.8, -14.1}}}},
+	{"Pacific/Tahiti", [][][2]float64{{{-149.7, -17.4}, {-149.1, -17.4}, {-149.1, -17.9}, {-149.7, -17.9}}}},
+	{"Australia/Lord_Howe", [][][2]float64{{{159.0, -31.5}, {159.2, -31.5}, {159.2, -31.6}, {159.0, -31.6}}}},
+	{"Australia/Broken_Hill", [][][2]float64{{{141.0, -31.0}, {142.0, -31.0}, {142.0, -32.5}, {141.0, -32.5}}}},
+	{"Australia/Hobart", [][][2]float64{{{144.6, -40.6}, {148.4, -40.8}, {148.0, -43.0}, {146.8, -43.7},
+		{145.2, -42.2}}}},
+	{"Australia/Perth", [][][2]float64{{{129.0, -14.9}, {129.0, -31.7}, {124.0, -33.0}, {118.0, -35.1},
+		{115.0, -34.4}, {115.6, -33.5}, {115.6, -32.0}, {114.9, -29.0}, {113.2, -26.0}, {113.7, -22.0},
+		{116.7, -20.6}, {121.0, -19.5}, {122.2, -17.0}, {125.0, -14.5}, {127.0, -13.8}}}},
+	{"Australia/Darwin", [][][2]float64{{{129.0, -14.9}, {130.4, -12.2}, {132.0, -11.2}, {136.9, -12.2},
+		{135.6, -14.8}, {138.0, -16.6}, {138.0, -26.0}, {129.0, -26.0}}}},
+	{"Australia/Adelaide", [][][2]float64{{{129.0, -26.0}, {141.0, -26.0}, {141.0, -38.1}, {139.7, -37.2},
+		{138.9, -35.6}, {137.8, -35.7}, {138.2, -34.2}, {136.0, -35.0}, {135.2, -34.7}, {134.0, -32.7},
+		{131.2, -31.5}, {129.0, -31.7}}}},
+	{"Australia/Brisbane", [][][2]float64{{{138.0, -16.6}, {139.3, -17.3}, {140.9, -17.4}, {141.6, -12.7},
+		{142.5, -10.7}, {143.6, -14.0}, {145.4, -14.8}, {146.2, -18.9}, {149.2, -21.1}, {153.2, -25.0},
+		{153.6, -28.2}, {150.0, -28.6}, {141.0, -29.0}, {141.0, -26.0}, {138.0, -26.0}}}},
+	{"Australia/Sydney", [][][2]float64{{{141.0, -29.0}, {150.0, -28.6}, {153.6, -28.2}, {153.0, -31.0},
+		{151.5, -33.1}, {151.3, -34.0}, {150.0, -37.5}, {148.2, -36.8}, {141.0, -34.0}}}},
+	{"Australia/Melbourne", [][][2]float64{{{141.0, -34.0}, {148.2, -36.8}, {150.0, -37.5}, {146.3, -39.1},
+		{144.6, -38.3}, {143.5, -38.8}, {141.0, -38.1}}}},
+	{"Pacific/Auckland", [][][2]float64{
+		{{172.6, -34.4}, {174.8, -36.0}, {178.5, -37.7}, {176.8, -39.7}, {174.9, -41.6}, {174.6, -41.1},
+			{173.8, -39.2}, {174.3, -37.7}},
+		{{172.7, -40.5}, {174.3, -41.7}, {173.2, -43.3}, {171.2, -44.5}, {170.0, -46.4}, {166.5, -46.2},
+			{168.1, -44.0}, {171.3, -41.7}}}},
+}
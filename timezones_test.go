@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneAt(t *testing.T) {
+	for _, c := range []struct {
+		city     string
+		lat, lon float64
+		zone     string
+	}{
+		{"New York", 40.71, -74.01, "America/New_York"},
+		{"Chicago", 41.88, -87.63, "America/Chicago"},
+		{"Denver", 39.74, -104.99, "America/Denver"},
+		{"Phoenix", 33.45, -112.07, "America/Phoenix"},
+		{"Los Angeles", 34.05, -118.24, "America/Los_Angeles"},
+		{"Mexico City", 19.43, -99.13, "America/Mexico_City"},
+		{"Bogota", 4.71, -74.07, "America/Bogota"},
+		{"Sao Paulo", -23.55, -46.63, "America/Sao_Paulo"},
+		{"Buenos Aires", -34.60, -58.38, "America/Argentina/Buenos_Aires"},
+		{"London", 51.51, -0.13, "Europe/London"},
+		{"Paris", 48.86, 2.35, "Europe/Paris"},
+		{"Berlin", 52.52, 13.40, "Europe/Berlin"},
+		{"Kyiv", 50.45, 30.52, "Europe/Kyiv"},
+		{"Moscow", 55.76, 37.62, "Europe/Moscow"},
+		{"Istanbul", 41.01, 28.98, "Europe/Istanbul"},
+		{"Cairo", 30.04, 31.24, "Africa/Cairo"},
+		{"Lagos", 6.52, 3.38, "Africa/Lagos"},
+		{"Kinshasa", -4.33, 15.31, "Africa/Kinshasa"},
+		{"Nairobi", -1.29, 36.82, "Africa/Nairobi"},
+		{"Johannesburg", -26.20, 28.05, "Africa/Johannesburg"},
+		{"Casablanca", 33.57, -7.59, "Africa/Casablanca"},
+		{"Tehran", 35.69, 51.39, "Asia/Tehran"},
+		{"Riyadh", 24.71, 46.68, "Asia/Riyadh"},
+		{"Dubai", 25.20, 55.27, "Asia/Dubai"},
+		{"Karachi", 24.86, 67.01, "Asia/Karachi"},
+		{"Mumbai", 19.08, 72.88, "Asia/Kolkata"},
+		{"Kathmandu", 27.72, 85.32, "Asia/Kathmandu"},
+		{"Almaty", 43.24, 76.89, "Asia/Almaty"},
+		{"Tashkent", 41.30, 69.24, "Asia/Tashkent"},
+		{"Yekaterinburg", 56.84, 60.61, "Asia/Yekaterinburg"},
+		{"Novosibirsk", 55.03, 82.92, "Asia/Novosibirsk"},
+		{"Vladivostok", 43.12, 131.89, "Asia/Vladivostok"},
+		{"Bangkok", 13.76, 100.50, "Asia/Bangkok"},
+		{"Singapore", 1.29, 103.85, "Asia/Singapore"},
+		{"Jakarta", -6.21, 106.85, "Asia/Jakarta"},
+		{"Shanghai", 31.23, 121.47, "Asia/Shanghai"},
+		{"Hong Kong", 22.32, 114.17, "Asia/Hong_Kong"},
+		{"Seoul", 37.57, 126.98, "Asia/Seoul"},
+		{"Tokyo", 35.69, 139.69, "Asia/Tokyo"},
+		{"Perth", -31.95, 115.86, "Australia/Perth"},
+		{"Adelaide", -34.93, 138.60, "Australia/Adelaide"},
+		{"Sydney", -33.87, 151.21, "Australia/Sydney"},
+		{"Auckland", -36.85, 174.76, "Pacific/Auckland"},
+	} {
+		if zone := zoneAt(c.lon, c.lat); zone != c.zone {
+			t.Errorf("%s is in %q, expected %s", c.city, zone, c.zone)
+		}
+	}
+
+	for _, sea := range [][2]float64{{-30, 30}, {-150, 0}, {70, -30}, {160, 20}} {
+		if zone := zoneAt(sea[0], sea[1]); zone != "" {
+			t.Errorf("%g,%g at sea is in %s", sea[1], sea[0], zone)
+		}
+	}
+}
+
+func TestTimeZoneNames(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, zone := range timeZoneRings {
+		if seen[zone.Name] {
+			t.Errorf("%s is listed twice", zone.Name)
+		}
+		seen[zone.Name] = true
+		if _, err := time.LoadLocation(zone.Name); err != nil {
+			t.Errorf("%s: %s", zone.Name, err)
+		}
+		for _, ring := range zone.Rings {
+			if err := checkRing(ring); err != nil {
+				t.Errorf("%s: %s", zone.Name, err)
+			}
+		}
+	}
+}
+
+func TestZoneAnnotation(t *testing.T) {
+	winter := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		name string
+		res  IPInfoResult
+		want string
+	}{
+		{"polygon", IPInfoResult{"loc": "48.86,2.35"}, "Europe/Paris UTC+01:00"},
+		{"same clock", IPInfoResult{"loc": "48.86,2.35", "timezone": "Europe/Brussels"},
+			"Europe/Brussels UTC+01:00"},
+		{"other clock", IPInfoResult{"loc": "48.86,2.35", "timezone": "Europe/London"},
+			"Europe/Paris UTC+01:00 (result: Europe/London)"},
+		{"at sea", IPInfoResult{"loc": "30,-30"}, "band UTC-02:00"},
+		{"given only", IPInfoResult{"loc": "30,-30", "timezone": "Atlantic/Azores"},
+			"Atlantic/Azores UTC-01:00"},
+		{"unlocated", IPInfoResult{"timezone": "Asia/Tokyo"}, "Asia/Tokyo UTC+09:00"},
+		{"nothing", IPInfoResult{}, ""},
+	} {
+		if label := zoneAnnotation(c.res, winter); label != c.want {
+			t.Errorf("%s: %q, expected %q", c.name, label, c.want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Tor exit relays, from the Onionoo service of the Tor Project. The list is
+cached and fetched again once it is older than torMaxAge. With -tor, targets
+that are exit relays are flagged under "tor":
+
+	tor.exit      true or false
+	tor.nickname  nickname of the relay
+
+The "Tor exits" layer (t) plots every running exit relay, and the tor mode
+shows them alone.
+*/
+
+const (
+	torExitsURL = "https://onionoo.torproject.org/details?flag=Exit&running=true" +
+		"&fields=nickname,or_addresses,exit_addresses,country,latitude,longitude"
+	torMaxAge = time.Hour
+)
+
+var torFlag = flag.Bool("tor", false, "Flag the IP Address if it is a Tor exit relay")
+
+/*
+TorRelay - A Tor exit relay
+*/
+type TorRelay struct {
+	Nickname      string   `json:"nickname"`
+	ORAddresses   []string `json:"or_addresses"`
+	ExitAddresses []string `json:"exit_addresses"`
+	Country       string   `json:"country"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+}
+
+/*
+TorExits - The exit relays, by IP Address
+*/
+type TorExits struct {
+	Relays  []*TorRelay `json:"relays"`
+	Updated time.Time   `json:"-"`
+
+	byIP map[string]*TorRelay
+}
+
+var torExits struct {
+	sync.Mutex
+	exits *TorExits // nil until loaded
+}
+
+func torExitsPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip411", "tor-exits.json")
+}
+
+/*
+parseTorExits - Read an Onionoo details document
+*/
+func parseTorExits(data []byte) (*TorExits, error) {
+	exits := &TorExits{byIP: make(map[string]*TorRelay)}
+	if err := json.Unmarshal(data, exits); err != nil {
+		return nil, err
+	}
+	for _, relay := range exits.Relays {
+		addrs := append(append([]string{}, relay.ORAddresses...), relay.ExitAddresses...)
+		for _, addr := range addrs {
+			// OR addresses come with their port, IPv6 ones in brackets
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			if ip := net.ParseIP(addr); ip != nil {
+				exits.byIP[ip.String()] = relay
+			}
+		}
+	}
+	return exits, nil
+}
+
+/*
+loadTorExits - The exit relays, from the cache if it is recent enough, else
+fetched again. A stale cache is used if the fetch fails.
+*/
+func loadTorExits() (*TorExits, error) {
+	path := torExitsPath()
+	var cached *TorExits
+	if info, err := os.Stat(path); err == nil {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			if cached, err = parseTorExits(data); err == nil {
+				cached.Updated = info.ModTime()
+				if time.Since(info.ModTime()) < torMaxAge {
+					return cached, nil
+				}
+			}
+		}
+	}
+
+	var fetched *TorExits
+	err := download(torExitsURL, path, func(data []byte) error {
+		var err error
+		fetched, err = parseTorExits(data)
+		if err == nil && len(fetched.Relays) == 0 {
+			err = fmt.Errorf("No exit relays")
+		}
+		return err
+	})
+	if err != nil {
+		if cached != nil {
+			warnf("Could not update the Tor exits, using the list of %s: %s",
+				cached.Updated.Format("2006-01-02 15:04"), err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	fetched.Updated = time.Now()
+	return fetched, nil
+}
+
+/*
+updateTorExits - Load the exit relays into torExits
+*/
+func updateTorExits() (*TorExits, error) {
+	exits, err := loadTorExits()
+	if err == nil {
+		torExits.Lock()
+		torExits.exits = exits
+		torExits.Unlock()
+	}
+	return exits, err
+}
+
+/*
+addTor - Flag res under "tor" if its IP Address is an exit relay. Returns the
+relay, nil if it is not one.
+*/
+func addTor(res IPInfoResult) (*TorRelay, error) {
+	torExits.Lock()
+	exits := torExits.exits
+	torExits.Unlock()
+	if exits == nil || time.Since(exits.Updated) >= torMaxAge {
+		var err error
+		if exits, err = updateTorExits(); err != nil {
+			return nil, err
+		}
+	}
+	relay := exits.byIP[fieldValue(res, "ip")]
+	tor := map[string]interface{}{"exit": relay != nil}
+	if relay != nil {
+		tor["nickname"] = relay.Nickname
+	}
+	res["tor"] = tor
+	return relay, nil
+}
+
+/*
+torStatus - Flag res, returning what the status bar should warn about ("" if
+nothing)
+*/
+func torStatus(res IPInfoResult) string {
+	relay, err := addTor(res)
+	if err != nil {
+		return fmt.Sprintf("Tor exit list unavailable: %s", err)
+	}
+	if relay != nil {
+		return fmt.Sprintf("Tor exit relay %s", relay.Nickname)
+	}
+	return ""
+}
+
+// Layer
+
+/*
+loadTorLayer - Fetch the exit relays for the layer, unless they are loaded
+*/
+func loadTorLayer() error {
+	torExits.Lock()
+	loaded := torExits.exits != nil
+	torExits.Unlock()
+	if loaded {
+		return nil
+	}
+	_, err := updateTorExits()
+	return err
+}
+
+func drawTorExits(mc *MapCanvas) {
+	torExits.Lock()
+	exits := torExits.exits
+	torExits.Unlock()
+	if exits == nil {
+		return
+	}
+	for _, relay := range exits.Relays {
+		if relay.Latitude != nil && relay.Longitude != nil {
+			mc.Plot(*relay.Longitude, *relay.Latitude)
+		}
+	}
+}
+
+// Mode
+
+/*
+summary - Number of exit relays, by country for the most common ones
+*/
+func (exits *TorExits) summary() string {
+	results := make([]IPInfoResult, len(exits.Relays))
+	for i, relay := range exits.Relays {
+		results[i] = IPInfoResult{"country": strings.ToUpper(relay.Country)}
+	}
+	rows := countBy(results, func(res IPInfoResult) string {
+		return fieldValue(res, "country")
+	}, false)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tor exit relays: %d (as of %s)\n", len(exits.Relays),
+		exits.Updated.Format("2006-01-02 15:04"))
+	for i, row := range rows {
+		if i == 10 {
+			fmt.Fprintf(&b, "%d more countries\n", len(rows)-i)
+			break
+		}
+		fmt.Fprintf(&b, "%s: %d (%.1f%%)\n", row.Key, row.Count, row.Percent)
+	}
+	return b.String()
+}
+
+/*
+torHelp - What the usage message of tor says after the synopsis
+*/
+const torHelp = `Plot every running Tor exit relay, refreshing the list hourly.
+`
+
+func runTor(args []string) error {
+	flags := flag.NewFlagSet("tor", flag.ExitOnError)
+	addOutputFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s tor\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(torHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Expected none.")
+	}
+	exits, err := updateTorExits()
+	if err != nil {
+		return withExitCode(exitLookupFailed, err)
+	}
+	for _, layer := range layers {
+		if layer.Key == 't' {
+			layer.Enabled = true
+		}
+	}
+
+	show := func(g *Gui, exits *TorExits) {
+		g.Execute(func(g *Gui) error {
+			view, err := g.View("info")
+			if err != nil {
+				return err
+			}
+			view.Clear()
+			fmt.Fprint(view, exits.summary())
+			return redrawMap(g)
+		})
+	}
+	return runGui(func(gui *Gui) error {
+		guiLoadStatus(gui)
+		show(gui, exits)
+		go func() {
+			for range time.Tick(torMaxAge) {
+				exits, err := updateTorExits()
+				if err != nil {
+					guiShowStatus(gui, "Could not update the Tor exit relays: %s", err)
+					continue
+				}
+				show(gui, exits)
+			}
+		}()
+		return nil
+	})
+}
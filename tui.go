@@ -0,0 +1,870 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+/*
+The interface is made of named views (map, info, status, the prompts and
+lists opened on top) laid out by a layout function and drawn by a Gui, the
+only part of ip411 that knows the terminal library (tcell). Its model is the
+one of gocui, which it replaced: views are created by SetView (answering
+ErrUnknownView the first time so that they can be set up), written with
+fmt.Fprint (ANSI colors included), and updated from other goroutines only
+through Execute, which runs a function in the main loop.
+
+Views remember the rows they last drew, so that rewriting one (Clear, then
+fmt.Fprint again, as watch and the live modes do on every result) only
+draws the rows that changed: slow terminals and SSH sessions do not flicker
+with screens drawn again from blank. The whole screen is drawn again when
+views are placed, moved or removed, on resize, and when a change would draw
+over a view on top of it.
+
+Text is laid out in columns, not runes: CJK and most emoji take two, as
+go-runewidth measures them (with the table tcell draws with), and combining
+marks none, kept with the rune they follow. A wide rune that does not fit
+at the end of a row is not drawn, its column left blank.
+
+Key bindings apply to one view, or to all with "": every binding of a key
+runs, rune bindings excepted while an editable view (a prompt) has the focus
+and takes the text typed.
+*/
+
+var (
+	// ErrQuit - Returned by a binding to leave the main loop
+	ErrQuit = errors.New("quit")
+	// ErrUnknownView - The view does not exist (yet, for SetView)
+	ErrUnknownView = errors.New("unknown view")
+)
+
+/*
+Attribute - A color of the views, ColorDefault for the terminal's own
+*/
+type Attribute int
+
+const (
+	ColorDefault Attribute = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+)
+
+func (a Attribute) color() tcell.Color {
+	if a == ColorDefault {
+		return tcell.ColorDefault
+	}
+	return tcell.PaletteColor(int(a - ColorBlack))
+}
+
+/*
+Key - A key without a character of its own. Keys with one are bound by
+rune ('f'), space excepted.
+*/
+type Key int
+
+const (
+	KeyUnknown Key = iota
+	KeyArrowUp
+	KeyArrowDown
+	KeyArrowLeft
+	KeyArrowRight
+	KeyEnter
+	KeyEsc
+	KeyTab
+	KeySpace
+	KeyBackspace
+	KeyDelete
+	KeyHome
+	KeyEnd
+	KeyPgup
+	KeyPgdn
+	KeyCtrlC
+	KeyCtrlR
+)
+
+var tcellKeys = map[tcell.Key]Key{
+	tcell.KeyUp:         KeyArrowUp,
+	tcell.KeyDown:       KeyArrowDown,
+	tcell.KeyLeft:       KeyArrowLeft,
+	tcell.KeyRight:      KeyArrowRight,
+	tcell.KeyEnter:      KeyEnter,
+	tcell.KeyEscape:     KeyEsc,
+	tcell.KeyTab:        KeyTab,
+	tcell.KeyBackspace:  KeyBackspace,
+	tcell.KeyBackspace2: KeyBackspace,
+	tcell.KeyDelete:     KeyDelete,
+	tcell.KeyHome:       KeyHome,
+	tcell.KeyEnd:        KeyEnd,
+	tcell.KeyPgUp:       KeyPgup,
+	tcell.KeyPgDn:       KeyPgdn,
+	tcell.KeyCtrlC:      KeyCtrlC,
+	tcell.KeyCtrlR:      KeyCtrlR,
+}
+
+/*
+Modifier - A key held with the key bound
+*/
+type Modifier int
+
+const (
+	ModNone Modifier = iota
+	ModAlt
+)
+
+/*
+KeybindingHandler - What a key binding does, given the focused view (nil if
+none)
+*/
+type KeybindingHandler func(*Gui, *View) error
+
+type keybinding struct {
+	view    string
+	key     Key
+	ch      rune
+	mod     Modifier
+	handler KeybindingHandler
+}
+
+/*
+Gui - The views of the terminal and their key bindings
+*/
+type Gui struct {
+	screen      tcell.Screen
+	views       []*View // in drawing order, the last on top
+	current     *View
+	keybindings []*keybinding
+	layout      func(*Gui) error
+	mu          sync.Mutex
+	tasks       []func(*Gui) error // of Execute in their order, protected by mu
+	wake        chan struct{}      // signaled when tasks are added
+	full        bool               // draw every view again, from a blank screen
+}
+
+/*
+NewGui - Create the interface, shown by Init
+*/
+func NewGui() *Gui {
+	return &Gui{wake: make(chan struct{}, 1), full: true}
+}
+
+/*
+Init - Take over the terminal
+*/
+func (g *Gui) Init() error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	g.screen = screen
+	return nil
+}
+
+/*
+Close - Give the terminal back
+*/
+func (g *Gui) Close() {
+	if g.screen != nil {
+		g.screen.Fini()
+	}
+}
+
+/*
+Bell - Ring the bell of the terminal
+*/
+func (g *Gui) Bell() {
+	g.screen.Beep()
+}
+
+/*
+Size - Columns and rows of the terminal
+*/
+func (g *Gui) Size() (int, int) {
+	return g.screen.Size()
+}
+
+/*
+SetLayout - Set the function placing the views, run before every redraw
+*/
+func (g *Gui) SetLayout(layout func(*Gui) error) {
+	g.layout = layout
+}
+
+/*
+SetView - Place the view name with its frame at x0,y0 and x1,y1. A view
+created by the call comes with ErrUnknownView.
+*/
+func (g *Gui) SetView(name string, x0, y0, x1, y1 int) (*View, error) {
+	if x0 >= x1 || y0 >= y1 {
+		return nil, fmt.Errorf("Invalid dimensions of view '%s'", name)
+	}
+	if v, err := g.View(name); err == nil {
+		if v.x0 != x0 || v.y0 != y0 || v.x1 != x1 || v.y1 != y1 {
+			v.x0, v.y0, v.x1, v.y1 = x0, y0, x1, y1
+			g.full = true
+		}
+		return v, nil
+	}
+	v := &View{name: name, x0: x0, y0: y0, x1: x1, y1: y1, Frame: true}
+	g.views = append(g.views, v)
+	g.full = true
+	return v, ErrUnknownView
+}
+
+/*
+View - The view name
+*/
+func (g *Gui) View(name string) (*View, error) {
+	for _, v := range g.views {
+		if v.name == name {
+			return v, nil
+		}
+	}
+	return nil, ErrUnknownView
+}
+
+/*
+ViewPosition - Where the view name is
+*/
+func (g *Gui) ViewPosition(name string) (x0, y0, x1, y1 int, err error) {
+	v, err := g.View(name)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return v.x0, v.y0, v.x1, v.y1, nil
+}
+
+/*
+DeleteView - Remove the view name
+*/
+func (g *Gui) DeleteView(name string) error {
+	for i, v := range g.views {
+		if v.name == name {
+			g.views = append(g.views[:i], g.views[i+1:]...)
+			if g.current == v {
+				g.current = nil
+			}
+			g.full = true
+			return nil
+		}
+	}
+	return ErrUnknownView
+}
+
+/*
+SetCurrentView - Give the focus to the view name
+*/
+func (g *Gui) SetCurrentView(name string) error {
+	v, err := g.View(name)
+	if err != nil {
+		return err
+	}
+	g.current = v
+	return nil
+}
+
+/*
+SetKeybinding - Run handler on key, a Key or a rune, in the view named view
+or in all views if view is ""
+*/
+func (g *Gui) SetKeybinding(view string, key interface{}, mod Modifier, handler KeybindingHandler) error {
+	kb := &keybinding{view: view, mod: mod, handler: handler}
+	switch k := key.(type) {
+	case Key:
+		kb.key = k
+	case rune:
+		kb.ch = k
+	default:
+		return fmt.Errorf("Invalid key %v", key)
+	}
+	g.keybindings = append(g.keybindings, kb)
+	return nil
+}
+
+/*
+Execute - Run f in the main loop, where views may be changed. The functions
+run in the order they were given, without blocking the caller.
+*/
+func (g *Gui) Execute(f func(*Gui) error) {
+	g.mu.Lock()
+	g.tasks = append(g.tasks, f)
+	g.mu.Unlock()
+	select {
+	case g.wake <- struct{}{}:
+	default: // the loop has been woken already
+	}
+}
+
+/*
+runTasks - Run the functions given to Execute until none is left or one
+returns an error
+*/
+func (g *Gui) runTasks() error {
+	for {
+		g.mu.Lock()
+		if len(g.tasks) == 0 {
+			g.mu.Unlock()
+			return nil
+		}
+		task := g.tasks[0]
+		g.tasks[0] = nil
+		g.tasks = g.tasks[1:]
+		g.mu.Unlock()
+		if err := task(g); err != nil {
+			return err
+		}
+	}
+}
+
+/*
+MainLoop - Draw the views and handle the keys until a binding returns an
+error, ErrQuit to leave
+*/
+func (g *Gui) MainLoop() error {
+	events := make(chan tcell.Event, 16)
+	quit := make(chan struct{})
+	defer close(quit) // stops the events of the screen
+	go g.screen.ChannelEvents(events, quit)
+
+	for {
+		if err := g.flush(); err != nil {
+			return err
+		}
+		var err error
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil // the screen is finalized
+				continue
+			}
+			err = g.handleEvent(ev)
+		case <-g.wake:
+		}
+		// Run what is pending before drawing again
+		if err == nil {
+			err = g.runTasks()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (g *Gui) handleEvent(ev tcell.Event) error {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		g.screen.Sync()
+		g.full = true
+	case *tcell.EventKey:
+		key, ch := tcellKeys[ev.Key()], rune(0)
+		if ev.Key() == tcell.KeyRune {
+			if ch = ev.Rune(); ch == ' ' {
+				key, ch = KeySpace, 0
+			}
+		}
+		mod := ModNone
+		if ev.Modifiers()&tcell.ModAlt != 0 {
+			mod = ModAlt
+		}
+		return g.onKey(key, ch, mod)
+	}
+	return nil
+}
+
+/*
+onKey - Edit the focused view if editable, then run the bindings of the key
+*/
+func (g *Gui) onKey(key Key, ch rune, mod Modifier) error {
+	v := g.current
+	if v != nil && v.Editable && mod == ModNone {
+		v.edit(key, ch)
+	}
+	for _, kb := range g.keybindings {
+		if kb.key != key || kb.ch != ch || kb.mod != mod {
+			continue
+		}
+		if v != nil && v.Editable && kb.ch != 0 {
+			continue
+		}
+		if v != nil && v.Modal && kb.view == "" && key != KeyCtrlC {
+			continue
+		}
+		if kb.view != "" && (v == nil || kb.view != v.name) {
+			continue
+		}
+		if err := kb.handler(g, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Characters of the frames, and of the frames of consoles without Unicode
+var (
+	frameRunes      = [6]rune{'─', '│', '┌', '┐', '└', '┘'}
+	asciiFrameRunes = [6]rune{'-', '|', '+', '+', '+', '+'}
+)
+
+/*
+flush - Lay the views out and draw what changed since the last time
+*/
+func (g *Gui) flush() error {
+	if g.layout != nil {
+		if err := g.layout(g); err != nil {
+			return err
+		}
+	}
+	rows := make([][][]viewCell, len(g.views))
+	for i, v := range g.views {
+		rows[i] = v.render()
+		// A frame removed is only erased by drawing everything again
+		if v.shownFrame && !v.Frame {
+			g.full = true
+		}
+	}
+	if g.full || g.overdrawn(rows) {
+		g.screen.Clear()
+		g.full = true
+	}
+	for i, v := range g.views {
+		g.draw(v, rows[i])
+	}
+	g.full = false
+	if v := g.current; v != nil && v.Editable {
+		g.screen.ShowCursor(v.x0+1+v.cursorColumn(), v.y0+1+v.cy)
+	} else {
+		g.screen.HideCursor()
+	}
+	g.screen.Show()
+	return nil
+}
+
+/*
+overdrawn - Whether drawing the changes of a view, its rows being rows,
+would draw over a view on top of it
+*/
+func (g *Gui) overdrawn(rows [][][]viewCell) bool {
+	for i, v := range g.views {
+		for _, above := range g.views[i+1:] {
+			x0, y0, x1, y1 := above.extent()
+			if x0 > v.x1 || x1 < v.x0 || y0 > v.y1 || y1 < v.y0 {
+				continue
+			}
+			if v.Frame != v.shownFrame || v.Title != v.shownTitle {
+				return true
+			}
+			for y, row := range rows[i] {
+				if sy := v.y0 + 1 + y; sy < y0 || sy > y1 {
+					continue
+				}
+				if y >= len(v.shown) || !sameCells(row, v.shown[y]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func sameCells(a, b []viewCell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+draw - Draw the frame of v if it changed and those of its rows that differ
+from the ones shown, everything if the screen is drawn again
+*/
+func (g *Gui) draw(v *View, rows [][]viewCell) {
+	if v.Frame && (g.full || v.Title != v.shownTitle || !v.shownFrame) {
+		runes := frameRunes
+		if asciiMap {
+			runes = asciiFrameRunes
+		}
+		for x := v.x0 + 1; x < v.x1; x++ {
+			g.screen.SetContent(x, v.y0, runes[0], nil, tcell.StyleDefault)
+			g.screen.SetContent(x, v.y1, runes[0], nil, tcell.StyleDefault)
+		}
+		for y := v.y0 + 1; y < v.y1; y++ {
+			g.screen.SetContent(v.x0, y, runes[1], nil, tcell.StyleDefault)
+			g.screen.SetContent(v.x1, y, runes[1], nil, tcell.StyleDefault)
+		}
+		g.screen.SetContent(v.x0, v.y0, runes[2], nil, tcell.StyleDefault)
+		g.screen.SetContent(v.x1, v.y0, runes[3], nil, tcell.StyleDefault)
+		g.screen.SetContent(v.x0, v.y1, runes[4], nil, tcell.StyleDefault)
+		g.screen.SetContent(v.x1, v.y1, runes[5], nil, tcell.StyleDefault)
+		x := v.x0 + 2
+		for _, r := range v.Title {
+			w := runeColumns(r)
+			if x+w > v.x1 {
+				break
+			}
+			g.screen.SetContent(x, v.y0, r, nil, tcell.StyleDefault)
+			x += w
+		}
+	}
+	v.shownFrame, v.shownTitle = v.Frame, v.Title
+
+	for y, row := range rows {
+		if !g.full && y < len(v.shown) && sameCells(row, v.shown[y]) {
+			continue
+		}
+		for x, c := range row {
+			// The second column of a wide rune is drawn with it
+			if c.ch != 0 {
+				g.screen.SetContent(v.x0+1+x, v.y0+1+y, c.ch, []rune(c.comb), c.style)
+			}
+		}
+	}
+	v.shown = rows
+}
+
+/*
+View - A framed area of text
+*/
+type View struct {
+	Title      string
+	Frame      bool
+	Editable   bool // takes the text typed while focused
+	Highlight  bool // shows the line of the cursor in SelFgColor on SelBgColor
+	Modal      bool // while focused, only its own bindings (and C+c) run
+	SelFgColor Attribute
+	SelBgColor Attribute
+
+	name           string
+	x0, y0, x1, y1 int
+	ox, oy         int // origin of the text shown
+	cx, cy         int // cursor, relative to the origin, in runes
+	lines          [][]viewCell
+	style          tcell.Style // of the text written next
+	pending        []byte      // start of an escape sequence or a rune
+
+	// As last drawn, for flush to draw only what changed
+	shown      [][]viewCell
+	shownFrame bool
+	shownTitle string
+}
+
+/*
+viewCell - A rune and the combining marks following it. In the rows drawn,
+a rune two columns wide is followed by a cell whose ch is 0.
+*/
+type viewCell struct {
+	ch    rune
+	comb  string
+	style tcell.Style
+}
+
+/*
+runeColumns - The columns r takes on the screen, 1 for the runes
+go-runewidth gives no width (they are drawn anyway)
+*/
+func runeColumns(r rune) int {
+	if runewidth.RuneWidth(r) == 2 {
+		return 2
+	}
+	return 1
+}
+
+/*
+cellColumns - The columns a run of cells takes on the screen
+*/
+func cellColumns(cells []viewCell) int {
+	n := 0
+	for _, c := range cells {
+		n += runeColumns(c.ch)
+	}
+	return n
+}
+
+/*
+Name - The name of the view
+*/
+func (v *View) Name() string {
+	return v.name
+}
+
+/*
+Size - Columns and rows of text within the frame
+*/
+func (v *View) Size() (int, int) {
+	return v.x1 - v.x0 - 1, v.y1 - v.y0 - 1
+}
+
+/*
+extent - The cells the view draws, its frame included if it has one
+*/
+func (v *View) extent() (x0, y0, x1, y1 int) {
+	if v.Frame {
+		return v.x0, v.y0, v.x1, v.y1
+	}
+	return v.x0 + 1, v.y0 + 1, v.x1 - 1, v.y1 - 1
+}
+
+/*
+render - The rows of text within the frame as they are to be drawn, a cell a
+column, from the origin and with the line of the cursor highlighted
+*/
+func (v *View) render() [][]viewCell {
+	width, height := v.Size()
+	selected := tcell.StyleDefault.Foreground(v.SelFgColor.color()).Background(v.SelBgColor.color())
+	rows := make([][]viewCell, height)
+	for y := range rows {
+		var line []viewCell
+		if v.oy+y < len(v.lines) {
+			line = v.lines[v.oy+y]
+		}
+		row := make([]viewCell, 0, width)
+		for i := v.ox; i < len(line); i++ {
+			c := line[i]
+			if runeColumns(c.ch) == 1 {
+				row = append(row, c)
+			} else if len(row)+2 <= width {
+				row = append(row, c, viewCell{style: c.style})
+			} else {
+				break
+			}
+			if len(row) >= width {
+				break
+			}
+		}
+		for len(row) < width {
+			row = append(row, viewCell{ch: ' ', style: tcell.StyleDefault})
+		}
+		if v.Highlight && y == v.cy {
+			for x := range row {
+				row[x].style = selected
+			}
+		}
+		rows[y] = row
+	}
+	return rows
+}
+
+/*
+cursorColumn - The column of the cursor within the frame, the wide runes
+before it counted twice
+*/
+func (v *View) cursorColumn() int {
+	var line []viewCell
+	if y := v.oy + v.cy; y < len(v.lines) {
+		line = v.lines[y]
+	}
+	if v.ox >= len(line) {
+		return v.cx
+	}
+	end := v.ox + v.cx
+	if end > len(line) {
+		return cellColumns(line[v.ox:]) + end - len(line)
+	}
+	return cellColumns(line[v.ox:end])
+}
+
+/*
+SetCursor - Move the cursor, relative to the origin
+*/
+func (v *View) SetCursor(x, y int) error {
+	if x < 0 || y < 0 {
+		return fmt.Errorf("Invalid cursor position")
+	}
+	v.cx, v.cy = x, y
+	return nil
+}
+
+/*
+Cursor - The cursor, relative to the origin
+*/
+func (v *View) Cursor() (int, int) {
+	return v.cx, v.cy
+}
+
+/*
+SetOrigin - Scroll the text so that x,y is at the top left
+*/
+func (v *View) SetOrigin(x, y int) error {
+	if x < 0 || y < 0 {
+		return fmt.Errorf("Invalid origin")
+	}
+	v.ox, v.oy = x, y
+	return nil
+}
+
+/*
+Origin - The position of the text at the top left
+*/
+func (v *View) Origin() (int, int) {
+	return v.ox, v.oy
+}
+
+/*
+Clear - Erase the text
+*/
+func (v *View) Clear() {
+	v.lines = nil
+	v.style = tcell.StyleDefault
+	v.pending = nil
+}
+
+/*
+Buffer - The text, without its colors, each line ending with a newline
+*/
+func (v *View) Buffer() string {
+	var text strings.Builder
+	for _, line := range v.lines {
+		for _, c := range line {
+			text.WriteRune(c.ch)
+			text.WriteString(c.comb)
+		}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+/*
+Write - Append p to the text, applying the ANSI colors it sets
+(\x1b[31m...)
+*/
+func (v *View) Write(p []byte) (int, error) {
+	data := append(v.pending, p...)
+	v.pending = nil
+	if len(v.lines) == 0 {
+		v.lines = [][]viewCell{nil}
+	}
+	for len(data) > 0 {
+		if data[0] == '\x1b' {
+			end := strings.IndexFunc(string(data), func(r rune) bool {
+				return r >= '@' && r <= '~' && r != '['
+			})
+			if end < 0 {
+				v.pending = append([]byte{}, data...)
+				break
+			}
+			if data[end] == 'm' && len(data) > 1 && data[1] == '[' {
+				v.setStyle(string(data[2:end]))
+			}
+			data = data[end+1:]
+			continue
+		}
+		if !utf8.FullRune(data) {
+			v.pending = append([]byte{}, data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch r {
+		case '\n':
+			v.lines = append(v.lines, nil)
+		case '\r':
+		default:
+			last := len(v.lines) - 1
+			line := v.lines[last]
+			if runewidth.RuneWidth(r) == 0 && r >= ' ' && len(line) > 0 {
+				line[len(line)-1].comb += string(r)
+				continue
+			}
+			v.lines[last] = append(line, viewCell{ch: r, style: v.style})
+		}
+	}
+	return len(p), nil
+}
+
+/*
+setStyle - Apply the parameters of an SGR sequence: reset, bold, and the 8
+(and bright) foreground and background colors
+*/
+func (v *View) setStyle(params string) {
+	for _, param := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(param)
+		if err != nil && param != "" {
+			continue
+		}
+		switch {
+		case n == 0:
+			v.style = tcell.StyleDefault
+		case n == 1:
+			v.style = v.style.Bold(true)
+		case n >= 30 && n <= 37:
+			v.style = v.style.Foreground(tcell.PaletteColor(n - 30))
+		case n == 39:
+			v.style = v.style.Foreground(tcell.ColorDefault)
+		case n >= 40 && n <= 47:
+			v.style = v.style.Background(tcell.PaletteColor(n - 40))
+		case n == 49:
+			v.style = v.style.Background(tcell.ColorDefault)
+		case n >= 90 && n <= 97:
+			v.style = v.style.Foreground(tcell.PaletteColor(n - 90 + 8))
+		}
+	}
+}
+
+/*
+edit - Apply a key typed in an editable view to its first line
+*/
+func (v *View) edit(key Key, ch rune) {
+	if len(v.lines) == 0 {
+		v.lines = [][]viewCell{nil}
+	}
+	y := v.oy + v.cy
+	for y >= len(v.lines) {
+		v.lines = append(v.lines, nil)
+	}
+	line := v.lines[y]
+	x := v.ox + v.cx
+	if x > len(line) {
+		x = len(line)
+	}
+	switch {
+	case ch != 0 || key == KeySpace:
+		if key == KeySpace {
+			ch = ' '
+		}
+		line = append(line[:x], append([]viewCell{{ch: ch, style: tcell.StyleDefault}}, line[x:]...)...)
+		x++
+	case key == KeyBackspace && x > 0:
+		line = append(line[:x-1], line[x:]...)
+		x--
+	case key == KeyDelete && x < len(line):
+		line = append(line[:x], line[x+1:]...)
+	case key == KeyArrowLeft && x > 0:
+		x--
+	case key == KeyArrowRight && x < len(line):
+		x++
+	case key == KeyHome:
+		x = 0
+	case key == KeyEnd:
+		x = len(line)
+	}
+	v.lines[y] = line
+
+	// Keep the cursor within the view, counting the columns of wide runes
+	width, _ := v.Size()
+	if x < v.ox {
+		v.ox = x
+	}
+	for width > 0 && v.ox < x && cellColumns(line[v.ox:x]) >= width {
+		v.ox++
+	}
+	v.cx = x - v.ox
+}
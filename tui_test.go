@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func newSimulatedGui(t *testing.T) *Gui {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(80, 24)
+	g := NewGui()
+	g.screen = screen
+	return g
+}
+
+func TestExecuteKeepsOrder(t *testing.T) {
+	g := newSimulatedGui(t)
+	defer g.Close()
+
+	var ran []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			i := i
+			g.Execute(func(g *Gui) error {
+				ran = append(ran, i)
+				return nil
+			})
+		}
+		g.Execute(func(g *Gui) error { return ErrQuit })
+	}()
+	if err := g.MainLoop(); err != ErrQuit {
+		t.Fatalf("MainLoop: %v, expected ErrQuit", err)
+	}
+	wg.Wait()
+	if len(ran) != 1000 {
+		t.Fatalf("%d tasks ran, expected 1000", len(ran))
+	}
+	for i, n := range ran {
+		if n != i {
+			t.Fatalf("task %d ran at %d", n, i)
+		}
+	}
+}
+
+func TestMainLoopReturnsOnError(t *testing.T) {
+	g := newSimulatedGui(t)
+	defer g.Close()
+
+	broken := errors.New("broken")
+	later := false
+	g.Execute(func(g *Gui) error { return broken })
+	g.Execute(func(g *Gui) error {
+		later = true
+		return nil
+	})
+	if err := g.MainLoop(); err != broken {
+		t.Fatalf("MainLoop: %v, expected the error of the task", err)
+	}
+	if later {
+		t.Fatal("a task ran after an error")
+	}
+	// Entered again, the loop runs what was left
+	g.Execute(func(g *Gui) error { return ErrQuit })
+	if err := g.MainLoop(); err != ErrQuit {
+		t.Fatalf("MainLoop: %v, expected ErrQuit", err)
+	}
+	if !later {
+		t.Fatal("the task left by the error did not run")
+	}
+}
+
+/*
+screenText - The runes drawn on row y from column x0 to x1, the second
+column of wide runes skipped
+*/
+func screenText(g *Gui, y, x0, x1 int) string {
+	var text []rune
+	for x := x0; x < x1; x++ {
+		ch, comb, _, width := g.screen.GetContent(x, y)
+		text = append(text, ch)
+		text = append(text, comb...)
+		x += width - 1
+	}
+	return string(text)
+}
+
+func TestWideRunes(t *testing.T) {
+	g := newSimulatedGui(t)
+	defer g.Close()
+
+	v, err := g.SetView("info", 0, 0, 11, 4)
+	if err != ErrUnknownView {
+		t.Fatal(err)
+	}
+	v.Frame = true
+	v.Title = "東京都"
+	// Ten columns: the last wide rune of a row that does not fit is cut
+	fmt.Fprintln(v, "東京 Tokyo!")
+	fmt.Fprintln(v, "ok 🌍 🌍 🌍 x")
+	fmt.Fprint(v, "Zürich")
+	if err := g.flush(); err != nil {
+		t.Fatal(err)
+	}
+	for y, want := range []string{"─東京都───", "東京 Tokyo", "ok 🌍 🌍  ", "Zu\u0308rich    "} {
+		if got := screenText(g, y, 1, 11); got != want {
+			t.Errorf("row %d: %+q, expected %+q", y, got, want)
+		}
+	}
+	if v.Buffer() != "東京 Tokyo!\nok 🌍 🌍 🌍 x\nZürich\n" {
+		t.Errorf("buffer %q", v.Buffer())
+	}
+	if got := cutColumn("東京都 Tokyo", 6); got != "東京~ " {
+		t.Errorf("cut %q", got)
+	}
+}
+
+func TestEditWideRunes(t *testing.T) {
+	g := newSimulatedGui(t)
+	defer g.Close()
+
+	v, _ := g.SetView("prompt", 0, 0, 7, 2)
+	v.Editable = true
+	for _, r := range "日本語abc" {
+		v.edit(0, r)
+	}
+	// Six columns: 語abc takes five, the cursor the sixth
+	if v.ox != 2 || v.cx != 4 || v.cursorColumn() != 5 {
+		t.Errorf("origin %d, cursor %d at column %d, expected 2, 4 and 5", v.ox, v.cx, v.cursorColumn())
+	}
+	v.edit(KeyHome, 0)
+	v.edit(KeyArrowRight, 0)
+	if v.ox != 0 || v.cursorColumn() != 2 {
+		t.Errorf("origin %d, cursor at column %d, expected 0 and 2", v.ox, v.cursorColumn())
+	}
+}
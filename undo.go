@@ -0,0 +1,144 @@
+package main
+
+/*
+Zooming, panning, toggling layers and filtering change what the map shows;
+each change keeps the state it replaced so that it can be undone:
+
+	u      undo the last change of the view
+	C+r    redo it
+
+Only the last maxUndo changes are kept.
+*/
+
+const maxUndo = 100
+
+/*
+ViewState - What the map shows, as far as undo goes
+*/
+type ViewState struct {
+	Viewport Viewport
+	Layers   []bool // Enabled of each layer
+	Filter   string // of the multi-IP modes
+}
+
+// States to go back and forth to, the last one first, only touched from the
+// gui goroutine
+var undoStack, redoStack []ViewState
+
+// The text of the filter of the multi-IP modes and applying one, set by
+// them. Only called from the gui goroutine.
+var (
+	currentFilter func() string
+	restoreFilter func(g *Gui, text string) error
+)
+
+func currentViewState() ViewState {
+	s := ViewState{Viewport: viewport, Layers: make([]bool, len(layers))}
+	for i, layer := range layers {
+		s.Layers[i] = layer.Enabled
+	}
+	if currentFilter != nil {
+		s.Filter = currentFilter()
+	}
+	return s
+}
+
+func (s ViewState) equal(other ViewState) bool {
+	if s.Viewport != other.Viewport || s.Filter != other.Filter ||
+		len(s.Layers) != len(other.Layers) {
+		return false
+	}
+	for i := range s.Layers {
+		if s.Layers[i] != other.Layers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pushState(stack []ViewState, s ViewState) []ViewState {
+	stack = append(stack, s)
+	if len(stack) > maxUndo {
+		stack = stack[len(stack)-maxUndo:]
+	}
+	return stack
+}
+
+/*
+recordState - Keep before for undo if the view changed since
+*/
+func recordState(before ViewState) {
+	if currentViewState().equal(before) {
+		return
+	}
+	undoStack = pushState(undoStack, before)
+	redoStack = nil
+}
+
+/*
+undoable - handler, its changes of the view kept for undo
+*/
+func undoable(handler KeybindingHandler) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		before := currentViewState()
+		err := handler(g, v)
+		recordState(before)
+		return err
+	}
+}
+
+/*
+restoreState - Show the map as of s
+*/
+func restoreState(g *Gui, s ViewState) error {
+	viewport = s.Viewport
+	for i, layer := range layers {
+		if i < len(s.Layers) && layer.Enabled != s.Layers[i] {
+			// Loads the data of the layer if need be
+			if err := toggleLayer(layer)(g, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if restoreFilter != nil && currentFilter() != s.Filter {
+		if err := restoreFilter(g, s.Filter); err != nil {
+			return err
+		}
+	}
+	return redrawMap(g)
+}
+
+func undo(g *Gui, v *View) error {
+	n := len(undoStack)
+	if n == 0 {
+		guiShowStatus(g, "Nothing to undo")
+		return nil
+	}
+	redoStack = pushState(redoStack, currentViewState())
+	s := undoStack[n-1]
+	undoStack = undoStack[:n-1]
+	return restoreState(g, s)
+}
+
+func redo(g *Gui, v *View) error {
+	n := len(redoStack)
+	if n == 0 {
+		guiShowStatus(g, "Nothing to redo")
+		return nil
+	}
+	undoStack = pushState(undoStack, currentViewState())
+	s := redoStack[n-1]
+	redoStack = redoStack[:n-1]
+	return restoreState(g, s)
+}
+
+/*
+setUndoKeybindings - Register the keys undoing and redoing changes of the
+view
+*/
+func setUndoKeybindings(g *Gui) error {
+	if err := g.SetKeybinding("", 'u', ModNone, undo); err != nil {
+		return err
+	}
+	return g.SetKeybinding("", KeyCtrlR, ModNone, redo)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+The map can be zoomed into a region:
+
+	+/-         zoom in/out (in centers on the target at first)
+	arrows      pan, or move the crosshair
+	x           show or hide the crosshair (see reverse.go)
+	0           back to the whole world, or the region of -region
+
+-region starts the map zoomed to a preset region (europe, na...) or a
+bounding box, and keeps it there: the map zooms out no further than the
+region and pans within it, and only the coastlines crossing it are loaded
+and drawn.
+*/
+
+const maxZoom = 64
+
+/*
+Viewport - Region of the world shown by the map. A zoom of 1 (or 0) shows
+the whole world.
+*/
+type Viewport struct {
+	CenterLon float64
+	CenterLat float64
+	Zoom      float64
+}
+
+var viewport = Viewport{Zoom: 1} // only touched from the gui goroutine
+
+// The viewport of -region, the whole world without one
+var homeViewport = Viewport{Zoom: 1}
+
+/*
+BBox - A region of the world, as its edges in degrees
+*/
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// Presets of -region
+var regionPresets = map[string]BBox{
+	"europe":  {-25, 34, 45, 72},
+	"na":      {-170, 10, -50, 75},
+	"sa":      {-92, -57, -30, 15},
+	"africa":  {-20, -36, 55, 38},
+	"mena":    {-20, 12, 65, 42},
+	"apac":    {60, -50, 180, 55},
+	"oceania": {110, -50, 180, 0},
+}
+
+var mapRegion *BBox // of -region, nil for the whole world
+
+/*
+parseBBox - A region given as minLon,minLat,maxLon,maxLat
+*/
+func parseBBox(text string) (BBox, error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("Expected minLon,minLat,maxLon,maxLat")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := parseDegrees(part)
+		if err != nil {
+			return BBox{}, err
+		}
+		v[i] = f
+	}
+	box := BBox{v[0], v[1], v[2], v[3]}
+	if !validCoords(box.MinLat, box.MinLon) || !validCoords(box.MaxLat, box.MaxLon) {
+		return BBox{}, fmt.Errorf("Out of the world")
+	}
+	if box.MinLon >= box.MaxLon || box.MinLat >= box.MaxLat {
+		return BBox{}, fmt.Errorf("Expected the minimums before the maximums")
+	}
+	return box, nil
+}
+
+/*
+Viewport - The smallest viewport showing all of the box
+*/
+func (box BBox) Viewport() Viewport {
+	zoom := math.Min(360/(box.MaxLon-box.MinLon), 180/(box.MaxLat-box.MinLat))
+	return Viewport{
+		CenterLon: (box.MinLon + box.MaxLon) / 2,
+		CenterLat: (box.MinLat + box.MaxLat) / 2,
+		Zoom:      math.Max(1, zoom),
+	}.clampTo(Viewport{Zoom: 1})
+}
+
+/*
+crosses - Whether a shape has a point in the box, or runs across it
+*/
+func (box BBox) crosses(minLon, maxLon, minLat, maxLat float64) bool {
+	return minLon <= box.MaxLon && maxLon >= box.MinLon &&
+		minLat <= box.MaxLat && maxLat >= box.MinLat
+}
+
+/*
+regionFlag - -region, applied as soon as it is parsed
+*/
+type regionFlag struct{}
+
+func (regionFlag) String() string {
+	return ""
+}
+
+func (regionFlag) Set(region string) error {
+	box, ok := regionPresets[region]
+	if !ok {
+		var err error
+		if box, err = parseBBox(region); err != nil {
+			var names []string
+			for name := range regionPresets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return invalidInput("Invalid region '%s': Expected one of %s or a bounding box (%s).",
+				region, strings.Join(names, ", "), err)
+		}
+	}
+	mapRegion = &box
+	homeViewport = box.Viewport()
+	viewport = homeViewport
+	return nil
+}
+
+func addRegionFlag(flags *flag.FlagSet) {
+	flags.Var(regionFlag{}, "region",
+		"Keep the map on europe, na, sa, africa, mena, apac, oceania or a bounding box\n"+
+			"minLon,minLat,maxLon,maxLat")
+}
+
+var (
+	worldMapOnce sync.Once
+	worldMap     Coordinates
+)
+
+/*
+regionMap - The coastlines of the world crossing the region of -region,
+parsed once
+*/
+func regionMap() Coordinates {
+	worldMapOnce.Do(func() {
+		world := CreateWorldMap()
+		if mapRegion == nil {
+			worldMap = world
+			return
+		}
+		for _, shape := range world {
+			if len(shape) == 0 {
+				continue
+			}
+			minLon, maxLon := shape[0].Lon, shape[0].Lon
+			minLat, maxLat := shape[0].Lat, shape[0].Lat
+			for _, p := range shape[1:] {
+				minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+				minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+			}
+			if mapRegion.crosses(minLon, maxLon, minLat, maxLat) {
+				worldMap = append(worldMap, shape)
+			}
+		}
+	})
+	return worldMap
+}
+
+/*
+Zoomed - Whether the viewport shows less than the whole world
+*/
+func (vp Viewport) Zoomed() bool {
+	return vp.Zoom > 1
+}
+
+/*
+Bounds - Longitudes and latitudes at the edges of the viewport
+*/
+func (vp Viewport) Bounds() (minLon, maxLon, minLat, maxLat float64) {
+	if !vp.Zoomed() {
+		return -180, 180, -90, 90
+	}
+	halfLon, halfLat := 180/vp.Zoom, 90/vp.Zoom
+	return vp.CenterLon - halfLon, vp.CenterLon + halfLon,
+		vp.CenterLat - halfLat, vp.CenterLat + halfLat
+}
+
+/*
+clamp - The viewport moved so that it stays within the world, or the region
+of -region
+*/
+func (vp Viewport) clamp() Viewport {
+	return vp.clampTo(homeViewport)
+}
+
+func (vp Viewport) clampTo(home Viewport) Viewport {
+	if vp.Zoom < home.Zoom {
+		vp.Zoom = home.Zoom
+	} else if vp.Zoom > maxZoom {
+		vp.Zoom = maxZoom
+	}
+	minLon, maxLon, minLat, maxLat := home.Bounds()
+	halfLon, halfLat := 180/vp.Zoom, 90/vp.Zoom
+	vp.CenterLon = math.Max(minLon+halfLon, math.Min(maxLon-halfLon, vp.CenterLon))
+	vp.CenterLat = math.Max(minLat+halfLat, math.Min(maxLat-halfLat, vp.CenterLat))
+	return vp
+}
+
+/*
+inside - Whether a canvas point is within the canvas
+*/
+func (mc *MapCanvas) inside(x, y float64) bool {
+	return x >= 0 && y >= 0 && x <= mc.width && y <= mc.height
+}
+
+/*
+clippedLine - Plot the part of a line within the canvas, point by point
+*/
+func (mc *MapCanvas) clippedLine(xA, yA, xB, yB float64) {
+	if !mc.inside(xA, yA) && !mc.inside(xB, yB) &&
+		(math.Max(xA, xB) < 0 || math.Min(xA, xB) > mc.width ||
+			math.Max(yA, yB) < 0 || math.Min(yA, yB) > mc.height) {
+		return
+	}
+	n := int(math.Max(math.Abs(xB-xA), math.Abs(yB-yA)))
+	for i := 0; i <= n; i++ {
+		t := 1.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		x, y := xA+t*(xB-xA), yA+t*(yB-yA)
+		if mc.inside(x, y) {
+			mc.canvas.Set(int(x), int(y))
+		}
+	}
+}
+
+/*
+fixedString - The canvas as text, not trimmed to its content so that a
+zoomed map keeps its place
+*/
+func (mc *MapCanvas) fixedString() string {
+	return strings.Join(mc.canvas.Rows(0, 0, int(mc.width), int(mc.height)), "\n")
+}
+
+func redrawMap(g *Gui) error {
+	view, err := g.View("map")
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	markers := lastMarkers
+	mu.Unlock()
+	drawMap(view, markers)
+	return nil
+}
+
+/*
+centerOn - Center the viewport on a location, if zoomed
+*/
+func centerOn(lon, lat float64) {
+	if viewport.Zoomed() {
+		viewport.CenterLon, viewport.CenterLat = lon, lat
+		viewport = viewport.clamp()
+	}
+}
+
+func zoom(factor float64) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		if !viewport.Zoomed() && factor > 1 {
+			mu.Lock()
+			if n := len(lastMarkers); n > 0 {
+				viewport.CenterLon = lastMarkers[n-1].Lon
+				viewport.CenterLat = lastMarkers[n-1].Lat
+			}
+			mu.Unlock()
+		}
+		viewport.Zoom *= factor
+		viewport = viewport.clamp()
+		return redrawMap(g)
+	}
+}
+
+/*
+pan - Move the viewport by a fraction of its size
+*/
+func pan(dx, dy float64) KeybindingHandler {
+	return func(g *Gui, v *View) error {
+		if crosshair != nil {
+			return moveCrosshair(g, dx/8, dy/8)
+		}
+		if !viewport.Zoomed() {
+			return nil
+		}
+		viewport.CenterLon += dx * 360 / viewport.Zoom
+		viewport.CenterLat += dy * 180 / viewport.Zoom
+		viewport = viewport.clamp()
+		return redrawMap(g)
+	}
+}
+
+func resetZoom(g *Gui, v *View) error {
+	viewport = homeViewport
+	return redrawMap(g)
+}
+
+/*
+setViewportKeybindings - Register the keys zooming and panning the map
+*/
+func setViewportKeybindings(g *Gui) error {
+	bindings := []struct {
+		view    string
+		key     interface{}
+		handler KeybindingHandler
+	}{
+		{"", '+', undoable(zoom(2))},
+		{"", '=', undoable(zoom(2))},
+		{"", '-', undoable(zoom(0.5))},
+		{"", '0', undoable(resetZoom)},
+		{"", 'x', toggleCrosshair},
+		{"map", KeyArrowLeft, undoable(pan(-0.25, 0))},
+		{"map", KeyArrowRight, undoable(pan(0.25, 0))},
+		{"map", KeyArrowUp, undoable(pan(0, 0.25))},
+		{"map", KeyArrowDown, undoable(pan(0, -0.25))},
+	}
+	for _, b := range bindings {
+		if err := g.SetKeybinding(b.view, b.key, ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
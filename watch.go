@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+/*
+watchHelp - What the usage message of watch says after the synopsis
+*/
+const watchHelp = `Locate ip every interval. Without ip, the client's public IP Address
+is watched and an ip_change event is published when it changes. Every
+result is kept for ip411 history to show what changed between them.
+`
+
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	addOutputFlags(flags)
+	addAccessibleFlag(flags)
+	addProviderFlag(flags)
+	interval := flags.Duration("interval", 5*time.Minute,
+		"How often to locate the IP Address again")
+	history := flags.Bool("history", true,
+		"Keep every result, for ip411 history to compare")
+	sinks := addSinkFlags(flags)
+	pipelineFlags := addPipelineFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s watch [-interval d] [-accessible] [sink flags] [ip]\n",
+			os.Args[0])
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprint(os.Stderr, tr(watchHelp))
+		fmt.Fprintln(os.Stderr, "")
+		printDefaults(flags)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return invalidInput("Invalid number of arguments: Specify one IP Address.")
+	}
+
+	ip, err := makeIP(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := pipelineFlags.open()
+	if err != nil {
+		return err
+	}
+	defer pipeline.Close()
+
+	events, err := sinks.open()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	target := flags.Arg(0) // "" for the public IP Address
+	watch := func() {
+		var previous string
+		for {
+			bus.Publish(Message{Topic: MsgLookupRequested, Source: "watch", Target: target})
+			ipinfo, rtt, err := getIPInfoTimed(ip)
+			keep := true
+			if err == nil {
+				err = locationError(ipinfo)
+			}
+			if err == nil {
+				keep, err = pipeline.Process(ipinfo)
+			}
+			if err != nil {
+				bus.Publish(Message{Topic: MsgLookupFailed, Source: "watch", Target: target, Err: err})
+			} else if keep {
+				current, _ := ipinfo.GetKey("ip")
+				if previous != "" && current != previous {
+					e := NewEvent(EventIPChange, ipinfo)
+					e.Previous = previous
+					events.Emit(e)
+				}
+				previous = current
+				if *history {
+					if err := appendSnapshot(current, ipinfo); err != nil {
+						warnf("Could not keep the result: %s", err)
+					}
+				}
+				bus.Publish(Message{Topic: MsgResultReady, Source: "watch", Target: current,
+					Result: ipinfo, RTT: rtt})
+			} else {
+				bus.Publish(Message{Topic: MsgResultDropped, Source: "watch", Target: target, Result: ipinfo})
+			}
+			time.Sleep(*interval)
+		}
+	}
+
+	if accessible {
+		bus.Subscribe(NewNarrator(os.Stdout).Handle, MsgResultReady, MsgLookupFailed, MsgResultDropped)
+		watch() // until interrupted
+		return nil
+	}
+
+	var stop func()
+	err = runGui(func(gui *Gui) error {
+		stop = bus.Subscribe(func(m Message) {
+			if m.Err != nil {
+				guiLoadError(m.Err, *interval, gui)
+			} else if m.Topic == MsgResultReady {
+				guiLoadInfo(m.Result, gui)
+				guiLoadMap(m.Result, gui)
+			}
+			guiLoadStatus(gui)
+		}, MsgResultReady, MsgLookupFailed, MsgResultDropped)
+
+		go watch()
+		return nil
+	})
+	if stop != nil {
+		stop()
+	}
+	return err
+}
+
+/*
+guiLoadError - Report a failed lookup in the info pane
+*/
+func guiLoadError(lookupErr error, retry time.Duration, gui *Gui) {
+	gui.Execute(func(g *Gui) error {
+
+		view, err := gui.View("info")
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		view.Clear()
+		fmt.Fprintf(view, tr("Lookup failed: %s\n"), lookupErr)
+		fmt.Fprintf(view, tr("Trying again in %s\n"), retry)
+		mu.Unlock()
+
+		return nil
+	})
+}
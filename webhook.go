@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	webhookAttempts = 4
+	webhookBacklog  = 100
+)
+
+// Default payload: the whole event as JSON
+const defaultWebhookTemplate = `{{json .}}`
+
+/*
+webhookFuncs - Functions available in webhook templates, on top of the
+fields of Event:
+
+	{{json .}}              the value as JSON
+	{{field .Result "org"}} a field of the lookup result ("" when missing)
+*/
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"field": func(res IPInfoResult, key string) string {
+		val, err := res.GetKey(key)
+		if err != nil {
+			return ""
+		}
+		return val
+	},
+}
+
+/*
+WebhookSink - POSTs a templated body to a URL for each event. Requests are
+sent in the background, retried with backoff on network errors, 429 and 5xx
+responses, and signed with HMAC-SHA256 when a secret is set.
+*/
+type WebhookSink struct {
+	url     string
+	secret  []byte
+	types   map[string]bool
+	tmpl    *template.Template
+	client  *http.Client
+	backlog chan []byte
+	done    chan struct{}
+}
+
+/*
+NewWebhookSink - Create a sink posting to url. templatePath names a
+text/template file rendered with the Event ("" for the event as JSON). Only
+event types listed in types are sent, all of them if types is empty.
+*/
+func NewWebhookSink(url, templatePath, secret string, types []string) (*WebhookSink, error) {
+	text := defaultWebhookTemplate
+	if templatePath != "" {
+		data, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &WebhookSink{
+		url:     url,
+		tmpl:    tmpl,
+		client:  &http.Client{Timeout: 15 * time.Second},
+		backlog: make(chan []byte, webhookBacklog),
+		done:    make(chan struct{}),
+	}
+	if secret != "" {
+		s.secret = []byte(secret)
+	}
+	if len(types) > 0 {
+		s.types = make(map[string]bool)
+		for _, typ := range types {
+			s.types[typ] = true
+		}
+	}
+	go s.sendLoop()
+	return s, nil
+}
+
+/*
+Publish - Render the payload for e and queue it for sending
+*/
+func (s *WebhookSink) Publish(e Event) error {
+	if s.types != nil && !s.types[e.Type] {
+		return nil
+	}
+
+	var body bytes.Buffer
+	if err := s.tmpl.Execute(&body, e); err != nil {
+		return err
+	}
+
+	select {
+	case s.backlog <- body.Bytes():
+		return nil
+	default:
+		return fmt.Errorf("Webhook backlog full, dropping %s event", e.Type)
+	}
+}
+
+/*
+sign - Hex HMAC-SHA256 of body with the sink secret
+*/
+func (s *WebhookSink) sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+send - POST body once, returning the response status
+*/
+func (s *WebhookSink) send(body []byte) (int, error) {
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ip411")
+	if s.secret != nil {
+		req.Header.Set("X-Ip411-Signature", "sha256="+s.sign(body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("Webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+/*
+retryable - Whether a failed send is worth retrying: network errors (no
+status), rate limiting and server errors
+*/
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+func (s *WebhookSink) sendLoop() {
+	defer close(s.done)
+	for body := range s.backlog {
+		backoff := time.Second
+		for attempt := 1; ; attempt++ {
+			status, err := s.send(body)
+			if err == nil {
+				break
+			}
+			if !retryable(status) || attempt == webhookAttempts {
+				warnf("Giving up on webhook after %d attempts: %s", attempt, err)
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+/*
+Close - Wait for the queued requests to be sent
+*/
+func (s *WebhookSink) Close() error {
+	close(s.backlog)
+	<-s.done
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+/*
+In logs and monitor the markers can be scaled by a metric of the top talkers
+table (see talkers.go): each marker gets a disc of dots growing with its
+value, and a color from cyan through yellow to red unless its group colors
+it. The scale is logarithmic, relative to the largest value on the map, and
+the info pane shows its legend.
+
+	m    next metric (none, hits, bytes, packets)
+*/
+
+// Radius in dots of the disc of the heaviest marker
+const maxMarkerRadius = 4
+
+/*
+markerWeight - Weight of a marker with value v when the largest value is max,
+between 0 and 1
+*/
+func markerWeight(v, max int) float64 {
+	if v <= 0 || max <= 0 {
+		return 0
+	}
+	return math.Log1p(float64(v)) / math.Log1p(float64(max))
+}
+
+/*
+heatColor - ANSI color of a marker of weight w
+*/
+func heatColor(w float64) int {
+	switch {
+	case w >= 2.0/3:
+		return 31 // red
+	case w >= 1.0/3:
+		return 33 // yellow
+	}
+	return 36 // cyan
+}
+
+/*
+heatThresholds - The values at the thresholds of heatColor when the largest
+is max
+*/
+func heatThresholds(max int) (int, int) {
+	at := func(w float64) int {
+		return int(math.Ceil(math.Expm1(w * math.Log1p(float64(max)))))
+	}
+	return at(1.0 / 3), at(2.0 / 3)
+}
+
+/*
+Disc - Plot a disc of dots around a point, of a radius growing with weight,
+in the heat color of weight
+*/
+func (mc *MapCanvas) Disc(longitude, latitude, weight float64) {
+	x0, y0 := mc.GetX(longitude), mc.GetY(latitude)
+	r := math.Round(weight * maxMarkerRadius)
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			x, y := x0+dx, y0+dy
+			if dx*dx+dy*dy > r*r || !mc.inside(x, y) {
+				continue
+			}
+			mc.canvas.Set(int(x), int(y))
+			mc.canvas.Paint(int(x), int(y), 1, CellAttr{Fg: heatColor(weight)})
+		}
+	}
+}
+
+/*
+weightValues - The value of the metric of every target in the current
+window, and the largest. Must be called with b.mu held.
+*/
+func (b *Batch) weightValues() (map[string]int, int) {
+	if b.weightMetric == 0 {
+		return nil, 0
+	}
+	value := talkerColumns[b.weightMetric-1].value
+	traffic := b.windowTraffic()
+	values := make(map[string]int, len(traffic))
+	max := 0
+	for target, t := range traffic {
+		if _, ok := b.results[target]; !ok {
+			continue
+		}
+		v := value(t)
+		values[target] = v
+		if v > max {
+			max = v
+		}
+	}
+	return values, max
+}
+
+/*
+weightLegend - Line of the info pane explaining the scale, "" if markers are
+not scaled. Must be called with b.mu held.
+*/
+func (b *Batch) weightLegend() string {
+	_, max := b.weightValues()
+	if max == 0 {
+		return ""
+	}
+	low, high := heatThresholds(max)
+	return fmt.Sprintf("Markers by %s: cyan < %d <= yellow < %d <= red, largest %d",
+		talkerColumns[b.weightMetric-1].name, low, high, max)
+}
+
+func (b *Batch) nextWeightMetric(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.weightMetric = (b.weightMetric + 1) % (len(talkerColumns) + 1)
+	b.mu.Unlock()
+
+	b.refresh(g)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+In logs and monitor the map, the statistics and the top talkers can be
+limited to the addresses seen lately. Sightings are kept in one minute
+buckets for the longest window, so that switching windows only sums buckets.
+
+	w    next window (all time, last 5 minutes, last hour)
+*/
+
+var timeWindows = []struct {
+	name string
+	span time.Duration // 0 for all time
+}{
+	{"all time", 0},
+	{"last 5 minutes", 5 * time.Minute},
+	{"last hour", time.Hour},
+}
+
+const trafficBucketSize = time.Minute
+
+type trafficBucket struct {
+	start   time.Time
+	traffic map[string]*Traffic
+}
+
+/*
+TrafficStore - Sightings of the remote addresses, in total and by minute for
+the last hour
+*/
+type TrafficStore struct {
+	total   map[string]*Traffic
+	buckets []*trafficBucket // oldest first
+
+	recent *recentSet // targets by last sighting
+	max    int        // targets kept, unlimited if 0, see limits.go
+}
+
+/*
+NewTrafficStore - Create an empty store
+*/
+func NewTrafficStore() *TrafficStore {
+	return &TrafficStore{total: make(map[string]*Traffic), recent: newRecentSet()}
+}
+
+func addTraffic(m map[string]*Traffic, target string, delta Traffic) {
+	t := m[target]
+	if t == nil {
+		t = &Traffic{}
+		m[target] = t
+	}
+	t.Hits += delta.Hits
+	t.Bytes += delta.Bytes
+	t.Packets += delta.Packets
+}
+
+/*
+Add - Count the traffic of target at now
+*/
+func (s *TrafficStore) Add(target string, delta Traffic, now time.Time) {
+	addTraffic(s.total, target, delta)
+	s.recent.touch(target)
+	s.recent.trim(s.max, s.drop)
+
+	start := now.Truncate(trafficBucketSize)
+	if n := len(s.buckets); n == 0 || s.buckets[n-1].start.Before(start) {
+		s.buckets = append(s.buckets, &trafficBucket{start, make(map[string]*Traffic)})
+		s.prune(now)
+	}
+	addTraffic(s.buckets[len(s.buckets)-1].traffic, target, delta)
+}
+
+/*
+forget - Drop the traffic of target
+*/
+func (s *TrafficStore) forget(target string) {
+	s.recent.remove(target)
+	s.drop(target)
+}
+
+func (s *TrafficStore) drop(target string) {
+	delete(s.total, target)
+	for _, bucket := range s.buckets {
+		delete(bucket.traffic, target)
+	}
+}
+
+/*
+prune - Drop the buckets older than the longest window
+*/
+func (s *TrafficStore) prune(now time.Time) {
+	longest := timeWindows[len(timeWindows)-1].span
+	i := 0
+	for i < len(s.buckets) && now.Sub(s.buckets[i].start) > longest+trafficBucketSize {
+		i++
+	}
+	s.buckets = s.buckets[i:]
+}
+
+/*
+Window - Sightings of the last span (of all time if 0), by target
+*/
+func (s *TrafficStore) Window(span time.Duration, now time.Time) map[string]Traffic {
+	window := make(map[string]Traffic)
+	if span == 0 {
+		for target, t := range s.total {
+			window[target] = *t
+		}
+		return window
+	}
+	// Whole buckets: the window starts at the beginning of a minute
+	since := now.Add(-span).Truncate(trafficBucketSize)
+	for _, bucket := range s.buckets {
+		if bucket.start.Before(since) {
+			continue
+		}
+		for target, t := range bucket.traffic {
+			w := window[target]
+			w.Hits += t.Hits
+			w.Bytes += t.Bytes
+			w.Packets += t.Packets
+			window[target] = w
+		}
+	}
+	return window
+}
+
+/*
+windowTraffic - Sightings in the current window. Must be called with b.mu
+held.
+*/
+func (b *Batch) windowTraffic() map[string]Traffic {
+	return b.traffic.Window(timeWindows[b.window].span, time.Now())
+}
+
+/*
+inWindow - Whether target should be shown in the current window: always for
+all time, else if it was seen during the window. Must be called with b.mu
+held, seen as returned by windowTraffic.
+*/
+func (b *Batch) inWindow(target string, seen map[string]Traffic) bool {
+	if timeWindows[b.window].span == 0 {
+		return true
+	}
+	_, ok := seen[target]
+	return ok
+}
+
+/*
+windowSummary - Line of the info pane naming the window, "" for all time.
+Must be called with b.mu held.
+*/
+func (b *Batch) windowSummary() string {
+	if timeWindows[b.window].span == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Window: %s (w)", timeWindows[b.window].name)
+}
+
+func (b *Batch) nextWindow(g *Gui, v *View) error {
+	b.mu.Lock()
+	b.window = (b.window + 1) % len(timeWindows)
+	b.mu.Unlock()
+
+	b.refresh(g)
+	return nil
+}
+
+/*
+expireWindow - Refresh the map as addresses leave the window, forever
+*/
+func (b *Batch) expireWindow(gui *Gui) {
+	for range time.Tick(trafficBucketSize) {
+		b.mu.Lock()
+		windowed := timeWindows[b.window].span != 0
+		b.mu.Unlock()
+		if windowed {
+			b.refresh(gui)
+		}
+	}
+}